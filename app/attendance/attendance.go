@@ -0,0 +1,313 @@
+package attendance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+)
+
+// ErrInvalidSequence dikembalikan jika jenis absen tidak sesuai absen sebelumnya, mis. clock out sebelum clock in
+var ErrInvalidSequence = errors.New("urutan absensi tidak valid")
+
+// ErrLocationRequired dikembalikan jika cabang memakai geofence tetapi lokasi tidak dikirim
+var ErrLocationRequired = errors.New("lokasi wajib dikirim untuk absen di cabang ini")
+
+// ErrOutsideGeofence dikembalikan jika lokasi absen di luar radius cabang
+var ErrOutsideGeofence = errors.New("lokasi absen di luar area cabang")
+
+// ErrUnknownDevice dikembalikan jika absen dari perangkat yang bukan perangkat terdaftar staff
+var ErrUnknownDevice = errors.New("perangkat tidak terdaftar untuk absensi staff ini")
+
+// earlyClockIn adalah batas clock in sebelum awal shift yang masih dihitung untuk shift tersebut
+const earlyClockIn = 2 * time.Hour
+
+// next adalah jenis absen yang boleh dicatat setelah jenis absen terakhir ("" = belum pernah absen)
+var next = map[string][]string{
+	"":                     {models.PunchClockIn},
+	models.PunchClockOut:   {models.PunchClockIn},
+	models.PunchClockIn:    {models.PunchBreakStart, models.PunchClockOut},
+	models.PunchBreakEnd:   {models.PunchBreakStart, models.PunchClockOut},
+	models.PunchBreakStart: {models.PunchBreakEnd},
+}
+
+// Punch memeriksa lalu menyimpan absen staff. Absen staff diserialkan dengan mengunci baris user staff.
+// Absen non-manual diperiksa terhadap perangkat terdaftar staff dan geofence cabang.
+func Punch(tx *gorm.DB, punch *models.AttendancePunch, branch *models.Branch) error {
+	var staff models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&staff, punch.StaffID).Error; err != nil {
+		return err
+	}
+
+	if !punch.Manual {
+		if staff.AttendanceDeviceID != "" && punch.DeviceID != staff.AttendanceDeviceID {
+			return ErrUnknownDevice
+		}
+	}
+	if branch.Latitude != nil && branch.Longitude != nil && punch.Latitude != nil && punch.Longitude != nil {
+		distance := int(math.Round(Distance(*branch.Latitude, *branch.Longitude, *punch.Latitude, *punch.Longitude)))
+		punch.DistanceMeters = &distance
+	}
+	if !punch.Manual && branch.GeofenceRadiusMeters > 0 && branch.Latitude != nil && branch.Longitude != nil {
+		if punch.DistanceMeters == nil {
+			return ErrLocationRequired
+		}
+		if *punch.DistanceMeters > branch.GeofenceRadiusMeters {
+			return fmt.Errorf("%w (%d m dari cabang, maksimal %d m)", ErrOutsideGeofence, *punch.DistanceMeters, branch.GeofenceRadiusMeters)
+		}
+	}
+
+	var last models.AttendancePunch
+	err := tx.Where("staff_id = ? AND punched_at <= ?", punch.StaffID, punch.PunchedAt).
+		Order("punched_at DESC, id DESC").First(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	allowed := false
+	for _, t := range next[last.Type] {
+		allowed = allowed || t == punch.Type
+	}
+	if !allowed {
+		if last.Type == "" {
+			return fmt.Errorf("%w: %s harus diawali clock_in", ErrInvalidSequence, punch.Type)
+		}
+		return fmt.Errorf("%w: %s tidak dapat dicatat setelah %s", ErrInvalidSequence, punch.Type, last.Type)
+	}
+
+	return tx.Create(punch).Error
+}
+
+// Distance menghitung jarak dua koordinat dalam meter dengan rumus haversine
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371000.0
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLng := (lng2 - lng1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// Status kehadiran satu shift
+const (
+	StatusPresent  = "present"
+	StatusLate     = "late"
+	StatusAbsent   = "absent"
+	StatusUpcoming = "upcoming"
+	// StatusOngoing berarti shift sedang berjalan dan staff belum clock in namun masih dalam toleransi
+	StatusOngoing = "ongoing"
+)
+
+// ShiftAttendance adalah kehadiran staff pada satu shift
+type ShiftAttendance struct {
+	ShiftID     uint       `json:"shift_id"`
+	BranchID    uint       `json:"branch_id"`
+	StartAt     time.Time  `json:"start_at"`
+	EndAt       time.Time  `json:"end_at"`
+	ClockInAt   *time.Time `json:"clock_in_at"`
+	ClockOutAt  *time.Time `json:"clock_out_at"`
+	Status      string     `json:"status"`
+	LateMinutes int        `json:"late_minutes"`
+	// EarlyLeaveMinutes adalah selisih clock out sebelum akhir shift
+	EarlyLeaveMinutes int `json:"early_leave_minutes"`
+}
+
+// Summary adalah rekap absensi satu staff pada satu periode
+type Summary struct {
+	StaffID           uint              `json:"staff_id"`
+	StaffName         string            `json:"staff_name"`
+	Shifts            int               `json:"shifts"`
+	Present           int               `json:"present"`
+	Late              int               `json:"late"`
+	Absent            int               `json:"absent"`
+	LateMinutes       int               `json:"late_minutes"`
+	EarlyLeaveMinutes int               `json:"early_leave_minutes"`
+	WorkedMinutes     int               `json:"worked_minutes"`
+	BreakMinutes      int               `json:"break_minutes"`
+	ShiftDetails      []ShiftAttendance `json:"shift_details"`
+}
+
+// Report menyusun rekap absensi per staff untuk shift dan absen pada rentang [from, to). Shift dianggap hadir
+// jika ada clock in mulai 2 jam sebelum awal shift sampai akhir shift; terlambat jika clock in melewati awal
+// shift ditambah toleransi cabang; absen jika shift sudah berakhir tanpa clock in. Jam kerja dihitung dari
+// pasangan clock in/clock out dikurangi istirahat. staffID 0 berarti semua staff.
+func Report(db *gorm.DB, salonID, staffID uint, from, to, now time.Time) ([]Summary, error) {
+	var staff []models.User
+	query := db.Where("salon_id = ?", salonID)
+	if staffID != 0 {
+		query = query.Where("id = ?", staffID)
+	}
+	if err := query.Order("name").Find(&staff).Error; err != nil {
+		return nil, err
+	}
+
+	var shifts []models.StaffShift
+	query = db.Where("salon_id = ? AND start_at >= ? AND start_at < ?", salonID, from, to)
+	if staffID != 0 {
+		query = query.Where("staff_id = ?", staffID)
+	}
+	if err := query.Order("start_at").Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+
+	// Absen diambil lebih lebar agar clock in awal dan clock out shift yang melewati batas periode ikut terbaca
+	var punches []models.AttendancePunch
+	query = db.Where("salon_id = ? AND punched_at >= ? AND punched_at < ?", salonID, from.Add(-earlyClockIn), to.Add(12*time.Hour))
+	if staffID != 0 {
+		query = query.Where("staff_id = ?", staffID)
+	}
+	if err := query.Order("punched_at, id").Find(&punches).Error; err != nil {
+		return nil, err
+	}
+
+	var branches []models.Branch
+	if err := db.Where("salon_id = ?", salonID).Find(&branches).Error; err != nil {
+		return nil, err
+	}
+	grace := make(map[uint]time.Duration, len(branches))
+	for _, branch := range branches {
+		grace[branch.ID] = time.Duration(branch.LateGraceMinutes) * time.Minute
+	}
+
+	byStaff := map[uint][]models.AttendancePunch{}
+	for _, punch := range punches {
+		byStaff[punch.StaffID] = append(byStaff[punch.StaffID], punch)
+	}
+	shiftsByStaff := map[uint][]models.StaffShift{}
+	for _, shift := range shifts {
+		shiftsByStaff[shift.StaffID] = append(shiftsByStaff[shift.StaffID], shift)
+	}
+
+	summaries := make([]Summary, 0, len(staff))
+	for _, member := range staff {
+		summary := Summary{StaffID: member.ID, StaffName: member.Name, ShiftDetails: []ShiftAttendance{}}
+		own := byStaff[member.ID]
+		used := map[uint]bool{}
+
+		for _, shift := range shiftsByStaff[member.ID] {
+			detail := matchShift(shift, own, used, grace[shift.BranchID], now)
+			summary.Shifts++
+			switch detail.Status {
+			case StatusPresent:
+				summary.Present++
+			case StatusLate:
+				summary.Present++
+				summary.Late++
+			case StatusAbsent:
+				summary.Absent++
+			}
+			summary.LateMinutes += detail.LateMinutes
+			summary.EarlyLeaveMinutes += detail.EarlyLeaveMinutes
+			summary.ShiftDetails = append(summary.ShiftDetails, detail)
+		}
+
+		summary.WorkedMinutes, summary.BreakMinutes = workedMinutes(own, from, to, now)
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// matchShift mencari clock in dan clock out untuk satu shift. Clock in yang sudah dipakai shift lain dilewati.
+func matchShift(shift models.StaffShift, punches []models.AttendancePunch, used map[uint]bool, grace time.Duration, now time.Time) ShiftAttendance {
+	detail := ShiftAttendance{ShiftID: shift.ID, BranchID: shift.BranchID, StartAt: shift.StartAt, EndAt: shift.EndAt}
+
+	for i, punch := range punches {
+		if punch.Type != models.PunchClockIn || used[punch.ID] ||
+			punch.PunchedAt.Before(shift.StartAt.Add(-earlyClockIn)) || !punch.PunchedAt.Before(shift.EndAt) {
+			continue
+		}
+		used[punch.ID] = true
+		clockIn := punch.PunchedAt
+		detail.ClockInAt = &clockIn
+		for _, out := range punches[i+1:] {
+			if out.Type == models.PunchClockIn {
+				break
+			}
+			if out.Type == models.PunchClockOut {
+				clockOut := out.PunchedAt
+				detail.ClockOutAt = &clockOut
+				break
+			}
+		}
+		break
+	}
+
+	switch {
+	case detail.ClockInAt != nil && detail.ClockInAt.After(shift.StartAt.Add(grace)):
+		detail.Status = StatusLate
+		detail.LateMinutes = int(detail.ClockInAt.Sub(shift.StartAt).Minutes())
+	case detail.ClockInAt != nil:
+		detail.Status = StatusPresent
+	case !now.Before(shift.EndAt):
+		detail.Status = StatusAbsent
+	case now.Before(shift.StartAt):
+		detail.Status = StatusUpcoming
+	case now.After(shift.StartAt.Add(grace)):
+		detail.Status = StatusLate
+		detail.LateMinutes = int(now.Sub(shift.StartAt).Minutes())
+	default:
+		detail.Status = StatusOngoing
+	}
+	if detail.ClockOutAt != nil && detail.ClockOutAt.Before(shift.EndAt) {
+		detail.EarlyLeaveMinutes = int(shift.EndAt.Sub(*detail.ClockOutAt).Minutes())
+	}
+	return detail
+}
+
+// workedMinutes menjumlahkan jam kerja dan istirahat dari urutan absen, dibatasi rentang [from, to).
+// Sesi yang belum clock out dihitung sampai now.
+func workedMinutes(punches []models.AttendancePunch, from, to, now time.Time) (int, int) {
+	sorted := append([]models.AttendancePunch(nil), punches...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].PunchedAt.Before(sorted[j].PunchedAt) })
+
+	clip := func(start, end time.Time) time.Duration {
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) {
+			return 0
+		}
+		return end.Sub(start)
+	}
+
+	var worked, breaks time.Duration
+	var workStart, breakStart *time.Time
+	for i := range sorted {
+		at := sorted[i].PunchedAt
+		switch sorted[i].Type {
+		case models.PunchClockIn, models.PunchBreakEnd:
+			if breakStart != nil {
+				breaks += clip(*breakStart, at)
+				breakStart = nil
+			}
+			workStart = &at
+		case models.PunchBreakStart, models.PunchClockOut:
+			if workStart != nil {
+				worked += clip(*workStart, at)
+				workStart = nil
+			}
+			if sorted[i].Type == models.PunchBreakStart {
+				breakStart = &at
+			}
+		}
+	}
+	end := now
+	if end.After(to) {
+		end = to
+	}
+	if workStart != nil {
+		worked += clip(*workStart, end)
+	}
+	if breakStart != nil {
+		breaks += clip(*breakStart, end)
+	}
+	return int(worked.Minutes()), int(breaks.Minutes())
+}
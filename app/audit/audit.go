@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/models"
+)
+
+// Aksi yang dicatat di audit log
+const (
+	ActionSaleRefunded        = "sale.refunded"
+	ActionSaleVoided          = "sale.voided"
+	ActionPaymentRefunded     = "payment.refunded"
+	ActionGiftCardIssued      = "gift_card.issued"
+	ActionLoyaltyAdjusted     = "loyalty.adjusted"
+	ActionMembershipCancelled = "membership.cancelled"
+	ActionPayrollApproved     = "payroll.approved"
+)
+
+// Record menambahkan satu baris audit log. Gunakan tx dari transaksi aksi yang dicatat
+// agar log hanya tersimpan jika aksinya berhasil.
+func Record(tx *gorm.DB, entry models.AuditLog, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	entry.Data = string(raw)
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	return tx.Create(&entry).Error
+}
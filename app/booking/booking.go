@@ -0,0 +1,138 @@
+package booking
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/realtime"
+)
+
+// ErrSlotTaken dikembalikan jika staff sudah punya booking atau slot sedang ditahan untuk waitlist
+var ErrSlotTaken = errors.New("slot sudah terisi")
+
+// ErrNotCancellable dikembalikan jika booking sudah tidak aktif
+var ErrNotCancellable = errors.New("booking tidak dapat dibatalkan")
+
+// MigrateBranches memindahkan booking dan waitlist lama yang belum memiliki cabang ke cabang utama salon
+func MigrateBranches(db *gorm.DB) error {
+	for _, table := range []string{"bookings", "waitlist_entries", "waitlist_offers"} {
+		if err := inventory.AssignMainBranch(db, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckAvailability memastikan staff kosong pada rentang [start, end).
+// holdOfferID diisi saat slot dipakai oleh penawaran waitlist itu sendiri.
+func CheckAvailability(tx *gorm.DB, staffID uint, start, end time.Time, excludeBookingID, holdOfferID uint) error {
+	// Kunci baris user staff agar dua booking untuk staff yang sama tidak lolos bersamaan
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.User{}, staffID).Error; err != nil {
+		return err
+	}
+
+	var count int64
+	if err := tx.Model(&models.Booking{}).
+		Where("staff_id = ? AND status = ? AND start_at < ? AND end_at > ? AND id <> ?",
+			staffID, models.BookingStatusBooked, end, start, excludeBookingID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrSlotTaken
+	}
+
+	if err := tx.Model(&models.WaitlistOffer{}).
+		Where("staff_id = ? AND status = ? AND expires_at > ? AND start_at < ? AND end_at > ? AND id <> ?",
+			staffID, models.WaitlistOfferPending, time.Now(), end, start, holdOfferID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrSlotTaken
+	}
+	return nil
+}
+
+// Create menyimpan booking baru setelah memastikan slot staff masih kosong
+func Create(tx *gorm.DB, b *models.Booking) error {
+	if err := CheckAvailability(tx, b.StaffID, b.StartAt, b.EndAt, 0, 0); err != nil {
+		return err
+	}
+	b.Status = models.BookingStatusBooked
+	if err := tx.Create(b).Error; err != nil {
+		return err
+	}
+
+	realtime.Publish(tx, b.SalonID, realtime.EventBookingCreated, eventData(b))
+	return nil
+}
+
+// Reschedule memindahkan booking ke jam dan/atau staff lain setelah memastikan slot baru kosong
+func Reschedule(tx *gorm.DB, b *models.Booking, staffID uint, start time.Time) error {
+	if b.Status != models.BookingStatusBooked {
+		return ErrNotCancellable
+	}
+
+	duration := b.EndAt.Sub(b.StartAt)
+	if err := CheckAvailability(tx, staffID, start, start.Add(duration), b.ID, 0); err != nil {
+		return err
+	}
+
+	previous := *b
+	b.StaffID = staffID
+	b.StartAt = start
+	b.EndAt = start.Add(duration)
+	if err := tx.Model(b).Select("staff_id", "start_at", "end_at").Updates(b).Error; err != nil {
+		return err
+	}
+
+	realtime.Publish(tx, b.SalonID, realtime.EventBookingMoved, map[string]interface{}{
+		"booking":        eventData(b),
+		"previous_start": previous.StartAt,
+		"previous_staff": previous.StaffID,
+	})
+	return nil
+}
+
+// Cancel membatalkan booking lalu menawarkan slot yang kosong ke antrian waitlist
+func Cancel(tx *gorm.DB, b *models.Booking, reason string) error {
+	if b.Status != models.BookingStatusBooked {
+		return ErrNotCancellable
+	}
+
+	now := time.Now()
+	b.Status = models.BookingStatusCancelled
+	b.CancelledAt = &now
+	b.CancelReason = reason
+	if err := tx.Model(b).Select("status", "cancelled_at", "cancel_reason").Updates(b).Error; err != nil {
+		return err
+	}
+	realtime.Publish(tx, b.SalonID, realtime.EventBookingCancelled, eventData(b))
+
+	// Slot di masa lalu tidak perlu ditawarkan
+	if b.StartAt.Before(now) {
+		return nil
+	}
+	_, err := OfferSlot(tx, b)
+	return err
+}
+
+// eventData adalah ringkasan booking yang dikirim ke klien realtime
+func eventData(b *models.Booking) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          b.ID,
+		"branch_id":   b.BranchID,
+		"customer_id": b.CustomerID,
+		"staff_id":    b.StaffID,
+		"service_id":  b.ServiceID,
+		"start_at":    b.StartAt,
+		"end_at":      b.EndAt,
+		"status":      b.Status,
+	}
+}
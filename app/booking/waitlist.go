@@ -0,0 +1,192 @@
+package booking
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/realtime"
+	"gin-sass-salon/config"
+)
+
+// ErrOfferNotPending dikembalikan jika penawaran sudah dijawab atau kedaluwarsa
+var ErrOfferNotPending = errors.New("penawaran sudah tidak berlaku")
+
+// OfferSlot menawarkan slot dari booking yang dibatalkan ke entri waitlist pertama yang cocok
+// di cabang yang sama (urut waktu daftar). Slot ditahan selama WAITLIST_HOLD_MINUTES. Mengembalikan nil jika
+// tidak ada entri yang cocok.
+func OfferSlot(tx *gorm.DB, source *models.Booking) (*models.WaitlistOffer, error) {
+	// Entri yang sudah pernah ditawari slot ini (menolak/kedaluwarsa) dilewati
+	offered := tx.Model(&models.WaitlistOffer{}).Select("entry_id").Where("source_booking_id = ?", source.ID)
+
+	var entries []models.WaitlistEntry
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Preload("Service").
+		Where("salon_id = ? AND status = ?", source.SalonID, models.WaitlistStatusWaiting).
+		Where("branch_id IS NOT DISTINCT FROM ?", source.BranchID).
+		Where("staff_id IS NULL OR staff_id = ?", source.StaffID).
+		Where("window_start <= ? AND window_end > ?", source.StartAt, source.StartAt).
+		Where("id NOT IN (?)", offered).
+		Order("created_at, id").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	slotLength := source.EndAt.Sub(source.StartAt)
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Service == nil {
+			continue
+		}
+		duration := time.Duration(entry.Service.DurationMinutes) * time.Minute
+		end := source.StartAt.Add(duration)
+		if duration > slotLength || end.After(entry.WindowEnd) {
+			continue
+		}
+
+		offer := models.WaitlistOffer{
+			SalonID:         source.SalonID,
+			EntryID:         entry.ID,
+			SourceBookingID: source.ID,
+			BranchID:        source.BranchID,
+			StaffID:         source.StaffID,
+			StartAt:         source.StartAt,
+			EndAt:           end,
+			ExpiresAt:       time.Now().Add(config.WaitlistHoldDuration()),
+			Status:          models.WaitlistOfferPending,
+		}
+		if err := tx.Create(&offer).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(entry).Update("status", models.WaitlistStatusOffered).Error; err != nil {
+			return nil, err
+		}
+
+		log.Printf("📋 Slot booking #%d ditawarkan ke waitlist #%d sampai %s", source.ID, entry.ID, offer.ExpiresAt.Format(time.RFC3339))
+		return &offer, nil
+	}
+
+	return nil, nil
+}
+
+// AcceptOffer membuat booking dari penawaran waitlist yang masih berlaku
+func AcceptOffer(tx *gorm.DB, offer *models.WaitlistOffer) (*models.Booking, error) {
+	if offer.Status != models.WaitlistOfferPending || !offer.ExpiresAt.After(time.Now()) {
+		return nil, ErrOfferNotPending
+	}
+
+	var entry models.WaitlistEntry
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, offer.EntryID).Error; err != nil {
+		return nil, err
+	}
+
+	if err := CheckAvailability(tx, offer.StaffID, offer.StartAt, offer.EndAt, 0, offer.ID); err != nil {
+		return nil, err
+	}
+
+	b := models.Booking{
+		SalonID:    offer.SalonID,
+		BranchID:   offer.BranchID,
+		CustomerID: entry.CustomerID,
+		StaffID:    offer.StaffID,
+		ServiceID:  entry.ServiceID,
+		StartAt:    offer.StartAt,
+		EndAt:      offer.EndAt,
+		Status:     models.BookingStatusBooked,
+		Notes:      entry.Notes,
+	}
+	if err := tx.Create(&b).Error; err != nil {
+		return nil, err
+	}
+	realtime.Publish(tx, b.SalonID, realtime.EventBookingCreated, eventData(&b))
+
+	if err := respond(tx, offer, models.WaitlistOfferAccepted); err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&entry).Updates(map[string]interface{}{
+		"status":     models.WaitlistStatusBooked,
+		"booking_id": b.ID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// DeclineOffer menolak penawaran; entri kembali menunggu dan slot ditawarkan ke antrian berikutnya
+func DeclineOffer(tx *gorm.DB, offer *models.WaitlistOffer) error {
+	if offer.Status != models.WaitlistOfferPending {
+		return ErrOfferNotPending
+	}
+	return release(tx, offer, models.WaitlistOfferDeclined)
+}
+
+// ExpireOffers menandai penawaran yang melewati batas waktu hold dan meneruskan slotnya
+func ExpireOffers(db *gorm.DB) (int, error) {
+	expired := 0
+	for {
+		done := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var offer models.WaitlistOffer
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("status = ? AND expires_at <= ?", models.WaitlistOfferPending, time.Now()).
+				Order("expires_at").
+				First(&offer).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				done = true
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return release(tx, &offer, models.WaitlistOfferExpired)
+		})
+		if err != nil {
+			return expired, err
+		}
+		if done {
+			return expired, nil
+		}
+		expired++
+	}
+}
+
+// release menutup penawaran, mengembalikan entri ke antrian, lalu menawarkan slot ke entri berikutnya
+func release(tx *gorm.DB, offer *models.WaitlistOffer, status string) error {
+	if err := respond(tx, offer, status); err != nil {
+		return err
+	}
+	if err := tx.Model(&models.WaitlistEntry{}).
+		Where("id = ? AND status = ?", offer.EntryID, models.WaitlistStatusOffered).
+		Update("status", models.WaitlistStatusWaiting).Error; err != nil {
+		return err
+	}
+
+	var source models.Booking
+	if err := tx.First(&source, offer.SourceBookingID).Error; err != nil {
+		return err
+	}
+	if !source.StartAt.After(time.Now()) {
+		return nil
+	}
+	// Slot mungkin sudah diisi booking lain selama ditahan
+	if err := CheckAvailability(tx, source.StaffID, source.StartAt, source.EndAt, 0, 0); err != nil {
+		if errors.Is(err, ErrSlotTaken) {
+			return nil
+		}
+		return err
+	}
+	_, err := OfferSlot(tx, &source)
+	return err
+}
+
+func respond(tx *gorm.DB, offer *models.WaitlistOffer, status string) error {
+	now := time.Now()
+	offer.Status = status
+	offer.RespondedAt = &now
+	return tx.Model(offer).Select("status", "responded_at").Updates(offer).Error
+}
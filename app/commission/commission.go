@@ -0,0 +1,304 @@
+package commission
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/pos"
+)
+
+// ErrInvalidSplit dikembalikan jika pembagian staff tidak berjumlah 100% atau staff ganda
+var ErrInvalidSplit = errors.New("pembagian staff harus unik dan berjumlah 10000 bps (100%)")
+
+// Split adalah bagian satu staff dari baris layanan
+type Split struct {
+	StaffID  uint
+	ShareBps int
+}
+
+// SetSplits mengganti pembagian staff pada baris layanan. Split kosong berarti baris sepenuhnya milik
+// line.StaffID. Staff utama baris diganti staff dengan bagian terbesar.
+func SetSplits(tx *gorm.DB, line *models.SaleLine, splits []Split) error {
+	if len(splits) > 0 {
+		total := 0
+		seen := make(map[uint]bool, len(splits))
+		for _, split := range splits {
+			if split.ShareBps <= 0 || seen[split.StaffID] {
+				return ErrInvalidSplit
+			}
+			seen[split.StaffID] = true
+			total += split.ShareBps
+		}
+		if total != 10000 {
+			return ErrInvalidSplit
+		}
+	}
+
+	if err := tx.Where("sale_line_id = ?", line.ID).Delete(&models.SaleLineSplit{}).Error; err != nil {
+		return err
+	}
+	if len(splits) < 2 {
+		if len(splits) == 1 {
+			line.StaffID = &splits[0].StaffID
+		}
+		return tx.Model(line).Update("staff_id", line.StaffID).Error
+	}
+
+	rows := make([]models.SaleLineSplit, 0, len(splits))
+	lead := splits[0]
+	for _, split := range splits {
+		rows = append(rows, models.SaleLineSplit{
+			SaleID:     line.SaleID,
+			SaleLineID: line.ID,
+			StaffID:    split.StaffID,
+			ShareBps:   split.ShareBps,
+		})
+		if split.ShareBps > lead.ShareBps {
+			lead = split
+		}
+	}
+	line.StaffID = &lead.StaffID
+	if err := tx.Model(line).Update("staff_id", line.StaffID).Error; err != nil {
+		return err
+	}
+	return tx.Create(&rows).Error
+}
+
+// StatementLine adalah komisi satu staff dari satu baris penjualan
+type StatementLine struct {
+	SaleID        uint      `json:"sale_id"`
+	SaleLineID    uint      `json:"sale_line_id"`
+	ReceiptNumber string    `json:"receipt_number"`
+	CompletedAt   time.Time `json:"completed_at"`
+	Type          string    `json:"type"`
+	Description   string    `json:"description"`
+	Category      string    `json:"category"`
+	// Quantity adalah jumlah unit setelah dikurangi refund
+	Quantity int   `json:"quantity"`
+	ShareBps int   `json:"share_bps"`
+	Revenue  int64 `json:"revenue"`
+	// RuleID adalah aturan komisi yang dipakai; kosong jika staff tidak punya aturan yang cocok
+	RuleID     *uint `json:"rule_id"`
+	Commission int64 `json:"commission"`
+}
+
+// Statement adalah rekap komisi satu staff pada satu periode
+type Statement struct {
+	StaffID           uint            `json:"staff_id"`
+	StaffName         string          `json:"staff_name"`
+	PlanID            *uint           `json:"plan_id"`
+	PlanName          string          `json:"plan_name"`
+	ServiceRevenue    int64           `json:"service_revenue"`
+	RetailRevenue     int64           `json:"retail_revenue"`
+	ServiceCommission int64           `json:"service_commission"`
+	RetailCommission  int64           `json:"retail_commission"`
+	TotalCommission   int64           `json:"total_commission"`
+	Tips              int64           `json:"tips"`
+	Lines             []StatementLine `json:"lines"`
+}
+
+// attributedLine adalah baris penjualan yang sudah dibagi per staff
+type attributedLine struct {
+	StatementLine
+	StaffID          uint
+	LineQuantity     int
+	RefundedQuantity int
+	TaxableAmount    int64
+}
+
+// Statements menghitung komisi per staff salon untuk transaksi pada rentang [from, to) menurut zona waktu
+// loc. Tier dihitung dari omzet staff sebulan penuh sehingga periode yang tidak dimulai di awal bulan tetap
+// memakai tier bulanannya. Nilai baris adalah nilai setelah diskon tanpa pajak, dikurangi bagian yang di-refund;
+// transaksi yang di-void tidak dihitung. staffID 0 berarti semua staff.
+func Statements(db *gorm.DB, salonID, staffID uint, from, to time.Time, loc *time.Location) ([]Statement, error) {
+	monthStart := time.Date(from.In(loc).Year(), from.In(loc).Month(), 1, 0, 0, 0, 0, loc)
+	last := to.Add(-time.Nanosecond).In(loc)
+	monthEnd := time.Date(last.Year(), last.Month(), 1, 0, 0, 0, 0, loc).AddDate(0, 1, 0)
+
+	lines, err := loadLines(db, salonID, staffID, monthStart, monthEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var staff []models.User
+	query := db.Where("salon_id = ?", salonID)
+	if staffID != 0 {
+		query = query.Where("id = ?", staffID)
+	}
+	if err := query.Order("name").Find(&staff).Error; err != nil {
+		return nil, err
+	}
+	plans, err := loadPlans(db, salonID)
+	if err != nil {
+		return nil, err
+	}
+	tips, err := loadTips(db, salonID, staffID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Omzet per staff, jenis item dan bulan untuk menentukan tier
+	type revenueKey struct {
+		staffID  uint
+		itemType string
+		month    string
+	}
+	revenue := map[revenueKey]int64{}
+	for _, line := range lines {
+		revenue[revenueKey{line.StaffID, line.Type, line.CompletedAt.In(loc).Format("2006-01")}] += line.Revenue
+	}
+
+	byStaff := make(map[uint]*Statement, len(staff))
+	statements := make([]Statement, len(staff))
+	for i, member := range staff {
+		statements[i] = Statement{StaffID: member.ID, StaffName: member.Name, PlanID: member.CommissionPlanID,
+			Tips: tips[member.ID], Lines: []StatementLine{}}
+		if member.CommissionPlanID != nil {
+			if plan, ok := plans[*member.CommissionPlanID]; ok {
+				statements[i].PlanName = plan.Name
+			}
+		}
+		byStaff[member.ID] = &statements[i]
+	}
+
+	for _, line := range lines {
+		if line.CompletedAt.Before(from) || !line.CompletedAt.Before(to) {
+			continue
+		}
+		statement, ok := byStaff[line.StaffID]
+		if !ok {
+			continue
+		}
+		if statement.PlanID != nil {
+			if plan, ok := plans[*statement.PlanID]; ok && plan.IsActive {
+				monthly := revenue[revenueKey{line.StaffID, line.Type, line.CompletedAt.In(loc).Format("2006-01")}]
+				if rule := matchRule(plan.Rules, line.Type, line.Category, monthly); rule != nil {
+					line.RuleID = &rule.ID
+					line.Commission = ruleAmount(rule, line)
+				}
+			}
+		}
+
+		if line.Type == models.SaleLineService {
+			statement.ServiceRevenue += line.Revenue
+			statement.ServiceCommission += line.Commission
+		} else {
+			statement.RetailRevenue += line.Revenue
+			statement.RetailCommission += line.Commission
+		}
+		statement.TotalCommission += line.Commission
+		statement.Lines = append(statement.Lines, line.StatementLine)
+	}
+	return statements, nil
+}
+
+// loadLines mengambil baris layanan dan produk yang sudah dibagi per staff pada rentang [from, to)
+func loadLines(db *gorm.DB, salonID, staffID uint, from, to time.Time) ([]attributedLine, error) {
+	query := db.Table("sale_lines").
+		Select("sales.id AS sale_id, sale_lines.id AS sale_line_id, sales.receipt_number, sales.completed_at, "+
+			"sale_lines.type, sale_lines.description, COALESCE(services.category, products.category, '') AS category, "+
+			"COALESCE(sale_line_splits.staff_id, sale_lines.staff_id) AS staff_id, "+
+			"COALESCE(sale_line_splits.share_bps, 10000) AS share_bps, sale_lines.quantity AS line_quantity, "+
+			"sale_lines.refunded_quantity, sale_lines.taxable_amount").
+		Joins("JOIN sales ON sales.id = sale_lines.sale_id").
+		Joins("LEFT JOIN services ON services.id = sale_lines.service_id").
+		Joins("LEFT JOIN products ON products.id = sale_lines.product_id").
+		Joins("LEFT JOIN sale_line_splits ON sale_line_splits.sale_line_id = sale_lines.id").
+		Where("sales.salon_id = ? AND sales.status <> ? AND sales.deleted_at IS NULL AND sale_lines.deleted_at IS NULL",
+			salonID, models.SaleStatusVoided).
+		Where("sales.completed_at >= ? AND sales.completed_at < ?", from, to).
+		Where("sale_lines.type IN ?", []string{models.SaleLineService, models.SaleLineProduct}).
+		Where("COALESCE(sale_line_splits.staff_id, sale_lines.staff_id) IS NOT NULL")
+	if staffID != 0 {
+		query = query.Where("COALESCE(sale_line_splits.staff_id, sale_lines.staff_id) = ?", staffID)
+	}
+
+	var lines []attributedLine
+	if err := query.Order("sales.completed_at, sale_lines.id").Scan(&lines).Error; err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		lines[i].attribute()
+	}
+	return lines, nil
+}
+
+// attribute menghitung jumlah unit setelah refund dan omzet bagian staff dari nilai baris tanpa pajak
+func (line *attributedLine) attribute() {
+	line.Quantity = line.LineQuantity - line.RefundedQuantity
+	base := line.TaxableAmount
+	if line.LineQuantity > 0 {
+		base = line.TaxableAmount * int64(line.Quantity) / int64(line.LineQuantity)
+	}
+	line.Revenue = pos.PercentOf(base, line.ShareBps)
+}
+
+// loadPlans mengambil seluruh skema komisi salon beserta aturannya
+func loadPlans(db *gorm.DB, salonID uint) (map[uint]models.CommissionPlan, error) {
+	var plans []models.CommissionPlan
+	if err := db.Preload("Rules").Where("salon_id = ?", salonID).Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uint]models.CommissionPlan, len(plans))
+	for _, plan := range plans {
+		result[plan.ID] = plan
+	}
+	return result, nil
+}
+
+// loadTips menjumlahkan tip per staff pada rentang [from, to) setelah dikurangi refund
+func loadTips(db *gorm.DB, salonID, staffID uint, from, to time.Time) (map[uint]int64, error) {
+	type row struct {
+		StaffID uint
+		Amount  int64
+	}
+	query := db.Table("sale_lines").
+		Select("sale_lines.staff_id, SUM(sale_lines.net_amount - sale_lines.refunded_amount) AS amount").
+		Joins("JOIN sales ON sales.id = sale_lines.sale_id").
+		Where("sales.salon_id = ? AND sales.status <> ? AND sales.deleted_at IS NULL AND sale_lines.deleted_at IS NULL",
+			salonID, models.SaleStatusVoided).
+		Where("sales.completed_at >= ? AND sales.completed_at < ?", from, to).
+		Where("sale_lines.type = ? AND sale_lines.staff_id IS NOT NULL", models.SaleLineTip)
+	if staffID != 0 {
+		query = query.Where("sale_lines.staff_id = ?", staffID)
+	}
+
+	var rows []row
+	if err := query.Group("sale_lines.staff_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	tips := make(map[uint]int64, len(rows))
+	for _, r := range rows {
+		tips[r.StaffID] = r.Amount
+	}
+	return tips, nil
+}
+
+// matchRule memilih aturan yang berlaku: aturan dengan kategori yang sama diutamakan daripada aturan semua
+// kategori, lalu tier dengan MinMonthlyRevenue tertinggi yang sudah tercapai
+func matchRule(rules []models.CommissionRule, itemType, category string, monthly int64) *models.CommissionRule {
+	var best *models.CommissionRule
+	for i := range rules {
+		rule := &rules[i]
+		if rule.ItemType != itemType || (rule.Category != "" && rule.Category != category) || monthly < rule.MinMonthlyRevenue {
+			continue
+		}
+		if best == nil ||
+			(rule.Category != "" && best.Category == "") ||
+			(rule.Category == best.Category && rule.MinMonthlyRevenue > best.MinMonthlyRevenue) {
+			best = rule
+		}
+	}
+	return best
+}
+
+// ruleAmount menghitung komisi satu baris menurut aturan
+func ruleAmount(rule *models.CommissionRule, line attributedLine) int64 {
+	if rule.Type == models.CommissionTypeFlat {
+		return pos.PercentOf(rule.ValueAmount*int64(line.Quantity), line.ShareBps)
+	}
+	return pos.PercentOf(line.Revenue, rule.ValueBps)
+}
@@ -0,0 +1,88 @@
+package commission
+
+import (
+	"testing"
+
+	"gin-sass-salon/app/models"
+)
+
+func TestMatchRule(t *testing.T) {
+	rules := []models.CommissionRule{
+		{ID: 1, ItemType: models.SaleLineService, Type: models.CommissionTypePercent, ValueBps: 1000},
+		{ID: 2, ItemType: models.SaleLineService, Type: models.CommissionTypePercent, ValueBps: 1500, MinMonthlyRevenue: 10000000},
+		{ID: 3, ItemType: models.SaleLineService, Type: models.CommissionTypePercent, ValueBps: 2000, MinMonthlyRevenue: 20000000},
+		{ID: 4, ItemType: models.SaleLineService, Category: "Coloring", Type: models.CommissionTypePercent, ValueBps: 1200},
+		{ID: 5, ItemType: models.SaleLineService, Category: "Coloring", Type: models.CommissionTypePercent, ValueBps: 1800, MinMonthlyRevenue: 15000000},
+		{ID: 6, ItemType: models.SaleLineProduct, Type: models.CommissionTypeFlat, ValueAmount: 5000, MinMonthlyRevenue: 1000000},
+	}
+
+	tests := []struct {
+		name     string
+		itemType string
+		category string
+		monthly  int64
+		want     uint
+	}{
+		{"tier dasar", models.SaleLineService, "Haircut", 0, 1},
+		{"tepat di batas tier", models.SaleLineService, "Haircut", 10000000, 2},
+		{"tier tertinggi yang tercapai", models.SaleLineService, "Haircut", 25000000, 3},
+		{"kategori diutamakan daripada semua kategori", models.SaleLineService, "Coloring", 0, 4},
+		// Tier semua kategori 20% sudah tercapai, tetapi aturan kategori tetap dipakai
+		{"kategori diutamakan walau tier semua kategori lebih tinggi", models.SaleLineService, "Coloring", 25000000, 5},
+		{"tier kategori", models.SaleLineService, "Coloring", 15000000, 5},
+		{"jenis item lain tidak dipakai", models.SaleLineProduct, "Haircut", 2000000, 6},
+		{"tier belum tercapai", models.SaleLineProduct, "", 999999, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got uint
+			if rule := matchRule(rules, tt.itemType, tt.category, tt.monthly); rule != nil {
+				got = rule.ID
+			}
+			if got != tt.want {
+				t.Errorf("matchRule = aturan %d, ingin %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleAmount(t *testing.T) {
+	percent := &models.CommissionRule{Type: models.CommissionTypePercent, ValueBps: 1500}
+	flat := &models.CommissionRule{Type: models.CommissionTypeFlat, ValueAmount: 5000}
+
+	tests := []struct {
+		name           string
+		rule           *models.CommissionRule
+		lineQuantity   int
+		refunded       int
+		taxable        int64
+		shareBps       int
+		wantQuantity   int
+		wantRevenue    int64
+		wantCommission int64
+	}{
+		{"persen penuh", percent, 1, 0, 150000, 10000, 1, 150000, 22500},
+		{"persen split 60:40 bagian 60", percent, 1, 0, 150000, 6000, 1, 90000, 13500},
+		{"persen split 60:40 bagian 40", percent, 1, 0, 150000, 4000, 1, 60000, 9000},
+		// 3333 bps dari 100001 = 33330,3 dibulatkan 33330, komisi 15% = 4999,5 dibulatkan 5000
+		{"persen split sepertiga dibulatkan", percent, 1, 0, 100001, 3333, 1, 33330, 5000},
+		{"persen setelah refund sebagian", percent, 3, 1, 90000, 10000, 2, 60000, 9000},
+		{"persen refund penuh", percent, 2, 2, 90000, 10000, 0, 0, 0},
+		{"flat per unit", flat, 3, 0, 90000, 10000, 3, 90000, 15000},
+		{"flat split 50:50", flat, 3, 0, 90000, 5000, 3, 45000, 7500},
+		{"flat setelah refund", flat, 3, 2, 90000, 10000, 1, 30000, 5000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := attributedLine{LineQuantity: tt.lineQuantity, RefundedQuantity: tt.refunded, TaxableAmount: tt.taxable}
+			line.ShareBps = tt.shareBps
+			line.attribute()
+			if line.Quantity != tt.wantQuantity || line.Revenue != tt.wantRevenue {
+				t.Errorf("jumlah/omzet = %d/%d, ingin %d/%d", line.Quantity, line.Revenue, tt.wantQuantity, tt.wantRevenue)
+			}
+			if got := ruleAmount(tt.rule, line); got != tt.wantCommission {
+				t.Errorf("ruleAmount = %d, ingin %d", got, tt.wantCommission)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package giftcard
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/config"
+)
+
+// ErrNotFound dikembalikan jika kode gift card tidak dikenal di salon
+var ErrNotFound = errors.New("gift card tidak ditemukan")
+
+// ErrNotUsable dikembalikan jika gift card sudah kedaluwarsa atau dibatalkan
+var ErrNotUsable = errors.New("gift card sudah kedaluwarsa atau tidak aktif")
+
+// ErrInsufficientBalance dikembalikan jika saldo gift card kurang dari nominal pembayaran
+var ErrInsufficientBalance = errors.New("saldo gift card tidak mencukupi")
+
+// ErrAlreadyUsed dikembalikan jika gift card yang dijual akan di-refund padahal saldonya sudah dipakai
+var ErrAlreadyUsed = errors.New("gift card sudah dipakai sehingga tidak dapat di-refund")
+
+// ErrGiftCardForGiftCard dikembalikan jika gift card dibeli dengan gift card lain
+var ErrGiftCardForGiftCard = errors.New("gift card tidak dapat dibeli dengan gift card")
+
+// codeAlphabet tanpa karakter yang mudah tertukar (0/O, 1/I/L)
+const codeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// NewCode membuat kode acak berformat GC-XXXX-XXXX-XXXX (sekitar 59 bit entropi)
+func NewCode() (string, error) {
+	var b strings.Builder
+	b.WriteString("GC")
+	base := big.NewInt(int64(len(codeAlphabet)))
+	for i := 0; i < 12; i++ {
+		if i%4 == 0 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, base)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// NormalizeCode merapikan kode yang diketik kasir: huruf besar, tanpa spasi, dengan tanda hubung standar
+func NormalizeCode(code string) string {
+	var raw []rune
+	for _, r := range strings.ToUpper(code) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			raw = append(raw, r)
+		}
+	}
+	s := string(raw)
+	if len(s) != 14 || !strings.HasPrefix(s, "GC") {
+		return s
+	}
+	return "GC-" + s[2:6] + "-" + s[6:10] + "-" + s[10:14]
+}
+
+// Mask menyembunyikan kode kecuali 4 karakter terakhir, untuk referensi di struk dan tender
+func Mask(code string) string {
+	if len(code) <= 4 {
+		return code
+	}
+	return "GC-****-****-" + code[len(code)-4:]
+}
+
+// Issue menerbitkan gift card baru dengan kode unik dan mencatat saldo awalnya di ledger.
+// ExpiresAt kosong diisi dengan masa berlaku default (GIFT_CARD_VALIDITY_DAYS).
+func Issue(tx *gorm.DB, card *models.GiftCard) error {
+	if card.InitialValue <= 0 {
+		return errors.New("nilai gift card harus lebih dari 0")
+	}
+	if card.ExpiresAt == nil {
+		expires := time.Now().Add(config.GiftCardValidity())
+		card.ExpiresAt = &expires
+	}
+	card.Balance = card.InitialValue
+	card.Status = models.GiftCardStatusActive
+
+	// Kode bentrok sangat jarang; coba ulang beberapa kali dengan ON CONFLICT DO NOTHING
+	for attempt := 0; ; attempt++ {
+		code, err := NewCode()
+		if err != nil {
+			return err
+		}
+		card.Code = code
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(card)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			break
+		}
+		if attempt >= 4 {
+			return errors.New("gagal membuat kode gift card unik")
+		}
+	}
+
+	return record(tx, card, models.GiftCardTxIssue, card.InitialValue, nil, nil, &card.IssuedByID, card.Notes)
+}
+
+// Lock mengambil gift card salon berdasarkan kode dengan FOR UPDATE
+func Lock(tx *gorm.DB, salonID uint, code string) (*models.GiftCard, error) {
+	var card models.GiftCard
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("salon_id = ? AND code = ?", salonID, NormalizeCode(code)).First(&card).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// Usable mengecek status dan masa berlaku gift card pada waktu now
+func Usable(card *models.GiftCard, now time.Time) bool {
+	if card.Status != models.GiftCardStatusActive {
+		return false
+	}
+	return card.ExpiresAt == nil || now.Before(*card.ExpiresAt)
+}
+
+// AttachToSale dijalankan setelah sale tersimpan di transaksi yang sama: memotong saldo gift card
+// untuk tender gift_card (kode dibaca dari Reference lalu disamarkan) dan menerbitkan gift card
+// untuk setiap baris gift_card yang dijual.
+func AttachToSale(tx *gorm.DB, sale *models.Sale) ([]models.GiftCard, error) {
+	now := time.Now()
+	paidWithGiftCard := false
+	for i := range sale.Tenders {
+		tender := &sale.Tenders[i]
+		if tender.Method != models.TenderGiftCard {
+			continue
+		}
+		paidWithGiftCard = true
+
+		card, err := Lock(tx, sale.SalonID, tender.Reference)
+		if err != nil {
+			return nil, err
+		}
+		if !Usable(card, now) {
+			return nil, ErrNotUsable
+		}
+		if card.Balance < tender.Amount {
+			return nil, ErrInsufficientBalance
+		}
+
+		card.Balance -= tender.Amount
+		if err := tx.Model(card).Update("balance", card.Balance).Error; err != nil {
+			return nil, err
+		}
+		if err := record(tx, card, models.GiftCardTxRedeem, -tender.Amount, &sale.ID, &tender.ID, &sale.CashierID, sale.ReceiptNumber); err != nil {
+			return nil, err
+		}
+
+		tender.GiftCardID = &card.ID
+		tender.Reference = Mask(card.Code)
+		if err := tx.Model(tender).Select("gift_card_id", "reference").Updates(tender).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var issued []models.GiftCard
+	for _, line := range sale.Lines {
+		if line.Type != models.SaleLineGiftCard {
+			continue
+		}
+		if paidWithGiftCard {
+			return nil, ErrGiftCardForGiftCard
+		}
+		for n := 0; n < line.Quantity; n++ {
+			lineID := line.ID
+			card := models.GiftCard{
+				SalonID:      sale.SalonID,
+				InitialValue: line.UnitPrice,
+				CustomerID:   sale.CustomerID,
+				SaleID:       &sale.ID,
+				SaleLineID:   &lineID,
+				IssuedByID:   sale.CashierID,
+				Notes:        sale.ReceiptNumber,
+			}
+			if err := Issue(tx, &card); err != nil {
+				return nil, err
+			}
+			issued = append(issued, card)
+		}
+	}
+	return issued, nil
+}
+
+// Credit mengembalikan dana refund ke gift card asal tender. Gift card yang sudah kedaluwarsa
+// diaktifkan lagi dengan masa berlaku baru agar dana pelanggan tidak hangus.
+func Credit(tx *gorm.DB, giftCardID uint, amount int64, saleID, tenderID, userID uint) error {
+	var card models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, giftCardID).Error; err != nil {
+		return err
+	}
+	if card.Status == models.GiftCardStatusVoided {
+		return ErrNotUsable
+	}
+
+	card.Balance += amount
+	now := time.Now()
+	if !Usable(&card, now) {
+		expires := now.Add(config.GiftCardValidity())
+		card.Status = models.GiftCardStatusActive
+		card.ExpiresAt = &expires
+	}
+	if err := tx.Model(&card).Select("balance", "status", "expires_at").Updates(&card).Error; err != nil {
+		return err
+	}
+	return record(tx, &card, models.GiftCardTxRefund, amount, &saleID, &tenderID, &userID, "")
+}
+
+// VoidSold membatalkan qty gift card yang dijual pada satu baris sale (refund/void penjualan
+// gift card). Hanya gift card yang saldonya belum dipakai sama sekali yang boleh dibatalkan.
+func VoidSold(tx *gorm.DB, line *models.SaleLine, qty int, userID uint) error {
+	var cards []models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("sale_line_id = ? AND status = ? AND balance = initial_value", line.ID, models.GiftCardStatusActive).
+		Order("id").Limit(qty).Find(&cards).Error; err != nil {
+		return err
+	}
+	if len(cards) < qty {
+		return ErrAlreadyUsed
+	}
+
+	for i := range cards {
+		card := &cards[i]
+		amount := card.Balance
+		card.Balance = 0
+		card.Status = models.GiftCardStatusVoided
+		if err := tx.Model(card).Select("balance", "status").Updates(card).Error; err != nil {
+			return err
+		}
+		if err := record(tx, card, models.GiftCardTxVoid, -amount, &line.SaleID, nil, &userID, "Refund penjualan gift card"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpireDue menghanguskan saldo gift card aktif yang sudah melewati masa berlaku, satu per satu
+// dalam transaksi terpisah sehingga aman dijalankan di banyak replika
+func ExpireDue(db *gorm.DB) (int, error) {
+	expired := 0
+	for {
+		done := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var card models.GiftCard
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("status = ? AND expires_at <= ?", models.GiftCardStatusActive, time.Now()).
+				Order("expires_at").
+				First(&card).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				done = true
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			amount := card.Balance
+			card.Balance = 0
+			card.Status = models.GiftCardStatusExpired
+			if err := tx.Model(&card).Select("balance", "status").Updates(&card).Error; err != nil {
+				return err
+			}
+			return record(tx, &card, models.GiftCardTxExpire, -amount, nil, nil, nil, "")
+		})
+		if err != nil {
+			return expired, err
+		}
+		if done {
+			return expired, nil
+		}
+		expired++
+	}
+}
+
+// record menambah satu baris ledger dengan saldo setelah mutasi
+func record(tx *gorm.DB, card *models.GiftCard, txType string, amount int64, saleID, tenderID, userID *uint, note string) error {
+	return tx.Create(&models.GiftCardTransaction{
+		GiftCardID:   card.ID,
+		Type:         txType,
+		Amount:       amount,
+		BalanceAfter: card.Balance,
+		SaleID:       saleID,
+		SaleTenderID: tenderID,
+		UserID:       userID,
+		Note:         note,
+		CreatedAt:    time.Now(),
+	}).Error
+}
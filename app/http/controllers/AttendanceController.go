@@ -0,0 +1,614 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gin-sass-salon/app/attendance"
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/models"
+)
+
+// PunchRequest struktur untuk request absen staff yang sedang login
+type PunchRequest struct {
+	Type string `json:"type" binding:"required,oneof=clock_in clock_out break_start break_end" example:"clock_in"`
+	// BranchID kosong berarti cabang default staff (cabang penugasan atau cabang utama)
+	BranchID  *uint    `json:"branch_id" example:"1"`
+	Latitude  *float64 `json:"latitude" binding:"omitempty,latitude" example:"-6.2607"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,longitude" example:"106.8137"`
+	DeviceID  string   `json:"device_id" example:"a1b2c3d4-device"`
+	Note      string   `json:"note" example:""`
+}
+
+// ManualPunchRequest struktur untuk request absen manual oleh owner/manager
+type ManualPunchRequest struct {
+	StaffID   uint      `json:"staff_id" binding:"required" example:"2"`
+	Type      string    `json:"type" binding:"required,oneof=clock_in clock_out break_start break_end" example:"clock_out"`
+	PunchedAt time.Time `json:"punched_at" binding:"required" example:"2025-01-15T18:00:00+07:00"`
+	BranchID  *uint     `json:"branch_id" example:"1"`
+	Note      string    `json:"note" binding:"required" example:"Lupa clock out"`
+}
+
+// StaffShiftRequest struktur untuk request create/update jadwal shift staff
+type StaffShiftRequest struct {
+	StaffID  uint      `json:"staff_id" binding:"required" example:"2"`
+	BranchID *uint     `json:"branch_id" example:"1"`
+	StartAt  time.Time `json:"start_at" binding:"required" example:"2025-01-15T09:00:00+07:00"`
+	EndAt    time.Time `json:"end_at" binding:"required" example:"2025-01-15T17:00:00+07:00"`
+	Note     string    `json:"note" example:"Shift pagi"`
+}
+
+// AttendanceDeviceRequest struktur untuk request perangkat absensi staff
+type AttendanceDeviceRequest struct {
+	// DeviceID kosong berarti staff boleh absen dari perangkat mana pun
+	DeviceID string `json:"device_id" example:"a1b2c3d4-device"`
+}
+
+// CreatePunch godoc
+// @Summary      Clock in/out
+// @Description  Mencatat absen staff yang sedang login: clock_in, break_start, break_end atau clock_out. Jika cabang
+// @Description  memakai geofence, lokasi wajib dikirim dan harus di dalam radius cabang. Jika staff punya perangkat
+// @Description  terdaftar, device_id harus sama.
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      PunchRequest  true  "Punch Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /attendance/punches [post]
+func CreatePunch(c *gin.Context) {
+	var req PunchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "latitude dan longitude harus diisi bersamaan"})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	punch := models.AttendancePunch{
+		SalonID:      *user.SalonID,
+		StaffID:      user.ID,
+		Type:         req.Type,
+		PunchedAt:    time.Now(),
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		DeviceID:     req.DeviceID,
+		RecordedByID: user.ID,
+		Note:         req.Note,
+	}
+	savePunch(c, user, &punch, req.BranchID)
+}
+
+// CreateManualPunch godoc
+// @Summary      Record manual punch
+// @Description  Mencatat absen staff secara manual, mis. staff lupa clock out (khusus owner/manager). Absen manual
+// @Description  tidak diperiksa geofence maupun perangkat dan wajib diberi catatan.
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      ManualPunchRequest  true  "Manual Punch Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /attendance/punches/manual [post]
+func CreateManualPunch(c *gin.Context) {
+	var req ManualPunchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+	if _, ok := findScopedStaff(c, user, req.StaffID); !ok {
+		return
+	}
+	if req.PunchedAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "punched_at tidak boleh di masa depan"})
+		return
+	}
+
+	punch := models.AttendancePunch{
+		SalonID:      *user.SalonID,
+		StaffID:      req.StaffID,
+		Type:         req.Type,
+		PunchedAt:    req.PunchedAt,
+		Manual:       true,
+		RecordedByID: user.ID,
+		Note:         req.Note,
+	}
+	savePunch(c, user, &punch, req.BranchID)
+}
+
+// GetPunches godoc
+// @Summary      Get attendance punches
+// @Description  Mengambil riwayat absen pada rentang tanggal. Staff hanya dapat melihat absennya sendiri dan
+// @Description  branch manager hanya absen di cabangnya.
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from      query     string  false  "Tanggal awal (YYYY-MM-DD), default awal bulan ini"
+// @Param        to        query     string  false  "Tanggal akhir inklusif (YYYY-MM-DD)"
+// @Param        staff_id  query     int     false  "Staff ID"
+// @Success      200       {object}  map[string]interface{}
+// @Failure      400       {object}  map[string]interface{}
+// @Failure      401       {object}  map[string]interface{}
+// @Failure      403       {object}  map[string]interface{}
+// @Failure      500       {object}  map[string]interface{}
+// @Router       /attendance/punches [get]
+func GetPunches(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	query := DBConnection.Where("salon_id = ? AND punched_at >= ? AND punched_at < ?", *user.SalonID, from, to)
+	if scope != nil {
+		query = query.Where("branch_id IN ?", scope)
+	}
+	if !user.IsManager() {
+		query = query.Where("staff_id = ?", user.ID)
+	} else if staffID := c.Query("staff_id"); staffID != "" {
+		query = query.Where("staff_id = ?", staffID)
+	}
+
+	var punches []models.AttendancePunch
+	if err := query.Order("punched_at, id").Find(&punches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": punches})
+}
+
+// GetStaffShifts godoc
+// @Summary      Get staff shifts
+// @Description  Mengambil jadwal shift staff pada rentang tanggal. Staff hanya dapat melihat jadwalnya sendiri dan
+// @Description  branch manager hanya jadwal di cabangnya.
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from       query     string  false  "Tanggal awal (YYYY-MM-DD), default awal bulan ini"
+// @Param        to         query     string  false  "Tanggal akhir inklusif (YYYY-MM-DD)"
+// @Param        staff_id   query     int     false  "Staff ID"
+// @Param        branch_id  query     int     false  "Branch ID"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]interface{}
+// @Failure      401        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]interface{}
+// @Router       /staff-shifts [get]
+func GetStaffShifts(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	query := DBConnection.Where("salon_id = ? AND start_at >= ? AND start_at < ?", *user.SalonID, from, to)
+	if scope != nil {
+		query = query.Where("branch_id IN ?", scope)
+	}
+	if !user.IsManager() {
+		query = query.Where("staff_id = ?", user.ID)
+	} else if staffID := c.Query("staff_id"); staffID != "" {
+		query = query.Where("staff_id = ?", staffID)
+	}
+	if branchID := c.Query("branch_id"); branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+
+	var shifts []models.StaffShift
+	if err := query.Order("start_at, staff_id").Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": shifts})
+}
+
+// CreateStaffShift godoc
+// @Summary      Create staff shift
+// @Description  Menjadwalkan shift kerja staff (khusus owner/manager). Shift staff tidak boleh bertumpuk.
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      StaffShiftRequest  true  "Staff Shift Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /staff-shifts [post]
+func CreateStaffShift(c *gin.Context) {
+	var req StaffShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	shift := models.StaffShift{SalonID: *user.SalonID, CreatedByID: user.ID}
+	if !applyStaffShiftRequest(c, user, &shift, req) {
+		return
+	}
+	if !saveStaffShift(c, &shift) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Shift staff berhasil dibuat", "data": shift})
+}
+
+// UpdateStaffShift godoc
+// @Summary      Update staff shift
+// @Description  Memperbarui jadwal shift staff (khusus owner/manager)
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                true  "Staff Shift ID"
+// @Param        request  body      StaffShiftRequest  true  "Staff Shift Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /staff-shifts/{id} [put]
+func UpdateStaffShift(c *gin.Context) {
+	shiftID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req StaffShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	query := DBConnection.Where("salon_id = ?", *user.SalonID)
+	if scope != nil {
+		query = query.Where("branch_id IN ?", scope)
+	}
+	var shift models.StaffShift
+	if err := query.First(&shift, shiftID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Shift staff tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !applyStaffShiftRequest(c, user, &shift, req) {
+		return
+	}
+	if !saveStaffShift(c, &shift) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shift staff berhasil diperbarui", "data": shift})
+}
+
+// DeleteStaffShift godoc
+// @Summary      Delete staff shift
+// @Description  Menghapus jadwal shift staff (khusus owner/manager)
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Staff Shift ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /staff-shifts/{id} [delete]
+func DeleteStaffShift(c *gin.Context) {
+	shiftID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	query := DBConnection.Where("salon_id = ?", *user.SalonID)
+	if scope != nil {
+		query = query.Where("branch_id IN ?", scope)
+	}
+	result := query.Delete(&models.StaffShift{}, shiftID)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menghapus shift staff"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shift staff tidak ditemukan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shift staff berhasil dihapus"})
+}
+
+// UpdateStaffAttendanceDevice godoc
+// @Summary      Register staff attendance device
+// @Description  Mendaftarkan perangkat absensi staff (khusus owner/manager). Setelah terdaftar, staff hanya dapat
+// @Description  absen dari perangkat tersebut.
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                      true  "Staff (User) ID"
+// @Param        request  body      AttendanceDeviceRequest  true  "Attendance Device Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /salon/staff/{id}/attendance-device [put]
+func UpdateStaffAttendanceDevice(c *gin.Context) {
+	staffID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req AttendanceDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	staff, ok := findSalonStaff(c, *user.SalonID, staffID)
+	if !ok {
+		return
+	}
+	if err := DBConnection.Model(&staff).Update("attendance_device_id", req.DeviceID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui perangkat absensi staff"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Perangkat absensi staff berhasil diperbarui", "data": staff})
+}
+
+// GetAttendanceReport godoc
+// @Summary      Attendance report
+// @Description  Rekap absensi per staff: jumlah shift, hadir, terlambat, tidak hadir, menit terlambat dan pulang awal,
+// @Description  serta jam kerja dan istirahat. Staff hanya dapat melihat rekap miliknya sendiri dan branch manager
+// @Description  hanya staff yang ditugaskan ke cabangnya.
+// @Tags         attendance
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from      query     string  false  "Tanggal awal (YYYY-MM-DD), default awal bulan ini"
+// @Param        to        query     string  false  "Tanggal akhir inklusif (YYYY-MM-DD)"
+// @Param        staff_id  query     int     false  "Staff ID"
+// @Success      200       {object}  map[string]interface{}
+// @Failure      400       {object}  map[string]interface{}
+// @Failure      401       {object}  map[string]interface{}
+// @Failure      403       {object}  map[string]interface{}
+// @Failure      500       {object}  map[string]interface{}
+// @Router       /reports/attendance [get]
+func GetAttendanceReport(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+	staffID, _ := strconv.ParseUint(c.Query("staff_id"), 10, 32)
+	if !user.IsManager() {
+		staffID = uint64(user.ID)
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	summaries, err := attendance.Report(DBConnection, *user.SalonID, uint(staffID), from, to, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if scope != nil {
+		// Branch manager hanya melihat staff yang ditugaskan ke cabangnya
+		var staffIDs []uint
+		if err := DBConnection.Model(&models.BranchStaff{}).Where("branch_id IN ?", scope).
+			Distinct().Pluck("user_id", &staffIDs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		allowed := make(map[uint]bool, len(staffIDs))
+		for _, id := range staffIDs {
+			allowed[id] = true
+		}
+		scoped := summaries[:0]
+		for _, summary := range summaries {
+			if allowed[summary.StaffID] {
+				scoped = append(scoped, summary)
+			}
+		}
+		summaries = scoped
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": from.Format("2006-01-02"),
+		"to":   to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"data": summaries,
+	})
+}
+
+// savePunch menentukan cabang absen (default cabang penugasan staff atau cabang utama) lalu menyimpan absen
+// dan menulis response. Cabang yang dikirim harus dapat diakses user yang mencatat.
+func savePunch(c *gin.Context, user models.User, punch *models.AttendancePunch, branchID *uint) {
+	var branch models.Branch
+	if branchID != nil {
+		found, ok := findUserBranch(c, user, *branchID)
+		if !ok {
+			return
+		}
+		branch = found
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if branchID == nil {
+			defaultBranch, err := inventory.DefaultBranch(tx, punch.SalonID, punch.StaffID)
+			if err != nil {
+				return err
+			}
+			branch = *defaultBranch
+		}
+		punch.BranchID = branch.ID
+		return attendance.Punch(tx, punch, &branch)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, attendance.ErrOutsideGeofence), errors.Is(err, attendance.ErrUnknownDevice):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, attendance.ErrLocationRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, attendance.ErrInvalidSequence):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan absensi"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Absensi berhasil dicatat", "data": punch})
+}
+
+// applyStaffShiftRequest memvalidasi request lalu menyalinnya ke shift. Response error sudah ditulis jika false.
+func applyStaffShiftRequest(c *gin.Context, user models.User, shift *models.StaffShift, req StaffShiftRequest) bool {
+	if !req.EndAt.After(req.StartAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_at harus setelah start_at"})
+		return false
+	}
+	if req.EndAt.Sub(req.StartAt) > 24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Durasi shift maksimal 24 jam"})
+		return false
+	}
+	if _, ok := findScopedStaff(c, user, req.StaffID); !ok {
+		return false
+	}
+	branchID, ok := staffBranch(c, user, req.StaffID, req.BranchID)
+	if !ok {
+		return false
+	}
+	shift.BranchID = branchID
+
+	shift.StaffID = req.StaffID
+	shift.StartAt = req.StartAt
+	shift.EndAt = req.EndAt
+	shift.Note = req.Note
+	return true
+}
+
+// saveStaffShift menyimpan shift jika tidak bertumpuk dengan shift lain staff yang sama. Pemeriksaan
+// diserialkan dengan mengunci baris user staff. Response error sudah ditulis jika false.
+func saveStaffShift(c *gin.Context, shift *models.StaffShift) bool {
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.User{}, shift.StaffID).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.StaffShift{}).
+			Where("staff_id = ? AND id <> ? AND start_at < ? AND end_at > ?", shift.StaffID, shift.ID, shift.EndAt, shift.StartAt).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errShiftOverlap
+		}
+		return tx.Save(shift).Error
+	})
+	if err != nil {
+		if errors.Is(err, errShiftOverlap) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan shift staff"})
+		return false
+	}
+	return true
+}
+
+// errShiftOverlap dikembalikan jika shift bertumpuk dengan shift lain staff yang sama
+var errShiftOverlap = errors.New("Shift bertumpuk dengan shift lain staff ini")
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gin-sass-salon/app/models"
+)
+
+// GetAuditLogs godoc
+// @Summary      Get audit logs
+// @Description  Mengambil audit log salon (refund, void, dll.), terbaru lebih dulu (owner/manager)
+// @Tags         audit
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        action       query     string  false  "Filter aksi (mis. sale.refunded)"
+// @Param        entity_type  query     string  false  "Filter jenis entitas (mis. sale)"
+// @Param        entity_id    query     int     false  "Filter ID entitas"
+// @Param        limit        query     int     false  "Jumlah maksimal (default 100, maks 500)"
+// @Success      200          {object}  map[string]interface{}
+// @Failure      401          {object}  map[string]interface{}
+// @Failure      403          {object}  map[string]interface{}
+// @Failure      500          {object}  map[string]interface{}
+// @Router       /audit-logs [get]
+func GetAuditLogs(c *gin.Context) {
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	query := DBConnection.Where("salon_id = ?", *user.SalonID)
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if entityType := c.Query("entity_type"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": logs})
+}
@@ -0,0 +1,312 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gin-sass-salon/app/booking"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/config"
+)
+
+// CreateBookingRequest struktur untuk request create booking
+type CreateBookingRequest struct {
+	CustomerID uint      `json:"customer_id" binding:"required" example:"1"`
+	ServiceID  uint      `json:"service_id" binding:"required" example:"1"`
+	StaffID    uint      `json:"staff_id" binding:"required" example:"2"`
+	StartAt    time.Time `json:"start_at" binding:"required" example:"2025-01-15T10:00:00+07:00"`
+	Notes      string    `json:"notes" example:"Minta stylist yang sama seperti sebelumnya"`
+	// BranchID kosong berarti cabang default staff (cabang penugasan atau cabang utama)
+	BranchID *uint `json:"branch_id" example:"1"`
+}
+
+// CancelBookingRequest struktur untuk request pembatalan booking
+type CancelBookingRequest struct {
+	Reason string `json:"reason" example:"Pelanggan berhalangan"`
+}
+
+// RescheduleBookingRequest struktur untuk request memindahkan booking
+type RescheduleBookingRequest struct {
+	StartAt time.Time `json:"start_at" binding:"required" example:"2025-01-15T13:00:00+07:00"`
+	StaffID *uint     `json:"staff_id" example:"3"`
+}
+
+// GetBookings godoc
+// @Summary      Get bookings
+// @Description  Mengambil booking salon pada satu hari (default hari ini), dapat difilter per staff dan cabang.
+// @Description  Branch manager hanya melihat booking di cabangnya.
+// @Tags         bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        date       query     string  false  "Tanggal (YYYY-MM-DD)"
+// @Param        staff_id   query     int     false  "Staff ID"
+// @Param        branch_id  query     int     false  "Branch ID"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]interface{}
+// @Failure      401        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]interface{}
+// @Router       /bookings [get]
+func GetBookings(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	loc := config.Location()
+	day := time.Now().In(loc)
+	if date := c.Query("date"); date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", date, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal tidak valid, gunakan YYYY-MM-DD"})
+			return
+		}
+		day = parsed
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	query := DBConnection.Preload("Customer").Preload("Service").
+		Where("salon_id = ? AND start_at >= ? AND start_at < ?", *user.SalonID, start, start.AddDate(0, 0, 1))
+	if scope != nil {
+		query = query.Where("branch_id IN ?", scope)
+	}
+	if staffID := c.Query("staff_id"); staffID != "" {
+		query = query.Where("staff_id = ?", staffID)
+	}
+	if branchID := c.Query("branch_id"); branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+
+	var bookings []models.Booking
+	if err := query.Order("start_at").Find(&bookings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": bookings})
+}
+
+// CreateBooking godoc
+// @Summary      Create booking
+// @Description  Membuat booking baru; ditolak jika staff sudah terisi atau slot sedang ditahan untuk waitlist
+// @Tags         bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CreateBookingRequest  true  "Create Booking Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /bookings [post]
+func CreateBooking(c *gin.Context) {
+	var req CreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+	salonID := *user.SalonID
+
+	if _, ok := findSalonCustomer(c, salonID, req.CustomerID); !ok {
+		return
+	}
+	service, ok := findSalonService(c, salonID, req.ServiceID)
+	if !ok {
+		return
+	}
+	if _, ok := findScopedStaff(c, user, req.StaffID); !ok {
+		return
+	}
+	branchID, ok := staffBranch(c, user, req.StaffID, req.BranchID)
+	if !ok {
+		return
+	}
+
+	b := models.Booking{
+		SalonID:    salonID,
+		BranchID:   &branchID,
+		CustomerID: req.CustomerID,
+		StaffID:    req.StaffID,
+		ServiceID:  req.ServiceID,
+		StartAt:    req.StartAt,
+		EndAt:      req.StartAt.Add(time.Duration(service.DurationMinutes) * time.Minute),
+		Notes:      req.Notes,
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		return booking.Create(tx, &b)
+	})
+	if err != nil {
+		if errors.Is(err, booking.ErrSlotTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Staff sudah memiliki booking pada jam tersebut"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat booking"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Booking berhasil dibuat", "data": b})
+}
+
+// CancelBooking godoc
+// @Summary      Cancel booking
+// @Description  Membatalkan booking; slot yang kosong otomatis ditawarkan ke waitlist
+// @Tags         bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                   true   "Booking ID"
+// @Param        request  body      CancelBookingRequest  false  "Cancel Booking Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /bookings/{id}/cancel [post]
+func CancelBooking(c *gin.Context) {
+	bookingID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req CancelBookingRequest
+	_ = c.ShouldBindJSON(&req)
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	var b models.Booking
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := lockSalonBooking(tx, &b, *user.SalonID, scope, bookingID); err != nil {
+			return err
+		}
+		return booking.Cancel(tx, &b, req.Reason)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, booking.ErrNotCancellable):
+			c.JSON(http.StatusConflict, gin.H{"error": "Booking sudah tidak aktif"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membatalkan booking"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking berhasil dibatalkan", "data": b})
+}
+
+// RescheduleBooking godoc
+// @Summary      Reschedule booking
+// @Description  Memindahkan booking ke jam dan/atau staff lain
+// @Tags         bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                       true  "Booking ID"
+// @Param        request  body      RescheduleBookingRequest  true  "Reschedule Booking Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /bookings/{id}/reschedule [post]
+func RescheduleBooking(c *gin.Context) {
+	bookingID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req RescheduleBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+	if req.StaffID != nil {
+		if _, ok := findScopedStaff(c, user, *req.StaffID); !ok {
+			return
+		}
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	var b models.Booking
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := lockSalonBooking(tx, &b, *user.SalonID, scope, bookingID); err != nil {
+			return err
+		}
+		staffID := b.StaffID
+		if req.StaffID != nil {
+			staffID = *req.StaffID
+		}
+		return booking.Reschedule(tx, &b, staffID, req.StartAt)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, booking.ErrNotCancellable):
+			c.JSON(http.StatusConflict, gin.H{"error": "Booking sudah tidak aktif"})
+		case errors.Is(err, booking.ErrSlotTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Staff sudah memiliki booking pada jam tersebut"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memindahkan booking"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking berhasil dipindahkan", "data": b})
+}
+
+// lockSalonBooking mengambil booking milik salon dengan FOR UPDATE; errBranchAccess jika booking di luar scope cabang
+func lockSalonBooking(tx *gorm.DB, b *models.Booking, salonID uint, scope []uint, bookingID uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("salon_id = ?", salonID).
+		First(b, bookingID).Error; err != nil {
+		return err
+	}
+	if !optionalInBranchScope(scope, b.BranchID) {
+		return errBranchAccess
+	}
+	return nil
+}
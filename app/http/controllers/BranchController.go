@@ -0,0 +1,604 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/config"
+)
+
+// BranchRequest struktur untuk request create/update cabang
+type BranchRequest struct {
+	Name    string `json:"name" binding:"required" example:"Cabang Kemang"`
+	Address string `json:"address" example:"Jl. Kemang Raya No. 10, Jakarta"`
+	Phone   string `json:"phone" example:"0217190000"`
+	// Timezone adalah zona waktu IANA; kosong berarti zona waktu aplikasi
+	Timezone  string   `json:"timezone" example:"Asia/Jakarta"`
+	Latitude  *float64 `json:"latitude" binding:"omitempty,latitude" example:"-6.2607"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,longitude" example:"106.8137"`
+	// GeofenceRadiusMeters 0 berarti absensi staff tidak dibatasi lokasi
+	GeofenceRadiusMeters int   `json:"geofence_radius_meters" binding:"min=0" example:"150"`
+	LateGraceMinutes     int   `json:"late_grace_minutes" binding:"min=0" example:"10"`
+	IsActive             *bool `json:"is_active" example:"true"`
+}
+
+// OpeningHourRequest adalah satu rentang jam buka cabang
+type OpeningHourRequest struct {
+	// Weekday 0 = Minggu sampai 6 = Sabtu
+	Weekday   int    `json:"weekday" binding:"min=0,max=6" example:"1"`
+	OpenTime  string `json:"open_time" binding:"required,datetime=15:04" example:"09:00"`
+	CloseTime string `json:"close_time" binding:"required,datetime=15:04" example:"21:00"`
+}
+
+// OpeningHoursRequest struktur untuk request mengganti seluruh jam buka cabang
+type OpeningHoursRequest struct {
+	Hours []OpeningHourRequest `json:"hours" binding:"dive"`
+}
+
+// StaffBranchesRequest struktur untuk request mengatur cabang tempat staff bekerja
+type StaffBranchesRequest struct {
+	// BranchIDs kosong berarti staff tidak ditugaskan ke cabang tertentu
+	BranchIDs []uint `json:"branch_ids" example:"1,2"`
+}
+
+// errBranchAccess dikembalikan jika branch manager mengakses data cabang yang tidak ditugaskan kepadanya
+var errBranchAccess = errors.New("Anda tidak memiliki akses ke cabang ini")
+
+// GetBranches godoc
+// @Summary      Get branches
+// @Description  Mengambil daftar cabang salon beserta jam bukanya. Cabang utama dibuat otomatis jika belum ada.
+// @Tags         branches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /branches [get]
+func GetBranches(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	if _, err := inventory.MainBranch(DBConnection, *user.SalonID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var branches []models.Branch
+	if err := DBConnection.Preload("OpeningHours", func(db *gorm.DB) *gorm.DB {
+		return db.Order("weekday, open_time")
+	}).Where("salon_id = ?", *user.SalonID).Order("is_main DESC, name").Find(&branches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": branches})
+}
+
+// GetBranch godoc
+// @Summary      Get branch by ID
+// @Description  Mengambil detail cabang beserta jam buka, staff yang ditugaskan dan status buka saat ini
+// @Description  menurut zona waktu cabang
+// @Tags         branches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Branch ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /branches/{id} [get]
+func GetBranch(c *gin.Context) {
+	branchID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	var branch models.Branch
+	if err := DBConnection.Preload("OpeningHours", func(db *gorm.DB) *gorm.DB {
+		return db.Order("weekday, open_time")
+	}).Where("salon_id = ?", *user.SalonID).First(&branch, branchID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cabang tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var staff []models.User
+	if err := DBConnection.Joins("JOIN branch_staffs ON branch_staffs.user_id = users.id").
+		Where("branch_staffs.branch_id = ? AND users.salon_id = ?", branch.ID, *user.SalonID).
+		Order("users.name").Find(&staff).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	staffResponse := []gin.H{}
+	for _, member := range staff {
+		staffResponse = append(staffResponse, gin.H{
+			"id":    member.ID,
+			"name":  member.Name,
+			"email": member.Email,
+			"role":  member.Role,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"branch":   branch,
+		"staff":    staffResponse,
+		"open_now": branch.IsOpenAt(time.Now(), config.Location()),
+	}})
+}
+
+// CreateBranch godoc
+// @Summary      Create branch
+// @Description  Menambahkan cabang salon (khusus owner/manager)
+// @Tags         branches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      BranchRequest  true  "Branch Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /branches [post]
+func CreateBranch(c *gin.Context) {
+	var req BranchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !validBranchRequest(c, req) {
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var branch models.Branch
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		// Cabang utama dibuat lebih dulu agar cabang baru tidak menjadi satu-satunya cabang
+		if _, err := inventory.MainBranch(tx, *user.SalonID); err != nil {
+			return err
+		}
+		branch = models.Branch{SalonID: *user.SalonID, IsActive: true}
+		applyBranchRequest(&branch, req)
+		return tx.Create(&branch).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat cabang"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Cabang berhasil dibuat", "data": branch})
+}
+
+// UpdateBranch godoc
+// @Summary      Update branch
+// @Description  Memperbarui cabang salon termasuk alamat, zona waktu, lokasi dan radius geofence absensi (khusus owner/manager).
+// @Description  Cabang utama tidak dapat dinonaktifkan.
+// @Tags         branches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int            true  "Branch ID"
+// @Param        request  body      BranchRequest  true  "Branch Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /branches/{id} [put]
+func UpdateBranch(c *gin.Context) {
+	branchID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req BranchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !validBranchRequest(c, req) {
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var branch models.Branch
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&branch, branchID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Cabang tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if branch.IsMain && req.IsActive != nil && !*req.IsActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cabang utama tidak dapat dinonaktifkan"})
+		return
+	}
+
+	applyBranchRequest(&branch, req)
+	if err := DBConnection.Save(&branch).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui cabang"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cabang berhasil diperbarui", "data": branch})
+}
+
+// UpdateBranchOpeningHours godoc
+// @Summary      Update branch opening hours
+// @Description  Mengganti seluruh jam buka cabang dalam zona waktu cabang. Hari tanpa rentang berarti tutup.
+// @Description  Khusus owner/manager, atau branch manager cabang tersebut.
+// @Tags         branches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                  true  "Branch ID"
+// @Param        request  body      OpeningHoursRequest  true  "Opening Hours Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /branches/{id}/opening-hours [put]
+func UpdateBranchOpeningHours(c *gin.Context) {
+	branchID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req OpeningHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hours := make([]models.BranchOpeningHour, 0, len(req.Hours))
+	for _, hour := range req.Hours {
+		if hour.OpenTime >= hour.CloseTime {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "close_time harus setelah open_time"})
+			return
+		}
+		for _, other := range hours {
+			if other.Weekday == hour.Weekday && hour.OpenTime < other.CloseTime && other.OpenTime < hour.CloseTime {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Jam buka pada hari yang sama tidak boleh bertumpuk"})
+				return
+			}
+		}
+		hours = append(hours, models.BranchOpeningHour{
+			BranchID:  branchID,
+			Weekday:   hour.Weekday,
+			OpenTime:  hour.OpenTime,
+			CloseTime: hour.CloseTime,
+		})
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+	branch, ok := findUserBranch(c, user, branchID)
+	if !ok {
+		return
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("branch_id = ?", branch.ID).Delete(&models.BranchOpeningHour{}).Error; err != nil {
+			return err
+		}
+		if len(hours) == 0 {
+			return nil
+		}
+		return tx.Create(&hours).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan jam buka cabang"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Jam buka cabang berhasil disimpan", "data": hours})
+}
+
+// UpdateStaffBranches godoc
+// @Summary      Update staff branches
+// @Description  Mengatur cabang tempat staff bekerja (khusus owner/manager). Branch manager hanya dapat mengakses
+// @Description  data cabang yang ditugaskan kepadanya; staff tanpa penugasan dapat bekerja di semua cabang.
+// @Tags         branches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                   true  "Staff ID"
+// @Param        request  body      StaffBranchesRequest  true  "Staff Branches Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /salon/staff/{id}/branches [put]
+func UpdateStaffBranches(c *gin.Context) {
+	staffID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req StaffBranchesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+	staff, ok := findSalonStaff(c, *user.SalonID, staffID)
+	if !ok {
+		return
+	}
+
+	assignments := make([]models.BranchStaff, 0, len(req.BranchIDs))
+	seen := make(map[uint]bool)
+	for _, branchID := range req.BranchIDs {
+		if seen[branchID] {
+			continue
+		}
+		seen[branchID] = true
+		if _, ok := findSalonBranch(c, *user.SalonID, branchID); !ok {
+			return
+		}
+		assignments = append(assignments, models.BranchStaff{BranchID: branchID, UserID: staff.ID})
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", staff.ID).Delete(&models.BranchStaff{}).Error; err != nil {
+			return err
+		}
+		if len(assignments) == 0 {
+			return nil
+		}
+		return tx.Create(&assignments).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan cabang staff"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cabang staff berhasil disimpan", "data": assignments})
+}
+
+// validBranchRequest memvalidasi zona waktu cabang dan menulis response error jika tidak valid
+func validBranchRequest(c *gin.Context, req BranchRequest) bool {
+	if req.Timezone == "" {
+		return true
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Zona waktu cabang tidak dikenal"})
+		return false
+	}
+	return true
+}
+
+// applyBranchRequest menyalin request ke cabang
+func applyBranchRequest(branch *models.Branch, req BranchRequest) {
+	branch.Name = req.Name
+	branch.Address = req.Address
+	branch.Phone = req.Phone
+	branch.Timezone = req.Timezone
+	branch.Latitude = req.Latitude
+	branch.Longitude = req.Longitude
+	branch.GeofenceRadiusMeters = req.GeofenceRadiusMeters
+	branch.LateGraceMinutes = req.LateGraceMinutes
+	if req.IsActive != nil {
+		branch.IsActive = *req.IsActive
+	}
+}
+
+// findSalonBranch memastikan branchID adalah cabang aktif salon dan menulis response error jika bukan
+func findSalonBranch(c *gin.Context, salonID, branchID uint) (models.Branch, bool) {
+	var branch models.Branch
+	if err := DBConnection.Where("salon_id = ? AND is_active", salonID).First(&branch, branchID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cabang tidak ditemukan di salon ini"})
+			return branch, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return branch, false
+	}
+	return branch, true
+}
+
+// branchScope mengembalikan ID cabang yang boleh diakses user. nil berarti semua cabang (owner, manager dan
+// staff); branch manager hanya cabang yang ditugaskan kepadanya. Response error sudah ditulis jika false.
+func branchScope(c *gin.Context, user models.User) ([]uint, bool) {
+	if user.Role != models.RoleBranchManager {
+		return nil, true
+	}
+	ids, err := inventory.AssignedBranchIDs(DBConnection, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Branch manager belum ditugaskan ke cabang mana pun"})
+		return nil, false
+	}
+	return ids, true
+}
+
+// inBranchScope mengecek apakah branchID termasuk scope; scope nil berarti semua cabang
+func inBranchScope(scope []uint, branchID uint) bool {
+	if scope == nil {
+		return true
+	}
+	for _, id := range scope {
+		if id == branchID {
+			return true
+		}
+	}
+	return false
+}
+
+// optionalInBranchScope seperti inBranchScope untuk data dengan cabang opsional; data tanpa cabang hanya
+// termasuk scope semua cabang
+func optionalInBranchScope(scope []uint, branchID *uint) bool {
+	if scope == nil {
+		return true
+	}
+	return branchID != nil && inBranchScope(scope, *branchID)
+}
+
+// findUserBranch seperti findSalonBranch tetapi juga memastikan cabang boleh diakses user
+func findUserBranch(c *gin.Context, user models.User, branchID uint) (models.Branch, bool) {
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return models.Branch{}, false
+	}
+	branch, ok := findSalonBranch(c, *user.SalonID, branchID)
+	if !ok {
+		return branch, false
+	}
+	if !inBranchScope(scope, branch.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errBranchAccess.Error()})
+		return branch, false
+	}
+	return branch, true
+}
+
+// userDefaultBranch mengambil cabang yang dipakai jika request tidak menyertakan branch_id: cabang yang dikirim
+// (dicek aksesnya) atau cabang default user. Response error sudah ditulis jika false.
+func userDefaultBranch(c *gin.Context, user models.User, branchID *uint) (uint, bool) {
+	if branchID != nil {
+		branch, ok := findUserBranch(c, user, *branchID)
+		return branch.ID, ok
+	}
+	branch, err := inventory.DefaultBranch(DBConnection, *user.SalonID, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	return branch.ID, true
+}
+
+// staffBranch mengambil cabang untuk pekerjaan seorang staff: cabang yang dikirim (dicek aksesnya) atau cabang
+// default staff, yang bagi branch manager harus termasuk cabangnya. Response error sudah ditulis jika false.
+func staffBranch(c *gin.Context, user models.User, staffID uint, branchID *uint) (uint, bool) {
+	if branchID != nil {
+		branch, ok := findUserBranch(c, user, *branchID)
+		return branch.ID, ok
+	}
+	branch, err := inventory.DefaultBranch(DBConnection, *user.SalonID, staffID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return 0, false
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return 0, false
+	}
+	if !inBranchScope(scope, branch.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "branch_id wajib karena cabang default staff bukan cabang Anda"})
+		return 0, false
+	}
+	return branch.ID, true
+}
+
+// queryBranch membaca query branch_id opsional lalu memilih cabang seperti userDefaultBranch
+func queryBranch(c *gin.Context, user models.User) (uint, bool) {
+	var requested *uint
+	if raw := c.Query("branch_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "branch_id tidak valid"})
+			return 0, false
+		}
+		branchID := uint(id)
+		requested = &branchID
+	}
+	return userDefaultBranch(c, user, requested)
+}
+
+// scopedBranchParam membaca query branch_id untuk laporan per cabang; 0 berarti semua cabang. Branch manager
+// tanpa branch_id memakai satu-satunya cabangnya dan wajib memilih jika ditugaskan ke beberapa cabang.
+func scopedBranchParam(c *gin.Context, user models.User) (uint, bool) {
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return 0, false
+	}
+	raw := c.Query("branch_id")
+	if raw == "" {
+		switch len(scope) {
+		case 0:
+			return 0, true
+		case 1:
+			return scope[0], true
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "branch_id wajib untuk branch manager dengan beberapa cabang"})
+		return 0, false
+	}
+	id, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "branch_id tidak valid"})
+		return 0, false
+	}
+	if !inBranchScope(scope, uint(id)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errBranchAccess.Error()})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// findScopedStaff seperti findSalonStaff tetapi untuk branch manager juga memastikan staff ditugaskan ke
+// salah satu cabangnya
+func findScopedStaff(c *gin.Context, user models.User, staffID uint) (models.User, bool) {
+	staff, ok := findSalonStaff(c, *user.SalonID, staffID)
+	if !ok {
+		return staff, false
+	}
+	scope, ok := branchScope(c, user)
+	if !ok || scope == nil {
+		return staff, ok
+	}
+	var count int64
+	if err := DBConnection.Model(&models.BranchStaff{}).
+		Where("user_id = ? AND branch_id IN ?", staff.ID, scope).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return staff, false
+	}
+	if count == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Staff tidak ditugaskan ke cabang Anda"})
+		return staff, false
+	}
+	return staff, true
+}
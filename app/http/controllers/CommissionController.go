@@ -0,0 +1,424 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/commission"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/pos"
+	"gin-sass-salon/config"
+)
+
+// CommissionRuleRequest adalah satu aturan/tier komisi
+type CommissionRuleRequest struct {
+	ItemType string `json:"item_type" binding:"required,oneof=service product" example:"service"`
+	// Category kosong berarti semua kategori layanan/produk
+	Category          string `json:"category" example:"Coloring"`
+	Type              string `json:"type" binding:"required,oneof=percent flat" example:"percent"`
+	ValueBps          int    `json:"value_bps" binding:"min=0,max=10000" example:"1500"`
+	ValueAmount       int64  `json:"value_amount" binding:"min=0" example:"0"`
+	MinMonthlyRevenue int64  `json:"min_monthly_revenue" binding:"min=0" example:"20000000"`
+}
+
+// CommissionPlanRequest struktur untuk request create/update skema komisi
+type CommissionPlanRequest struct {
+	Name     string                  `json:"name" binding:"required" example:"Senior Stylist"`
+	IsActive *bool                   `json:"is_active" example:"true"`
+	Rules    []CommissionRuleRequest `json:"rules" binding:"required,min=1,dive"`
+}
+
+// StaffCommissionPlanRequest struktur untuk request memasang skema komisi ke staff
+type StaffCommissionPlanRequest struct {
+	// CommissionPlanID null berarti staff tidak mendapat komisi
+	CommissionPlanID *uint `json:"commission_plan_id" example:"1"`
+}
+
+// SaleSplitRequest adalah bagian satu staff pada layanan yang dikerjakan bersama
+type SaleSplitRequest struct {
+	StaffID  uint `json:"staff_id" binding:"required" example:"2"`
+	ShareBps int  `json:"share_bps" binding:"required,min=1,max=10000" example:"5000"`
+}
+
+// SaleLineStaffRequest struktur untuk request koreksi staff pada baris penjualan
+type SaleLineStaffRequest struct {
+	StaffID *uint `json:"staff_id" example:"2"`
+	// Splits membagi layanan ke beberapa staff; jika diisi, staff_id diabaikan
+	Splits []SaleSplitRequest `json:"splits" binding:"dive"`
+}
+
+// GetCommissionPlans godoc
+// @Summary      Get commission plans
+// @Description  Mengambil skema komisi salon beserta aturannya (khusus owner/manager)
+// @Tags         commissions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /commission-plans [get]
+func GetCommissionPlans(c *gin.Context) {
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var plans []models.CommissionPlan
+	if err := DBConnection.Preload("Rules").Where("salon_id = ?", *user.SalonID).
+		Order("name").Find(&plans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": plans})
+}
+
+// CreateCommissionPlan godoc
+// @Summary      Create commission plan
+// @Description  Membuat skema komisi (khusus owner/manager): tarif persen atau flat per kategori layanan,
+// @Description  tarif retail tersendiri, dan tier berdasarkan omzet bulanan staff lewat min_monthly_revenue
+// @Tags         commissions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CommissionPlanRequest  true  "Commission Plan Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /commission-plans [post]
+func CreateCommissionPlan(c *gin.Context) {
+	var req CommissionPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	plan := models.CommissionPlan{SalonID: *user.SalonID, IsActive: true}
+	applyCommissionPlanRequest(&plan, req)
+	if err := DBConnection.Create(&plan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat skema komisi"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Skema komisi berhasil dibuat", "data": plan})
+}
+
+// UpdateCommissionPlan godoc
+// @Summary      Update commission plan
+// @Description  Memperbarui skema komisi dan mengganti seluruh aturannya (khusus owner/manager). Statement komisi
+// @Description  selalu dihitung ulang dengan aturan terbaru.
+// @Tags         commissions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                    true  "Commission Plan ID"
+// @Param        request  body      CommissionPlanRequest  true  "Commission Plan Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /commission-plans/{id} [put]
+func UpdateCommissionPlan(c *gin.Context) {
+	planID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req CommissionPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var plan models.CommissionPlan
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&plan, planID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Skema komisi tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	applyCommissionPlanRequest(&plan, req)
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("plan_id = ?", plan.ID).Delete(&models.CommissionRule{}).Error; err != nil {
+			return err
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&plan).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui skema komisi"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Skema komisi berhasil diperbarui", "data": plan})
+}
+
+// UpdateStaffCommissionPlan godoc
+// @Summary      Assign commission plan to staff
+// @Description  Memasang atau melepas skema komisi staff (khusus owner/manager)
+// @Tags         commissions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                         true  "Staff (User) ID"
+// @Param        request  body      StaffCommissionPlanRequest  true  "Staff Commission Plan Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /salon/staff/{id}/commission-plan [put]
+func UpdateStaffCommissionPlan(c *gin.Context) {
+	staffID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req StaffCommissionPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	staff, ok := findSalonStaff(c, *user.SalonID, staffID)
+	if !ok {
+		return
+	}
+	if req.CommissionPlanID != nil {
+		var plan models.CommissionPlan
+		if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&plan, *req.CommissionPlanID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Skema komisi tidak ditemukan"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := DBConnection.Model(&staff).Update("commission_plan_id", req.CommissionPlanID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui skema komisi staff"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Skema komisi staff berhasil diperbarui", "data": staff})
+}
+
+// UpdateSaleLineStaff godoc
+// @Summary      Update sale line staff attribution
+// @Description  Mengoreksi staff yang mengerjakan baris layanan/produk, termasuk membagi layanan ke beberapa staff
+// @Description  (khusus owner/manager). Statement komisi langsung memakai pembagian terbaru.
+// @Tags         commissions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                   true  "Sale ID"
+// @Param        line_id  path      int                   true  "Sale Line ID"
+// @Param        request  body      SaleLineStaffRequest  true  "Sale Line Staff Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /sales/{id}/lines/{line_id}/staff [put]
+func UpdateSaleLineStaff(c *gin.Context) {
+	saleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	lineID, ok := parseIDParam(c, "line_id")
+	if !ok {
+		return
+	}
+
+	var req SaleLineStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	splits, ok := saleSplits(c, *user.SalonID, req.Splits)
+	if !ok {
+		return
+	}
+	if len(splits) == 0 {
+		if req.StaffID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "staff_id atau splits wajib diisi"})
+			return
+		}
+		if _, ok := findSalonStaff(c, *user.SalonID, *req.StaffID); !ok {
+			return
+		}
+		splits = []commission.Split{{StaffID: *req.StaffID, ShareBps: 10000}}
+	}
+
+	var line *models.SaleLine
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		sale, err := pos.LockSale(tx, *user.SalonID, saleID)
+		if err != nil {
+			return err
+		}
+		if !saleInScope(scope, sale) {
+			return errBranchAccess
+		}
+		for i := range sale.Lines {
+			if sale.Lines[i].ID == lineID &&
+				(sale.Lines[i].Type == models.SaleLineService || sale.Lines[i].Type == models.SaleLineProduct) {
+				line = &sale.Lines[i]
+			}
+		}
+		if line == nil {
+			return gorm.ErrRecordNotFound
+		}
+		if len(splits) > 1 && line.Type != models.SaleLineService {
+			return commission.ErrInvalidSplit
+		}
+		return commission.SetSplits(tx, line, splits)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Baris penjualan tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, commission.ErrInvalidSplit):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui staff baris penjualan"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Staff baris penjualan berhasil diperbarui", "data": line})
+}
+
+// GetCommissionStatement godoc
+// @Summary      Commission statement
+// @Description  Statement komisi per staff untuk satu periode: omzet layanan dan retail, komisi per baris penjualan
+// @Description  beserta aturan yang dipakai, dan tip. Staff dan branch manager hanya dapat melihat statement miliknya sendiri.
+// @Tags         commissions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from      query     string  false  "Tanggal awal (YYYY-MM-DD), default awal bulan ini"
+// @Param        to        query     string  false  "Tanggal akhir inklusif (YYYY-MM-DD)"
+// @Param        staff_id  query     int     false  "Staff ID"
+// @Success      200       {object}  map[string]interface{}
+// @Failure      400       {object}  map[string]interface{}
+// @Failure      401       {object}  map[string]interface{}
+// @Failure      403       {object}  map[string]interface{}
+// @Failure      500       {object}  map[string]interface{}
+// @Router       /commissions/statement [get]
+func GetCommissionStatement(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+	staffID, _ := strconv.ParseUint(c.Query("staff_id"), 10, 32)
+	if !user.IsBrandManager() {
+		staffID = uint64(user.ID)
+	}
+
+	statements, err := commission.Statements(DBConnection, *user.SalonID, uint(staffID), from, to, config.Location())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": from.Format("2006-01-02"),
+		"to":   to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"data": statements,
+	})
+}
+
+// applyCommissionPlanRequest menyalin request ke skema komisi
+func applyCommissionPlanRequest(plan *models.CommissionPlan, req CommissionPlanRequest) {
+	plan.Name = req.Name
+	if req.IsActive != nil {
+		plan.IsActive = *req.IsActive
+	}
+	plan.Rules = nil
+	for _, rule := range req.Rules {
+		plan.Rules = append(plan.Rules, models.CommissionRule{
+			ItemType:          rule.ItemType,
+			Category:          rule.Category,
+			Type:              rule.Type,
+			ValueBps:          rule.ValueBps,
+			ValueAmount:       rule.ValueAmount,
+			MinMonthlyRevenue: rule.MinMonthlyRevenue,
+		})
+	}
+}
+
+// saleSplits memastikan semua staff split milik salon, unik, dan bagiannya berjumlah 100%.
+// Response error sudah ditulis jika false.
+func saleSplits(c *gin.Context, salonID uint, reqs []SaleSplitRequest) ([]commission.Split, bool) {
+	if len(reqs) == 0 {
+		return nil, true
+	}
+
+	splits := make([]commission.Split, 0, len(reqs))
+	seen := make(map[uint]bool, len(reqs))
+	total := 0
+	for _, split := range reqs {
+		if seen[split.StaffID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Staff split tidak boleh duplikat"})
+			return nil, false
+		}
+		seen[split.StaffID] = true
+		if _, ok := findSalonStaff(c, salonID, split.StaffID); !ok {
+			return nil, false
+		}
+		total += split.ShareBps
+		splits = append(splits, commission.Split{StaffID: split.StaffID, ShareBps: split.ShareBps})
+	}
+	if total != 10000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": commission.ErrInvalidSplit.Error()})
+		return nil, false
+	}
+	return splits, true
+}
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/models"
+)
+
+// CustomerRequest struktur untuk request create customer
+type CustomerRequest struct {
+	Name      string `json:"name" binding:"required" example:"Siti Aminah"`
+	Phone     string `json:"phone" example:"081234567890"`
+	Email     string `json:"email" binding:"omitempty,email" example:"siti@example.com"`
+	BirthDate string `json:"birth_date" binding:"omitempty,datetime=2006-01-02" example:"1995-04-12"`
+	// BranchID adalah cabang tempat pelanggan didaftarkan; kosong berarti cabang default user
+	BranchID *uint `json:"branch_id" example:"1"`
+}
+
+// GetCustomers godoc
+// @Summary      Get salon customers
+// @Description  Mengambil daftar pelanggan salon, dapat dicari berdasarkan nama atau nomor telepon. Branch manager
+// @Description  hanya melihat pelanggan yang didaftarkan atau pernah booking, walk-in atau bertransaksi di cabangnya.
+// @Tags         customers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q    query     string  false  "Kata kunci nama / telepon"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /customers [get]
+func GetCustomers(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	query := customerBranchScope(DBConnection.Where("salon_id = ?", *user.SalonID), scope)
+	if q := c.Query("q"); q != "" {
+		query = query.Where("name ILIKE ? OR phone LIKE ?", "%"+q+"%", "%"+q+"%")
+	}
+
+	var customers []models.Customer
+	if err := query.Order("name").Limit(100).Find(&customers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": customers})
+}
+
+// GetCustomer godoc
+// @Summary      Get customer by ID
+// @Description  Mengambil data pelanggan salon berdasarkan ID; branch manager hanya pelanggan cabangnya
+// @Tags         customers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Customer ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /customers/{id} [get]
+func GetCustomer(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	customer, ok := findScopedCustomer(c, user, customerID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": customer})
+}
+
+// CreateCustomer godoc
+// @Summary      Create customer
+// @Description  Mendaftarkan pelanggan baru ke salon
+// @Tags         customers
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CustomerRequest  true  "Customer Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /customers [post]
+func CreateCustomer(c *gin.Context) {
+	var req CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+	branchID, ok := userDefaultBranch(c, user, req.BranchID)
+	if !ok {
+		return
+	}
+
+	customer := models.Customer{
+		SalonID:  *user.SalonID,
+		BranchID: &branchID,
+		Name:     req.Name,
+		Phone:    req.Phone,
+		Email:    req.Email,
+	}
+	if req.BirthDate != "" {
+		birthDate, _ := time.Parse("2006-01-02", req.BirthDate)
+		customer.BirthDate = &birthDate
+	}
+
+	if err := DBConnection.Create(&customer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat pelanggan"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Pelanggan berhasil dibuat", "data": customer})
+}
+
+// findSalonCustomer mengambil pelanggan milik salon dan menulis response error jika gagal
+func findSalonCustomer(c *gin.Context, salonID, customerID uint) (models.Customer, bool) {
+	var customer models.Customer
+	if err := DBConnection.Where("salon_id = ?", salonID).First(&customer, customerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pelanggan tidak ditemukan"})
+			return customer, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return customer, false
+	}
+	return customer, true
+}
+
+// findScopedCustomer seperti findSalonCustomer tetapi untuk branch manager juga memastikan pelanggan termasuk
+// pelanggan cabangnya
+func findScopedCustomer(c *gin.Context, user models.User, customerID uint) (models.Customer, bool) {
+	customer, ok := findSalonCustomer(c, *user.SalonID, customerID)
+	if !ok {
+		return customer, false
+	}
+	scope, ok := branchScope(c, user)
+	if !ok || scope == nil {
+		return customer, ok
+	}
+	var count int64
+	if err := customerBranchScope(DBConnection.Model(&models.Customer{}).Where("id = ?", customer.ID), scope).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return customer, false
+	}
+	if count == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": errBranchAccess.Error()})
+		return customer, false
+	}
+	return customer, true
+}
+
+// customerBranchScope membatasi query pelanggan ke scope cabang: pelanggan yang didaftarkan di cabang tersebut atau
+// pernah booking, walk-in atau bertransaksi di sana. Pelanggan berlaku di semua cabang sehingga tetap dapat dipilih
+// saat booking atau checkout di cabang mana pun.
+func customerBranchScope(query *gorm.DB, scope []uint) *gorm.DB {
+	if scope == nil {
+		return query
+	}
+	return query.Where("(customers.branch_id IN ? "+
+		"OR EXISTS (SELECT 1 FROM bookings WHERE bookings.customer_id = customers.id AND bookings.branch_id IN ?) "+
+		"OR EXISTS (SELECT 1 FROM walk_ins WHERE walk_ins.customer_id = customers.id AND walk_ins.branch_id IN ?) "+
+		"OR EXISTS (SELECT 1 FROM sales WHERE sales.customer_id = customers.id AND sales.branch_id IN ?))",
+		scope, scope, scope, scope)
+}
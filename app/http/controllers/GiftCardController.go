@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/audit"
+	"gin-sass-salon/app/giftcard"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/config"
+)
+
+// GiftCardRequest struktur untuk request penerbitan gift card tanpa penjualan (komplimen/promosi)
+type GiftCardRequest struct {
+	InitialValue  int64  `json:"initial_value" binding:"required,min=1" example:"250000"`
+	ExpiresAt     string `json:"expires_at" example:"2026-12-31"`
+	CustomerID    *uint  `json:"customer_id" example:"1"`
+	RecipientName string `json:"recipient_name" example:"Siti"`
+	Notes         string `json:"notes" example:"Kompensasi keluhan pelanggan"`
+}
+
+// IssueGiftCard godoc
+// @Summary      Issue gift card
+// @Description  Menerbitkan gift card tanpa penjualan, misalnya komplimen atau hadiah promosi (khusus owner/manager).
+// @Description  Gift card yang dibeli pelanggan diterbitkan lewat checkout dengan item bertipe gift_card.
+// @Tags         gift-cards
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      GiftCardRequest  true  "Gift Card Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /gift-cards [post]
+func IssueGiftCard(c *gin.Context) {
+	var req GiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	card := models.GiftCard{
+		SalonID:       *user.SalonID,
+		InitialValue:  req.InitialValue,
+		RecipientName: req.RecipientName,
+		IssuedByID:    user.ID,
+		Notes:         req.Notes,
+	}
+	if req.CustomerID != nil {
+		if _, ok := findSalonCustomer(c, card.SalonID, *req.CustomerID); !ok {
+			return
+		}
+		card.CustomerID = req.CustomerID
+	}
+	if req.ExpiresAt != "" {
+		day, err := time.ParseInLocation("2006-01-02", req.ExpiresAt, config.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format expires_at tidak valid, gunakan YYYY-MM-DD"})
+			return
+		}
+		// Berlaku sampai akhir hari yang dipilih
+		expires := day.AddDate(0, 0, 1)
+		if !expires.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at harus di masa depan"})
+			return
+		}
+		card.ExpiresAt = &expires
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := giftcard.Issue(tx, &card); err != nil {
+			return err
+		}
+		return audit.Record(tx, models.AuditLog{
+			SalonID:    card.SalonID,
+			UserID:     user.ID,
+			ApprovedBy: &user.ID,
+			Action:     audit.ActionGiftCardIssued,
+			EntityType: "gift_card",
+			EntityID:   card.ID,
+			Reason:     card.Notes,
+		}, gin.H{"initial_value": card.InitialValue, "code": giftcard.Mask(card.Code)})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menerbitkan gift card"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Gift card berhasil diterbitkan", "data": card})
+}
+
+// LookupGiftCard godoc
+// @Summary      Look up gift card by code
+// @Description  Mencari gift card berdasarkan kode untuk cek saldo di kasir, beserta riwayat mutasi saldonya
+// @Tags         gift-cards
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        code  query     string  true  "Kode gift card"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]interface{}
+// @Failure      403   {object}  map[string]interface{}
+// @Failure      404   {object}  map[string]interface{}
+// @Router       /gift-cards/lookup [get]
+func LookupGiftCard(c *gin.Context) {
+	code := giftcard.NormalizeCode(c.Query("code"))
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parameter code wajib diisi"})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	card, ok := findGiftCard(c, DBConnection.Where("salon_id = ? AND code = ?", *user.SalonID, code))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": card, "usable": giftcard.Usable(&card, time.Now())})
+}
+
+// GetGiftCard godoc
+// @Summary      Get gift card
+// @Description  Mengambil detail gift card beserta ledger mutasi saldonya (khusus owner/manager)
+// @Tags         gift-cards
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Gift Card ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Router       /gift-cards/{id} [get]
+func GetGiftCard(c *gin.Context) {
+	cardID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	card, ok := findGiftCard(c, DBConnection.Where("salon_id = ? AND id = ?", *user.SalonID, cardID))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": card, "usable": giftcard.Usable(&card, time.Now())})
+}
+
+// findGiftCard memuat gift card beserta ledger-nya dan menulis response error jika tidak ditemukan
+func findGiftCard(c *gin.Context, query *gorm.DB) (models.GiftCard, bool) {
+	var card models.GiftCard
+	err := query.Preload("Transactions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&card).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Gift card tidak ditemukan"})
+			return card, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return card, false
+	}
+	return card, true
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/queue"
+)
+
+// GetJobs godoc
+// @Summary      List background jobs
+// @Description  Mengambil daftar job pada antrian, dapat difilter berdasarkan status, queue dan type
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status  query     string  false  "Status job (pending, running, completed, dead)"
+// @Param        queue   query     string  false  "Nama queue"
+// @Param        type    query     string  false  "Jenis job"
+// @Param        limit   query     int     false  "Jumlah maksimal data (default 50, maks 200)"
+// @Success      200     {object}  map[string]interface{}
+// @Failure      401     {object}  map[string]interface{}
+// @Failure      403     {object}  map[string]interface{}
+// @Failure      500     {object}  map[string]interface{}
+// @Router       /admin/jobs [get]
+func GetJobs(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	query := DBConnection.Model(&models.Job{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if queueName := c.Query("queue"); queueName != "" {
+		query = query.Where("queue = ?", queueName)
+	}
+	if jobType := c.Query("type"); jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	var jobs []models.Job
+	if err := query.Order("id DESC").Limit(limit).Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Ringkasan jumlah job per status
+	var counts []struct {
+		Status string `json:"status"`
+		Total  int64  `json:"total"`
+	}
+	if err := DBConnection.Model(&models.Job{}).Select("status, COUNT(*) AS total").Group("status").Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": jobs, "summary": counts})
+}
+
+// GetJob godoc
+// @Summary      Get background job by ID
+// @Description  Mengambil detail job termasuk payload dan error terakhir
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Job ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /admin/jobs/{id} [get]
+func GetJob(c *gin.Context) {
+	job, ok := findJob(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// RetryJob godoc
+// @Summary      Retry failed job
+// @Description  Mengembalikan job dead-letter ke antrian dengan jatah percobaan baru
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Job ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /admin/jobs/{id}/retry [post]
+func RetryJob(c *gin.Context) {
+	job, ok := findJob(c)
+	if !ok {
+		return
+	}
+
+	if job.Status != models.JobStatusDead {
+		c.JSON(http.StatusConflict, gin.H{"error": "Hanya job dengan status dead yang dapat di-retry"})
+		return
+	}
+
+	if err := queue.Retry(DBConnection, &job); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal me-retry job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Job dikembalikan ke antrian", "data": job})
+}
+
+// findJob mengambil job dari parameter :id dan menulis response error jika gagal
+func findJob(c *gin.Context) (models.Job, bool) {
+	var job models.Job
+
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return job, false
+	}
+
+	if err := DBConnection.First(&job, jobID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job tidak ditemukan"})
+			return job, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return job, false
+	}
+
+	return job, true
+}
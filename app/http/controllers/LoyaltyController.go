@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gin-sass-salon/app/audit"
+	"gin-sass-salon/app/loyalty"
+	"gin-sass-salon/app/models"
+)
+
+// LoyaltyProgramRequest struktur untuk request pengaturan program loyalty salon
+type LoyaltyProgramRequest struct {
+	IsActive            bool  `json:"is_active" example:"true"`
+	SpendPerPoint       int64 `json:"spend_per_point" binding:"min=0" example:"10000"`
+	PointsPerVisit      int64 `json:"points_per_visit" binding:"min=0" example:"5"`
+	BirthdayBonusPoints int64 `json:"birthday_bonus_points" binding:"min=0" example:"50"`
+	PointValue          int64 `json:"point_value" binding:"min=0" example:"100"`
+	MinRedeemPoints     int64 `json:"min_redeem_points" binding:"min=0" example:"100"`
+	ExpiryDays          int   `json:"expiry_days" binding:"min=0" example:"365"`
+}
+
+// LoyaltyTierRequest struktur untuk request create/update tier loyalty
+type LoyaltyTierRequest struct {
+	Name              string `json:"name" binding:"required" example:"Gold"`
+	MinPoints         int64  `json:"min_points" binding:"min=0" example:"1000"`
+	EarnMultiplierBps int    `json:"earn_multiplier_bps" binding:"required,min=0,max=100000" example:"15000"`
+}
+
+// LoyaltyAdjustmentRequest struktur untuk request koreksi poin manual
+type LoyaltyAdjustmentRequest struct {
+	Points int64  `json:"points" binding:"required" example:"-50"`
+	Reason string `json:"reason" binding:"required" example:"Poin double karena kesalahan input"`
+}
+
+// GetLoyaltyProgram godoc
+// @Summary      Get loyalty program
+// @Description  Mengambil aturan program loyalty salon beserta tier-nya
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /loyalty/program [get]
+func GetLoyaltyProgram(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	var program models.LoyaltyProgram
+	err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&program).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tiers, err := loyalty.Tiers(DBConnection, *user.SalonID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var data interface{}
+	if program.ID != 0 {
+		data = program
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data, "tiers": tiers})
+}
+
+// UpdateLoyaltyProgram godoc
+// @Summary      Update loyalty program
+// @Description  Mengatur aturan poin salon (khusus owner/manager): poin per nominal belanja, per kunjungan, bonus
+// @Description  ulang tahun, nilai tukar poin, minimal penukaran dan masa berlaku poin
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      LoyaltyProgramRequest  true  "Loyalty Program Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /loyalty/program [put]
+func UpdateLoyaltyProgram(c *gin.Context) {
+	var req LoyaltyProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var program models.LoyaltyProgram
+	err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&program).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	program.SalonID = *user.SalonID
+	program.IsActive = req.IsActive
+	program.SpendPerPoint = req.SpendPerPoint
+	program.PointsPerVisit = req.PointsPerVisit
+	program.BirthdayBonusPoints = req.BirthdayBonusPoints
+	program.PointValue = req.PointValue
+	program.MinRedeemPoints = req.MinRedeemPoints
+	program.ExpiryDays = req.ExpiryDays
+
+	if err := DBConnection.Save(&program).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan program loyalty"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Program loyalty berhasil disimpan", "data": program})
+}
+
+// CreateLoyaltyTier godoc
+// @Summary      Create loyalty tier
+// @Description  Menambah tier member (khusus owner/manager). Pelanggan naik tier otomatis saat total poin yang
+// @Description  pernah diperoleh mencapai min_points.
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      LoyaltyTierRequest  true  "Loyalty Tier Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /loyalty/tiers [post]
+func CreateLoyaltyTier(c *gin.Context) {
+	var req LoyaltyTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	tier := models.LoyaltyTier{
+		SalonID:           *user.SalonID,
+		Name:              req.Name,
+		MinPoints:         req.MinPoints,
+		EarnMultiplierBps: req.EarnMultiplierBps,
+	}
+	if err := saveLoyaltyTier(&tier); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat tier"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Tier berhasil dibuat", "data": tier})
+}
+
+// UpdateLoyaltyTier godoc
+// @Summary      Update loyalty tier
+// @Description  Mengubah tier member (khusus owner/manager); tier semua pelanggan dihitung ulang
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                 true  "Tier ID"
+// @Param        request  body      LoyaltyTierRequest  true  "Loyalty Tier Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /loyalty/tiers/{id} [put]
+func UpdateLoyaltyTier(c *gin.Context) {
+	tierID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req LoyaltyTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var tier models.LoyaltyTier
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&tier, tierID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tier tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tier.Name = req.Name
+	tier.MinPoints = req.MinPoints
+	tier.EarnMultiplierBps = req.EarnMultiplierBps
+	if err := saveLoyaltyTier(&tier); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui tier"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tier berhasil diperbarui", "data": tier})
+}
+
+// saveLoyaltyTier menyimpan tier lalu menghitung ulang tier semua akun salon dalam satu transaksi
+func saveLoyaltyTier(tier *models.LoyaltyTier) error {
+	return DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(tier).Error; err != nil {
+			return err
+		}
+		var accounts []models.LoyaltyAccount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("salon_id = ?", tier.SalonID).Find(&accounts).Error; err != nil {
+			return err
+		}
+		for i := range accounts {
+			if err := loyalty.RefreshTier(tx, &accounts[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetCustomerLoyalty godoc
+// @Summary      Get customer loyalty points
+// @Description  Mengambil saldo poin, tier dan ledger poin pelanggan (terbaru lebih dulu)
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      int  true   "Customer ID"
+// @Param        limit  query     int  false  "Jumlah baris ledger (default 50, maks 200)"
+// @Success      200    {object}  map[string]interface{}
+// @Failure      400    {object}  map[string]interface{}
+// @Failure      401    {object}  map[string]interface{}
+// @Failure      403    {object}  map[string]interface{}
+// @Failure      404    {object}  map[string]interface{}
+// @Failure      500    {object}  map[string]interface{}
+// @Router       /customers/{id}/loyalty [get]
+func GetCustomerLoyalty(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := findScopedCustomer(c, user, customerID); !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	account := models.LoyaltyAccount{SalonID: *user.SalonID, CustomerID: customerID}
+	if err := DBConnection.Preload("Tier").Where("customer_id = ?", customerID).
+		First(&account).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	transactions := []models.LoyaltyTransaction{}
+	if err := DBConnection.Where("customer_id = ?", customerID).
+		Order("created_at DESC, id DESC").Limit(limit).Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": account, "transactions": transactions})
+}
+
+// AdjustCustomerLoyalty godoc
+// @Summary      Adjust customer loyalty points
+// @Description  Koreksi poin manual, positif untuk menambah dan negatif untuk mengurangi (khusus owner/manager).
+// @Description  Koreksi tidak mengubah tier dan dicatat di audit log.
+// @Tags         loyalty
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                       true  "Customer ID"
+// @Param        request  body      LoyaltyAdjustmentRequest  true  "Loyalty Adjustment Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /customers/{id}/loyalty/adjustments [post]
+func AdjustCustomerLoyalty(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req LoyaltyAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := findScopedCustomer(c, user, customerID); !ok {
+		return
+	}
+
+	var account *models.LoyaltyAccount
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var err error
+		account, err = loyalty.Adjust(tx, *user.SalonID, customerID, req.Points, user.ID, req.Reason)
+		if err != nil {
+			return err
+		}
+		if account.Balance < 0 {
+			return loyalty.ErrInsufficientPoints
+		}
+		return audit.Record(tx, models.AuditLog{
+			SalonID:    *user.SalonID,
+			UserID:     user.ID,
+			ApprovedBy: &user.ID,
+			Action:     audit.ActionLoyaltyAdjusted,
+			EntityType: "customer",
+			EntityID:   customerID,
+			Reason:     req.Reason,
+		}, gin.H{"points": req.Points, "balance_after": account.Balance})
+	})
+	if err != nil {
+		if errors.Is(err, loyalty.ErrInsufficientPoints) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Koreksi membuat saldo poin negatif"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengoreksi poin"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Poin berhasil dikoreksi", "data": account})
+}
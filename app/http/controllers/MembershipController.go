@@ -0,0 +1,269 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/audit"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/prepaid"
+)
+
+// MembershipBenefitRequest adalah layanan yang termasuk dalam plan membership
+type MembershipBenefitRequest struct {
+	ServiceID uint `json:"service_id" binding:"required" example:"1"`
+	// QuantityPerPeriod 0 berarti tanpa batas
+	QuantityPerPeriod int `json:"quantity_per_period" binding:"min=0" example:"4"`
+}
+
+// MembershipPlanRequest struktur untuk request create/update plan membership
+type MembershipPlanRequest struct {
+	Name         string                     `json:"name" binding:"required" example:"Hair Spa Bulanan"`
+	Price        int64                      `json:"price" binding:"min=0" example:"350000"`
+	PeriodMonths int                        `json:"period_months" binding:"required,min=1,max=12" example:"1"`
+	GraceDays    int                        `json:"grace_days" binding:"min=0" example:"7"`
+	TaxClassID   *uint                      `json:"tax_class_id" example:"1"`
+	IsActive     *bool                      `json:"is_active" example:"true"`
+	Benefits     []MembershipBenefitRequest `json:"benefits" binding:"dive"`
+}
+
+// CancelMembershipRequest struktur untuk request pembatalan membership
+type CancelMembershipRequest struct {
+	// Immediate menghentikan benefit saat ini juga; jika false membership berakhir di akhir periode berjalan
+	Immediate bool   `json:"immediate" example:"false"`
+	Reason    string `json:"reason" example:"Pindah kota"`
+}
+
+// GetMembershipPlans godoc
+// @Summary      Get membership plans
+// @Description  Mengambil plan membership salon beserta benefitnya
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /membership-plans [get]
+func GetMembershipPlans(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	var plans []models.MembershipPlan
+	if err := DBConnection.Preload("Benefits").Where("salon_id = ?", *user.SalonID).
+		Order("name").Find(&plans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": plans})
+}
+
+// CreateMembershipPlan godoc
+// @Summary      Create membership plan
+// @Description  Membuat plan membership berulang (khusus owner/manager) dengan harga per periode dan layanan yang termasuk
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      MembershipPlanRequest  true  "Membership Plan Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /membership-plans [post]
+func CreateMembershipPlan(c *gin.Context) {
+	var req MembershipPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	plan := models.MembershipPlan{SalonID: *user.SalonID, IsActive: true}
+	if !applyMembershipPlanRequest(c, &plan, req) {
+		return
+	}
+
+	if err := DBConnection.Create(&plan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat plan membership"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Plan membership berhasil dibuat", "data": plan})
+}
+
+// UpdateMembershipPlan godoc
+// @Summary      Update membership plan
+// @Description  Memperbarui plan membership (khusus owner/manager). Harga baru berlaku untuk perpanjangan berikutnya,
+// @Description  perubahan benefit langsung berlaku untuk semua member aktif.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                    true  "Membership Plan ID"
+// @Param        request  body      MembershipPlanRequest  true  "Membership Plan Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /membership-plans/{id} [put]
+func UpdateMembershipPlan(c *gin.Context) {
+	planID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req MembershipPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var plan models.MembershipPlan
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&plan, planID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Plan membership tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !applyMembershipPlanRequest(c, &plan, req) {
+		return
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("plan_id = ?", plan.ID).Delete(&models.MembershipBenefit{}).Error; err != nil {
+			return err
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&plan).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui plan membership"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Plan membership berhasil diperbarui", "data": plan})
+}
+
+// CancelMembership godoc
+// @Summary      Cancel membership
+// @Description  Menghentikan perpanjangan membership pelanggan (khusus owner/manager). Tanpa immediate, benefit tetap
+// @Description  berlaku sampai akhir periode yang sudah dibayar. Pembatalan tidak mengembalikan uang; gunakan refund
+// @Description  transaksi untuk itu.
+// @Tags         memberships
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                      true  "Membership ID"
+// @Param        request  body      CancelMembershipRequest  true  "Cancel Membership Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /memberships/{id}/cancel [post]
+func CancelMembership(c *gin.Context) {
+	membershipID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req CancelMembershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	var m models.Membership
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&m, membershipID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Membership tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if m.Status != models.MembershipStatusActive && m.Status != models.MembershipStatusPastDue {
+		c.JSON(http.StatusConflict, gin.H{"error": "Membership sudah tidak aktif"})
+		return
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := prepaid.Cancel(tx, &m, req.Immediate, time.Now()); err != nil {
+			return err
+		}
+		return audit.Record(tx, models.AuditLog{
+			SalonID:    *user.SalonID,
+			UserID:     user.ID,
+			Action:     audit.ActionMembershipCancelled,
+			EntityType: "membership",
+			EntityID:   m.ID,
+			Reason:     req.Reason,
+		}, gin.H{"immediate": req.Immediate, "paid_through": m.PaidThrough})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membatalkan membership"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Membership berhasil dibatalkan", "data": m})
+}
+
+// applyMembershipPlanRequest memvalidasi request lalu menyalinnya ke plan. Response error sudah ditulis jika false.
+func applyMembershipPlanRequest(c *gin.Context, plan *models.MembershipPlan, req MembershipPlanRequest) bool {
+	if req.TaxClassID != nil && !validTaxClass(c, plan.SalonID, *req.TaxClassID) {
+		return false
+	}
+
+	serviceIDs := make([]uint, 0, len(req.Benefits))
+	for _, benefit := range req.Benefits {
+		serviceIDs = append(serviceIDs, benefit.ServiceID)
+	}
+	if len(serviceIDs) > 0 && !validSalonServices(c, plan.SalonID, serviceIDs) {
+		return false
+	}
+
+	plan.Name = req.Name
+	plan.Price = req.Price
+	plan.PeriodMonths = req.PeriodMonths
+	plan.GraceDays = req.GraceDays
+	plan.TaxClassID = req.TaxClassID
+	if req.IsActive != nil {
+		plan.IsActive = *req.IsActive
+	}
+	plan.Benefits = nil
+	for _, benefit := range req.Benefits {
+		plan.Benefits = append(plan.Benefits, models.MembershipBenefit{
+			ServiceID:         benefit.ServiceID,
+			QuantityPerPeriod: benefit.QuantityPerPeriod,
+		})
+	}
+	return true
+}
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/prepaid"
+)
+
+// PackageItemRequest adalah kredit layanan yang diberikan paket
+type PackageItemRequest struct {
+	ServiceID uint `json:"service_id" binding:"required" example:"1"`
+	Quantity  int  `json:"quantity" binding:"required,min=1" example:"10"`
+}
+
+// PackageRequest struktur untuk request create/update paket prepaid
+type PackageRequest struct {
+	Name  string `json:"name" binding:"required" example:"10x Blow Dry"`
+	Price int64  `json:"price" binding:"min=0" example:"900000"`
+	// ValidityDays 0 berarti kredit tidak kedaluwarsa
+	ValidityDays int                  `json:"validity_days" binding:"min=0" example:"180"`
+	TaxClassID   *uint                `json:"tax_class_id" example:"1"`
+	IsActive     *bool                `json:"is_active" example:"true"`
+	Items        []PackageItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// GetPackages godoc
+// @Summary      Get packages
+// @Description  Mengambil katalog paket prepaid salon beserta kredit layanannya
+// @Tags         packages
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /packages [get]
+func GetPackages(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	var packages []models.Package
+	if err := DBConnection.Preload("Items").Where("salon_id = ?", *user.SalonID).
+		Order("name").Find(&packages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": packages})
+}
+
+// CreatePackage godoc
+// @Summary      Create package
+// @Description  Membuat paket prepaid (khusus owner/manager), mis. 10x blow dry dengan masa berlaku 180 hari
+// @Tags         packages
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      PackageRequest  true  "Package Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /packages [post]
+func CreatePackage(c *gin.Context) {
+	var req PackageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	pkg := models.Package{SalonID: *user.SalonID, IsActive: true}
+	if !applyPackageRequest(c, &pkg, req) {
+		return
+	}
+
+	if err := DBConnection.Create(&pkg).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat paket"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Paket berhasil dibuat", "data": pkg})
+}
+
+// UpdatePackage godoc
+// @Summary      Update package
+// @Description  Memperbarui paket prepaid (khusus owner/manager). Perubahan hanya berlaku untuk pembelian berikutnya;
+// @Description  kredit paket yang sudah dibeli pelanggan tidak berubah.
+// @Tags         packages
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int             true  "Package ID"
+// @Param        request  body      PackageRequest  true  "Package Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /packages/{id} [put]
+func UpdatePackage(c *gin.Context) {
+	packageID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req PackageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var pkg models.Package
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&pkg, packageID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Paket tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !applyPackageRequest(c, &pkg, req) {
+		return
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("package_id = ?", pkg.ID).Delete(&models.PackageItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&pkg).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui paket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Paket berhasil diperbarui", "data": pkg})
+}
+
+// GetCustomerPrepaid godoc
+// @Summary      Get customer prepaid balance
+// @Description  Mengambil paket pelanggan beserta sisa kreditnya dan membership beserta pemakaian benefit
+// @Description  pada periode berjalan
+// @Tags         packages
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Customer ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /customers/{id}/prepaid [get]
+func GetCustomerPrepaid(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := findScopedCustomer(c, user, customerID); !ok {
+		return
+	}
+
+	packages := []models.CustomerPackage{}
+	if err := DBConnection.Preload("Credits").Where("customer_id = ?", customerID).
+		Order("created_at DESC").Find(&packages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var memberships []models.Membership
+	if err := DBConnection.Preload("Plan.Benefits").Where("customer_id = ?", customerID).
+		Order("created_at DESC").Find(&memberships).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	type membershipSummary struct {
+		models.Membership
+		// Used adalah pemakaian benefit per service_id pada periode berjalan
+		Used map[uint]int `json:"used"`
+	}
+	now := time.Now()
+	summaries := []membershipSummary{}
+	for _, m := range memberships {
+		used, err := prepaid.Usage(DBConnection, &m, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		summaries = append(summaries, membershipSummary{Membership: m, Used: used})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"packages": packages, "memberships": summaries})
+}
+
+// applyPackageRequest memvalidasi request lalu menyalinnya ke paket. Response error sudah ditulis jika false.
+func applyPackageRequest(c *gin.Context, pkg *models.Package, req PackageRequest) bool {
+	if req.TaxClassID != nil && !validTaxClass(c, pkg.SalonID, *req.TaxClassID) {
+		return false
+	}
+
+	serviceIDs := make([]uint, 0, len(req.Items))
+	for _, item := range req.Items {
+		serviceIDs = append(serviceIDs, item.ServiceID)
+	}
+	if !validSalonServices(c, pkg.SalonID, serviceIDs) {
+		return false
+	}
+
+	pkg.Name = req.Name
+	pkg.Price = req.Price
+	pkg.ValidityDays = req.ValidityDays
+	pkg.TaxClassID = req.TaxClassID
+	if req.IsActive != nil {
+		pkg.IsActive = *req.IsActive
+	}
+	pkg.Items = nil
+	for _, item := range req.Items {
+		pkg.Items = append(pkg.Items, models.PackageItem{ServiceID: item.ServiceID, Quantity: item.Quantity})
+	}
+	return true
+}
+
+// validSalonServices memastikan semua layanan (tanpa duplikat) milik salon. Response error sudah ditulis jika false.
+func validSalonServices(c *gin.Context, salonID uint, serviceIDs []uint) bool {
+	seen := make(map[uint]bool, len(serviceIDs))
+	for _, id := range serviceIDs {
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Layanan tidak boleh duplikat"})
+			return false
+		}
+		seen[id] = true
+	}
+
+	var count int64
+	if err := DBConnection.Model(&models.Service{}).
+		Where("salon_id = ? AND id IN ?", salonID, serviceIDs).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if int(count) != len(serviceIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Layanan tidak ditemukan di salon ini"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/audit"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/payment"
+	"gin-sass-salon/config"
+)
+
+// CreatePaymentRequest struktur untuk request pembuatan tagihan QRIS / virtual account / kartu
+type CreatePaymentRequest struct {
+	Method      string `json:"method" binding:"required,oneof=qris virtual_account card" example:"qris"`
+	Amount      int64  `json:"amount" binding:"required,gt=0" example:"150000"`
+	Description string `json:"description" example:"Potong rambut + creambath"`
+	// Scenario hanya dipakai provider fake: success, failure atau delayed
+	Scenario string `json:"scenario" example:"delayed"`
+}
+
+// RefundPaymentRequest struktur untuk request refund payment
+type RefundPaymentRequest struct {
+	Amount int64  `json:"amount" binding:"required,gt=0" example:"50000"`
+	Reason string `json:"reason" binding:"required" example:"Pelanggan membatalkan layanan"`
+}
+
+// CreatePayment godoc
+// @Summary      Create payment
+// @Description  Membuat tagihan di payment provider (PAYMENT_PROVIDER). Response berisi QR string, nomor VA atau URL kartu.
+// @Description  Setelah status succeeded, payment_id dipakai sebagai tender saat checkout.
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CreatePaymentRequest  true  "Create Payment Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      502      {object}  map[string]interface{}
+// @Router       /payments [post]
+func CreatePayment(c *gin.Context) {
+	var req CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	provider, err := payment.Get(config.PaymentProvider())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	p := models.Payment{
+		SalonID:     *user.SalonID,
+		Method:      req.Method,
+		Amount:      req.Amount,
+		Description: req.Description,
+		CreatedByID: user.ID,
+	}
+	if err := payment.Create(c.Request.Context(), DBConnection, provider, &p, map[string]string{"scenario": req.Scenario}); err != nil {
+		log.Printf("❌ Gagal membuat tagihan %s: %v", provider.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal membuat tagihan di payment provider"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Tagihan berhasil dibuat", "data": p})
+}
+
+// GetPayment godoc
+// @Summary      Get payment by ID
+// @Description  Mengambil status payment; payment yang masih pending disinkronkan dulu ke provider
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Payment ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /payments/{id} [get]
+func GetPayment(c *gin.Context) {
+	paymentID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	p, ok := findSalonPayment(c, *user.SalonID, paymentID)
+	if !ok {
+		return
+	}
+
+	if p.Status == models.PaymentStatusPending {
+		if err := payment.Sync(c.Request.Context(), DBConnection, &p); err != nil {
+			log.Printf("❌ Gagal sinkron payment #%d: %v", p.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": p})
+}
+
+// RefundPayment godoc
+// @Summary      Refund payment
+// @Description  Mengembalikan sebagian atau seluruh dana payment yang belum dipakai checkout (owner/manager).
+// @Description  Payment yang sudah menjadi tender di-refund lewat POST /sales/{id}/refund. Refund dicatat dulu lalu
+// @Description  dikirim ke provider; 202 berarti provider belum merespons dan worker akan mencoba ulang.
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                   true  "Payment ID"
+// @Param        request  body      RefundPaymentRequest  true  "Refund Payment Request"
+// @Success      200      {object}  map[string]interface{}
+// @Success      202      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Failure      502      {object}  map[string]interface{}
+// @Router       /payments/{id}/refund [post]
+func RefundPayment(c *gin.Context) {
+	paymentID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req RefundPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	p, ok := findSalonPayment(c, *user.SalonID, paymentID)
+	if !ok {
+		return
+	}
+
+	var refund *models.PaymentRefund
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var err error
+		refund, err = payment.RequestRefund(tx, &p, nil, req.Amount)
+		if err != nil {
+			return err
+		}
+		return audit.Record(tx, models.AuditLog{
+			SalonID:    p.SalonID,
+			UserID:     user.ID,
+			ApprovedBy: &user.ID,
+			Action:     audit.ActionPaymentRefunded,
+			EntityType: "payment",
+			EntityID:   p.ID,
+			Reason:     req.Reason,
+		}, gin.H{"amount": req.Amount, "payment_refund_id": refund.ID})
+	})
+	if err != nil {
+		if errors.Is(err, payment.ErrNotRefundable) || errors.Is(err, payment.ErrAttachedToSale) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencatat refund"})
+		return
+	}
+
+	// Refund sudah tercatat; jika provider sedang bermasalah, worker akan mencoba ulang
+	if processed, err := payment.ProcessRefund(c.Request.Context(), DBConnection, refund.ID); err != nil {
+		log.Printf("❌ Gagal mengirim refund #%d ke payment provider, dicoba ulang oleh worker: %v", refund.ID, err)
+	} else {
+		refund = processed
+	}
+	if err := DBConnection.First(&p, p.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch refund.Status {
+	case models.PaymentRefundFailed:
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Refund ditolak payment provider: " + refund.FailureReason, "data": p, "refund": refund})
+	case models.PaymentRefundPending:
+		c.JSON(http.StatusAccepted, gin.H{"message": "Refund sedang diproses payment provider", "data": p, "refund": refund})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "Refund berhasil", "data": p, "refund": refund})
+	}
+}
+
+// PaymentWebhook godoc
+// @Summary      Payment provider webhook
+// @Description  Menerima notifikasi status dari payment provider. Signature diverifikasi oleh provider terkait;
+// @Description  event dengan event_id yang sama hanya diproses sekali.
+// @Tags         payments
+// @Accept       json
+// @Produce      json
+// @Param        provider  path      string  true  "Nama provider (midtrans, atau fake di development)"
+// @Success      200       {object}  map[string]interface{}
+// @Failure      400       {object}  map[string]interface{}
+// @Failure      401       {object}  map[string]interface{}
+// @Failure      404       {object}  map[string]interface{}
+// @Failure      500       {object}  map[string]interface{}
+// @Router       /payments/webhooks/{provider} [post]
+func PaymentWebhook(c *gin.Context) {
+	provider, err := payment.Get(c.Param("provider"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, err := payment.HandleWebhook(DBConnection, provider, c.Request.Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, payment.ErrInvalidSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, payment.ErrInvalidWebhook):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, payment.ErrPaymentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			log.Printf("❌ Gagal memproses webhook %s: %v", provider.Name(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses webhook"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "OK", "status": p.Status})
+}
+
+// findSalonPayment mengambil payment milik salon dan menulis response error jika gagal
+func findSalonPayment(c *gin.Context, salonID, paymentID uint) (models.Payment, bool) {
+	var p models.Payment
+	if err := DBConnection.Where("salon_id = ?", salonID).First(&p, paymentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payment tidak ditemukan"})
+			return p, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return p, false
+	}
+	return p, true
+}
@@ -0,0 +1,482 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/audit"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/payroll"
+	"gin-sass-salon/config"
+)
+
+// PayrollPeriodRequest struktur untuk request membuat periode payroll
+type PayrollPeriodRequest struct {
+	StartDate string `json:"start_date" binding:"required,datetime=2006-01-02" example:"2026-10-01"`
+	EndDate   string `json:"end_date" binding:"required,datetime=2006-01-02" example:"2026-10-31"`
+	Notes     string `json:"notes" example:"Gaji Oktober"`
+}
+
+// PayrollDeductionRequest adalah satu potongan gaji
+type PayrollDeductionRequest struct {
+	Description string `json:"description" binding:"required" example:"Kasbon"`
+	Amount      int64  `json:"amount" binding:"required,min=1" example:"250000"`
+}
+
+// PayrollEntryRequest struktur untuk request mengubah entri payroll staff
+type PayrollEntryRequest struct {
+	BasePay    int64                     `json:"base_pay" binding:"min=0" example:"3500000"`
+	Deductions []PayrollDeductionRequest `json:"deductions" binding:"dive"`
+	Notes      string                    `json:"notes" example:"Potong kasbon September"`
+}
+
+// StaffBaseSalaryRequest struktur untuk request gaji pokok staff
+type StaffBaseSalaryRequest struct {
+	BaseSalary int64 `json:"base_salary" binding:"min=0" example:"3500000"`
+}
+
+// GetPayrollPeriods godoc
+// @Summary      Get payroll periods
+// @Description  Mengambil daftar periode payroll salon (khusus owner/manager)
+// @Tags         payroll
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /payroll-periods [get]
+func GetPayrollPeriods(c *gin.Context) {
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var periods []models.PayrollPeriod
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).Order("start_date DESC").Find(&periods).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": periods})
+}
+
+// GetPayrollPeriod godoc
+// @Summary      Get payroll period
+// @Description  Mengambil periode payroll beserta rincian gaji pokok, komisi, tip dan potongan setiap staff
+// @Tags         payroll
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Payroll Period ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /payroll-periods/{id} [get]
+func GetPayrollPeriod(c *gin.Context) {
+	period, ok := findPayrollPeriod(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": period})
+}
+
+// CreatePayrollPeriod godoc
+// @Summary      Create payroll period
+// @Description  Membuat periode payroll draft lalu menghitung gaji pokok, komisi dan tip setiap staff (khusus owner/manager).
+// @Description  Periode tidak boleh beririsan dengan periode lain.
+// @Tags         payroll
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      PayrollPeriodRequest  true  "Payroll Period Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /payroll-periods [post]
+func CreatePayrollPeriod(c *gin.Context) {
+	var req PayrollPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	start, _ := time.Parse("2006-01-02", req.StartDate)
+	end, _ := time.Parse("2006-01-02", req.EndDate)
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date harus sama dengan atau setelah start_date"})
+		return
+	}
+
+	period := models.PayrollPeriod{
+		SalonID:     *user.SalonID,
+		StartDate:   start,
+		EndDate:     end,
+		CreatedByID: user.ID,
+		Notes:       req.Notes,
+	}
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		return payroll.Create(tx, &period, config.Location())
+	})
+	if err != nil {
+		if errors.Is(err, payroll.ErrOverlap) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat periode payroll"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Periode payroll berhasil dibuat", "data": period})
+}
+
+// RecalculatePayrollPeriod godoc
+// @Summary      Recalculate payroll period
+// @Description  Menghitung ulang komisi dan tip periode draft dari transaksi terbaru (khusus owner/manager).
+// @Description  Gaji pokok dan potongan yang sudah diubah tidak ikut berubah.
+// @Tags         payroll
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Payroll Period ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /payroll-periods/{id}/recalculate [post]
+func RecalculatePayrollPeriod(c *gin.Context) {
+	periodID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var period *models.PayrollPeriod
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var err error
+		period, err = payroll.Lock(tx, *user.SalonID, periodID)
+		if err != nil {
+			return err
+		}
+		return payroll.Calculate(tx, period, config.Location())
+	})
+	if err != nil {
+		writePayrollError(c, err, "Gagal menghitung ulang payroll")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payroll berhasil dihitung ulang", "data": period})
+}
+
+// UpdatePayrollEntry godoc
+// @Summary      Update payroll entry
+// @Description  Mengubah gaji pokok, potongan dan catatan satu staff pada periode draft (khusus owner/manager).
+// @Description  Deductions mengganti seluruh potongan entri.
+// @Tags         payroll
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                  true  "Payroll Period ID"
+// @Param        entry_id  path      int                  true  "Payroll Entry ID"
+// @Param        request   body      PayrollEntryRequest  true  "Payroll Entry Request"
+// @Success      200       {object}  map[string]interface{}
+// @Failure      400       {object}  map[string]interface{}
+// @Failure      401       {object}  map[string]interface{}
+// @Failure      403       {object}  map[string]interface{}
+// @Failure      404       {object}  map[string]interface{}
+// @Failure      409       {object}  map[string]interface{}
+// @Failure      500       {object}  map[string]interface{}
+// @Router       /payroll-periods/{id}/entries/{entry_id} [put]
+func UpdatePayrollEntry(c *gin.Context) {
+	periodID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	entryID, ok := parseIDParam(c, "entry_id")
+	if !ok {
+		return
+	}
+
+	var req PayrollEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	deductions := make([]payroll.Deduction, 0, len(req.Deductions))
+	for _, d := range req.Deductions {
+		deductions = append(deductions, payroll.Deduction{Description: d.Description, Amount: d.Amount})
+	}
+
+	var entry *models.PayrollEntry
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		period, err := payroll.Lock(tx, *user.SalonID, periodID)
+		if err != nil {
+			return err
+		}
+		for i := range period.Entries {
+			if period.Entries[i].ID == entryID {
+				entry = &period.Entries[i]
+			}
+		}
+		if entry == nil {
+			return gorm.ErrRecordNotFound
+		}
+		return payroll.UpdateEntry(tx, period, entry, req.BasePay, deductions, req.Notes)
+	})
+	if err != nil {
+		writePayrollError(c, err, "Gagal memperbarui entri payroll")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Entri payroll berhasil diperbarui", "data": entry})
+}
+
+// ApprovePayrollPeriod godoc
+// @Summary      Approve payroll period
+// @Description  Menyetujui dan mengunci periode payroll (khusus owner/manager); setelah disetujui periode tidak dapat
+// @Description  dihitung ulang, diubah maupun dihapus.
+// @Tags         payroll
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Payroll Period ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /payroll-periods/{id}/approve [post]
+func ApprovePayrollPeriod(c *gin.Context) {
+	periodID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var period *models.PayrollPeriod
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var err error
+		period, err = payroll.Lock(tx, *user.SalonID, periodID)
+		if err != nil {
+			return err
+		}
+		if err := payroll.Approve(tx, period, user.ID); err != nil {
+			return err
+		}
+		var net int64
+		for _, entry := range period.Entries {
+			net += entry.NetPay
+		}
+		return audit.Record(tx, models.AuditLog{
+			SalonID:    *user.SalonID,
+			UserID:     user.ID,
+			Action:     audit.ActionPayrollApproved,
+			EntityType: "payroll_period",
+			EntityID:   period.ID,
+		}, gin.H{"start_date": period.StartDate, "end_date": period.EndDate, "staff": len(period.Entries), "net_pay": net})
+	})
+	if err != nil {
+		writePayrollError(c, err, "Gagal menyetujui payroll")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payroll berhasil disetujui", "data": period})
+}
+
+// DeletePayrollPeriod godoc
+// @Summary      Delete payroll period
+// @Description  Menghapus periode payroll yang masih draft (khusus owner/manager)
+// @Tags         payroll
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Payroll Period ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /payroll-periods/{id} [delete]
+func DeletePayrollPeriod(c *gin.Context) {
+	periodID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		period, err := payroll.Lock(tx, *user.SalonID, periodID)
+		if err != nil {
+			return err
+		}
+		if period.Status != models.PayrollStatusDraft {
+			return payroll.ErrLocked
+		}
+		entryIDs := tx.Model(&models.PayrollEntry{}).Select("id").Where("period_id = ?", period.ID)
+		if err := tx.Where("entry_id IN (?)", entryIDs).Delete(&models.PayrollDeduction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("period_id = ?", period.ID).Delete(&models.PayrollEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(period).Error
+	})
+	if err != nil {
+		writePayrollError(c, err, "Gagal menghapus periode payroll")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Periode payroll berhasil dihapus"})
+}
+
+// ExportPayrollPeriod godoc
+// @Summary      Export payroll period
+// @Description  Mengunduh payroll sebagai CSV atau XLSX, satu baris per staff dengan nominal dalam Rupiah (khusus owner/manager)
+// @Tags         payroll
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security     BearerAuth
+// @Param        id      path      int     true   "Payroll Period ID"
+// @Param        format  query     string  false  "csv (default) atau xlsx"
+// @Success      200     {file}    file
+// @Failure      400     {object}  map[string]interface{}
+// @Failure      401     {object}  map[string]interface{}
+// @Failure      403     {object}  map[string]interface{}
+// @Failure      404     {object}  map[string]interface{}
+// @Failure      500     {object}  map[string]interface{}
+// @Router       /payroll-periods/{id}/export [get]
+func ExportPayrollPeriod(c *gin.Context) {
+	period, ok := findPayrollPeriod(c)
+	if !ok {
+		return
+	}
+
+	name := fmt.Sprintf("payroll-%s-%s", period.StartDate.Format("20060102"), period.EndDate.Format("20060102"))
+	writeTable(c, payroll.Table(period), name)
+}
+
+// UpdateStaffBaseSalary godoc
+// @Summary      Update staff base salary
+// @Description  Mengatur gaji pokok staff per periode payroll (khusus owner/manager). Berlaku untuk periode yang dibuat
+// @Description  atau dihitung ulang setelahnya bagi staff yang belum punya entri.
+// @Tags         payroll
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                     true  "Staff (User) ID"
+// @Param        request  body      StaffBaseSalaryRequest  true  "Staff Base Salary Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /salon/staff/{id}/base-salary [put]
+func UpdateStaffBaseSalary(c *gin.Context) {
+	staffID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req StaffBaseSalaryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	staff, ok := findSalonStaff(c, *user.SalonID, staffID)
+	if !ok {
+		return
+	}
+	if err := DBConnection.Model(&staff).Update("base_salary", req.BaseSalary).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui gaji pokok staff"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Gaji pokok staff berhasil diperbarui", "data": staff})
+}
+
+// findPayrollPeriod mengambil periode payroll dari parameter path id beserta entrinya (khusus owner/manager).
+// Response error sudah ditulis jika false.
+func findPayrollPeriod(c *gin.Context) (*models.PayrollPeriod, bool) {
+	periodID, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return nil, false
+	}
+
+	var period models.PayrollPeriod
+	if err := DBConnection.Preload("Entries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("staff_name, id")
+	}).Preload("Entries.DeductionItems").Where("salon_id = ?", *user.SalonID).First(&period, periodID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Periode payroll tidak ditemukan"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &period, true
+}
+
+// writePayrollError menulis response untuk error aksi periode payroll
+func writePayrollError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Periode atau entri payroll tidak ditemukan"})
+	case errors.Is(err, payroll.ErrLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/models"
+)
+
+// ProductRequest struktur untuk request create/update produk
+type ProductRequest struct {
+	SKU      string `json:"sku" binding:"required" example:"SHP-250"`
+	Barcode  string `json:"barcode" example:"8991234567890"`
+	Name     string `json:"name" binding:"required" example:"Shampoo Keratin 250ml"`
+	Brand    string `json:"brand" example:"Makarizo"`
+	Category string `json:"category" example:"haircare"`
+	// Unit adalah satuan stok, mis. pcs, tube, ml
+	Unit string `json:"unit" example:"pcs"`
+	// CostPrice adalah harga pokok awal; kosong pada update berarti tidak berubah. Setelah produk memiliki mutasi
+	// stok, harga pokok mengikuti rata-rata penerimaan barang dan hanya dapat dikoreksi lewat adjustment stok.
+	CostPrice   *int64 `json:"cost_price" binding:"omitempty,min=0" example:"45000"`
+	RetailPrice int64  `json:"retail_price" binding:"min=0" example:"85000"`
+	// IsRetail false untuk bahan salon yang tidak dijual di kasir
+	IsRetail          *bool `json:"is_retail" example:"true"`
+	LowStockThreshold int64 `json:"low_stock_threshold" binding:"min=0" example:"5"`
+	TaxClassID        *uint `json:"tax_class_id" example:"1"`
+	IsActive          *bool `json:"is_active" example:"true"`
+}
+
+// GetProducts godoc
+// @Summary      Get products
+// @Description  Mengambil katalog produk salon, dapat difilter dengan pencarian nama/SKU/barcode dan kategori
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q         query     string  false  "Cari nama, SKU atau barcode"
+// @Param        category  query     string  false  "Kategori"
+// @Success      200       {object}  map[string]interface{}
+// @Failure      401       {object}  map[string]interface{}
+// @Failure      403       {object}  map[string]interface{}
+// @Failure      500       {object}  map[string]interface{}
+// @Router       /products [get]
+func GetProducts(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	query := DBConnection.Where("salon_id = ?", *user.SalonID)
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("name ILIKE ? OR sku ILIKE ? OR barcode = ?", like, like, q)
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+
+	var products []models.Product
+	if err := query.Order("category, name").Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": products})
+}
+
+// LookupProduct godoc
+// @Summary      Lookup product by barcode
+// @Description  Mencari produk aktif berdasarkan barcode atau SKU (untuk scanner di kasir)
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        code  query     string  true  "Barcode atau SKU"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]interface{}
+// @Failure      403   {object}  map[string]interface{}
+// @Failure      404   {object}  map[string]interface{}
+// @Failure      500   {object}  map[string]interface{}
+// @Router       /products/lookup [get]
+func LookupProduct(c *gin.Context) {
+	code := strings.TrimSpace(c.Query("code"))
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code wajib diisi"})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	var product models.Product
+	if err := DBConnection.Where("salon_id = ? AND is_active AND (barcode = ? OR sku = ?)", *user.SalonID, code, code).
+		First(&product).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Produk tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": product})
+}
+
+// CreateProduct godoc
+// @Summary      Create product
+// @Description  Menambahkan produk retail atau bahan salon (khusus owner/manager)
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      ProductRequest  true  "Product Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /products [post]
+func CreateProduct(c *gin.Context) {
+	var req ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	product := models.Product{SalonID: *user.SalonID, IsRetail: true, IsActive: true}
+	if !applyProductRequest(c, &product, req) {
+		return
+	}
+
+	if err := DBConnection.Create(&product).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat produk"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Produk berhasil dibuat", "data": product})
+}
+
+// UpdateProduct godoc
+// @Summary      Update product
+// @Description  Memperbarui produk (khusus owner/manager). Stok hanya berubah lewat mutasi stok; harga pokok produk yang
+// @Description  sudah memiliki mutasi stok hanya dapat diubah lewat adjustment stok dengan unit_cost.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int             true  "Product ID"
+// @Param        request  body      ProductRequest  true  "Product Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /products/{id} [put]
+func UpdateProduct(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var product models.Product
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&product, productID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Produk tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !applyProductRequest(c, &product, req) {
+		return
+	}
+
+	// Harga pokok dipelihara penerimaan barang; hanya ditulis jika request mengubahnya
+	query := DBConnection
+	if req.CostPrice == nil {
+		query = query.Omit("cost_price")
+	}
+	if err := query.Save(&product).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui produk"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Produk berhasil diperbarui", "data": product})
+}
+
+// GetProductStock godoc
+// @Summary      Get product stock
+// @Description  Mengambil stok produk per cabang beserta mutasi stok terakhir
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path      int  true   "Product ID"
+// @Param        limit  query     int  false  "Jumlah mutasi (default 50, maks 200)"
+// @Success      200    {object}  map[string]interface{}
+// @Failure      400    {object}  map[string]interface{}
+// @Failure      401    {object}  map[string]interface{}
+// @Failure      403    {object}  map[string]interface{}
+// @Failure      500    {object}  map[string]interface{}
+// @Router       /products/{id}/stock [get]
+func GetProductStock(c *gin.Context) {
+	productID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	product, ok := findSalonProduct(c, *user.SalonID, productID)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	levels := []models.StockLevel{}
+	if err := DBConnection.Where("product_id = ?", product.ID).Order("branch_id").Find(&levels).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	movements := []models.StockMovement{}
+	if err := DBConnection.Where("product_id = ?", product.ID).
+		Order("created_at DESC, id DESC").Limit(limit).Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": product, "stock": levels, "movements": movements})
+}
+
+// applyProductRequest memvalidasi request lalu menyalinnya ke produk. Response error sudah ditulis jika false.
+func applyProductRequest(c *gin.Context, product *models.Product, req ProductRequest) bool {
+	if req.TaxClassID != nil && !validTaxClass(c, product.SalonID, *req.TaxClassID) {
+		return false
+	}
+
+	sku := strings.ToUpper(strings.TrimSpace(req.SKU))
+	var existing int64
+	if err := DBConnection.Model(&models.Product{}).
+		Where("salon_id = ? AND sku = ? AND id <> ?", product.SalonID, sku, product.ID).Count(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "SKU sudah dipakai produk lain"})
+		return false
+	}
+
+	product.SKU = sku
+	product.Barcode = strings.TrimSpace(req.Barcode)
+	product.Name = req.Name
+	product.Brand = req.Brand
+	product.Category = req.Category
+	product.Unit = req.Unit
+	if product.Unit == "" {
+		product.Unit = "pcs"
+	}
+	if req.CostPrice != nil && *req.CostPrice != product.CostPrice {
+		if product.ID != 0 {
+			var movements int64
+			if err := DBConnection.Model(&models.StockMovement{}).Where("product_id = ?", product.ID).
+				Count(&movements).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return false
+			}
+			if movements > 0 {
+				c.JSON(http.StatusConflict, gin.H{"error": "Produk sudah memiliki mutasi stok; ubah harga pokok lewat adjustment stok dengan unit_cost"})
+				return false
+			}
+		}
+		product.CostPrice = *req.CostPrice
+	}
+	product.RetailPrice = req.RetailPrice
+	product.LowStockThreshold = req.LowStockThreshold
+	product.TaxClassID = req.TaxClassID
+	if req.IsRetail != nil {
+		product.IsRetail = *req.IsRetail
+	}
+	if req.IsActive != nil {
+		product.IsActive = *req.IsActive
+	}
+	return true
+}
+
+// findSalonProduct memastikan productID adalah produk aktif salon dan menulis response error jika bukan
+func findSalonProduct(c *gin.Context, salonID, productID uint) (models.Product, bool) {
+	var product models.Product
+	if err := DBConnection.Where("salon_id = ? AND is_active", salonID).First(&product, productID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Produk tidak ditemukan di salon ini"})
+			return product, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return product, false
+	}
+	return product, true
+}
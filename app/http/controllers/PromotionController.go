@@ -0,0 +1,369 @@
+package controllers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/pos"
+	"gin-sass-salon/app/promo"
+	"gin-sass-salon/config"
+)
+
+// clockPattern memvalidasi jam HH:MM
+var clockPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// PromotionRequest struktur untuk request create/update promo
+type PromotionRequest struct {
+	Code        string `json:"code" binding:"required,max=32" example:"WEEKDAY20"`
+	Name        string `json:"name" binding:"required" example:"Diskon coloring 20% hari kerja"`
+	Description string `json:"description" example:"Berlaku Senin-Jumat"`
+	IsActive    *bool  `json:"is_active" example:"true"`
+	// Type: percent (value_bps), amount (value_amount) atau buy_x_get_y (buy_quantity, get_quantity, value_bps)
+	Type        string `json:"type" binding:"required,oneof=percent amount buy_x_get_y" example:"percent"`
+	ValueBps    int    `json:"value_bps" binding:"min=0,max=10000" example:"2000"`
+	ValueAmount int64  `json:"value_amount" binding:"min=0" example:"0"`
+	BuyQuantity int    `json:"buy_quantity" binding:"min=0" example:"0"`
+	GetQuantity int    `json:"get_quantity" binding:"min=0" example:"0"`
+	// Category membatasi item yang didiskon ke kategori layanan tertentu
+	Category string `json:"category" example:"Coloring"`
+	StartsAt string `json:"starts_at" example:"2025-01-01"`
+	EndsAt   string `json:"ends_at" example:"2025-03-31"`
+	// DaysOfWeek: 0 = Minggu ... 6 = Sabtu
+	DaysOfWeek         []int  `json:"days_of_week" binding:"dive,min=0,max=6" example:"1,2,3,4,5"`
+	StartTime          string `json:"start_time" example:"10:00"`
+	EndTime            string `json:"end_time" example:"17:00"`
+	MinSpend           int64  `json:"min_spend" binding:"min=0" example:"0"`
+	FirstVisitOnly     bool   `json:"first_visit_only" example:"false"`
+	CustomerSegment    string `json:"customer_segment" binding:"omitempty,oneof=returning lapsed birthday_month" example:""`
+	MaxUses            int    `json:"max_uses" binding:"min=0" example:"100"`
+	MaxUsesPerCustomer int    `json:"max_uses_per_customer" binding:"min=0" example:"1"`
+	Stackable          bool   `json:"stackable" example:"false"`
+}
+
+// GetPromotions godoc
+// @Summary      Get promotions
+// @Description  Mengambil semua promo salon beserta jumlah pemakaiannya (khusus owner/manager)
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /promotions [get]
+func GetPromotions(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	var promotions []models.Promotion
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).Order("code").Find(&promotions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Pemakaian dihitung dari transaksi yang tidak di-void
+	var usages []struct {
+		PromotionID uint  `json:"promotion_id"`
+		Uses        int64 `json:"uses"`
+		Amount      int64 `json:"amount"`
+	}
+	if err := DBConnection.Model(&models.PromotionRedemption{}).
+		Select("promotion_redemptions.promotion_id, COUNT(*) AS uses, COALESCE(SUM(promotion_redemptions.amount), 0) AS amount").
+		Joins("JOIN sales ON sales.id = promotion_redemptions.sale_id").
+		Where("sales.salon_id = ? AND sales.status <> ?", *user.SalonID, models.SaleStatusVoided).
+		Group("promotion_redemptions.promotion_id").Scan(&usages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": promotions, "usage": usages})
+}
+
+// CreatePromotion godoc
+// @Summary      Create promotion
+// @Description  Membuat kode promo dengan kondisi (kategori, hari/jam, segmen pelanggan, kunjungan pertama,
+// @Description  minimal belanja), batas pemakaian dan aturan penggabungan (khusus owner/manager)
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      PromotionRequest  true  "Promotion Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /promotions [post]
+func CreatePromotion(c *gin.Context) {
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	p := models.Promotion{SalonID: *user.SalonID, IsActive: true}
+	if !applyPromotionRequest(c, &p, req) {
+		return
+	}
+
+	if err := DBConnection.Create(&p).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat promo"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Promo berhasil dibuat", "data": p})
+}
+
+// UpdatePromotion godoc
+// @Summary      Update promotion
+// @Description  Memperbarui aturan promo (khusus owner/manager). Pemakaian sebelumnya tetap dihitung untuk batas pemakaian.
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int               true  "Promotion ID"
+// @Param        request  body      PromotionRequest  true  "Promotion Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /promotions/{id} [put]
+func UpdatePromotion(c *gin.Context) {
+	promotionID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req PromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var p models.Promotion
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&p, promotionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Promo tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !applyPromotionRequest(c, &p, req) {
+		return
+	}
+
+	if err := DBConnection.Save(&p).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui promo"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promo berhasil diperbarui", "data": p})
+}
+
+// ValidatePromotions godoc
+// @Summary      Validate promo codes
+// @Description  Memeriksa kode promo terhadap keranjang dan pelanggan tanpa menyimpan transaksi. Setiap kode yang
+// @Description  ditolak dijelaskan lengkap dengan semua aturan yang tidak terpenuhi; kode yang diterima disertai
+// @Description  nilai diskonnya dan hasil perhitungan keranjang.
+// @Tags         promotions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      QuoteSaleRequest  true  "Keranjang beserta promo_codes"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /promotions/validate [post]
+func ValidatePromotions(c *gin.Context) {
+	var req QuoteSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.PromoCodes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "promo_codes wajib diisi"})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	cart, cfg, in, ok := buildSaleCart(c, *user.SalonID, req)
+	if !ok {
+		return
+	}
+
+	promotions, rejections, err := promo.Resolve(DBConnection, *user.SalonID, req.PromoCodes, in)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sale, err := pos.Calculate(withPromotions(cart, promotions), cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accepted := make([]gin.H, 0, len(promotions))
+	for _, applied := range promotions {
+		var amount int64
+		for _, line := range sale.Lines {
+			if line.PromotionID != nil && *line.PromotionID == applied.Promotion.ID {
+				amount -= line.Gross
+			}
+		}
+		accepted = append(accepted, gin.H{
+			"code":     applied.Promotion.Code,
+			"name":     applied.Promotion.Name,
+			"discount": amount,
+		})
+	}
+	if rejections == nil {
+		rejections = []promo.Rejection{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"valid":    len(rejections) == 0,
+		"accepted": accepted,
+		"rejected": rejections,
+		"sale":     sale,
+	}})
+}
+
+// applyPromotionRequest memvalidasi request lalu mengisinya ke promo. Menulis response error dan
+// mengembalikan false jika request tidak valid atau kode sudah dipakai promo lain.
+func applyPromotionRequest(c *gin.Context, p *models.Promotion, req PromotionRequest) bool {
+	code := promo.NormalizeCode(req.Code)
+	if strings.ContainsAny(code, " \t") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kode promo tidak boleh mengandung spasi"})
+		return false
+	}
+
+	switch req.Type {
+	case models.PromotionTypePercent:
+		if req.ValueBps <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "value_bps wajib diisi untuk promo percent"})
+			return false
+		}
+	case models.PromotionTypeAmount:
+		if req.ValueAmount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "value_amount wajib diisi untuk promo amount"})
+			return false
+		}
+	case models.PromotionTypeBuyXGetY:
+		if req.BuyQuantity <= 0 || req.GetQuantity <= 0 || req.ValueBps <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "buy_quantity, get_quantity dan value_bps wajib diisi untuk promo buy_x_get_y"})
+			return false
+		}
+	}
+
+	if (req.StartTime != "" && !clockPattern.MatchString(req.StartTime)) || (req.EndTime != "" && !clockPattern.MatchString(req.EndTime)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format start_time/end_time tidak valid, gunakan HH:MM"})
+		return false
+	}
+	if req.StartTime != "" && req.EndTime != "" && req.StartTime >= req.EndTime {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_time harus setelah start_time"})
+		return false
+	}
+
+	loc := config.Location()
+	var startsAt, endsAt *time.Time
+	if req.StartsAt != "" {
+		day, err := time.ParseInLocation("2006-01-02", req.StartsAt, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format starts_at tidak valid, gunakan YYYY-MM-DD"})
+			return false
+		}
+		startsAt = &day
+	}
+	if req.EndsAt != "" {
+		day, err := time.ParseInLocation("2006-01-02", req.EndsAt, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format ends_at tidak valid, gunakan YYYY-MM-DD"})
+			return false
+		}
+		// Berlaku sampai akhir hari ends_at
+		end := day.AddDate(0, 0, 1)
+		if startsAt != nil && !end.After(*startsAt) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at tidak boleh sebelum starts_at"})
+			return false
+		}
+		endsAt = &end
+	}
+
+	var existing int64
+	if err := DBConnection.Model(&models.Promotion{}).
+		Where("salon_id = ? AND code = ? AND id <> ?", p.SalonID, code, p.ID).Count(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Kode promo sudah dipakai"})
+		return false
+	}
+
+	days := make([]string, 0, len(req.DaysOfWeek))
+	for d := 0; d <= 6; d++ {
+		for _, day := range req.DaysOfWeek {
+			if day == d {
+				days = append(days, strconv.Itoa(d))
+				break
+			}
+		}
+	}
+
+	p.Code = code
+	p.Name = req.Name
+	p.Description = req.Description
+	if req.IsActive != nil {
+		p.IsActive = *req.IsActive
+	}
+	p.Type = req.Type
+	p.ValueBps = req.ValueBps
+	p.ValueAmount = req.ValueAmount
+	p.BuyQuantity = req.BuyQuantity
+	p.GetQuantity = req.GetQuantity
+	p.Category = strings.TrimSpace(req.Category)
+	p.StartsAt = startsAt
+	p.EndsAt = endsAt
+	p.DaysOfWeek = strings.Join(days, ",")
+	p.StartTime = req.StartTime
+	p.EndTime = req.EndTime
+	p.MinSpend = req.MinSpend
+	p.FirstVisitOnly = req.FirstVisitOnly
+	p.CustomerSegment = req.CustomerSegment
+	p.MaxUses = req.MaxUses
+	p.MaxUsesPerCustomer = req.MaxUsesPerCustomer
+	p.Stackable = req.Stackable
+	return true
+}
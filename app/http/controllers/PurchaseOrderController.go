@@ -0,0 +1,645 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/mailer"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/purchasing"
+	"gin-sass-salon/config"
+)
+
+// PurchaseOrderLineRequest adalah satu produk yang dipesan
+type PurchaseOrderLineRequest struct {
+	ProductID uint  `json:"product_id" binding:"required" example:"1"`
+	Quantity  int64 `json:"quantity" binding:"required,gt=0" example:"24"`
+	// UnitCost kosong berarti harga pokok produk saat ini
+	UnitCost int64 `json:"unit_cost" binding:"min=0" example:"45000"`
+}
+
+// PurchaseOrderRequest struktur untuk request create/update purchase order (hanya status draft)
+type PurchaseOrderRequest struct {
+	SupplierID uint `json:"supplier_id" binding:"required" example:"1"`
+	// BranchID kosong berarti cabang default user (cabang penugasan atau cabang utama)
+	BranchID   *uint                      `json:"branch_id" example:"1"`
+	ExpectedAt string                     `json:"expected_at" example:"2024-06-10"`
+	Notes      string                     `json:"notes" example:"Kirim pagi hari"`
+	Lines      []PurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// ReceivePurchaseOrderRequest struktur untuk request penerimaan barang
+type ReceivePurchaseOrderRequest struct {
+	Lines []ReceivePurchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+	Note  string                            `json:"note" example:"1 karton menyusul"`
+}
+
+// ReceivePurchaseOrderLineRequest adalah jumlah barang yang diterima untuk satu baris PO
+type ReceivePurchaseOrderLineRequest struct {
+	LineID   uint  `json:"line_id" binding:"required" example:"1"`
+	Quantity int64 `json:"quantity" binding:"required,gt=0" example:"12"`
+	// UnitCost kosong berarti harga di PO; 0 untuk barang yang diterima gratis
+	UnitCost *int64 `json:"unit_cost" binding:"omitempty,min=0" example:"45000"`
+}
+
+// GetPurchaseOrders godoc
+// @Summary      Get purchase orders
+// @Description  Mengambil daftar purchase order salon, terbaru lebih dulu (khusus owner/manager)
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status       query     string  false  "Filter status"
+// @Param        supplier_id  query     int     false  "Filter supplier"
+// @Param        limit        query     int     false  "Jumlah data (default 50, maks 200)"
+// @Success      200          {object}  map[string]interface{}
+// @Failure      401          {object}  map[string]interface{}
+// @Failure      403          {object}  map[string]interface{}
+// @Failure      500          {object}  map[string]interface{}
+// @Router       /purchase-orders [get]
+func GetPurchaseOrders(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	query := DBConnection.Preload("Supplier").Where("salon_id = ?", *user.SalonID)
+	if scope != nil {
+		query = query.Where("branch_id IN ?", scope)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	orders := []models.PurchaseOrder{}
+	if err := query.Order("seq DESC").Limit(limit).Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": orders})
+}
+
+// GetPurchaseOrder godoc
+// @Summary      Get purchase order
+// @Description  Detail purchase order beserta baris dan riwayat penerimaan barang (khusus owner/manager)
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Purchase Order ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /purchase-orders/{id} [get]
+func GetPurchaseOrder(c *gin.Context) {
+	poID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	var po models.PurchaseOrder
+	if err := DBConnection.Preload("Supplier").Preload("Lines").Preload("Receipts.Lines").
+		Where("salon_id = ?", *user.SalonID).First(&po, poID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !purchaseOrderInScope(c, user, &po) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": po})
+}
+
+// CreatePurchaseOrder godoc
+// @Summary      Create purchase order
+// @Description  Membuat purchase order berstatus draft (khusus owner/manager)
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      PurchaseOrderRequest  true  "Purchase Order Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /purchase-orders [post]
+func CreatePurchaseOrder(c *gin.Context) {
+	var req PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	po := models.PurchaseOrder{SalonID: *user.SalonID, Status: models.PurchaseOrderDraft, CreatedByID: user.ID}
+	if !applyPurchaseOrderRequest(c, user, &po, req) {
+		return
+	}
+	if po.BranchID == 0 {
+		branchID, ok := userDefaultBranch(c, user, nil)
+		if !ok {
+			return
+		}
+		po.BranchID = branchID
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := purchasing.Number(tx, &po); err != nil {
+			return err
+		}
+		return tx.Create(&po).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat purchase order"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Purchase order berhasil dibuat", "data": po})
+}
+
+// UpdatePurchaseOrder godoc
+// @Summary      Update purchase order
+// @Description  Mengubah purchase order yang masih draft (khusus owner/manager); seluruh baris diganti
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                   true  "Purchase Order ID"
+// @Param        request  body      PurchaseOrderRequest  true  "Purchase Order Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /purchase-orders/{id} [put]
+func UpdatePurchaseOrder(c *gin.Context) {
+	poID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	var po models.PurchaseOrder
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&po, poID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !purchaseOrderInScope(c, user, &po) {
+		return
+	}
+	if !applyPurchaseOrderRequest(c, user, &po, req) {
+		return
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		locked, err := purchasing.Lock(tx, *user.SalonID, poID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != models.PurchaseOrderDraft {
+			return purchasing.ErrInvalidStatus
+		}
+		if err := tx.Where("purchase_order_id = ?", po.ID).Delete(&models.PurchaseOrderLine{}).Error; err != nil {
+			return err
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&po).Error
+	})
+	if err != nil {
+		if errors.Is(err, purchasing.ErrInvalidStatus) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Hanya purchase order draft yang dapat diubah"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui purchase order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Purchase order berhasil diperbarui", "data": po})
+}
+
+// SendPurchaseOrder godoc
+// @Summary      Send purchase order
+// @Description  Menandai purchase order draft sebagai terkirim (khusus owner/manager). Response berisi teks PO dan
+// @Description  link WhatsApp ke nomor supplier; jika supplier memiliki email, PO juga dikirim lewat email.
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Purchase Order ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /purchase-orders/{id}/send [post]
+func SendPurchaseOrder(c *gin.Context) {
+	poID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	var po *models.PurchaseOrder
+	var message, whatsapp string
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if po, err = purchasing.Lock(tx, *user.SalonID, poID); err != nil {
+			return err
+		}
+		if !inBranchScope(scope, po.BranchID) {
+			return errBranchAccess
+		}
+		if po.Status != models.PurchaseOrderDraft {
+			return purchasing.ErrInvalidStatus
+		}
+
+		var supplier models.Supplier
+		var salon models.Salon
+		var branch models.Branch
+		if err := tx.First(&supplier, po.SupplierID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&salon, po.SalonID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&branch, po.BranchID).Error; err != nil {
+			return err
+		}
+		productIDs := make([]uint, 0, len(po.Lines))
+		for _, line := range po.Lines {
+			productIDs = append(productIDs, line.ProductID)
+		}
+		var list []models.Product
+		if err := tx.Unscoped().Where("id IN ?", productIDs).Find(&list).Error; err != nil {
+			return err
+		}
+		products := make(map[uint]models.Product, len(list))
+		for _, product := range list {
+			products[product.ID] = product
+		}
+
+		now := time.Now()
+		po.Status = models.PurchaseOrderSent
+		po.SentAt = &now
+		if err := tx.Model(po).Select("status", "sent_at").Updates(po).Error; err != nil {
+			return err
+		}
+
+		message = purchasing.Message(po, &salon, &branch, products)
+		if phone := whatsappNumber(supplier.Phone); phone != "" {
+			whatsapp = "https://wa.me/" + phone + "?text=" + url.QueryEscape(message)
+		}
+		if supplier.Email != "" {
+			_, err := mailer.Queue(tx, mailer.Message{
+				To:      []string{supplier.Email},
+				Subject: "Purchase Order " + po.Number + " - " + salon.Name,
+				Body:    message,
+			})
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, purchasing.ErrInvalidStatus):
+			c.JSON(http.StatusConflict, gin.H{"error": "Hanya purchase order draft yang dapat dikirim"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengirim purchase order"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Purchase order ditandai terkirim", "data": po, "text": message, "whatsapp_url": whatsapp})
+}
+
+// ReceivePurchaseOrder godoc
+// @Summary      Receive purchase order
+// @Description  Mencatat barang yang diterima dari purchase order terkirim (khusus owner/manager): stok cabang
+// @Description  bertambah dan harga pokok produk diperbarui dengan rata-rata tertimbang. Penerimaan boleh bertahap.
+// @Description  unit_cost kosong memakai harga di PO; unit_cost 0 mencatat barang gratis.
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                          true  "Purchase Order ID"
+// @Param        request  body      ReceivePurchaseOrderRequest  true  "Receive Purchase Order Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /purchase-orders/{id}/receive [post]
+func ReceivePurchaseOrder(c *gin.Context) {
+	poID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ReceivePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	lines := make([]purchasing.ReceiveLine, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		lines = append(lines, purchasing.ReceiveLine{LineID: line.LineID, Quantity: line.Quantity, UnitCost: line.UnitCost})
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	var po *models.PurchaseOrder
+	var receipt *models.GoodsReceipt
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if po, err = purchasing.Lock(tx, *user.SalonID, poID); err != nil {
+			return err
+		}
+		if !inBranchScope(scope, po.BranchID) {
+			return errBranchAccess
+		}
+		receipt, err = purchasing.Receive(tx, po, lines, user.ID, req.Note)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, purchasing.ErrOverReceive):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, purchasing.ErrInvalidStatus):
+			c.JSON(http.StatusConflict, gin.H{"error": "Hanya purchase order terkirim yang dapat diterima"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencatat penerimaan barang"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Penerimaan barang berhasil dicatat", "data": po, "receipt": receipt})
+}
+
+// CancelPurchaseOrder godoc
+// @Summary      Cancel purchase order
+// @Description  Membatalkan purchase order draft atau terkirim yang belum ada penerimaan barang (khusus owner/manager)
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Purchase Order ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /purchase-orders/{id}/cancel [post]
+func CancelPurchaseOrder(c *gin.Context) {
+	poID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	var po *models.PurchaseOrder
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if po, err = purchasing.Lock(tx, *user.SalonID, poID); err != nil {
+			return err
+		}
+		if !inBranchScope(scope, po.BranchID) {
+			return errBranchAccess
+		}
+		if po.Status != models.PurchaseOrderDraft && po.Status != models.PurchaseOrderSent {
+			return purchasing.ErrInvalidStatus
+		}
+		po.Status = models.PurchaseOrderCancelled
+		return tx.Model(po).Update("status", po.Status).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Purchase order tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, purchasing.ErrInvalidStatus):
+			c.JSON(http.StatusConflict, gin.H{"error": "Hanya purchase order draft atau terkirim tanpa penerimaan yang dapat dibatalkan"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membatalkan purchase order"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Purchase order dibatalkan", "data": po})
+}
+
+// GetReorderSuggestions godoc
+// @Summary      Reorder suggestions
+// @Description  Saran pemesanan ulang per cabang dari stok, barang dalam pesanan dan pemakaian terakhir: target stok
+// @Description  adalah pemakaian harian x cover_days (minimal low_stock_threshold) (khusus owner/manager)
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        branch_id   query     int  false  "Cabang (default cabang penugasan user atau cabang utama)"
+// @Param        days        query     int  false  "Periode pemakaian dalam hari (default 30)"
+// @Param        cover_days  query     int  false  "Stok ditargetkan cukup untuk berapa hari (default 30)"
+// @Success      200         {object}  map[string]interface{}
+// @Failure      400         {object}  map[string]interface{}
+// @Failure      401         {object}  map[string]interface{}
+// @Failure      403         {object}  map[string]interface{}
+// @Failure      500         {object}  map[string]interface{}
+// @Router       /purchase-orders/reorder-suggestions [get]
+func GetReorderSuggestions(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 || days > 365 {
+		days = 30
+	}
+	coverDays, err := strconv.Atoi(c.DefaultQuery("cover_days", "30"))
+	if err != nil || coverDays <= 0 || coverDays > 365 {
+		coverDays = 30
+	}
+
+	branchID, ok := queryBranch(c, user)
+	if !ok {
+		return
+	}
+
+	suggestions, err := purchasing.SuggestReorder(DBConnection, *user.SalonID, branchID, days, coverDays, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": suggestions, "branch_id": branchID, "days": days, "cover_days": coverDays})
+}
+
+// applyPurchaseOrderRequest memvalidasi supplier, cabang dan produk lalu menyalin request ke PO.
+// Response error sudah ditulis jika false.
+func applyPurchaseOrderRequest(c *gin.Context, user models.User, po *models.PurchaseOrder, req PurchaseOrderRequest) bool {
+	var supplier models.Supplier
+	if err := DBConnection.Where("salon_id = ? AND is_active", po.SalonID).First(&supplier, req.SupplierID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Supplier tidak ditemukan di salon ini"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if req.BranchID != nil {
+		if _, ok := findUserBranch(c, user, *req.BranchID); !ok {
+			return false
+		}
+		po.BranchID = *req.BranchID
+	}
+
+	var expectedAt *time.Time
+	if req.ExpectedAt != "" {
+		day, err := time.ParseInLocation("2006-01-02", req.ExpectedAt, config.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format expected_at tidak valid, gunakan YYYY-MM-DD"})
+			return false
+		}
+		expectedAt = &day
+	}
+
+	po.SupplierID = supplier.ID
+	po.ExpectedAt = expectedAt
+	po.Notes = strings.TrimSpace(req.Notes)
+	po.Lines = nil
+	po.Total = 0
+	for _, item := range req.Lines {
+		product, ok := findSalonProduct(c, po.SalonID, item.ProductID)
+		if !ok {
+			return false
+		}
+		unitCost := item.UnitCost
+		if unitCost == 0 {
+			unitCost = product.CostPrice
+		}
+		po.Lines = append(po.Lines, models.PurchaseOrderLine{ProductID: product.ID, Quantity: item.Quantity, UnitCost: unitCost})
+		po.Total += item.Quantity * unitCost
+	}
+	return true
+}
+
+// purchaseOrderInScope memastikan cabang PO boleh diakses user dan menulis response error jika tidak
+func purchaseOrderInScope(c *gin.Context, user models.User, po *models.PurchaseOrder) bool {
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return false
+	}
+	if !inBranchScope(scope, po.BranchID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errBranchAccess.Error()})
+		return false
+	}
+	return true
+}
+
+// whatsappNumber mengubah nomor telepon lokal (08xx) menjadi format internasional untuk link wa.me
+func whatsappNumber(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	number := digits.String()
+	if strings.HasPrefix(number, "0") {
+		number = "62" + number[1:]
+	}
+	return number
+}
@@ -0,0 +1,402 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/realtime"
+	"gin-sass-salon/app/walkin"
+	"gin-sass-salon/config"
+)
+
+// CheckInRequest struktur untuk request check-in walk-in
+type CheckInRequest struct {
+	CustomerID       *uint  `json:"customer_id" example:"1"`
+	CustomerName     string `json:"customer_name" example:"Budi"`
+	Phone            string `json:"phone" example:"081234567890"`
+	ServiceID        uint   `json:"service_id" binding:"required" example:"1"`
+	PreferredStaffID *uint  `json:"preferred_staff_id" example:"2"`
+	// BranchID kosong berarti cabang default user (cabang penugasan atau cabang utama)
+	BranchID *uint `json:"branch_id" example:"1"`
+}
+
+// AssignWalkInRequest struktur untuk request assign walk-in ke stylist
+type AssignWalkInRequest struct {
+	StaffID *uint `json:"staff_id" example:"2"`
+}
+
+// errInvalidTransition dikembalikan jika status walk-in tidak sesuai untuk aksi yang diminta
+var errInvalidTransition = errors.New("status walk-in tidak valid untuk aksi ini")
+
+// GetQueue godoc
+// @Summary      Get walk-in queue
+// @Description  Mengambil antrian walk-in aktif satu cabang beserta perkiraan waktu tunggu. Tanpa branch_id dipakai
+// @Description  cabang default user.
+// @Tags         queue
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        branch_id  query     int  false  "Branch ID"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]interface{}
+// @Failure      401        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]interface{}
+// @Failure      404        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]interface{}
+// @Router       /queue [get]
+func GetQueue(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+	branchID, ok := queryBranch(c, user)
+	if !ok {
+		return
+	}
+
+	var walkIns []models.WalkIn
+	if err := DBConnection.Preload("Service").
+		Where("salon_id = ? AND branch_id = ? AND status IN ?", *user.SalonID, branchID,
+			[]string{models.WalkInStatusWaiting, models.WalkInStatusAssigned, models.WalkInStatusInService}).
+		Order("checked_in_at, id").Find(&walkIns).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	estimates, err := walkin.EstimateWaits(DBConnection, *user.SalonID, branchID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byWalkIn := make(map[uint]walkin.Estimate, len(estimates))
+	for _, estimate := range estimates {
+		byWalkIn[estimate.WalkInID] = estimate
+	}
+
+	var response []gin.H
+	for _, w := range walkIns {
+		item := gin.H{"walk_in": w}
+		if estimate, ok := byWalkIn[w.ID]; ok {
+			item["estimate"] = estimate
+		}
+		response = append(response, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+// CheckInWalkIn godoc
+// @Summary      Check in walk-in customer
+// @Description  Memasukkan pelanggan walk-in ke antrian dan mengembalikan nomor tiket serta perkiraan waktu tunggu
+// @Tags         queue
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CheckInRequest  true  "Check In Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /queue [post]
+func CheckInWalkIn(c *gin.Context) {
+	var req CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+	salonID := *user.SalonID
+
+	if req.CustomerID != nil {
+		customer, ok := findSalonCustomer(c, salonID, *req.CustomerID)
+		if !ok {
+			return
+		}
+		req.CustomerName = customer.Name
+		if req.Phone == "" {
+			req.Phone = customer.Phone
+		}
+	}
+	if req.CustomerName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "customer_id atau customer_name wajib diisi"})
+		return
+	}
+	if _, ok := findSalonService(c, salonID, req.ServiceID); !ok {
+		return
+	}
+	if req.PreferredStaffID != nil {
+		if _, ok := findScopedStaff(c, user, *req.PreferredStaffID); !ok {
+			return
+		}
+	}
+	branchID, ok := userDefaultBranch(c, user, req.BranchID)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	w := models.WalkIn{
+		SalonID:          salonID,
+		BranchID:         &branchID,
+		CustomerID:       req.CustomerID,
+		CustomerName:     req.CustomerName,
+		Phone:            req.Phone,
+		ServiceID:        req.ServiceID,
+		PreferredStaffID: req.PreferredStaffID,
+		Status:           models.WalkInStatusWaiting,
+		CheckedInAt:      now,
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		// Kunci cabang agar nomor tiket harian tidak dobel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Branch{}, branchID).Error; err != nil {
+			return err
+		}
+
+		loc := config.Location()
+		local := now.In(loc)
+		startOfDay := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+		var count int64
+		if err := tx.Model(&models.WalkIn{}).Where("branch_id = ? AND checked_in_at >= ?", branchID, startOfDay).Count(&count).Error; err != nil {
+			return err
+		}
+		w.TicketNumber = int(count) + 1
+		if err := tx.Create(&w).Error; err != nil {
+			return err
+		}
+
+		realtime.Publish(tx, salonID, realtime.EventQueueUpdated, queueEventData(&w))
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal check-in walk-in"})
+		return
+	}
+
+	response := gin.H{"walk_in": w}
+	if estimates, err := walkin.EstimateWaits(DBConnection, salonID, branchID, time.Now()); err == nil {
+		for _, estimate := range estimates {
+			if estimate.WalkInID == w.ID {
+				response["estimate"] = estimate
+			}
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Walk-in masuk antrian", "data": response})
+}
+
+// AssignWalkIn godoc
+// @Summary      Assign walk-in to stylist
+// @Description  Menugaskan walk-in ke stylist tertentu atau ke stylist berikutnya yang kosong
+// @Tags         queue
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                  true   "Walk-in ID"
+// @Param        request  body      AssignWalkInRequest  false  "Assign Walk-in Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /queue/{id}/assign [post]
+func AssignWalkIn(c *gin.Context) {
+	var req AssignWalkInRequest
+	_ = c.ShouldBindJSON(&req)
+
+	transitionWalkIn(c, "Walk-in ditugaskan ke stylist", func(tx *gorm.DB, w *models.WalkIn) error {
+		if w.Status != models.WalkInStatusWaiting {
+			return errInvalidTransition
+		}
+
+		var staffID uint
+		if req.StaffID != nil {
+			var count int64
+			if err := tx.Model(&models.User{}).Where("id = ? AND salon_id = ?", *req.StaffID, w.SalonID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return gorm.ErrRecordNotFound
+			}
+			staffID = *req.StaffID
+		} else {
+			id, err := walkin.NextFreeStaff(tx, *w, time.Now())
+			if err != nil {
+				return err
+			}
+			staffID = id
+		}
+
+		now := time.Now()
+		w.StaffID = &staffID
+		w.Status = models.WalkInStatusAssigned
+		w.AssignedAt = &now
+		return tx.Model(w).Select("staff_id", "status", "assigned_at").Updates(w).Error
+	})
+}
+
+// StartWalkIn godoc
+// @Summary      Start walk-in service
+// @Description  Menandai walk-in yang sudah ditugaskan mulai dilayani
+// @Tags         queue
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Walk-in ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /queue/{id}/start [post]
+func StartWalkIn(c *gin.Context) {
+	transitionWalkIn(c, "Layanan walk-in dimulai", func(tx *gorm.DB, w *models.WalkIn) error {
+		if w.Status != models.WalkInStatusAssigned {
+			return errInvalidTransition
+		}
+		now := time.Now()
+		w.Status = models.WalkInStatusInService
+		w.StartedAt = &now
+		return tx.Model(w).Select("status", "started_at").Updates(w).Error
+	})
+}
+
+// FinishWalkIn godoc
+// @Summary      Finish walk-in service
+// @Description  Menandai layanan walk-in selesai; durasinya dipakai untuk perkiraan waktu tunggu berikutnya
+// @Tags         queue
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Walk-in ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /queue/{id}/finish [post]
+func FinishWalkIn(c *gin.Context) {
+	transitionWalkIn(c, "Layanan walk-in selesai", func(tx *gorm.DB, w *models.WalkIn) error {
+		if w.Status != models.WalkInStatusInService {
+			return errInvalidTransition
+		}
+		now := time.Now()
+		w.Status = models.WalkInStatusCompleted
+		w.FinishedAt = &now
+		return tx.Model(w).Select("status", "finished_at").Updates(w).Error
+	})
+}
+
+// AbandonWalkIn godoc
+// @Summary      Abandon walk-in
+// @Description  Menandai walk-in meninggalkan antrian sebelum dilayani
+// @Tags         queue
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Walk-in ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /queue/{id}/abandon [post]
+func AbandonWalkIn(c *gin.Context) {
+	transitionWalkIn(c, "Walk-in meninggalkan antrian", func(tx *gorm.DB, w *models.WalkIn) error {
+		if w.Status != models.WalkInStatusWaiting && w.Status != models.WalkInStatusAssigned {
+			return errInvalidTransition
+		}
+		now := time.Now()
+		w.Status = models.WalkInStatusAbandoned
+		w.AbandonedAt = &now
+		return tx.Model(w).Select("status", "abandoned_at").Updates(w).Error
+	})
+}
+
+// transitionWalkIn mengunci walk-in milik salon (dan cabang branch manager), menjalankan fn di dalam transaksi
+// dan menulis response
+func transitionWalkIn(c *gin.Context, message string, fn func(tx *gorm.DB, w *models.WalkIn) error) {
+	walkInID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	var w models.WalkIn
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		// Kunci salon agar dua walk-in tidak ditugaskan ke stylist yang sama bersamaan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Salon{}, *user.SalonID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("salon_id = ?", *user.SalonID).First(&w, walkInID).Error; err != nil {
+			return err
+		}
+		if !optionalInBranchScope(scope, w.BranchID) {
+			return errBranchAccess
+		}
+		if err := fn(tx, &w); err != nil {
+			return err
+		}
+
+		realtime.Publish(tx, w.SalonID, realtime.EventQueueUpdated, queueEventData(&w))
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Walk-in atau staff tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, errInvalidTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": "Status walk-in tidak valid untuk aksi ini", "status": w.Status})
+		case errors.Is(err, walkin.ErrNoFreeStaff):
+			c.JSON(http.StatusConflict, gin.H{"error": "Belum ada stylist yang kosong"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": w})
+}
+
+// queueEventData adalah ringkasan walk-in yang dikirim ke klien realtime
+func queueEventData(w *models.WalkIn) gin.H {
+	return gin.H{
+		"id":            w.ID,
+		"branch_id":     w.BranchID,
+		"ticket_number": w.TicketNumber,
+		"customer_name": w.CustomerName,
+		"service_id":    w.ServiceID,
+		"staff_id":      w.StaffID,
+		"status":        w.Status,
+	}
+}
@@ -0,0 +1,364 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/mailer"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/receipt"
+)
+
+// maxLogoSize adalah ukuran maksimal file logo yang di-upload (1 MB)
+const maxLogoSize = 1 << 20
+
+// ReceiptTemplateRequest struktur untuk request pengaturan struk/invoice salon
+type ReceiptTemplateRequest struct {
+	Paper        string `json:"paper" binding:"omitempty,oneof=a4 80mm" example:"80mm"`
+	NPWP         string `json:"npwp" example:"01.234.567.8-901.000"`
+	Header       string `json:"header" example:"Buka setiap hari 09.00 - 21.00"`
+	Footer       string `json:"footer" example:"Terima kasih, sampai jumpa lagi!"`
+	EmailSubject string `json:"email_subject" example:"Struk {receipt_number} dari {salon}"`
+	EmailBody    string `json:"email_body" example:"Halo {customer}, terima kasih. Total transaksi {total}."`
+}
+
+// EmailReceiptRequest struktur untuk request kirim struk/invoice lewat email
+type EmailReceiptRequest struct {
+	// Email kosong berarti memakai email pelanggan transaksi
+	Email string `json:"email" binding:"omitempty,email" example:"budi@example.com"`
+	Paper string `json:"paper" binding:"omitempty,oneof=a4 80mm" example:"a4"`
+	Type  string `json:"type" binding:"omitempty,oneof=receipt invoice" example:"invoice"`
+}
+
+// GetSaleReceiptPDF godoc
+// @Summary      Download receipt PDF
+// @Description  Mencetak struk atau invoice transaksi sebagai PDF dengan logo, NPWP, header dan footer salon. Branch
+// @Description  manager hanya untuk transaksi cabangnya.
+// @Tags         sales
+// @Produce      application/pdf
+// @Security     BearerAuth
+// @Param        id     path      int     true   "Sale ID"
+// @Param        paper  query     string  false  "Ukuran kertas: a4 atau 80mm (default pengaturan salon)"
+// @Param        type   query     string  false  "Jenis dokumen: receipt atau invoice (default receipt)"
+// @Success      200    {file}    file
+// @Failure      400    {object}  map[string]interface{}
+// @Failure      401    {object}  map[string]interface{}
+// @Failure      403    {object}  map[string]interface{}
+// @Failure      404    {object}  map[string]interface{}
+// @Failure      500    {object}  map[string]interface{}
+// @Router       /sales/{id}/receipt.pdf [get]
+func GetSaleReceiptPDF(c *gin.Context) {
+	saleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	opt, ok := receiptOptions(c, c.Query("paper"), c.Query("type"))
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	doc, ok := loadReceiptDocument(c, user, saleID)
+	if !ok {
+		return
+	}
+
+	pdf, err := receipt.Render(doc, opt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+receipt.Filename(doc.Sale, opt.Kind)+`"`)
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// EmailSaleReceipt godoc
+// @Summary      Email receipt
+// @Description  Mengirim struk atau invoice PDF ke email pelanggan (atau alamat lain) lewat antrian email. Branch
+// @Description  manager hanya untuk transaksi cabangnya.
+// @Tags         sales
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                  true  "Sale ID"
+// @Param        request  body      EmailReceiptRequest  true  "Email Receipt Request"
+// @Success      202      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /sales/{id}/receipt/email [post]
+func EmailSaleReceipt(c *gin.Context) {
+	saleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req EmailReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opt, ok := receiptOptions(c, req.Paper, req.Type)
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	doc, ok := loadReceiptDocument(c, user, saleID)
+	if !ok {
+		return
+	}
+
+	to := req.Email
+	if to == "" && doc.Customer != nil {
+		to = doc.Customer.Email
+	}
+	if to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pelanggan tidak memiliki email, isi field email"})
+		return
+	}
+
+	msg, err := receipt.Email(doc, opt, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	job, err := mailer.Queue(DBConnection, msg)
+	if err != nil {
+		log.Printf("❌ Gagal mengantrikan email struk %s: %v", doc.Sale.ReceiptNumber, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengirim email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Email sedang dikirim", "to": to, "job_id": job.ID})
+}
+
+// GetReceiptTemplate godoc
+// @Summary      Get receipt template
+// @Description  Mengambil pengaturan struk/invoice salon
+// @Tags         salon
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /salon/receipt-template [get]
+func GetReceiptTemplate(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	tmpl, err := findReceiptTemplate(*user.SalonID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tmpl})
+}
+
+// UpdateReceiptTemplate godoc
+// @Summary      Update receipt template
+// @Description  Mengatur kertas default, NPWP, header, footer dan email struk/invoice salon (khusus owner/manager)
+// @Tags         salon
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      ReceiptTemplateRequest  true  "Receipt Template Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /salon/receipt-template [put]
+func UpdateReceiptTemplate(c *gin.Context) {
+	var req ReceiptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	tmpl, err := findReceiptTemplate(*user.SalonID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Paper != "" {
+		tmpl.Paper = req.Paper
+	}
+	tmpl.Header = req.Header
+	tmpl.Footer = req.Footer
+	tmpl.EmailSubject = req.EmailSubject
+	tmpl.EmailBody = req.EmailBody
+
+	err = DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Salon{}).Where("id = ?", *user.SalonID).Update("npwp", req.NPWP).Error; err != nil {
+			return err
+		}
+		return tx.Save(&tmpl).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui template struk"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template struk berhasil diperbarui", "data": tmpl, "npwp": req.NPWP})
+}
+
+// UploadSalonLogo godoc
+// @Summary      Upload salon logo
+// @Description  Upload logo salon (JPEG/PNG, maks 1 MB) untuk struk dan invoice (khusus owner/manager)
+// @Tags         salon
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        logo  formData  file  true  "Logo JPEG/PNG"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]interface{}
+// @Failure      403   {object}  map[string]interface{}
+// @Failure      500   {object}  map[string]interface{}
+// @Router       /salon/logo [put]
+func UploadSalonLogo(c *gin.Context) {
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("logo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File logo wajib diisi"})
+		return
+	}
+	if file.Size > maxLogoSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ukuran logo maksimal 1 MB"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	raw, err := io.ReadAll(io.LimitReader(f, maxLogoSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, width, height, err := receipt.NormalizeLogo(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tmpl, err := findReceiptTemplate(*user.SalonID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tmpl.LogoData = data
+	tmpl.LogoWidth = width
+	tmpl.LogoHeight = height
+	tmpl.HasLogo = true
+	if err := DBConnection.Save(&tmpl).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan logo"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logo berhasil disimpan", "data": tmpl})
+}
+
+// receiptOptions memvalidasi ukuran kertas dan jenis dokumen, menulis response error jika tidak valid
+func receiptOptions(c *gin.Context, paper, kind string) (receipt.Options, bool) {
+	if paper != "" && paper != models.ReceiptPaperA4 && paper != models.ReceiptPaperThermal {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "paper harus a4 atau 80mm"})
+		return receipt.Options{}, false
+	}
+	if kind != "" && kind != receipt.KindReceipt && kind != receipt.KindInvoice {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type harus receipt atau invoice"})
+		return receipt.Options{}, false
+	}
+	return receipt.Options{Paper: paper, Kind: kind}, true
+}
+
+// findReceiptTemplate mengambil template struk salon; salon tanpa template memakai pengaturan bawaan
+func findReceiptTemplate(salonID uint) (models.ReceiptTemplate, error) {
+	tmpl := models.ReceiptTemplate{SalonID: salonID, Paper: models.ReceiptPaperThermal}
+	err := DBConnection.Where("salon_id = ?", salonID).First(&tmpl).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tmpl, nil
+	}
+	return tmpl, err
+}
+
+// loadReceiptDocument mengumpulkan transaksi, salon, template, pelanggan dan kasir untuk dicetak. Branch manager
+// hanya dapat mencetak transaksi cabangnya. Menulis response error dan mengembalikan false jika gagal.
+func loadReceiptDocument(c *gin.Context, user models.User, saleID uint) (receipt.Document, bool) {
+	var doc receipt.Document
+	salonID := *user.SalonID
+
+	sale, ok := findSalonSale(c, salonID, saleID)
+	if !ok {
+		return doc, false
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return doc, false
+	}
+	if !saleInScope(scope, &sale) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errBranchAccess.Error()})
+		return doc, false
+	}
+	doc.Sale = sale
+
+	if err := DBConnection.First(&doc.Salon, salonID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return doc, false
+	}
+
+	tmpl, err := findReceiptTemplate(salonID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return doc, false
+	}
+	doc.Template = tmpl
+
+	if sale.CustomerID != nil {
+		var customer models.Customer
+		if err := DBConnection.First(&customer, *sale.CustomerID).Error; err == nil {
+			doc.Customer = &customer
+		}
+	}
+
+	var cashier models.User
+	if err := DBConnection.Select("id", "name").First(&cashier, sale.CashierID).Error; err == nil {
+		doc.CashierName = cashier.Name
+	}
+	return doc, true
+}
@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/pos"
+)
+
+// ProductQuantityRequest adalah jumlah pemakaian satu produk dalam satuan produk
+type ProductQuantityRequest struct {
+	ProductID uint  `json:"product_id" binding:"required" example:"1"`
+	Quantity  int64 `json:"quantity" binding:"min=0" example:"60"`
+}
+
+// ServiceRecipeRequest struktur untuk request resep bahan layanan
+type ServiceRecipeRequest struct {
+	Items []ProductQuantityRequest `json:"items" binding:"dive"`
+}
+
+// ServiceUsageRequest struktur untuk request pemakaian bahan nyata pada satu baris layanan
+type ServiceUsageRequest struct {
+	Items []ProductQuantityRequest `json:"items" binding:"dive"`
+}
+
+// GetServiceRecipe godoc
+// @Summary      Get service recipe
+// @Description  Mengambil perkiraan pemakaian bahan back-bar untuk satu kali layanan
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Service ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /services/{id}/recipe [get]
+func GetServiceRecipe(c *gin.Context) {
+	serviceID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	service, ok := findSalonService(c, *user.SalonID, serviceID)
+	if !ok {
+		return
+	}
+
+	recipes := []models.ServiceRecipe{}
+	if err := DBConnection.Where("service_id = ?", service.ID).Order("id").Find(&recipes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": recipes})
+}
+
+// UpdateServiceRecipe godoc
+// @Summary      Update service recipe
+// @Description  Mengganti resep bahan layanan (khusus owner/manager). Stok bahan dikurangi otomatis sesuai resep
+// @Description  setiap layanan dibayar di kasir.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                   true  "Service ID"
+// @Param        request  body      ServiceRecipeRequest  true  "Service Recipe Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /services/{id}/recipe [put]
+func UpdateServiceRecipe(c *gin.Context) {
+	serviceID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ServiceRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	service, ok := findSalonService(c, *user.SalonID, serviceID)
+	if !ok {
+		return
+	}
+	quantities, ok := productQuantities(c, *user.SalonID, req.Items)
+	if !ok {
+		return
+	}
+
+	recipes := []models.ServiceRecipe{}
+	for _, item := range req.Items {
+		if quantities[item.ProductID] > 0 {
+			recipes = append(recipes, models.ServiceRecipe{
+				SalonID:   *user.SalonID,
+				ServiceID: service.ID,
+				ProductID: item.ProductID,
+				Quantity:  quantities[item.ProductID],
+			})
+		}
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("service_id = ?", service.ID).Delete(&models.ServiceRecipe{}).Error; err != nil {
+			return err
+		}
+		if len(recipes) == 0 {
+			return nil
+		}
+		return tx.Create(&recipes).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan resep layanan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Resep layanan berhasil disimpan", "data": recipes})
+}
+
+// GetSaleUsage godoc
+// @Summary      Get sale product usage
+// @Description  Mengambil pemakaian bahan (perkiraan dan nyata) untuk setiap baris layanan pada transaksi
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Sale ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /sales/{id}/usage [get]
+func GetSaleUsage(c *gin.Context) {
+	saleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	var sale models.Sale
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&sale, saleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaksi tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+	if !saleInScope(scope, &sale) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errBranchAccess.Error()})
+		return
+	}
+
+	usages := []models.ServiceUsage{}
+	if err := DBConnection.Where("sale_id = ?", sale.ID).Order("sale_line_id, id").Find(&usages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": usages})
+}
+
+// UpdateSaleLineUsage godoc
+// @Summary      Record actual product usage
+// @Description  Mencatat pemakaian bahan nyata untuk satu baris layanan (staff yang mengerjakan atau owner/manager).
+// @Description  Items berisi seluruh bahan yang terpakai; selisih terhadap catatan sebelumnya dibukukan ke stok.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                  true  "Sale ID"
+// @Param        line_id  path      int                  true  "Sale Line ID"
+// @Param        request  body      ServiceUsageRequest  true  "Service Usage Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /sales/{id}/lines/{line_id}/usage [put]
+func UpdateSaleLineUsage(c *gin.Context) {
+	saleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	lineID, ok := parseIDParam(c, "line_id")
+	if !ok {
+		return
+	}
+
+	var req ServiceUsageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	quantities, ok := productQuantities(c, *user.SalonID, req.Items)
+	if !ok {
+		return
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	var usages []models.ServiceUsage
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		sale, err := pos.LockSale(tx, *user.SalonID, saleID)
+		if err != nil {
+			return err
+		}
+		if sale.Status == models.SaleStatusVoided {
+			return pos.ErrSaleClosed
+		}
+		var line *models.SaleLine
+		for i := range sale.Lines {
+			if sale.Lines[i].ID == lineID && sale.Lines[i].Type == models.SaleLineService {
+				line = &sale.Lines[i]
+			}
+		}
+		if line == nil {
+			return gorm.ErrRecordNotFound
+		}
+		if !(user.IsManager() && saleInScope(scope, sale)) && (line.StaffID == nil || *line.StaffID != user.ID) {
+			return errNotLineStaff
+		}
+		usages, err = inventory.SetActualUsage(tx, sale, line, quantities, user.ID)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Baris layanan tidak ditemukan"})
+		case errors.Is(err, errNotLineStaff):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, pos.ErrSaleClosed):
+			c.JSON(http.StatusConflict, gin.H{"error": "Transaksi sudah di-void"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencatat pemakaian bahan"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pemakaian bahan berhasil dicatat", "data": usages})
+}
+
+// errNotLineStaff dikembalikan jika staff mencatat pemakaian untuk layanan yang bukan dikerjakannya
+var errNotLineStaff = errors.New("Hanya staff yang mengerjakan layanan atau owner/manager yang dapat mencatat pemakaian")
+
+// productQuantities memastikan semua produk milik salon dan tidak duplikat lalu mengembalikan jumlah
+// per product_id. Response error sudah ditulis jika false.
+func productQuantities(c *gin.Context, salonID uint, items []ProductQuantityRequest) (map[uint]int64, bool) {
+	quantities := make(map[uint]int64, len(items))
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Produk tidak boleh duplikat"})
+			return nil, false
+		}
+		if _, ok := findSalonProduct(c, salonID, item.ProductID); !ok {
+			return nil, false
+		}
+		quantities[item.ProductID] = item.Quantity
+	}
+	return quantities, true
+}
@@ -0,0 +1,723 @@
+package controllers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/report"
+	"gin-sass-salon/app/spreadsheet"
+	"gin-sass-salon/config"
+)
+
+// parseDateRange membaca query from dan to (YYYY-MM-DD, to inklusif) pada zona waktu salon dan mengembalikan
+// rentang [from, to+1 hari). Default bulan berjalan. Response error sudah ditulis jika false.
+func parseDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	return parseDateRangeIn(c, config.Location())
+}
+
+// parseDateRangeIn seperti parseDateRange tetapi pada zona waktu loc
+func parseDateRangeIn(c *gin.Context, loc *time.Location) (time.Time, time.Time, bool) {
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 1, 0)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal tidak valid, gunakan YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal tidak valid, gunakan YYYY-MM-DD"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tanggal from harus sebelum atau sama dengan to"})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
+
+// reportLocation membaca query timezone (nama IANA, mis. Asia/Makassar); default zona waktu aplikasi.
+// Response error sudah ditulis jika false.
+func reportLocation(c *gin.Context) (*time.Location, bool) {
+	name := c.Query("timezone")
+	if name == "" {
+		return config.Location(), true
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Zona waktu tidak dikenal"})
+		return nil, false
+	}
+	return loc, true
+}
+
+// reportBranches membaca query branch_id untuk laporan yang dapat menjumlahkan beberapa cabang. Kosong berarti
+// semua cabang yang boleh diakses user (nil untuk owner/manager). Response error sudah ditulis jika false.
+func reportBranches(c *gin.Context, user models.User) ([]uint, bool) {
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return nil, false
+	}
+	raw := c.Query("branch_id")
+	if raw == "" {
+		return scope, true
+	}
+	id, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "branch_id tidak valid"})
+		return nil, false
+	}
+	if !inBranchScope(scope, uint(id)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errBranchAccess.Error()})
+		return nil, false
+	}
+	return []uint{uint(id)}, true
+}
+
+// previousRange mengembalikan periode sebelumnya dengan panjang yang sama. Rentang satu bulan kalender penuh
+// dibandingkan dengan bulan kalender sebelumnya.
+func previousRange(from, to time.Time) (time.Time, time.Time) {
+	if from.Day() == 1 && from.AddDate(0, 1, 0).Equal(to) {
+		return from.AddDate(0, -1, 0), from
+	}
+	days := int(to.Sub(from).Hours()/24 + 0.5)
+	return from.AddDate(0, 0, -days), from
+}
+
+// writeTable mengirim tabel sebagai file unduhan sesuai query format: csv (default) atau xlsx
+func writeTable(c *gin.Context, table spreadsheet.Table, name string) {
+	var (
+		data        []byte
+		err         error
+		contentType string
+	)
+	switch format := c.DefaultQuery("format", "csv"); format {
+	case "csv":
+		data, err = table.CSV()
+		contentType = "text/csv"
+	case "xlsx":
+		data, err = table.XLSX()
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format harus csv atau xlsx"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+name+"."+c.DefaultQuery("format", "csv")+`"`)
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// GetBackBarVarianceReport godoc
+// @Summary      Back-bar usage variance report
+// @Description  Perkiraan (resep) vs pemakaian nyata bahan back-bar per staff dan produk (khusus owner/manager)
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from       query     string  false  "Tanggal awal (YYYY-MM-DD), default awal bulan ini"
+// @Param        to         query     string  false  "Tanggal akhir inklusif (YYYY-MM-DD)"
+// @Param        branch_id  query     int     false  "Branch ID (wajib untuk branch manager dengan beberapa cabang)"
+// @Param        staff_id   query     int     false  "Staff ID"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]interface{}
+// @Failure      401        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]interface{}
+// @Router       /reports/backbar-variance [get]
+func GetBackBarVarianceReport(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+	branchID, ok := scopedBranchParam(c, user)
+	if !ok {
+		return
+	}
+	staffID, _ := strconv.ParseUint(c.Query("staff_id"), 10, 32)
+
+	rows, err := inventory.Variance(DBConnection, *user.SalonID, branchID, uint(staffID), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var varianceCost int64
+	for _, row := range rows {
+		varianceCost += row.VarianceCost
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":          from.Format("2006-01-02"),
+		"to":            to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"variance_cost": varianceCost,
+		"data":          rows,
+	})
+}
+
+// GetBranchReport godoc
+// @Summary      Branch rollup report
+// @Description  Ringkasan per cabang (transaksi, penjualan, refund, pajak, tip, rata-rata transaksi, nilai stok dan
+// @Description  jumlah staff) beserta total tingkat brand. Branch manager hanya melihat cabangnya.
+// @Tags         branches
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from  query     string  false  "Tanggal awal (YYYY-MM-DD), default awal bulan ini"
+// @Param        to    query     string  false  "Tanggal akhir inklusif (YYYY-MM-DD)"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]interface{}
+// @Failure      403   {object}  map[string]interface{}
+// @Failure      500   {object}  map[string]interface{}
+// @Router       /reports/branches [get]
+func GetBranchReport(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	rows, err := report.Branches(DBConnection, *user.SalonID, scope, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Staff yang ditugaskan ke beberapa cabang hanya dihitung sekali pada total
+	staff := DBConnection.Model(&models.BranchStaff{}).
+		Joins("JOIN branches ON branches.id = branch_staffs.branch_id").
+		Joins("JOIN users ON users.id = branch_staffs.user_id AND users.deleted_at IS NULL").
+		Where("branches.salon_id = ? AND users.salon_id = ?", *user.SalonID, *user.SalonID)
+	if scope != nil {
+		staff = staff.Where("branch_staffs.branch_id IN ?", scope)
+	}
+	total := report.BranchTotal(rows)
+	if err := staff.Distinct("branch_staffs.user_id").Count(&total.Staff).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":  from.Format("2006-01-02"),
+		"to":    to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"total": total,
+		"data":  rows,
+	})
+}
+
+// GetRevenueReport godoc
+// @Summary      Revenue report
+// @Description  Laporan pendapatan per hari, minggu, bulan, kategori layanan/produk, staff, cabang atau metode pembayaran
+// @Description  beserta total periode. compare=true menambahkan total periode sebelumnya (bulan kalender sebelumnya
+// @Description  jika rentang satu bulan penuh). format=csv|xlsx mengunduh tabel. Branch manager hanya melihat cabangnya.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        group_by   query     string  false  "day (default), week, month, category, staff, branch, payment_method"
+// @Param        from       query     string  false  "Tanggal awal (YYYY-MM-DD), default awal bulan ini"
+// @Param        to         query     string  false  "Tanggal akhir inklusif (YYYY-MM-DD)"
+// @Param        timezone   query     string  false  "Zona waktu IANA, default zona waktu aplikasi"
+// @Param        branch_id  query     int     false  "Branch ID"
+// @Param        compare    query     bool    false  "Sertakan total periode sebelumnya"
+// @Param        format     query     string  false  "csv atau xlsx untuk mengunduh"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]interface{}
+// @Failure      401        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]interface{}
+// @Router       /reports/revenue [get]
+func GetRevenueReport(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	group := c.DefaultQuery("group_by", report.GroupDay)
+	if !slices.Contains(report.Groups, group) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by harus salah satu dari " + strings.Join(report.Groups, ", ")})
+		return
+	}
+	loc, ok := reportLocation(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseDateRangeIn(c, loc)
+	if !ok {
+		return
+	}
+	branchIDs, ok := reportBranches(c, user)
+	if !ok {
+		return
+	}
+
+	filter := report.RevenueFilter{SalonID: *user.SalonID, BranchIDs: branchIDs, From: from, To: to, Location: loc}
+	rows, err := report.Revenue(DBConnection, filter, group)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") != "" {
+		table := spreadsheet.Table{
+			Sheet: "Pendapatan",
+			Header: []string{"Key", "Label", "Transaksi", "Qty", "Penjualan", "Diskon", "Refund", "Penjualan Bersih",
+				"Pajak", "Tip", "Rata-rata Transaksi"},
+		}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []any{row.Key, row.Label, row.Transactions, row.Quantity, row.Sales,
+				row.Discounts, row.Refunds, row.NetSales, row.Tax, row.Tips, row.AverageTicket})
+		}
+		writeTable(c, table, "revenue-"+group+"-"+from.Format("20060102")+"-"+to.AddDate(0, 0, -1).Format("20060102"))
+		return
+	}
+
+	summary, err := report.RevenueSummary(DBConnection, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response := gin.H{
+		"from":     from.Format("2006-01-02"),
+		"to":       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"timezone": loc.String(),
+		"group_by": group,
+		"summary":  summary,
+		"data":     rows,
+	}
+
+	if c.Query("compare") == "true" {
+		previous := filter
+		previous.From, previous.To = previousRange(from, to)
+		total, err := report.RevenueSummary(DBConnection, previous)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response["previous"] = gin.H{
+			"from":    previous.From.Format("2006-01-02"),
+			"to":      previous.To.AddDate(0, 0, -1).Format("2006-01-02"),
+			"summary": total,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetStaffUtilizationReport godoc
+// @Summary      Staff utilization report
+// @Description  Produktivitas per staff: jam shift tersedia vs jam booking dan walk-in, rata-rata transaksi, rebooking
+// @Description  rate (booking berikutnya dibuat dalam 24 jam setelah kunjungan) dan retail attachment rate (transaksi
+// @Description  layanan yang juga berisi produk). Rasio dalam basis poin. Diurutkan dari utilisasi terendah.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from       query     string  false  "Tanggal awal (YYYY-MM-DD), default awal bulan ini"
+// @Param        to         query     string  false  "Tanggal akhir inklusif (YYYY-MM-DD)"
+// @Param        timezone   query     string  false  "Zona waktu IANA, default zona waktu aplikasi"
+// @Param        branch_id  query     int     false  "Branch ID"
+// @Param        staff_id   query     int     false  "Staff ID"
+// @Param        format     query     string  false  "csv atau xlsx untuk mengunduh"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]interface{}
+// @Failure      401        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]interface{}
+// @Router       /reports/staff-utilization [get]
+func GetStaffUtilizationReport(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	loc, ok := reportLocation(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseDateRangeIn(c, loc)
+	if !ok {
+		return
+	}
+	branchIDs, ok := reportBranches(c, user)
+	if !ok {
+		return
+	}
+	staffID, _ := strconv.ParseUint(c.Query("staff_id"), 10, 32)
+	if staffID != 0 {
+		if _, ok := findScopedStaff(c, user, uint(staffID)); !ok {
+			return
+		}
+	}
+
+	filter := report.RevenueFilter{SalonID: *user.SalonID, BranchIDs: branchIDs, From: from, To: to, Location: loc}
+	rows, err := report.Utilization(DBConnection, filter, uint(staffID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") != "" {
+		table := spreadsheet.Table{
+			Sheet: "Utilisasi Staff",
+			Header: []string{"Staff ID", "Nama", "Jam Tersedia", "Jam Terpakai", "Jam Walk-in", "Utilisasi (bps)",
+				"Transaksi", "Pendapatan", "Rata-rata Transaksi", "Transaksi Layanan", "Dengan Retail",
+				"Retail Attachment (bps)", "Kunjungan", "Rebook", "Rebooking (bps)"},
+		}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []any{row.StaffID, row.StaffName, row.AvailableHours, row.BookedHours,
+				row.WalkInHours, row.UtilizationBps, row.Tickets, row.Revenue, row.AverageTicket, row.ServiceTickets,
+				row.RetailAttached, row.RetailAttachmentBps, row.Visits, row.Rebooked, row.RebookingBps})
+		}
+		writeTable(c, table, "staff-utilization-"+from.Format("20060102")+"-"+to.AddDate(0, 0, -1).Format("20060102"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from.Format("2006-01-02"),
+		"to":       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"timezone": loc.String(),
+		"data":     rows,
+	})
+}
+
+// GetCustomerReport godoc
+// @Summary      New vs returning customers report
+// @Description  Pelanggan baru vs kembali per hari, minggu atau bulan (pelanggan baru = kunjungan pertama pada periode)
+// @Description  beserta return rate: porsi pelanggan baru pada rentang yang kembali dalam return_days hari (default 60).
+// @Description  Return rate hanya dihitung dari pelanggan yang kunjungan pertamanya sudah lewat return_days hari.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        group_by     query     string  false  "day, week, month (default)"
+// @Param        from         query     string  false  "Tanggal awal (YYYY-MM-DD), default awal bulan ini"
+// @Param        to           query     string  false  "Tanggal akhir inklusif (YYYY-MM-DD)"
+// @Param        timezone     query     string  false  "Zona waktu IANA, default zona waktu aplikasi"
+// @Param        branch_id    query     int     false  "Branch ID"
+// @Param        return_days  query     int     false  "Batas hari kunjungan kedua, default 60"
+// @Param        format       query     string  false  "csv atau xlsx untuk mengunduh"
+// @Success      200          {object}  map[string]interface{}
+// @Failure      400          {object}  map[string]interface{}
+// @Failure      401          {object}  map[string]interface{}
+// @Failure      403          {object}  map[string]interface{}
+// @Failure      500          {object}  map[string]interface{}
+// @Router       /reports/customers [get]
+func GetCustomerReport(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	group := c.DefaultQuery("group_by", report.GroupMonth)
+	if group != report.GroupDay && group != report.GroupWeek && group != report.GroupMonth {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by harus salah satu dari day, week, month"})
+		return
+	}
+	returnDays, err := strconv.Atoi(c.DefaultQuery("return_days", strconv.Itoa(report.DefaultReturnDays)))
+	if err != nil || returnDays <= 0 || returnDays > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "return_days harus antara 1 dan 365"})
+		return
+	}
+	loc, ok := reportLocation(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseDateRangeIn(c, loc)
+	if !ok {
+		return
+	}
+	branchIDs, ok := reportBranches(c, user)
+	if !ok {
+		return
+	}
+
+	filter := report.RevenueFilter{SalonID: *user.SalonID, BranchIDs: branchIDs, From: from, To: to, Location: loc}
+	rows, err := report.CustomerTrends(DBConnection, filter, group)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") != "" {
+		table := spreadsheet.Table{
+			Sheet: "Pelanggan",
+			Header: []string{"Periode", "Pelanggan", "Baru", "Kembali", "Kembali (bps)", "Kunjungan",
+				"Pendapatan Baru", "Pendapatan Kembali"},
+		}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []any{row.Key, row.Customers, row.NewCustomers, row.ReturningCustomers,
+				row.ReturningBps, row.Visits, row.NewRevenue, row.ReturningRevenue})
+		}
+		writeTable(c, table, "customers-"+group+"-"+from.Format("20060102")+"-"+to.AddDate(0, 0, -1).Format("20060102"))
+		return
+	}
+
+	summary, err := report.CustomerTrendSummary(DBConnection, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	returnRate, err := report.CustomerReturnRate(DBConnection, filter, returnDays, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":        from.Format("2006-01-02"),
+		"to":          to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"timezone":    loc.String(),
+		"group_by":    group,
+		"summary":     summary,
+		"return_rate": returnRate,
+		"data":        rows,
+	})
+}
+
+// GetCustomerCohortReport godoc
+// @Summary      Customer retention cohorts
+// @Description  Cohort pelanggan berdasarkan bulan kunjungan pertama beserta jumlah dan persentase (basis poin) yang
+// @Description  kembali pada bulan ke-n setelahnya. Default 12 bulan terakhir.
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        from       query     string  false  "Tanggal awal (YYYY-MM-DD), default awal bulan 11 bulan lalu"
+// @Param        to         query     string  false  "Tanggal akhir inklusif (YYYY-MM-DD), default akhir bulan ini"
+// @Param        timezone   query     string  false  "Zona waktu IANA, default zona waktu aplikasi"
+// @Param        branch_id  query     int     false  "Branch ID"
+// @Param        format     query     string  false  "csv atau xlsx untuk mengunduh"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]interface{}
+// @Failure      401        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]interface{}
+// @Router       /reports/customers/cohorts [get]
+func GetCustomerCohortReport(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	loc, ok := reportLocation(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parseDateRangeIn(c, loc)
+	if !ok {
+		return
+	}
+	if c.Query("from") == "" {
+		last := to.AddDate(0, 0, -1)
+		from = time.Date(last.Year(), last.Month()-11, 1, 0, 0, 0, 0, loc)
+	}
+	branchIDs, ok := reportBranches(c, user)
+	if !ok {
+		return
+	}
+
+	filter := report.RevenueFilter{SalonID: *user.SalonID, BranchIDs: branchIDs, From: from, To: to, Location: loc}
+	cohorts, err := report.Cohorts(DBConnection, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") != "" {
+		table := spreadsheet.Table{
+			Sheet:  "Cohort",
+			Header: []string{"Cohort", "Pelanggan", "Bulan Ke", "Pelanggan Aktif", "Retensi (bps)", "Pendapatan"},
+		}
+		for _, cohort := range cohorts {
+			for _, period := range cohort.Periods {
+				table.Rows = append(table.Rows, []any{cohort.Month, cohort.Customers, period.MonthOffset,
+					period.Customers, period.RetentionBps, period.Revenue})
+			}
+		}
+		writeTable(c, table, "customer-cohorts-"+from.Format("20060102")+"-"+to.AddDate(0, 0, -1).Format("20060102"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from.Format("2006-01-02"),
+		"to":       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"timezone": loc.String(),
+		"data":     cohorts,
+	})
+}
+
+// GetChurnRiskReport godoc
+// @Summary      Customer churn risk
+// @Description  Pelanggan dengan minimal dua kunjungan yang hari sejak kunjungan terakhirnya melebihi rata-rata interval
+// @Description  kunjungannya dikali factor_bps (default 15000 = 1,5x), diurutkan dari yang paling terlambat
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        factor_bps  query     int     false  "Kelipatan rata-rata interval dalam basis poin, default 15000"
+// @Param        limit       query     int     false  "Jumlah pelanggan, default 100 (maks 500)"
+// @Param        timezone    query     string  false  "Zona waktu IANA, default zona waktu aplikasi"
+// @Param        branch_id   query     int     false  "Branch ID"
+// @Param        format      query     string  false  "csv atau xlsx untuk mengunduh"
+// @Success      200         {object}  map[string]interface{}
+// @Failure      400         {object}  map[string]interface{}
+// @Failure      401         {object}  map[string]interface{}
+// @Failure      403         {object}  map[string]interface{}
+// @Failure      500         {object}  map[string]interface{}
+// @Router       /reports/customers/churn-risk [get]
+func GetChurnRiskReport(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	factorBps, err := strconv.ParseInt(c.DefaultQuery("factor_bps", strconv.Itoa(report.DefaultChurnFactorBps)), 10, 64)
+	if err != nil || factorBps < 10000 || factorBps > 100000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "factor_bps harus antara 10000 dan 100000"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	loc, ok := reportLocation(c)
+	if !ok {
+		return
+	}
+	branchIDs, ok := reportBranches(c, user)
+	if !ok {
+		return
+	}
+
+	filter := report.RevenueFilter{SalonID: *user.SalonID, BranchIDs: branchIDs, Location: loc}
+	rows, err := report.ChurnRisks(DBConnection, filter, time.Now(), factorBps, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") != "" {
+		table := spreadsheet.Table{
+			Sheet: "Risiko Churn",
+			Header: []string{"Customer ID", "Nama", "Telepon", "Kunjungan", "Total Belanja", "Kunjungan Terakhir",
+				"Rata-rata Interval (hari)", "Hari Sejak Kunjungan", "Terlambat (hari)", "Risiko (bps)"},
+		}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []any{row.CustomerID, row.CustomerName, row.Phone, row.Visits, row.Spend,
+				row.LastVisitAt.In(loc).Format("2006-01-02"), row.AverageIntervalDays, row.DaysSinceLastVisit,
+				row.OverdueDays, row.RiskBps})
+		}
+		writeTable(c, table, "churn-risk-"+time.Now().In(loc).Format("20060102"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"timezone":   loc.String(),
+		"factor_bps": factorBps,
+		"data":       rows,
+	})
+}
+
+// GetCustomerLifetimeValueReport godoc
+// @Summary      Customer lifetime value
+// @Description  Nilai seumur hidup pelanggan (total belanja setelah refund sejak kunjungan pertama) diurutkan dari
+// @Description  yang terbesar, beserta rata-rata nilai, kunjungan dan transaksi seluruh pelanggan
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        limit      query     int     false  "Jumlah pelanggan, default 100 (maks 500)"
+// @Param        timezone   query     string  false  "Zona waktu IANA, default zona waktu aplikasi"
+// @Param        branch_id  query     int     false  "Branch ID"
+// @Param        format     query     string  false  "csv atau xlsx untuk mengunduh"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]interface{}
+// @Failure      401        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]interface{}
+// @Router       /reports/customers/lifetime-value [get]
+func GetCustomerLifetimeValueReport(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	loc, ok := reportLocation(c)
+	if !ok {
+		return
+	}
+	branchIDs, ok := reportBranches(c, user)
+	if !ok {
+		return
+	}
+
+	filter := report.RevenueFilter{SalonID: *user.SalonID, BranchIDs: branchIDs, Location: loc}
+	rows, err := report.LifetimeValues(DBConnection, filter, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("format") != "" {
+		table := spreadsheet.Table{
+			Sheet: "Lifetime Value",
+			Header: []string{"Customer ID", "Nama", "Telepon", "Kunjungan", "Transaksi", "Total Belanja",
+				"Rata-rata Transaksi", "Kunjungan Pertama", "Kunjungan Terakhir", "Lama Menjadi Pelanggan (hari)"},
+		}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []any{row.CustomerID, row.CustomerName, row.Phone, row.Visits,
+				row.Transactions, row.Spend, row.AverageTicket, row.FirstVisitAt.In(loc).Format("2006-01-02"),
+				row.LastVisitAt.In(loc).Format("2006-01-02"), row.TenureDays})
+		}
+		writeTable(c, table, "customer-lifetime-value-"+time.Now().In(loc).Format("20060102"))
+		return
+	}
+
+	summary, err := report.LifetimeValueSummary(DBConnection, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"timezone": loc.String(),
+		"summary":  summary,
+		"data":     rows,
+	})
+}
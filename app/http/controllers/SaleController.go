@@ -0,0 +1,850 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/audit"
+	"gin-sass-salon/app/commission"
+	"gin-sass-salon/app/giftcard"
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/loyalty"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/payment"
+	"gin-sass-salon/app/pos"
+	"gin-sass-salon/app/prepaid"
+	"gin-sass-salon/app/promo"
+	"gin-sass-salon/config"
+)
+
+// SaleItemRequest adalah satu item layanan atau produk pada keranjang
+type SaleItemRequest struct {
+	Type      string `json:"type" binding:"required,oneof=service product gift_card package membership" example:"service"`
+	ServiceID *uint  `json:"service_id" example:"1"`
+	ProductID *uint  `json:"product_id" example:"1"`
+	StaffID   *uint  `json:"staff_id" example:"2"`
+	// Splits membagi layanan yang dikerjakan beberapa staff; bagian terbesar menjadi staff utama baris
+	Splits []SaleSplitRequest `json:"splits" binding:"dive"`
+	// PackageID dan MembershipPlanID untuk item package/membership; harga diambil dari katalog.
+	// Quantity membership adalah jumlah periode yang dibayar.
+	PackageID        *uint `json:"package_id" example:"1"`
+	MembershipPlanID *uint `json:"membership_plan_id" example:"1"`
+	// UseCredit membayar layanan dengan kredit paket atau benefit membership pelanggan
+	UseCredit bool `json:"use_credit" example:"false"`
+	// Description dan UnitPrice wajib untuk produk tanpa product_id; layanan dan produk katalog diambil dari katalog.
+	// Untuk gift_card, UnitPrice adalah nilai tiap gift card yang diterbitkan.
+	Description    string `json:"description" example:"Shampoo 250ml"`
+	Quantity       int    `json:"quantity" example:"1"`
+	UnitPrice      int64  `json:"unit_price" example:"85000"`
+	DiscountAmount int64  `json:"discount_amount" example:"0"`
+	DiscountBps    int    `json:"discount_bps" example:"1000"`
+	// TaxClassID untuk produk; layanan memakai kelas pajak dari katalog. Kosong = kelas default salon
+	TaxClassID *uint `json:"tax_class_id" example:"1"`
+}
+
+// SaleDiscountRequest adalah diskon tingkat transaksi (nominal dan/atau persen dalam basis poin)
+type SaleDiscountRequest struct {
+	Description string `json:"description" example:"Diskon member"`
+	Amount      int64  `json:"amount" example:"10000"`
+	Bps         int    `json:"bps" example:"0"`
+}
+
+// SaleTipRequest adalah tip untuk staff
+type SaleTipRequest struct {
+	StaffID *uint `json:"staff_id" example:"2"`
+	Amount  int64 `json:"amount" binding:"required" example:"20000"`
+}
+
+// SaleTenderRequest adalah satu pembayaran
+type SaleTenderRequest struct {
+	Method    string `json:"method" binding:"required,oneof=cash card qris virtual_account transfer gift_card" example:"cash"`
+	Amount    int64  `json:"amount" binding:"required" example:"200000"`
+	Reference string `json:"reference" example:"EDC-123456"`
+	// PaymentID diisi jika dibayar lewat payment provider (POST /api/payments)
+	PaymentID *uint `json:"payment_id" example:"1"`
+	// GiftCardCode wajib untuk tender gift_card
+	GiftCardCode string `json:"gift_card_code" example:"GC-7K3M-Q9XA-2BHD"`
+}
+
+// QuoteSaleRequest struktur untuk request perhitungan keranjang tanpa menyimpan
+type QuoteSaleRequest struct {
+	CustomerID *uint                 `json:"customer_id" example:"1"`
+	BookingID  *uint                 `json:"booking_id" example:"1"`
+	Items      []SaleItemRequest     `json:"items" binding:"required,min=1,dive"`
+	Discounts  []SaleDiscountRequest `json:"discounts" binding:"dive"`
+	Tips       []SaleTipRequest      `json:"tips" binding:"dive"`
+	// PromoCodes diterapkan berurutan sebelum diskon manual
+	PromoCodes []string `json:"promo_codes" example:"WEEKDAY20"`
+	// RedeemPoints adalah poin loyalty pelanggan yang ditukar sebagai diskon (diterapkan paling akhir)
+	RedeemPoints int64 `json:"redeem_points" binding:"min=0" example:"0"`
+}
+
+// CheckoutRequest struktur untuk request checkout POS
+type CheckoutRequest struct {
+	QuoteSaleRequest
+	Tenders []SaleTenderRequest `json:"tenders" binding:"required,min=1,dive"`
+	Notes   string              `json:"notes" example:"Bayar sebagian tunai"`
+	// BranchID adalah cabang tempat transaksi; kosong berarti cabang default kasir (cabang penugasan atau cabang utama)
+	BranchID *uint `json:"branch_id" example:"1"`
+}
+
+// QuoteSale godoc
+// @Summary      Quote sale
+// @Description  Menghitung subtotal, diskon, pajak, tip dan total keranjang tanpa menyimpan transaksi.
+// @Description  Kode promo yang ditolak dijelaskan di field rejections (lihat juga POST /promotions/validate).
+// @Tags         sales
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      QuoteSaleRequest  true  "Quote Sale Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /sales/quote [post]
+func QuoteSale(c *gin.Context) {
+	var req QuoteSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	sale, _, ok := calculateSale(c, *user.SalonID, req)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sale})
+}
+
+// Checkout godoc
+// @Summary      Checkout sale
+// @Description  Menyimpan transaksi POS dengan satu atau beberapa pembayaran dan memberi nomor struk berurutan tanpa celah.
+// @Description  Jika booking_id diisi, booking ditandai selesai. Item gift_card menerbitkan gift card baru (dikembalikan
+// @Description  di field gift_cards) dan tender gift_card memotong saldo gift card dari gift_card_code.
+// @Description  Kode promo di promo_codes diperiksa ulang kuotanya saat transaksi disimpan. Pelanggan dengan program
+// @Description  loyalty aktif memperoleh poin, dan redeem_points memotong saldo poinnya.
+// @Tags         sales
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CheckoutRequest  true  "Checkout Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /sales [post]
+func Checkout(c *gin.Context) {
+	var req CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+	salonID := *user.SalonID
+
+	sale, promotions, ok := calculateSale(c, salonID, req.QuoteSaleRequest)
+	if !ok {
+		return
+	}
+
+	tenders := make([]models.SaleTender, 0, len(req.Tenders))
+	for _, tender := range req.Tenders {
+		reference := tender.Reference
+		if tender.Method == models.TenderGiftCard {
+			if tender.GiftCardCode == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "gift_card_code wajib untuk pembayaran gift card"})
+				return
+			}
+			// Kode dibaca giftcard.AttachToSale lalu diganti versi tersamar
+			reference = tender.GiftCardCode
+		}
+		tenders = append(tenders, models.SaleTender{
+			Method:    tender.Method,
+			Amount:    tender.Amount,
+			Reference: reference,
+			PaymentID: tender.PaymentID,
+		})
+	}
+	if err := pos.ApplyTenders(sale, tenders); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sale.SalonID = salonID
+	sale.CustomerID = req.CustomerID
+	sale.BookingID = req.BookingID
+	sale.CashierID = user.ID
+	sale.Notes = req.Notes
+	branchID, ok := userDefaultBranch(c, user, req.BranchID)
+	if !ok {
+		return
+	}
+	sale.BranchID = &branchID
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	var giftCards []models.GiftCard
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if req.BookingID != nil {
+			var b models.Booking
+			if err := lockSalonBooking(tx, &b, salonID, scope, *req.BookingID); err != nil {
+				return err
+			}
+			if b.Status != models.BookingStatusBooked {
+				return errBookingNotOpen
+			}
+			if err := tx.Model(&b).Update("status", models.BookingStatusCompleted).Error; err != nil {
+				return err
+			}
+		}
+		if err := pos.Checkout(tx, sale); err != nil {
+			return err
+		}
+		// Baris item berurutan sama dengan item request
+		for i, item := range req.Items {
+			if len(item.Splits) == 0 {
+				continue
+			}
+			splits := make([]commission.Split, 0, len(item.Splits))
+			for _, split := range item.Splits {
+				splits = append(splits, commission.Split{StaffID: split.StaffID, ShareBps: split.ShareBps})
+			}
+			if err := commission.SetSplits(tx, &sale.Lines[i], splits); err != nil {
+				return err
+			}
+		}
+		if err := inventory.AttachToSale(tx, sale); err != nil {
+			return err
+		}
+		if err := payment.AttachToSale(tx, sale); err != nil {
+			return err
+		}
+		var err error
+		giftCards, err = giftcard.AttachToSale(tx, sale)
+		if err != nil {
+			return err
+		}
+		if err := promo.Redeem(tx, sale, promotions); err != nil {
+			return err
+		}
+		if err := prepaid.AttachToSale(tx, sale); err != nil {
+			return err
+		}
+		return loyalty.Apply(tx, sale, req.RedeemPoints)
+	})
+	if err != nil {
+		var rejection *promo.Rejection
+		switch {
+		case errors.As(err, &rejection):
+			c.JSON(http.StatusConflict, gin.H{"error": rejection.Error(), "rejections": []promo.Rejection{*rejection}})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, errBookingNotOpen):
+			c.JSON(http.StatusConflict, gin.H{"error": "Booking sudah tidak aktif"})
+		case errors.Is(err, giftcard.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, commission.ErrInvalidSplit):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, payment.ErrPaymentNotUsable), errors.Is(err, pos.ErrNoOpenShift),
+			errors.Is(err, giftcard.ErrNotUsable), errors.Is(err, giftcard.ErrInsufficientBalance),
+			errors.Is(err, giftcard.ErrGiftCardForGiftCard), errors.Is(err, loyalty.ErrInsufficientPoints),
+			errors.Is(err, loyalty.ErrProgramInactive), errors.Is(err, prepaid.ErrNoCredit):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan transaksi"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Transaksi berhasil disimpan", "data": sale, "gift_cards": giftCards})
+}
+
+// GetSales godoc
+// @Summary      Get sales
+// @Description  Mengambil transaksi salon pada satu hari (default hari ini). Branch manager hanya melihat cabangnya.
+// @Tags         sales
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        date  query     string  false  "Tanggal (YYYY-MM-DD)"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]interface{}
+// @Failure      403   {object}  map[string]interface{}
+// @Failure      500   {object}  map[string]interface{}
+// @Router       /sales [get]
+func GetSales(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	loc := config.Location()
+	day := time.Now().In(loc)
+	if date := c.Query("date"); date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", date, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal tidak valid, gunakan YYYY-MM-DD"})
+			return
+		}
+		day = parsed
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	query := DBConnection.Preload("Tenders").
+		Where("salon_id = ? AND completed_at >= ? AND completed_at < ?", *user.SalonID, start, start.AddDate(0, 0, 1))
+	if scope != nil {
+		query = query.Where("branch_id IN ?", scope)
+	}
+
+	var sales []models.Sale
+	if err := query.Order("receipt_seq").Find(&sales).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sales})
+}
+
+// GetSale godoc
+// @Summary      Get sale by ID
+// @Description  Mengambil detail transaksi beserta baris, pembayaran dan riwayat refund
+// @Tags         sales
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Sale ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /sales/{id} [get]
+func GetSale(c *gin.Context) {
+	saleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	sale, ok := findSalonSale(c, *user.SalonID, saleID)
+	if !ok {
+		return
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+	if !saleInScope(scope, &sale) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errBranchAccess.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sale})
+}
+
+// errBookingNotOpen dikembalikan jika booking yang di-checkout sudah selesai atau dibatalkan
+var errBookingNotOpen = errors.New("booking sudah tidak aktif")
+
+// calculateSale menyusun keranjang dari request, menerapkan kode promo lalu menghitung totalnya.
+// Menulis response error dan mengembalikan false jika gagal atau ada kode promo yang ditolak.
+func calculateSale(c *gin.Context, salonID uint, req QuoteSaleRequest) (*models.Sale, []promo.Applied, bool) {
+	cart, cfg, in, ok := buildSaleCart(c, salonID, req)
+	if !ok {
+		return nil, nil, false
+	}
+
+	promotions, rejections, err := promo.Resolve(DBConnection, salonID, req.PromoCodes, in)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	if len(rejections) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": rejections[0].Error(), "rejections": rejections})
+		return nil, nil, false
+	}
+
+	sale, err := pos.Calculate(withPromotions(cart, promotions), cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	return sale, promotions, true
+}
+
+// withPromotions menaruh diskon promo sebelum diskon manual kasir
+func withPromotions(cart pos.Cart, promotions []promo.Applied) pos.Cart {
+	discounts := make([]pos.Discount, 0, len(promotions)+len(cart.Discounts))
+	for _, applied := range promotions {
+		discounts = append(discounts, applied.Discount)
+	}
+	cart.Discounts = append(discounts, cart.Discounts...)
+	return cart
+}
+
+// buildSaleCart menyusun keranjang dari request (harga layanan diambil dari katalog salon) beserta
+// konfigurasi pajak dan konteks promonya. Menulis response error dan mengembalikan false jika gagal.
+func buildSaleCart(c *gin.Context, salonID uint, req QuoteSaleRequest) (pos.Cart, pos.TaxConfig, promo.Input, bool) {
+	var cart pos.Cart
+	var cfg pos.TaxConfig
+	in := promo.Input{Now: time.Now().In(config.Location())}
+
+	var salon models.Salon
+	if err := DBConnection.First(&salon, salonID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return cart, cfg, in, false
+	}
+	// credits menjumlahkan pemakaian kredit prepaid per layanan untuk dicek sekaligus
+	credits := map[uint]int{}
+
+	if req.CustomerID != nil {
+		customer, ok := findSalonCustomer(c, salonID, *req.CustomerID)
+		if !ok {
+			return cart, cfg, in, false
+		}
+		if len(req.PromoCodes) > 0 {
+			history, err := promo.LoadCustomer(DBConnection, customer)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return cart, cfg, in, false
+			}
+			in.Customer = history
+		}
+	}
+
+	taxes := pos.NewTaxResolver(DBConnection, salonID, in.Now)
+	cfg = pos.TaxConfig{
+		PricesIncludeTax: salon.PricesIncludeTax,
+		ServiceChargeBps: salon.ServiceChargeBps,
+	}
+	if salon.ServiceChargeTaxClassID != nil {
+		rates, err := taxes.Rates(salon.ServiceChargeTaxClassID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return cart, cfg, in, false
+		}
+		cfg.ServiceChargeRates = rates
+	}
+
+	for _, item := range req.Items {
+		if item.StaffID != nil {
+			if _, ok := findSalonStaff(c, salonID, *item.StaffID); !ok {
+				return cart, cfg, in, false
+			}
+		}
+		if len(item.Splits) > 0 {
+			if item.Type != models.SaleLineService {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "splits hanya untuk item layanan"})
+				return cart, cfg, in, false
+			}
+			if _, ok := saleSplits(c, salonID, item.Splits); !ok {
+				return cart, cfg, in, false
+			}
+		}
+
+		line := pos.Item{
+			Type:           item.Type,
+			ServiceID:      item.ServiceID,
+			ProductID:      item.ProductID,
+			StaffID:        item.StaffID,
+			Description:    item.Description,
+			Quantity:       item.Quantity,
+			UnitPrice:      item.UnitPrice,
+			DiscountAmount: item.DiscountAmount,
+			DiscountBps:    item.DiscountBps,
+
+			PackageID:        item.PackageID,
+			MembershipPlanID: item.MembershipPlanID,
+		}
+		if line.Quantity == 0 {
+			line.Quantity = 1
+		}
+		taxClassID := item.TaxClassID
+
+		switch item.Type {
+		case models.SaleLineService:
+			if item.ServiceID == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "service_id wajib untuk item layanan"})
+				return cart, cfg, in, false
+			}
+			service, ok := findSalonService(c, salonID, *item.ServiceID)
+			if !ok {
+				return cart, cfg, in, false
+			}
+			line.Description = service.Name
+			line.UnitPrice = service.Price
+			line.Category = service.Category
+			taxClassID = service.TaxClassID
+			if item.UseCredit {
+				if req.CustomerID == nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": prepaid.ErrCustomerRequired.Error()})
+					return cart, cfg, in, false
+				}
+				credits[service.ID] += line.Quantity
+				line.Description = service.Name + " (prepaid)"
+				line.UnitPrice = 0
+				line.DiscountAmount = 0
+				line.DiscountBps = 0
+				line.PrepaidCredit = true
+			}
+		case models.SaleLinePackage:
+			if item.PackageID == nil || req.CustomerID == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "package_id dan customer_id wajib untuk item paket"})
+				return cart, cfg, in, false
+			}
+			var pkg models.Package
+			if err := DBConnection.Where("salon_id = ? AND is_active = ?", salonID, true).First(&pkg, *item.PackageID).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Paket tidak ditemukan"})
+				return cart, cfg, in, false
+			}
+			line.Description = pkg.Name
+			line.UnitPrice = pkg.Price
+			taxClassID = pkg.TaxClassID
+		case models.SaleLineMembership:
+			if item.MembershipPlanID == nil || req.CustomerID == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "membership_plan_id dan customer_id wajib untuk item membership"})
+				return cart, cfg, in, false
+			}
+			var plan models.MembershipPlan
+			if err := DBConnection.Where("salon_id = ? AND is_active = ?", salonID, true).First(&plan, *item.MembershipPlanID).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Plan membership tidak ditemukan"})
+				return cart, cfg, in, false
+			}
+			line.Description = plan.Name
+			line.UnitPrice = plan.Price
+			taxClassID = plan.TaxClassID
+		case models.SaleLineProduct:
+			if item.ProductID != nil {
+				product, ok := findSalonProduct(c, salonID, *item.ProductID)
+				if !ok {
+					return cart, cfg, in, false
+				}
+				if !product.IsRetail {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Produk " + product.Name + " tidak dijual di kasir"})
+					return cart, cfg, in, false
+				}
+				line.Description = product.Name
+				line.UnitPrice = product.RetailPrice
+				line.Category = product.Category
+				taxClassID = product.TaxClassID
+			} else if item.Description == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "description wajib untuk item produk"})
+				return cart, cfg, in, false
+			}
+		case models.SaleLineGiftCard:
+			if line.Description == "" {
+				line.Description = "Gift card"
+			}
+			cart.Items = append(cart.Items, line)
+			continue
+		}
+
+		rates, err := taxes.Rates(taxClassID)
+		if err != nil {
+			if errors.Is(err, pos.ErrUnknownTaxClass) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return cart, cfg, in, false
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return cart, cfg, in, false
+		}
+		line.Rates = rates
+		cart.Items = append(cart.Items, line)
+	}
+
+	for serviceID, qty := range credits {
+		available, unlimited, err := prepaid.Available(DBConnection, *req.CustomerID, serviceID, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return cart, cfg, in, false
+		}
+		if !unlimited && available < qty {
+			c.JSON(http.StatusConflict, gin.H{"error": prepaid.ErrNoCredit.Error(), "service_id": serviceID, "available": available})
+			return cart, cfg, in, false
+		}
+	}
+
+	for _, discount := range req.Discounts {
+		cart.Discounts = append(cart.Discounts, pos.Discount{
+			Description: discount.Description,
+			Amount:      discount.Amount,
+			Bps:         discount.Bps,
+		})
+	}
+	if req.RedeemPoints > 0 {
+		program, err := loyalty.Program(DBConnection, salonID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return cart, cfg, in, false
+		}
+		value, err := loyalty.CheckRedemption(DBConnection, program, req.CustomerID, req.RedeemPoints)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return cart, cfg, in, false
+		}
+		cart.Discounts = append(cart.Discounts, pos.Discount{
+			Description: fmt.Sprintf("Tukar %d poin", req.RedeemPoints),
+			Amount:      value,
+			Strict:      true,
+		})
+	}
+	for _, tip := range req.Tips {
+		if tip.StaffID != nil {
+			if _, ok := findSalonStaff(c, salonID, *tip.StaffID); !ok {
+				return cart, cfg, in, false
+			}
+		}
+		cart.Tips = append(cart.Tips, pos.Tip{StaffID: tip.StaffID, Amount: tip.Amount})
+	}
+
+	in.Items = cart.Items
+	return cart, cfg, in, true
+}
+
+// findSalonSale mengambil transaksi milik salon beserta baris dan pembayarannya
+func findSalonSale(c *gin.Context, salonID, saleID uint) (models.Sale, bool) {
+	var sale models.Sale
+	if err := DBConnection.Preload("Lines.Taxes").Preload("Tenders").
+		Preload("Refunds.Lines").Preload("Refunds.Tenders").
+		Where("salon_id = ?", salonID).First(&sale, saleID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaksi tidak ditemukan"})
+			return sale, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return sale, false
+	}
+	return sale, true
+}
+
+// saleInScope mengecek apakah transaksi berada di cabang yang boleh diakses; transaksi lama tanpa cabang
+// hanya dapat diakses user dengan akses semua cabang
+func saleInScope(scope []uint, sale *models.Sale) bool {
+	return optionalInBranchScope(scope, sale.BranchID)
+}
+
+// RefundSaleRequest struktur untuk request refund. Jika lines kosong, seluruh sisa transaksi dikembalikan.
+// Kasir non-manager wajib menyertakan email dan password manager sebagai persetujuan.
+type RefundSaleRequest struct {
+	Reason          string                  `json:"reason" binding:"required" example:"Salah input layanan"`
+	Lines           []RefundSaleLineRequest `json:"lines" binding:"dive"`
+	ManagerEmail    string                  `json:"manager_email" example:"manager@example.com"`
+	ManagerPassword string                  `json:"manager_password" example:"password123"`
+}
+
+// RefundSaleLineRequest adalah jumlah item dari satu baris sale yang dikembalikan
+type RefundSaleLineRequest struct {
+	SaleLineID uint `json:"sale_line_id" binding:"required" example:"1"`
+	Quantity   int  `json:"quantity" binding:"required,gt=0" example:"1"`
+	// NoRestock untuk produk rusak/terpakai yang tidak dikembalikan ke stok
+	NoRestock bool `json:"no_restock" example:"false"`
+}
+
+// VoidSaleRequest struktur untuk request void transaksi hari ini
+type VoidSaleRequest struct {
+	Reason          string `json:"reason" binding:"required" example:"Transaksi ganda"`
+	ManagerEmail    string `json:"manager_email" example:"manager@example.com"`
+	ManagerPassword string `json:"manager_password" example:"password123"`
+}
+
+// RefundSale godoc
+// @Summary      Refund sale
+// @Description  Refund penuh atau sebagian; uang dikembalikan lewat tender asli dan produk retail dikembalikan ke stok.
+// @Description  Wajib alasan dan persetujuan owner/manager (user sendiri atau manager_email + manager_password).
+// @Tags         sales
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int                true  "Sale ID"
+// @Param        request  body      RefundSaleRequest  true  "Refund Sale Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /sales/{id}/refund [post]
+func RefundSale(c *gin.Context) {
+	saleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req RefundSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lines := make([]pos.RefundLine, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		lines = append(lines, pos.RefundLine{SaleLineID: line.SaleLineID, Quantity: line.Quantity, NoRestock: line.NoRestock})
+	}
+
+	reverseSale(c, saleID, models.RefundTypeRefund, req.Reason, lines, req.ManagerEmail, req.ManagerPassword)
+}
+
+// VoidSale godoc
+// @Summary      Void sale
+// @Description  Membatalkan seluruh transaksi hari ini yang belum pernah di-refund. Wajib alasan dan persetujuan owner/manager.
+// @Tags         sales
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int              true  "Sale ID"
+// @Param        request  body      VoidSaleRequest  true  "Void Sale Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /sales/{id}/void [post]
+func VoidSale(c *gin.Context) {
+	saleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req VoidSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reverseSale(c, saleID, models.RefundTypeVoid, req.Reason, nil, req.ManagerEmail, req.ManagerPassword)
+}
+
+// reverseSale menjalankan refund/void dengan persetujuan manager lalu mencatatnya di audit log
+func reverseSale(c *gin.Context, saleID uint, refundType, reason string, lines []pos.RefundLine, managerEmail, managerPassword string) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+	approver, ok := approvingManager(c, user, managerEmail, managerPassword)
+	if !ok {
+		return
+	}
+	scope, ok := branchScope(c, approver)
+	if !ok {
+		return
+	}
+
+	action := audit.ActionSaleRefunded
+	if refundType == models.RefundTypeVoid {
+		action = audit.ActionSaleVoided
+	}
+
+	var refund *models.SaleRefund
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		sale, err := pos.LockSale(tx, *user.SalonID, saleID)
+		if err != nil {
+			return err
+		}
+		if !saleInScope(scope, sale) {
+			return errBranchAccess
+		}
+
+		refund, err = pos.Refund(tx, sale, pos.RefundRequest{
+			Type:          refundType,
+			Reason:        reason,
+			Lines:         lines,
+			RequestedByID: user.ID,
+			ApprovedByID:  approver.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, models.AuditLog{
+			SalonID:    sale.SalonID,
+			UserID:     user.ID,
+			ApprovedBy: &approver.ID,
+			Action:     action,
+			EntityType: "sale",
+			EntityID:   sale.ID,
+			Reason:     reason,
+		}, gin.H{"refund_id": refund.ID, "amount": refund.Amount, "receipt_number": sale.ReceiptNumber})
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaksi tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, pos.ErrRefundQuantity), errors.Is(err, pos.ErrNothingToRefund):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, pos.ErrNotVoidable), errors.Is(err, pos.ErrSaleClosed), errors.Is(err, payment.ErrNotRefundable),
+			errors.Is(err, pos.ErrNoOpenShift), errors.Is(err, giftcard.ErrAlreadyUsed), errors.Is(err, giftcard.ErrNotUsable),
+			errors.Is(err, prepaid.ErrAlreadyUsed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses refund"})
+		}
+		return
+	}
+
+	pos.ProcessProviderRefunds(c.Request.Context(), DBConnection, refund)
+
+	message := "Refund berhasil"
+	if refundType == models.RefundTypeVoid {
+		message = "Transaksi berhasil di-void"
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": message, "data": refund})
+}
+
+// approvingManager mengembalikan manager yang menyetujui aksi: user sendiri jika owner/manager/branch manager,
+// atau manager salon yang sama yang email dan password-nya dikirim bersama request. Akses branch manager
+// terhadap cabang transaksi dicek oleh pemanggil.
+func approvingManager(c *gin.Context, user models.User, email, password string) (models.User, bool) {
+	if user.IsManager() {
+		return user, true
+	}
+	if email == "" || password == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Aksi ini membutuhkan persetujuan owner atau manager salon"})
+		return user, false
+	}
+
+	var manager models.User
+	err := DBConnection.Where("email = ? AND salon_id = ? AND role IN ?", email, *user.SalonID,
+		[]string{models.RoleOwner, models.RoleManager, models.RoleBranchManager}).First(&manager).Error
+	if err != nil || !manager.CheckPassword(password) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Persetujuan manager tidak valid"})
+		return user, false
+	}
+	return manager, true
+}
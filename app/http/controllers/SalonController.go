@@ -0,0 +1,501 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gin-sass-salon/app/mailer"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/realtime"
+)
+
+// CreateSalonRequest struktur untuk request create salon
+type CreateSalonRequest struct {
+	Name    string `json:"name" binding:"required" example:"Salon Cantik"`
+	Address string `json:"address" example:"Jl. Sudirman No. 1, Jakarta"`
+	Phone   string `json:"phone" example:"081234567890"`
+}
+
+// AddStaffRequest struktur untuk request menambahkan staff ke salon
+type AddStaffRequest struct {
+	Email string `json:"email" binding:"required,email" example:"jane@example.com"`
+	Role  string `json:"role" binding:"omitempty,oneof=manager branch_manager staff" example:"staff"`
+}
+
+// errInvitationNotPending dikembalikan jika undangan staff sudah diterima atau ditolak
+var errInvitationNotPending = errors.New("Undangan sudah tidak berlaku")
+
+// errAlreadyInSalon dikembalikan jika user yang menerima undangan sudah terhubung ke salon
+var errAlreadyInSalon = errors.New("User sudah terhubung ke salon")
+
+// CreateSalon godoc
+// @Summary      Create salon
+// @Description  Membuat salon baru; user yang login menjadi owner salon tersebut
+// @Tags         salon
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CreateSalonRequest  true  "Create Salon Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /salons [post]
+func CreateSalon(c *gin.Context) {
+	var req CreateSalonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if user.SalonID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "User sudah terhubung ke salon"})
+		return
+	}
+
+	salon := models.Salon{
+		Name:    req.Name,
+		Address: req.Address,
+		Phone:   req.Phone,
+		OwnerID: user.ID,
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&salon).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{"salon_id": salon.ID, "role": models.RoleOwner}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat salon"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Salon berhasil dibuat", "data": salon})
+}
+
+// GetSalon godoc
+// @Summary      Get current salon
+// @Description  Mengambil data salon milik user yang login
+// @Tags         salon
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /salon [get]
+func GetSalon(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	var salon models.Salon
+	if err := DBConnection.First(&salon, *user.SalonID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var staff []models.User
+	if err := DBConnection.Where("salon_id = ?", salon.ID).Order("name").Find(&staff).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var assignments []models.BranchStaff
+	if err := DBConnection.Joins("JOIN users ON users.id = branch_staffs.user_id").
+		Where("users.salon_id = ?", salon.ID).Order("branch_staffs.branch_id").Find(&assignments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	branchIDs := make(map[uint][]uint)
+	for _, assignment := range assignments {
+		branchIDs[assignment.UserID] = append(branchIDs[assignment.UserID], assignment.BranchID)
+	}
+
+	var staffResponse []gin.H
+	for _, member := range staff {
+		staffResponse = append(staffResponse, gin.H{
+			"id":         member.ID,
+			"name":       member.Name,
+			"email":      member.Email,
+			"role":       member.Role,
+			"branch_ids": branchIDs[member.ID],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"salon": salon, "staff": staffResponse}})
+}
+
+// AddSalonStaff godoc
+// @Summary      Add staff to salon
+// @Description  Mengundang user terdaftar ke salon sebagai staff, branch manager atau manager (khusus owner/manager).
+// @Description  User baru bergabung setelah menerima undangan lewat POST /invitations/{id}/accept; user yang sudah
+// @Description  menjadi anggota salon ini langsung diubah role-nya. Cabang branch manager diatur lewat
+// @Description  PUT /salon/staff/{id}/branches.
+// @Tags         salon
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      AddStaffRequest  true  "Add Staff Request"
+// @Success      200      {object}  map[string]interface{}
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /salon/staff [post]
+func AddSalonStaff(c *gin.Context) {
+	var req AddStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleStaff
+	}
+
+	manager, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := DBConnection.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if user.SalonID != nil && *user.SalonID != *manager.SalonID {
+		c.JSON(http.StatusConflict, gin.H{"error": "User sudah terhubung ke salon lain"})
+		return
+	}
+	if user.Role == models.RoleOwner && user.SalonID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Role owner tidak dapat diubah"})
+		return
+	}
+
+	// User di luar salon harus menyetujui sendiri sebelum terhubung ke salon
+	if user.SalonID == nil {
+		invitation, ok := inviteStaff(c, manager, user, req.Role)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Undangan dikirim; user bergabung setelah menerima undangan", "data": invitation})
+		return
+	}
+
+	if err := DBConnection.Model(&user).Update("role", req.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengubah role staff"})
+		return
+	}
+
+	data := gin.H{
+		"id":    user.ID,
+		"name":  user.Name,
+		"email": user.Email,
+		"role":  req.Role,
+	}
+	realtime.Publish(DBConnection, *manager.SalonID, realtime.EventUserUpdated, data)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role staff berhasil diubah", "data": data})
+}
+
+// inviteStaff membuat undangan salon untuk user (atau memperbarui role undangan yang masih pending) dan
+// mengirim email pemberitahuan ke user di transaksi yang sama
+func inviteStaff(c *gin.Context, manager, user models.User, role string) (models.StaffInvitation, bool) {
+	var invitation models.StaffInvitation
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var salon models.Salon
+		if err := tx.First(&salon, *manager.SalonID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("salon_id = ? AND user_id = ? AND status = ?", salon.ID, user.ID, models.StaffInvitationPending).
+			First(&invitation).Error
+		switch {
+		case err == nil:
+			invitation.Role = role
+			invitation.InvitedByID = manager.ID
+			if err := tx.Model(&invitation).Select("role", "invited_by_id").Updates(&invitation).Error; err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			invitation = models.StaffInvitation{
+				SalonID:     salon.ID,
+				UserID:      user.ID,
+				Role:        role,
+				InvitedByID: manager.ID,
+				Status:      models.StaffInvitationPending,
+			}
+			if err := tx.Create(&invitation).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		_, err = mailer.Queue(tx, mailer.Message{
+			To:      []string{user.Email},
+			Subject: "Undangan bergabung ke " + salon.Name,
+			Body: fmt.Sprintf("Halo %s,\n\n%s mengundang Anda bergabung ke %s sebagai %s. "+
+				"Masuk ke aplikasi untuk menerima atau menolak undangan ini.\n", user.Name, manager.Name, salon.Name, role),
+		})
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengirim undangan staff"})
+		return invitation, false
+	}
+	return invitation, true
+}
+
+// GetStaffInvitations godoc
+// @Summary      Get staff invitations
+// @Description  Menampilkan undangan salon yang masih pending untuk user yang login
+// @Tags         salon
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /invitations [get]
+func GetStaffInvitations(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var invitations []models.StaffInvitation
+	if err := DBConnection.Preload("Salon").Where("user_id = ? AND status = ?", user.ID, models.StaffInvitationPending).
+		Order("created_at DESC").Find(&invitations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil undangan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": invitations})
+}
+
+// AcceptStaffInvitation godoc
+// @Summary      Accept staff invitation
+// @Description  Menerima undangan salon: user yang login terhubung ke salon dengan role pada undangan.
+// @Description  Ditolak jika user sudah terhubung ke salon.
+// @Tags         salon
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Invitation ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /invitations/{id}/accept [post]
+func AcceptStaffInvitation(c *gin.Context) {
+	var member models.User
+	invitation, ok := respondStaffInvitation(c, models.StaffInvitationAccepted, func(tx *gorm.DB, user *models.User, invitation *models.StaffInvitation) error {
+		if user.SalonID != nil {
+			return errAlreadyInSalon
+		}
+		user.SalonID = &invitation.SalonID
+		user.Role = invitation.Role
+		member = *user
+		return tx.Model(user).Updates(map[string]interface{}{"salon_id": invitation.SalonID, "role": invitation.Role}).Error
+	})
+	if !ok {
+		return
+	}
+
+	data := gin.H{
+		"id":    member.ID,
+		"name":  member.Name,
+		"email": member.Email,
+		"role":  member.Role,
+	}
+	realtime.Publish(DBConnection, invitation.SalonID, realtime.EventUserAdded, data)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Undangan diterima", "data": data})
+}
+
+// DeclineStaffInvitation godoc
+// @Summary      Decline staff invitation
+// @Description  Menolak undangan salon untuk user yang login
+// @Tags         salon
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Invitation ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /invitations/{id}/decline [post]
+func DeclineStaffInvitation(c *gin.Context) {
+	_, ok := respondStaffInvitation(c, models.StaffInvitationDeclined, func(tx *gorm.DB, user *models.User, invitation *models.StaffInvitation) error {
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Undangan ditolak"})
+}
+
+// respondStaffInvitation mengunci undangan pending milik user yang login beserta baris user-nya, menjalankan fn,
+// lalu mengubah status undangan di dalam transaksi yang sama
+func respondStaffInvitation(c *gin.Context, status string, fn func(tx *gorm.DB, user *models.User, invitation *models.StaffInvitation) error) (models.StaffInvitation, bool) {
+	var invitation models.StaffInvitation
+	invitationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return invitation, false
+	}
+
+	current, ok := currentUser(c)
+	if !ok {
+		return invitation, false
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, current.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", user.ID).First(&invitation, invitationID).Error; err != nil {
+			return err
+		}
+		if invitation.Status != models.StaffInvitationPending {
+			return errInvitationNotPending
+		}
+		if err := fn(tx, &user, &invitation); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		invitation.Status = status
+		invitation.RespondedAt = &now
+		return tx.Model(&invitation).Select("status", "responded_at").Updates(&invitation).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Undangan tidak ditemukan"})
+		case errors.Is(err, errInvitationNotPending), errors.Is(err, errAlreadyInSalon):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses undangan"})
+		}
+		return invitation, false
+	}
+
+	return invitation, true
+}
+
+// currentUser mengambil user yang sedang login dan menulis response error jika gagal
+func currentUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User tidak terautentikasi"})
+		return user, false
+	}
+
+	if err := DBConnection.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User tidak ditemukan"})
+			return user, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return user, false
+	}
+
+	return user, true
+}
+
+// currentSalonUser seperti currentUser tetapi mewajibkan user terhubung ke salon
+func currentSalonUser(c *gin.Context) (models.User, bool) {
+	user, ok := currentUser(c)
+	if !ok {
+		return user, false
+	}
+	if user.SalonID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "User belum terhubung ke salon"})
+		return user, false
+	}
+	return user, true
+}
+
+// currentSalonManager seperti currentSalonUser tetapi mewajibkan role owner/manager/branch manager
+func currentSalonManager(c *gin.Context) (models.User, bool) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return user, false
+	}
+	if !user.IsManager() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Akses hanya untuk owner atau manager salon"})
+		return user, false
+	}
+	return user, true
+}
+
+// currentBrandManager seperti currentSalonUser tetapi mewajibkan role owner/manager yang mengelola seluruh
+// cabang; dipakai untuk pengaturan tingkat brand yang tidak boleh diubah branch manager
+func currentBrandManager(c *gin.Context) (models.User, bool) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return user, false
+	}
+	if !user.IsBrandManager() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Akses hanya untuk owner atau manager salon"})
+		return user, false
+	}
+	return user, true
+}
+
+// findSalonStaff memastikan staffID adalah anggota salon dan menulis response error jika bukan
+func findSalonStaff(c *gin.Context, salonID, staffID uint) (models.User, bool) {
+	var staff models.User
+	if err := DBConnection.Where("id = ? AND salon_id = ?", staffID, salonID).First(&staff).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Staff tidak ditemukan di salon ini"})
+			return staff, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return staff, false
+	}
+	return staff, true
+}
+
+// parseIDParam membaca parameter path berupa ID dan menulis response error jika tidak valid
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID tidak valid"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/models"
+)
+
+// ServiceRequest struktur untuk request create/update layanan
+type ServiceRequest struct {
+	Name            string `json:"name" binding:"required" example:"Hair Coloring"`
+	Category        string `json:"category" example:"coloring"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=5" example:"90"`
+	Price           int64  `json:"price" binding:"min=0" example:"350000"`
+	IsActive        *bool  `json:"is_active" example:"true"`
+	// TaxClassID kosong berarti memakai kelas pajak default salon
+	TaxClassID *uint `json:"tax_class_id" example:"1"`
+}
+
+// GetServices godoc
+// @Summary      Get salon services
+// @Description  Mengambil daftar layanan salon
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /services [get]
+func GetServices(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	var services []models.Service
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).Order("category, name").Find(&services).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": services})
+}
+
+// CreateService godoc
+// @Summary      Create salon service
+// @Description  Menambahkan layanan baru ke salon (khusus owner/manager)
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      ServiceRequest  true  "Service Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /services [post]
+func CreateService(c *gin.Context) {
+	var req ServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+	if req.TaxClassID != nil && !validTaxClass(c, *user.SalonID, *req.TaxClassID) {
+		return
+	}
+
+	service := models.Service{
+		SalonID:         *user.SalonID,
+		Name:            req.Name,
+		Category:        req.Category,
+		DurationMinutes: req.DurationMinutes,
+		Price:           req.Price,
+		IsActive:        req.IsActive == nil || *req.IsActive,
+		TaxClassID:      req.TaxClassID,
+	}
+	if err := DBConnection.Create(&service).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat layanan"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Layanan berhasil dibuat", "data": service})
+}
+
+// UpdateService godoc
+// @Summary      Update salon service
+// @Description  Memperbarui layanan salon (khusus owner/manager)
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int             true  "Service ID"
+// @Param        request  body      ServiceRequest  true  "Service Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /services/{id} [put]
+func UpdateService(c *gin.Context) {
+	serviceID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	if req.TaxClassID != nil && !validTaxClass(c, *user.SalonID, *req.TaxClassID) {
+		return
+	}
+
+	var service models.Service
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&service, serviceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Layanan tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	service.Name = req.Name
+	service.Category = req.Category
+	service.DurationMinutes = req.DurationMinutes
+	service.Price = req.Price
+	service.TaxClassID = req.TaxClassID
+	if req.IsActive != nil {
+		service.IsActive = *req.IsActive
+	}
+
+	if err := DBConnection.Save(&service).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui layanan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Layanan berhasil diperbarui", "data": service})
+}
+
+// findSalonService mengambil layanan aktif milik salon dan menulis response error jika gagal
+func findSalonService(c *gin.Context, salonID, serviceID uint) (models.Service, bool) {
+	var service models.Service
+	if err := DBConnection.Where("salon_id = ? AND is_active", salonID).First(&service, serviceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Layanan tidak ditemukan di salon ini"})
+			return service, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return service, false
+	}
+	return service, true
+}
@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/pos"
+	"gin-sass-salon/config"
+)
+
+// OpenShiftRequest struktur untuk request buka shift kasir
+type OpenShiftRequest struct {
+	OpeningFloat int64 `json:"opening_float" binding:"min=0" example:"500000"`
+	// BranchID adalah cabang laci kas; kosong berarti cabang default kasir (cabang penugasan atau cabang utama)
+	BranchID *uint `json:"branch_id" example:"1"`
+}
+
+// CashMovementRequest struktur untuk request kas masuk/keluar
+type CashMovementRequest struct {
+	Type   string `json:"type" binding:"required,oneof=cash_in cash_out" example:"cash_out"`
+	Amount int64  `json:"amount" binding:"required,gt=0" example:"50000"`
+	Reason string `json:"reason" binding:"required" example:"Beli tisu dan air mineral"`
+}
+
+// CloseShiftRequest struktur untuk request tutup shift kasir
+type CloseShiftRequest struct {
+	CountedCash *int64 `json:"counted_cash" binding:"required,min=0" example:"1250000"`
+	Notes       string `json:"notes" example:"Selisih karena kembalian"`
+}
+
+// OpenShift godoc
+// @Summary      Open cash shift
+// @Description  Membuka shift kasir dengan modal awal; hanya satu shift terbuka per cabang
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      OpenShiftRequest  true  "Open Shift Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /shifts/open [post]
+func OpenShift(c *gin.Context) {
+	var req OpenShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	branchID, ok := userDefaultBranch(c, user, req.BranchID)
+	if !ok {
+		return
+	}
+
+	var shift *models.CashShift
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var err error
+		shift, err = pos.OpenShift(tx, *user.SalonID, branchID, user.ID, req.OpeningFloat)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pos.ErrShiftAlreadyOpen) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuka shift"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Shift berhasil dibuka", "data": shift})
+}
+
+// GetCurrentShift godoc
+// @Summary      Get current cash shift
+// @Description  Mengambil shift kasir yang terbuka di cabang beserta kas masuk/keluar dan perkiraan uang di laci
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        branch_id  query     int  false  "Cabang (default cabang kasir)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /shifts/current [get]
+func GetCurrentShift(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	branchID, ok := queryBranch(c, user)
+	if !ok {
+		return
+	}
+
+	var shift models.CashShift
+	err := DBConnection.Preload("Movements").
+		Where("salon_id = ? AND branch_id = ? AND status = ?", *user.SalonID, branchID, models.CashShiftOpen).
+		First(&shift).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": pos.ErrNoOpenShift.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	expected, err := pos.ExpectedCash(DBConnection, &shift)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": shift, "expected_cash": expected})
+}
+
+// AddCashMovement godoc
+// @Summary      Add cash in/out
+// @Description  Mencatat kas masuk atau keluar di luar penjualan pada shift yang terbuka di cabang
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        branch_id  query     int                  false  "Cabang (default cabang kasir)"
+// @Param        request  body      CashMovementRequest  true  "Cash Movement Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /shifts/current/movements [post]
+func AddCashMovement(c *gin.Context) {
+	var req CashMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	branchID, ok := queryBranch(c, user)
+	if !ok {
+		return
+	}
+
+	var movement *models.CashMovement
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		shift, err := pos.CurrentShift(tx, *user.SalonID, branchID)
+		if err != nil {
+			return err
+		}
+		movement, err = pos.AddCashMovement(tx, shift, req.Type, req.Amount, req.Reason, user.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pos.ErrNoOpenShift) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencatat kas"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Kas berhasil dicatat", "data": movement})
+}
+
+// CloseShift godoc
+// @Summary      Close cash shift
+// @Description  Menutup shift cabang dengan uang hasil hitung; sistem menghitung uang seharusnya dan selisihnya
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        branch_id  query     int                false  "Cabang (default cabang kasir)"
+// @Param        request  body      CloseShiftRequest  true  "Close Shift Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /shifts/current/close [post]
+func CloseShift(c *gin.Context) {
+	var req CloseShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	branchID, ok := queryBranch(c, user)
+	if !ok {
+		return
+	}
+
+	var shift *models.CashShift
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var err error
+		shift, err = pos.CurrentShift(tx, *user.SalonID, branchID)
+		if err != nil {
+			return err
+		}
+		return pos.CloseShift(tx, shift, *req.CountedCash, user.ID, req.Notes)
+	})
+	if err != nil {
+		if errors.Is(err, pos.ErrNoOpenShift) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menutup shift"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shift berhasil ditutup", "data": shift})
+}
+
+// GetZReport godoc
+// @Summary      Get Z-report
+// @Description  Laporan akhir hari: penjualan, pembayaran per metode, refund/void, tip dan shift kasir (owner/manager).
+// @Description  Branch manager hanya dapat melihat cabangnya.
+// @Tags         shifts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        date       query     string  false  "Tanggal (YYYY-MM-DD, default hari ini)"
+// @Param        branch_id  query     int     false  "Filter cabang (default semua cabang)"
+// @Success      200   {object}  map[string]interface{}
+// @Failure      400   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]interface{}
+// @Failure      403   {object}  map[string]interface{}
+// @Failure      500   {object}  map[string]interface{}
+// @Router       /reports/z [get]
+func GetZReport(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	branchID, ok := scopedBranchParam(c, user)
+	if !ok {
+		return
+	}
+
+	loc := config.Location()
+	day := time.Now().In(loc)
+	if date := c.Query("date"); date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", date, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Format tanggal tidak valid, gunakan YYYY-MM-DD"})
+			return
+		}
+		day = parsed
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	report, err := pos.BuildZReport(DBConnection, *user.SalonID, branchID, start)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/purchasing"
+)
+
+// StockMovementRequest struktur untuk request mutasi stok manual
+type StockMovementRequest struct {
+	Type      string `json:"type" binding:"required,oneof=purchase adjustment usage" example:"adjustment"`
+	ProductID uint   `json:"product_id" binding:"required" example:"1"`
+	// BranchID kosong berarti cabang default user (cabang penugasan atau cabang utama)
+	BranchID *uint `json:"branch_id" example:"1"`
+	// Quantity positif untuk purchase dan usage; untuk adjustment bertanda (negatif mengurangi stok) dan boleh 0
+	// jika adjustment hanya mengoreksi harga pokok
+	Quantity int64 `json:"quantity" example:"-2"`
+	// UnitCost untuk purchase adalah harga beli per unit (kosong berarti harga pokok produk) dan ikut dirata-rata
+	// ke harga pokok; untuk adjustment menjadi harga pokok baru produk
+	UnitCost *int64 `json:"unit_cost" binding:"omitempty,min=0" example:"45000"`
+	Note     string `json:"note" example:"Selisih stock opname"`
+}
+
+// StockTransferRequest struktur untuk request transfer stok antar cabang
+type StockTransferRequest struct {
+	ProductID    uint   `json:"product_id" binding:"required" example:"1"`
+	FromBranchID uint   `json:"from_branch_id" binding:"required" example:"1"`
+	ToBranchID   uint   `json:"to_branch_id" binding:"required" example:"2"`
+	Quantity     int64  `json:"quantity" binding:"required,gt=0" example:"5"`
+	Note         string `json:"note" example:"Kebutuhan cabang Kemang"`
+}
+
+// CreateStockMovement godoc
+// @Summary      Create stock movement
+// @Description  Mencatat mutasi stok manual: purchase (barang masuk) dan adjustment (koreksi stok dan/atau harga pokok,
+// @Description  wajib note) khusus owner/manager, usage (pemakaian bahan salon) untuk semua staff
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      StockMovementRequest  true  "Stock Movement Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /stock/movements [post]
+func CreateStockMovement(c *gin.Context) {
+	var req StockMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	var ok bool
+	if req.Type == models.StockMovementUsage {
+		user, ok = currentSalonUser(c)
+	} else {
+		user, ok = currentSalonManager(c)
+	}
+	if !ok {
+		return
+	}
+
+	quantity := req.Quantity
+	switch req.Type {
+	case models.StockMovementPurchase, models.StockMovementUsage:
+		if quantity <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity harus lebih dari 0"})
+			return
+		}
+		if req.Type == models.StockMovementUsage {
+			quantity = -quantity
+		}
+	case models.StockMovementAdjustment:
+		if req.Note == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "note wajib diisi untuk adjustment"})
+			return
+		}
+		if quantity == 0 && req.UnitCost == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quantity atau unit_cost wajib diisi untuk adjustment"})
+			return
+		}
+	}
+
+	product, ok := findSalonProduct(c, *user.SalonID, req.ProductID)
+	if !ok {
+		return
+	}
+	branchID, ok := userDefaultBranch(c, user, req.BranchID)
+	if !ok {
+		return
+	}
+
+	movement := models.StockMovement{
+		SalonID:   *user.SalonID,
+		BranchID:  branchID,
+		ProductID: product.ID,
+		Type:      req.Type,
+		Quantity:  quantity,
+		UnitCost:  product.CostPrice,
+		UserID:    &user.ID,
+		Note:      req.Note,
+	}
+	if req.UnitCost != nil && req.Type != models.StockMovementUsage {
+		movement.UnitCost = *req.UnitCost
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		switch {
+		case req.Type == models.StockMovementPurchase:
+			if err := purchasing.UpdateAverageCost(tx, product.ID, quantity, movement.UnitCost); err != nil {
+				return err
+			}
+		case req.Type == models.StockMovementAdjustment && req.UnitCost != nil:
+			if err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Update("cost_price", *req.UnitCost).Error; err != nil {
+				return err
+			}
+		}
+		return inventory.Move(tx, &movement)
+	})
+	if err != nil {
+		if errors.Is(err, inventory.ErrInsufficientStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mencatat mutasi stok"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Mutasi stok berhasil dicatat", "data": movement})
+}
+
+// TransferStock godoc
+// @Summary      Transfer stock
+// @Description  Memindahkan stok produk antar cabang (khusus owner/manager). Branch manager hanya dapat mengirim
+// @Description  stok dari cabangnya sendiri.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      StockTransferRequest  true  "Stock Transfer Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /stock/transfers [post]
+func TransferStock(c *gin.Context) {
+	var req StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	product, ok := findSalonProduct(c, *user.SalonID, req.ProductID)
+	if !ok {
+		return
+	}
+	if _, ok := findUserBranch(c, user, req.FromBranchID); !ok {
+		return
+	}
+	if _, ok := findSalonBranch(c, *user.SalonID, req.ToBranchID); !ok {
+		return
+	}
+
+	var movements []models.StockMovement
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var err error
+		movements, err = inventory.Transfer(tx, &product, req.FromBranchID, req.ToBranchID, req.Quantity, user.ID, req.Note)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, inventory.ErrSameBranch):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, inventory.ErrInsufficientStock):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mentransfer stok"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Transfer stok berhasil", "data": movements})
+}
+
+// GetStockMovements godoc
+// @Summary      Get stock movements
+// @Description  Mengambil ledger mutasi stok salon, terbaru lebih dulu. Branch manager hanya melihat cabangnya.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        product_id  query     int     false  "Filter produk"
+// @Param        branch_id   query     int     false  "Filter cabang"
+// @Param        type        query     string  false  "Filter jenis mutasi"
+// @Param        limit       query     int     false  "Jumlah data (default 50, maks 200)"
+// @Success      200         {object}  map[string]interface{}
+// @Failure      401         {object}  map[string]interface{}
+// @Failure      403         {object}  map[string]interface{}
+// @Failure      500         {object}  map[string]interface{}
+// @Router       /stock/movements [get]
+func GetStockMovements(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	query := DBConnection.Where("salon_id = ?", *user.SalonID)
+	if scope != nil {
+		query = query.Where("branch_id IN ?", scope)
+	}
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if branchID := c.Query("branch_id"); branchID != "" {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if movementType := c.Query("type"); movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	movements := []models.StockMovement{}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": movements})
+}
+
+// GetLowStockReport godoc
+// @Summary      Low stock report
+// @Description  Mengambil produk yang stoknya di bawah atau sama dengan low_stock_threshold per cabang
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        branch_id  query     int  false  "Filter cabang (wajib untuk branch manager dengan beberapa cabang)"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      400        {object}  map[string]interface{}
+// @Failure      401        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]interface{}
+// @Router       /reports/low-stock [get]
+func GetLowStockReport(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	branchID, ok := scopedBranchParam(c, user)
+	if !ok {
+		return
+	}
+	items, err := inventory.LowStock(DBConnection, *user.SalonID, branchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gin-sass-salon/app/http/middleware"
+	"gin-sass-salon/app/realtime"
+)
+
+// CreateStreamTicket godoc
+// @Summary      Create stream ticket
+// @Description  Membuat tiket berumur 1 menit untuk membuka /stream lewat query ticket (EventSource tidak dapat
+// @Description  mengirim header Authorization). Minta tiket baru setiap kali koneksi stream dibuka ulang.
+// @Tags         realtime
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /stream/ticket [post]
+func CreateStreamTicket(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	ticket, err := middleware.GenerateStreamTicket(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat tiket stream"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ticket":     ticket,
+		"expires_in": int(middleware.StreamTicketTTL / time.Second),
+	})
+}
+
+// StreamEvents godoc
+// @Summary      Stream salon events (SSE)
+// @Description  Server-Sent Events berisi perubahan booking, antrian walk-in dan staff pada salon user.
+// @Description  Autentikasi lewat header Authorization atau query ticket dari POST /stream/ticket (untuk EventSource).
+// @Tags         realtime
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        ticket  query     string  false  "Tiket stream (alternatif header Authorization)"
+// @Success      200     {string}  string  "event stream"
+// @Failure      401     {object}  map[string]interface{}
+// @Failure      403     {object}  map[string]interface{}
+// @Router       /stream [get]
+func StreamEvents(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	events, unsubscribe := realtime.Subscribe(*user.SalonID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(25 * time.Second)
+	defer heartbeat.Stop()
+
+	// Event pertama memberi tahu klien bahwa stream sudah aktif
+	fmt.Fprintf(c.Writer, "event: ready\ndata: {\"salon_id\":%d}\n\n", *user.SalonID)
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			return true
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				return true
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			return true
+		}
+	})
+}
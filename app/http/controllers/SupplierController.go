@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/models"
+)
+
+// SupplierRequest struktur untuk request create/update supplier
+type SupplierRequest struct {
+	Name        string `json:"name" binding:"required" example:"PT Distributor Kosmetik"`
+	ContactName string `json:"contact_name" example:"Budi"`
+	Phone       string `json:"phone" example:"081234567890"`
+	Email       string `json:"email" binding:"omitempty,email" example:"order@distributor.co.id"`
+	Address     string `json:"address" example:"Jl. Gatot Subroto No. 1, Jakarta"`
+	Notes       string `json:"notes" example:"Minimal order Rp1.000.000"`
+	IsActive    *bool  `json:"is_active" example:"true"`
+}
+
+// GetSuppliers godoc
+// @Summary      Get suppliers
+// @Description  Mengambil daftar supplier salon (khusus owner/manager)
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /suppliers [get]
+func GetSuppliers(c *gin.Context) {
+	user, ok := currentSalonManager(c)
+	if !ok {
+		return
+	}
+
+	var suppliers []models.Supplier
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).Order("name").Find(&suppliers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": suppliers})
+}
+
+// CreateSupplier godoc
+// @Summary      Create supplier
+// @Description  Menambahkan supplier (khusus owner/manager)
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      SupplierRequest  true  "Supplier Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /suppliers [post]
+func CreateSupplier(c *gin.Context) {
+	var req SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	supplier := models.Supplier{SalonID: *user.SalonID, IsActive: true}
+	applySupplierRequest(&supplier, req)
+	if err := DBConnection.Create(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat supplier"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Supplier berhasil dibuat", "data": supplier})
+}
+
+// UpdateSupplier godoc
+// @Summary      Update supplier
+// @Description  Memperbarui data supplier (khusus owner/manager)
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int              true  "Supplier ID"
+// @Param        request  body      SupplierRequest  true  "Supplier Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /suppliers/{id} [put]
+func UpdateSupplier(c *gin.Context) {
+	supplierID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req SupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var supplier models.Supplier
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&supplier, supplierID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Supplier tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	applySupplierRequest(&supplier, req)
+	if err := DBConnection.Save(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui supplier"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Supplier berhasil diperbarui", "data": supplier})
+}
+
+// applySupplierRequest menyalin request ke supplier
+func applySupplierRequest(supplier *models.Supplier, req SupplierRequest) {
+	supplier.Name = req.Name
+	supplier.ContactName = req.ContactName
+	supplier.Phone = req.Phone
+	supplier.Email = req.Email
+	supplier.Address = req.Address
+	supplier.Notes = req.Notes
+	if req.IsActive != nil {
+		supplier.IsActive = *req.IsActive
+	}
+}
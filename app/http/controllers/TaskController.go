@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/scheduler"
+)
+
+// TaskScheduler adalah scheduler yang akan diinjeksi untuk trigger manual
+var TaskScheduler *scheduler.Scheduler
+
+// GetTasks godoc
+// @Summary      List scheduled tasks
+// @Description  Mengambil daftar task terjadwal beserta jadwal berikutnya dan eksekusi terakhir
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Router       /admin/tasks [get]
+func GetTasks(c *gin.Context) {
+	now := time.Now().In(TaskScheduler.Location)
+
+	var response []gin.H
+	for _, task := range scheduler.Tasks() {
+		var lastRun *models.ScheduledTaskRun
+		var run models.ScheduledTaskRun
+		if err := DBConnection.Where("task_name = ?", task.Name).Order("started_at DESC").Limit(1).Find(&run).Error; err == nil && run.ID != 0 {
+			lastRun = &run
+		}
+
+		response = append(response, gin.H{
+			"name":        task.Name,
+			"spec":        task.Spec,
+			"description": task.Description,
+			"next_run_at": task.Schedule.Next(now),
+			"last_run":    lastRun,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+// GetTaskRuns godoc
+// @Summary      List scheduled task runs
+// @Description  Mengambil riwayat eksekusi sebuah task terjadwal
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name   path      string  true   "Nama task"
+// @Param        limit  query     int     false  "Jumlah maksimal data (default 50, maks 200)"
+// @Success      200    {object}  map[string]interface{}
+// @Failure      401    {object}  map[string]interface{}
+// @Failure      403    {object}  map[string]interface{}
+// @Failure      404    {object}  map[string]interface{}
+// @Failure      500    {object}  map[string]interface{}
+// @Router       /admin/tasks/{name}/runs [get]
+func GetTaskRuns(c *gin.Context) {
+	name := c.Param("name")
+	if _, ok := scheduler.Find(name); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task tidak ditemukan"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	var runs []models.ScheduledTaskRun
+	if err := DBConnection.Where("task_name = ?", name).Order("started_at DESC").Limit(limit).Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": runs})
+}
+
+// RunTask godoc
+// @Summary      Trigger scheduled task
+// @Description  Menjalankan task terjadwal secara manual di background
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        name  path      string  true  "Nama task"
+// @Success      202   {object}  map[string]interface{}
+// @Failure      401   {object}  map[string]interface{}
+// @Failure      403   {object}  map[string]interface{}
+// @Failure      404   {object}  map[string]interface{}
+// @Failure      409   {object}  map[string]interface{}
+// @Failure      500   {object}  map[string]interface{}
+// @Router       /admin/tasks/{name}/run [post]
+func RunTask(c *gin.Context) {
+	var triggeredBy *uint
+	if userID, ok := c.Get("user_id"); ok {
+		id := userID.(uint)
+		triggeredBy = &id
+	}
+
+	run, err := TaskScheduler.Trigger(c.Request.Context(), c.Param("name"), triggeredBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, scheduler.ErrTaskNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task tidak ditemukan"})
+		case errors.Is(err, scheduler.ErrTaskRunning):
+			c.JSON(http.StatusConflict, gin.H{"error": "Task sedang berjalan, coba lagi nanti"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Task dijalankan", "data": run})
+}
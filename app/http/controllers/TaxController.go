@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/pos"
+	"gin-sass-salon/config"
+)
+
+// TaxClassRequest struktur untuk request create kelas pajak
+type TaxClassRequest struct {
+	Code      string `json:"code" binding:"required" example:"standard"`
+	Name      string `json:"name" binding:"required" example:"Standar (PPN)"`
+	IsDefault bool   `json:"is_default" example:"true"`
+}
+
+// TaxRateRequest struktur untuk request menambah tarif pajak pada kelas
+type TaxRateRequest struct {
+	Name          string `json:"name" binding:"required" example:"PPN"`
+	RateBps       int    `json:"rate_bps" binding:"min=0,max=10000" example:"1200"`
+	EffectiveFrom string `json:"effective_from" binding:"required" example:"2025-01-01"`
+	EffectiveTo   string `json:"effective_to" example:""`
+}
+
+// TaxSettingsRequest struktur untuk request pengaturan pajak salon
+type TaxSettingsRequest struct {
+	PricesIncludeTax        bool  `json:"prices_include_tax" example:"false"`
+	ServiceChargeBps        int   `json:"service_charge_bps" binding:"min=0,max=10000" example:"500"`
+	ServiceChargeTaxClassID *uint `json:"service_charge_tax_class_id" example:"1"`
+}
+
+// GetTaxClasses godoc
+// @Summary      Get tax classes
+// @Description  Mengambil kelas pajak salon beserta seluruh riwayat tarifnya
+// @Tags         taxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /tax-classes [get]
+func GetTaxClasses(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	var classes []models.TaxClass
+	if err := DBConnection.Preload("Rates", func(db *gorm.DB) *gorm.DB {
+		return db.Order("effective_from, id")
+	}).Where("salon_id = ?", *user.SalonID).Order("code").Find(&classes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": classes})
+}
+
+// CreateTaxClass godoc
+// @Summary      Create tax class
+// @Description  Membuat kelas pajak (khusus owner/manager). Kelas default dipakai item yang tidak memiliki kelas pajak.
+// @Tags         taxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      TaxClassRequest  true  "Tax Class Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /tax-classes [post]
+func CreateTaxClass(c *gin.Context) {
+	var req TaxClassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	class := models.TaxClass{
+		SalonID:   *user.SalonID,
+		Code:      req.Code,
+		Name:      req.Name,
+		IsDefault: req.IsDefault,
+	}
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		// Hanya satu kelas default per salon
+		if class.IsDefault {
+			if err := tx.Model(&models.TaxClass{}).Where("salon_id = ?", class.SalonID).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&class).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat kelas pajak"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Kelas pajak berhasil dibuat", "data": class})
+}
+
+// CreateTaxRate godoc
+// @Summary      Add tax rate
+// @Description  Menambah tarif pada kelas pajak (khusus owner/manager). Tarif baru tanpa tanggal akhir menutup tarif lama
+// @Description  bernama sama yang juga tanpa tanggal akhir pada effective_from tarif baru. Rentang lain yang bertumpuk
+// @Description  dengan tarif bernama sama ditolak (409).
+// @Tags         taxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      int             true  "Tax Class ID"
+// @Param        request  body      TaxRateRequest  true  "Tax Rate Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      409      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /tax-classes/{id}/rates [post]
+func CreateTaxRate(c *gin.Context) {
+	classID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req TaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	var class models.TaxClass
+	if err := DBConnection.Where("salon_id = ?", *user.SalonID).First(&class, classID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Kelas pajak tidak ditemukan"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	loc := config.Location()
+	from, err := time.ParseInLocation("2006-01-02", req.EffectiveFrom, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Format effective_from tidak valid, gunakan YYYY-MM-DD"})
+		return
+	}
+	rate := models.TaxRate{
+		TaxClassID:    class.ID,
+		Name:          req.Name,
+		RateBps:       req.RateBps,
+		EffectiveFrom: from,
+	}
+	if req.EffectiveTo != "" {
+		to, err := time.ParseInLocation("2006-01-02", req.EffectiveTo, loc)
+		if err != nil || !to.After(from) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "effective_to harus tanggal YYYY-MM-DD setelah effective_from"})
+			return
+		}
+		rate.EffectiveTo = &to
+	}
+
+	err = DBConnection.Transaction(func(tx *gorm.DB) error {
+		return pos.AddTaxRate(tx, &rate)
+	})
+	if err != nil {
+		if errors.Is(err, pos.ErrTaxRateOverlap) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menambah tarif pajak"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Tarif pajak berhasil ditambahkan", "data": rate})
+}
+
+// UpdateTaxSettings godoc
+// @Summary      Update salon tax settings
+// @Description  Mengatur harga termasuk/belum termasuk pajak dan service charge salon (khusus owner/manager)
+// @Tags         taxes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      TaxSettingsRequest  true  "Tax Settings Request"
+// @Success      200      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /salon/tax-settings [put]
+func UpdateTaxSettings(c *gin.Context) {
+	var req TaxSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+	if req.ServiceChargeTaxClassID != nil && !validTaxClass(c, *user.SalonID, *req.ServiceChargeTaxClassID) {
+		return
+	}
+
+	var salon models.Salon
+	if err := DBConnection.First(&salon, *user.SalonID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	salon.PricesIncludeTax = req.PricesIncludeTax
+	salon.ServiceChargeBps = req.ServiceChargeBps
+	salon.ServiceChargeTaxClassID = req.ServiceChargeTaxClassID
+	if err := DBConnection.Model(&salon).
+		Select("prices_include_tax", "service_charge_bps", "service_charge_tax_class_id").
+		Updates(&salon).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memperbarui pengaturan pajak"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pengaturan pajak berhasil diperbarui", "data": salon})
+}
+
+// validTaxClass memastikan kelas pajak milik salon dan menulis response error jika bukan
+func validTaxClass(c *gin.Context, salonID, classID uint) bool {
+	var count int64
+	if err := DBConnection.Model(&models.TaxClass{}).Where("id = ? AND salon_id = ?", classID, salonID).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if count == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kelas pajak tidak ditemukan di salon ini"})
+		return false
+	}
+	return true
+}
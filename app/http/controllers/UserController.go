@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/realtime"
 )
 
 // DBConnection adalah objek GORM yang akan diinjeksi
@@ -28,13 +29,14 @@ type UpdateUserRequest struct {
 
 // GetUsers godoc
 // @Summary      Get all users
-// @Description  Mengambil semua data user dari database
+// @Description  Mengambil semua user di salon yang sama (owner/manager)
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Success      200  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /users [get]
 func GetUsers(c *gin.Context) {
@@ -45,8 +47,13 @@ func GetUsers(c *gin.Context) {
 		return
 	}
 
-	// Mencari semua data user
-	result := DBConnection.Find(&users)
+	actor, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
+	// Mencari semua data user di salon yang sama
+	result := DBConnection.Where("salon_id = ?", *actor.SalonID).Find(&users)
 
 	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
@@ -60,6 +67,7 @@ func GetUsers(c *gin.Context) {
 			"id":         user.ID,
 			"name":       user.Name,
 			"email":      user.Email,
+			"role":       user.Role,
 			"created_at": user.CreatedAt,
 			"updated_at": user.UpdatedAt,
 		})
@@ -70,7 +78,7 @@ func GetUsers(c *gin.Context) {
 
 // GetUser godoc
 // @Summary      Get user by ID
-// @Description  Mengambil data user berdasarkan ID
+// @Description  Mengambil data user berdasarkan ID; user sendiri atau user di salon yang sama (owner/manager)
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -79,6 +87,7 @@ func GetUsers(c *gin.Context) {
 // @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
 // @Failure      404  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /users/{id} [get]
@@ -90,13 +99,8 @@ func GetUser(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := DBConnection.First(&user, userID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	user, ok := findManagedUser(c, uint(userID), false)
+	if !ok {
 		return
 	}
 
@@ -104,6 +108,7 @@ func GetUser(c *gin.Context) {
 		"id":    user.ID,
 		"name":  user.Name,
 		"email": user.Email,
+		"role":  user.Role,
 		"created_at": user.CreatedAt,
 		"updated_at": user.UpdatedAt,
 	}})
@@ -111,7 +116,7 @@ func GetUser(c *gin.Context) {
 
 // CreateUser godoc
 // @Summary      Create new user
-// @Description  Membuat user baru
+// @Description  Membuat user baru sebagai staff di salon yang sama (owner/manager)
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -120,10 +125,16 @@ func GetUser(c *gin.Context) {
 // @Success      201      {object}  map[string]interface{}
 // @Failure      400      {object}  map[string]interface{}
 // @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
 // @Failure      409      {object}  map[string]interface{}
 // @Failure      500      {object}  map[string]interface{}
 // @Router       /users [post]
 func CreateUser(c *gin.Context) {
+	actor, ok := currentBrandManager(c)
+	if !ok {
+		return
+	}
+
 	var req CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -142,6 +153,8 @@ func CreateUser(c *gin.Context) {
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: req.Password,
+		SalonID:  actor.SalonID,
+		Role:     models.RoleStaff,
 	}
 
 	// Hash password
@@ -169,7 +182,7 @@ func CreateUser(c *gin.Context) {
 
 // UpdateUser godoc
 // @Summary      Update user
-// @Description  Memperbarui data user berdasarkan ID
+// @Description  Memperbarui data user sendiri atau user di salon yang sama (owner/manager; data owner hanya oleh owner)
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -179,6 +192,7 @@ func CreateUser(c *gin.Context) {
 // @Success      200      {object}  map[string]interface{}
 // @Failure      400      {object}  map[string]interface{}
 // @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
 // @Failure      404      {object}  map[string]interface{}
 // @Failure      409      {object}  map[string]interface{}
 // @Failure      500      {object}  map[string]interface{}
@@ -198,13 +212,8 @@ func UpdateUser(c *gin.Context) {
 	}
 
 	// Cari user
-	var user models.User
-	if err := DBConnection.First(&user, userID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	user, ok := findManagedUser(c, uint(userID), true)
+	if !ok {
 		return
 	}
 
@@ -235,6 +244,15 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	if user.SalonID != nil {
+		realtime.Publish(DBConnection, *user.SalonID, realtime.EventUserUpdated, gin.H{
+			"id":    user.ID,
+			"name":  user.Name,
+			"email": user.Email,
+			"role":  user.Role,
+		})
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User berhasil diperbarui",
 		"data": gin.H{
@@ -248,7 +266,7 @@ func UpdateUser(c *gin.Context) {
 
 // DeleteUser godoc
 // @Summary      Delete user
-// @Description  Menghapus user berdasarkan ID
+// @Description  Menghapus user lain di salon yang sama (owner/manager; owner hanya oleh owner)
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -257,6 +275,7 @@ func UpdateUser(c *gin.Context) {
 // @Success      200  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]interface{}
 // @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
 // @Failure      404  {object}  map[string]interface{}
 // @Failure      500  {object}  map[string]interface{}
 // @Router       /users/{id} [delete]
@@ -268,14 +287,17 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	// Cari user
-	var user models.User
-	if err := DBConnection.First(&user, userID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Cari user; akun sendiri tidak dapat dihapus agar salon tidak kehilangan owner
+	actor, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if actor.ID == uint(userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Tidak dapat menghapus akun sendiri"})
+		return
+	}
+	user, ok := findManagedUser(c, uint(userID), true)
+	if !ok {
 		return
 	}
 
@@ -285,5 +307,41 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
+	if user.SalonID != nil {
+		realtime.Publish(DBConnection, *user.SalonID, realtime.EventUserRemoved, gin.H{"id": user.ID})
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "User berhasil dihapus"})
+}
+
+// findManagedUser mengambil user yang boleh diakses user login: dirinya sendiri, atau user di salon yang sama
+// jika user login owner/manager. Jika write, data owner lain hanya boleh diubah owner. Response error sudah
+// ditulis jika false.
+func findManagedUser(c *gin.Context, userID uint, write bool) (models.User, bool) {
+	actor, ok := currentUser(c)
+	if !ok {
+		return actor, false
+	}
+	if actor.ID == userID {
+		return actor, true
+	}
+	if actor.SalonID == nil || !actor.IsBrandManager() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Akses hanya untuk owner atau manager salon"})
+		return actor, false
+	}
+
+	var user models.User
+	if err := DBConnection.Where("salon_id = ?", *actor.SalonID).First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
+			return user, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return user, false
+	}
+	if write && user.Role == models.RoleOwner && actor.Role != models.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Hanya owner yang dapat mengubah data owner"})
+		return user, false
+	}
+	return user, true
 }
\ No newline at end of file
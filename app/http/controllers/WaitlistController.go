@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gin-sass-salon/app/booking"
+	"gin-sass-salon/app/models"
+)
+
+// CreateWaitlistRequest struktur untuk request mendaftarkan pelanggan ke waitlist
+type CreateWaitlistRequest struct {
+	CustomerID  uint      `json:"customer_id" binding:"required" example:"1"`
+	ServiceID   uint      `json:"service_id" binding:"required" example:"1"`
+	StaffID     *uint     `json:"staff_id" example:"2"`
+	WindowStart time.Time `json:"window_start" binding:"required" example:"2025-01-15T09:00:00+07:00"`
+	WindowEnd   time.Time `json:"window_end" binding:"required" example:"2025-01-15T17:00:00+07:00"`
+	Notes       string    `json:"notes" example:"Bisa datang kapan saja setelah jam 1 siang"`
+	// BranchID kosong berarti cabang default staff pilihan, atau cabang default user jika staff tidak dipilih
+	BranchID *uint `json:"branch_id" example:"1"`
+}
+
+// GetWaitlist godoc
+// @Summary      Get waitlist
+// @Description  Mengambil entri waitlist salon beserta penawaran slot yang masih berjalan. Branch manager hanya
+// @Description  melihat waitlist cabangnya.
+// @Tags         waitlist
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status     query     string  false  "Status entri (waiting, offered, booked, cancelled), default waiting dan offered"
+// @Param        branch_id  query     int     false  "Branch ID"
+// @Success      200        {object}  map[string]interface{}
+// @Failure      401        {object}  map[string]interface{}
+// @Failure      403        {object}  map[string]interface{}
+// @Failure      500        {object}  map[string]interface{}
+// @Router       /waitlist [get]
+func GetWaitlist(c *gin.Context) {
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+	// inBranches menerapkan scope dan filter cabang yang sama ke entri dan penawaran
+	inBranches := func(query *gorm.DB) *gorm.DB {
+		if scope != nil {
+			query = query.Where("branch_id IN ?", scope)
+		}
+		if branchID := c.Query("branch_id"); branchID != "" {
+			query = query.Where("branch_id = ?", branchID)
+		}
+		return query
+	}
+
+	query := inBranches(DBConnection.Preload("Customer").Preload("Service").Where("salon_id = ?", *user.SalonID))
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ?", []string{models.WaitlistStatusWaiting, models.WaitlistStatusOffered})
+	}
+
+	var entries []models.WaitlistEntry
+	if err := query.Order("created_at, id").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var offers []models.WaitlistOffer
+	if err := inBranches(DBConnection.Where("salon_id = ? AND status = ?", *user.SalonID, models.WaitlistOfferPending)).
+		Order("expires_at").Find(&offers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries, "offers": offers})
+}
+
+// CreateWaitlistEntry godoc
+// @Summary      Add customer to waitlist
+// @Description  Mendaftarkan pelanggan ke waitlist untuk layanan, preferensi staff dan rentang waktu tertentu
+// @Tags         waitlist
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CreateWaitlistRequest  true  "Create Waitlist Request"
+// @Success      201      {object}  map[string]interface{}
+// @Failure      400      {object}  map[string]interface{}
+// @Failure      401      {object}  map[string]interface{}
+// @Failure      403      {object}  map[string]interface{}
+// @Failure      404      {object}  map[string]interface{}
+// @Failure      500      {object}  map[string]interface{}
+// @Router       /waitlist [post]
+func CreateWaitlistEntry(c *gin.Context) {
+	var req CreateWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.WindowEnd.After(req.WindowStart) || !req.WindowEnd.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rentang waktu tidak valid"})
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+	salonID := *user.SalonID
+
+	if _, ok := findSalonCustomer(c, salonID, req.CustomerID); !ok {
+		return
+	}
+	if _, ok := findSalonService(c, salonID, req.ServiceID); !ok {
+		return
+	}
+	var branchID uint
+	if req.StaffID != nil {
+		if _, ok := findScopedStaff(c, user, *req.StaffID); !ok {
+			return
+		}
+		if branchID, ok = staffBranch(c, user, *req.StaffID, req.BranchID); !ok {
+			return
+		}
+	} else if branchID, ok = userDefaultBranch(c, user, req.BranchID); !ok {
+		return
+	}
+
+	entry := models.WaitlistEntry{
+		SalonID:     salonID,
+		BranchID:    &branchID,
+		CustomerID:  req.CustomerID,
+		ServiceID:   req.ServiceID,
+		StaffID:     req.StaffID,
+		WindowStart: req.WindowStart,
+		WindowEnd:   req.WindowEnd,
+		Status:      models.WaitlistStatusWaiting,
+		Notes:       req.Notes,
+	}
+	if err := DBConnection.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mendaftarkan waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Pelanggan masuk waitlist", "data": entry})
+}
+
+// CancelWaitlistEntry godoc
+// @Summary      Remove customer from waitlist
+// @Description  Membatalkan entri waitlist; penawaran yang sedang berjalan diteruskan ke antrian berikutnya
+// @Tags         waitlist
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Waitlist Entry ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /waitlist/{id} [delete]
+func CancelWaitlistEntry(c *gin.Context) {
+	entryID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return
+	}
+
+	var entry models.WaitlistEntry
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("salon_id = ?", *user.SalonID).First(&entry, entryID).Error; err != nil {
+			return err
+		}
+		if !optionalInBranchScope(scope, entry.BranchID) {
+			return errBranchAccess
+		}
+		if entry.Status != models.WaitlistStatusWaiting && entry.Status != models.WaitlistStatusOffered {
+			return booking.ErrOfferNotPending
+		}
+
+		// Penawaran yang masih berjalan dilepas agar slot pindah ke antrian berikutnya
+		var offer models.WaitlistOffer
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("entry_id = ? AND status = ?", entry.ID, models.WaitlistOfferPending).First(&offer).Error
+		if err == nil {
+			if err := booking.DeclineOffer(tx, &offer); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		entry.Status = models.WaitlistStatusCancelled
+		return tx.Model(&entry).Update("status", entry.Status).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entri waitlist tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, booking.ErrOfferNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": "Entri waitlist sudah tidak aktif"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membatalkan waitlist"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Entri waitlist dibatalkan", "data": entry})
+}
+
+// AcceptWaitlistOffer godoc
+// @Summary      Accept waitlist offer
+// @Description  Menerima slot yang ditawarkan ke pelanggan waitlist dan membuat booking
+// @Tags         waitlist
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Waitlist Offer ID"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /waitlist/offers/{id}/accept [post]
+func AcceptWaitlistOffer(c *gin.Context) {
+	var created *models.Booking
+	ok := respondWaitlistOffer(c, func(tx *gorm.DB, offer *models.WaitlistOffer) error {
+		b, err := booking.AcceptOffer(tx, offer)
+		created = b
+		return err
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Penawaran diterima, booking dibuat", "data": created})
+}
+
+// DeclineWaitlistOffer godoc
+// @Summary      Decline waitlist offer
+// @Description  Menolak slot yang ditawarkan; slot diteruskan ke pelanggan waitlist berikutnya
+// @Tags         waitlist
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Waitlist Offer ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]interface{}
+// @Failure      401  {object}  map[string]interface{}
+// @Failure      403  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]interface{}
+// @Failure      409  {object}  map[string]interface{}
+// @Failure      500  {object}  map[string]interface{}
+// @Router       /waitlist/offers/{id}/decline [post]
+func DeclineWaitlistOffer(c *gin.Context) {
+	ok := respondWaitlistOffer(c, func(tx *gorm.DB, offer *models.WaitlistOffer) error {
+		return booking.DeclineOffer(tx, offer)
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Penawaran ditolak"})
+}
+
+// respondWaitlistOffer mengunci penawaran milik salon (dan cabang branch manager) lalu menjalankan fn di dalam transaksi
+func respondWaitlistOffer(c *gin.Context, fn func(tx *gorm.DB, offer *models.WaitlistOffer) error) bool {
+	offerID, ok := parseIDParam(c, "id")
+	if !ok {
+		return false
+	}
+
+	user, ok := currentSalonUser(c)
+	if !ok {
+		return false
+	}
+	scope, ok := branchScope(c, user)
+	if !ok {
+		return false
+	}
+
+	err := DBConnection.Transaction(func(tx *gorm.DB) error {
+		var offer models.WaitlistOffer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("salon_id = ?", *user.SalonID).First(&offer, offerID).Error; err != nil {
+			return err
+		}
+		if !optionalInBranchScope(scope, offer.BranchID) {
+			return errBranchAccess
+		}
+		return fn(tx, &offer)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Penawaran tidak ditemukan"})
+		case errors.Is(err, errBranchAccess):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, booking.ErrOfferNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": "Penawaran sudah tidak berlaku"})
+		case errors.Is(err, booking.ErrSlotTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Slot sudah terisi"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal memproses penawaran"})
+		}
+		return false
+	}
+
+	return true
+}
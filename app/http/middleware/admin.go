@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gin-sass-salon/app/models"
+)
+
+// AdminMiddleware membatasi akses hanya untuk user dengan flag is_admin. Flag dibaca dari database pada setiap
+// request sehingga pencabutan akses langsung berlaku. Harus dipasang setelah AuthMiddleware.
+func AdminMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+
+		var user models.User
+		if err := db.Select("id", "is_admin").First(&user, userID).Error; err != nil || !user.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Akses hanya untuk admin"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -3,12 +3,19 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"gin-sass-salon/config"
 )
 
+// StreamTicketTTL adalah masa berlaku tiket stream; cukup untuk membuka koneksi EventSource
+const StreamTicketTTL = time.Minute
+
+// tokenTypeStream menandai tiket stream agar tidak dapat dipakai sebagai access token biasa
+const tokenTypeStream = "stream"
+
 // GetJWTSecretKey mengembalikan JWT secret key dari config
 func GetJWTSecretKey() []byte {
 	return []byte(config.JWTSecret())
@@ -32,31 +39,83 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-
-		// Parse dan verifikasi token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validasi algoritma
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return GetJWTSecretKey(), nil
-		})
+		authenticate(c, parts[1], "")
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak valid atau telah kedaluwarsa"})
-			c.Abort()
+// StreamAuthMiddleware sama seperti AuthMiddleware tetapi juga menerima tiket stream dari query ?ticket=,
+// karena EventSource di browser tidak dapat mengirim header Authorization. Tiket berumur pendek sehingga
+// URL yang tercatat di access log tidak dapat dipakai ulang sebagai access token.
+func StreamAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if parts := strings.Split(c.GetHeader("Authorization"), " "); len(parts) == 2 && parts[0] == "Bearer" {
+			authenticate(c, parts[1], "")
+			return
+		}
+		if ticket := c.Query("ticket"); ticket != "" {
+			authenticate(c, ticket, tokenTypeStream)
 			return
 		}
 
-		// Ekstrak claims dan simpan di context
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			userID := uint(claims["user_id"].(float64))
-			c.Set("user_id", userID)
-			c.Set("user_email", claims["email"])
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token diperlukan (header Authorization atau query ticket)"})
+		c.Abort()
+	}
+}
+
+// GenerateStreamTicket membuat tiket stream untuk user yang berlaku selama StreamTicketTTL
+func GenerateStreamTicket(userID uint) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"typ":     tokenTypeStream,
+		"exp":     time.Now().Add(StreamTicketTTL).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(GetJWTSecretKey())
+}
+
+// ParseToken memverifikasi JWT token dan mengembalikan claims-nya
+func ParseToken(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Validasi algoritma
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
 		}
+		return GetJWTSecretKey(), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
 
-		c.Next()
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, jwt.ErrTokenInvalidClaims
 	}
+	return claims, nil
 }
 
+// authenticate memverifikasi token bertipe typ ("" untuk access token) lalu menyimpan claims di context
+func authenticate(c *gin.Context, tokenString string, typ string) {
+	claims, err := ParseToken(tokenString)
+	if err == nil {
+		if value, _ := claims["typ"].(string); value != typ {
+			err = jwt.ErrTokenInvalidClaims
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak valid atau telah kedaluwarsa"})
+		c.Abort()
+		return
+	}
+
+	// Ekstrak claims dan simpan di context
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak valid atau telah kedaluwarsa"})
+		c.Abort()
+		return
+	}
+	c.Set("user_id", uint(userID))
+	c.Set("user_email", claims["email"])
+
+	c.Next()
+}
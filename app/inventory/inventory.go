@@ -0,0 +1,373 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+)
+
+// ErrInsufficientStock dikembalikan jika mutasi membuat stok cabang menjadi negatif
+var ErrInsufficientStock = errors.New("stok produk di cabang ini tidak mencukupi")
+
+// ErrSameBranch dikembalikan jika transfer stok ke cabang yang sama
+var ErrSameBranch = errors.New("cabang asal dan tujuan transfer tidak boleh sama")
+
+// MainBranch mengambil cabang utama salon, dibuat jika belum ada. Pembuatan diserialisasi dengan
+// mengunci baris salon agar tidak ada dua cabang utama.
+func MainBranch(tx *gorm.DB, salonID uint) (*models.Branch, error) {
+	var branch models.Branch
+	err := tx.Where("salon_id = ? AND is_main", salonID).First(&branch).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return &branch, err
+	}
+
+	var salon models.Salon
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&salon, salonID).Error; err != nil {
+		return nil, err
+	}
+	err = tx.Where("salon_id = ? AND is_main", salonID).First(&branch).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		branch = models.Branch{SalonID: salonID, Name: "Utama", IsMain: true, IsActive: true}
+		err = tx.Create(&branch).Error
+	}
+	return &branch, err
+}
+
+// AssignMainBranch mengisi branch_id kosong pada tabel dengan cabang utama salon masing-masing, untuk data yang
+// dibuat sebelum ada cabang. Cabang utama dibuat dulu bagi salon yang belum memilikinya.
+func AssignMainBranch(db *gorm.DB, table string) error {
+	var salonIDs []uint
+	if err := db.Table(table).Where("branch_id IS NULL").Distinct().Pluck("salon_id", &salonIDs).Error; err != nil {
+		return err
+	}
+	for _, salonID := range salonIDs {
+		if _, err := MainBranch(db, salonID); err != nil {
+			return err
+		}
+	}
+	return db.Exec(fmt.Sprintf(`UPDATE %[1]s SET branch_id = b.id FROM branches b
+		WHERE b.salon_id = %[1]s.salon_id AND b.is_main AND %[1]s.branch_id IS NULL`, table)).Error
+}
+
+// DefaultBranch mengambil cabang default untuk user: cabang aktif yang ditugaskan kepadanya (cabang utama
+// didahulukan), atau cabang utama salon jika user tidak memiliki penugasan.
+func DefaultBranch(tx *gorm.DB, salonID, userID uint) (*models.Branch, error) {
+	var branch models.Branch
+	err := tx.Joins("JOIN branch_staffs ON branch_staffs.branch_id = branches.id").
+		Where("branches.salon_id = ? AND branches.is_active AND branch_staffs.user_id = ?", salonID, userID).
+		Order("branches.is_main DESC, branches.id").First(&branch).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return &branch, err
+	}
+	return MainBranch(tx, salonID)
+}
+
+// AssignedBranchIDs mengambil ID cabang yang ditugaskan kepada user
+func AssignedBranchIDs(db *gorm.DB, userID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.BranchStaff{}).Where("user_id = ?", userID).Order("branch_id").Pluck("branch_id", &ids).Error
+	return ids, err
+}
+
+// Move mencatat satu mutasi stok dan memperbarui stok cabang dengan FOR UPDATE. Mutasi penjualan dan
+// pemakaian tetap dicatat walau stok menjadi negatif karena barangnya sudah keluar secara fisik;
+// mutasi lain ditolak dengan ErrInsufficientStock.
+func Move(tx *gorm.DB, m *models.StockMovement) error {
+	level := models.StockLevel{SalonID: m.SalonID, BranchID: m.BranchID, ProductID: m.ProductID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&level).Error; err != nil {
+		return err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("branch_id = ? AND product_id = ?", m.BranchID, m.ProductID).First(&level).Error; err != nil {
+		return err
+	}
+
+	level.Quantity += m.Quantity
+	if level.Quantity < 0 && m.Quantity < 0 && m.Type != models.StockMovementSale && m.Type != models.StockMovementUsage {
+		return fmt.Errorf("%w (sisa %d)", ErrInsufficientStock, level.Quantity-m.Quantity)
+	}
+	if err := tx.Model(&level).Updates(map[string]interface{}{"quantity": level.Quantity, "updated_at": time.Now()}).Error; err != nil {
+		return err
+	}
+
+	m.QuantityAfter = level.Quantity
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+	return tx.Create(m).Error
+}
+
+// Transfer memindahkan stok antar cabang sebagai dua mutasi berpasangan. Cabang dikunci berurutan
+// menurut ID agar dua transfer berlawanan arah tidak saling deadlock.
+func Transfer(tx *gorm.DB, product *models.Product, fromBranchID, toBranchID uint, qty int64, userID uint, note string) ([]models.StockMovement, error) {
+	if fromBranchID == toBranchID {
+		return nil, ErrSameBranch
+	}
+	out := models.StockMovement{
+		SalonID:             product.SalonID,
+		BranchID:            fromBranchID,
+		ProductID:           product.ID,
+		Type:                models.StockMovementTransfer,
+		Quantity:            -qty,
+		UnitCost:            product.CostPrice,
+		CounterpartBranchID: &toBranchID,
+		UserID:              &userID,
+		Note:                note,
+	}
+	in := out
+	in.BranchID, in.Quantity, in.CounterpartBranchID = toBranchID, qty, &fromBranchID
+
+	first, second := &out, &in
+	if toBranchID < fromBranchID {
+		first, second = second, first
+	}
+	if err := Move(tx, first); err != nil {
+		return nil, err
+	}
+	if err := Move(tx, second); err != nil {
+		return nil, err
+	}
+	return []models.StockMovement{out, in}, nil
+}
+
+// AttachToSale dijalankan setelah sale tersimpan di transaksi yang sama: mengurangi stok cabang
+// untuk setiap baris produk katalog dan mencatat pemakaian bahan back-bar sesuai resep layanan
+func AttachToSale(tx *gorm.DB, sale *models.Sale) error {
+	if sale.BranchID == nil {
+		return nil
+	}
+	for i := range sale.Lines {
+		line := &sale.Lines[i]
+		if line.Type == models.SaleLineService && line.ServiceID != nil {
+			if err := recordServiceUsage(tx, sale, line); err != nil {
+				return err
+			}
+			continue
+		}
+		if line.Type != models.SaleLineProduct || line.ProductID == nil {
+			continue
+		}
+		var product models.Product
+		if err := tx.Select("id", "cost_price").First(&product, *line.ProductID).Error; err != nil {
+			return err
+		}
+		if err := Move(tx, &models.StockMovement{
+			SalonID:    sale.SalonID,
+			BranchID:   *sale.BranchID,
+			ProductID:  product.ID,
+			Type:       models.StockMovementSale,
+			Quantity:   -int64(line.Quantity),
+			UnitCost:   product.CostPrice,
+			SaleID:     &sale.ID,
+			SaleLineID: &line.ID,
+			UserID:     &sale.CashierID,
+			Note:       sale.ReceiptNumber,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordServiceUsage mencatat pemakaian perkiraan resep untuk satu baris layanan dan mengurangi stoknya
+func recordServiceUsage(tx *gorm.DB, sale *models.Sale, line *models.SaleLine) error {
+	var recipes []models.ServiceRecipe
+	if err := tx.Where("service_id = ?", *line.ServiceID).Order("id").Find(&recipes).Error; err != nil {
+		return err
+	}
+	for _, recipe := range recipes {
+		expected := recipe.Quantity * int64(line.Quantity)
+		usage := models.ServiceUsage{
+			SalonID:          sale.SalonID,
+			BranchID:         *sale.BranchID,
+			SaleID:           sale.ID,
+			SaleLineID:       line.ID,
+			ProductID:        recipe.ProductID,
+			ServiceID:        *line.ServiceID,
+			StaffID:          line.StaffID,
+			ExpectedQuantity: expected,
+			ActualQuantity:   expected,
+		}
+		if err := tx.Create(&usage).Error; err != nil {
+			return err
+		}
+		if err := moveUsage(tx, &usage, -expected, sale.CashierID, sale.ReceiptNumber); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetActualUsage mengganti pemakaian nyata bahan pada satu baris layanan. actual berisi jumlah per
+// product_id; produk resep yang tidak disebut dianggap tidak terpakai. Selisih terhadap pemakaian
+// sebelumnya dibukukan sebagai mutasi usage di cabang transaksi.
+func SetActualUsage(tx *gorm.DB, sale *models.Sale, line *models.SaleLine, actual map[uint]int64, userID uint) ([]models.ServiceUsage, error) {
+	if sale.BranchID == nil || line.ServiceID == nil {
+		return nil, nil
+	}
+
+	var usages []models.ServiceUsage
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("sale_line_id = ?", line.ID).Order("id").Find(&usages).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[uint]bool, len(usages))
+	for i := range usages {
+		seen[usages[i].ProductID] = true
+	}
+	for productID := range actual {
+		if !seen[productID] {
+			usages = append(usages, models.ServiceUsage{
+				SalonID:    sale.SalonID,
+				BranchID:   *sale.BranchID,
+				SaleID:     sale.ID,
+				SaleLineID: line.ID,
+				ProductID:  productID,
+				ServiceID:  *line.ServiceID,
+				StaffID:    line.StaffID,
+			})
+		}
+	}
+
+	for i := range usages {
+		usage := &usages[i]
+		quantity := actual[usage.ProductID]
+		delta := quantity - usage.ActualQuantity
+		usage.ActualQuantity = quantity
+		usage.UpdatedByID = &userID
+		if err := tx.Save(usage).Error; err != nil {
+			return nil, err
+		}
+		if delta != 0 {
+			if err := moveUsage(tx, usage, -delta, userID, "Koreksi pemakaian "+sale.ReceiptNumber); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return usages, nil
+}
+
+// moveUsage membukukan mutasi usage untuk pemakaian bahan pada baris layanan
+func moveUsage(tx *gorm.DB, usage *models.ServiceUsage, quantity int64, userID uint, note string) error {
+	var product models.Product
+	if err := tx.Select("id", "cost_price").First(&product, usage.ProductID).Error; err != nil {
+		return err
+	}
+	return Move(tx, &models.StockMovement{
+		SalonID:    usage.SalonID,
+		BranchID:   usage.BranchID,
+		ProductID:  usage.ProductID,
+		Type:       models.StockMovementUsage,
+		Quantity:   quantity,
+		UnitCost:   product.CostPrice,
+		SaleID:     &usage.SaleID,
+		SaleLineID: &usage.SaleLineID,
+		UserID:     &userID,
+		Note:       note,
+	})
+}
+
+// Restock mengembalikan produk yang di-refund ke stok cabang tempat produk tersebut dijual
+func Restock(tx *gorm.DB, sale *models.Sale, line *models.SaleLine, qty int, userID uint) error {
+	if sale.BranchID == nil || line.ProductID == nil {
+		return nil
+	}
+	var sold models.StockMovement
+	if err := tx.Where("sale_line_id = ? AND type = ?", line.ID, models.StockMovementSale).
+		First(&sold).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return Move(tx, &models.StockMovement{
+		SalonID:    sale.SalonID,
+		BranchID:   sold.BranchID,
+		ProductID:  sold.ProductID,
+		Type:       models.StockMovementReturn,
+		Quantity:   int64(qty),
+		UnitCost:   sold.UnitCost,
+		SaleID:     &sale.ID,
+		SaleLineID: &line.ID,
+		UserID:     &userID,
+		Note:       sale.ReceiptNumber,
+	})
+}
+
+// LowStockItem adalah satu baris laporan stok menipis
+type LowStockItem struct {
+	BranchID          uint   `json:"branch_id"`
+	BranchName        string `json:"branch_name"`
+	ProductID         uint   `json:"product_id"`
+	SKU               string `json:"sku"`
+	Name              string `json:"name"`
+	Unit              string `json:"unit"`
+	Quantity          int64  `json:"quantity"`
+	LowStockThreshold int64  `json:"low_stock_threshold"`
+}
+
+// LowStock mengambil produk aktif yang stoknya di bawah atau sama dengan batas stok menipis, per cabang
+// aktif. Produk yang belum pernah punya stok di sebuah cabang dihitung 0. branchID 0 berarti semua cabang.
+func LowStock(db *gorm.DB, salonID, branchID uint) ([]LowStockItem, error) {
+	query := db.Table("products").
+		Select("branches.id AS branch_id, branches.name AS branch_name, products.id AS product_id, products.sku, "+
+			"products.name, products.unit, COALESCE(stock_levels.quantity, 0) AS quantity, products.low_stock_threshold").
+		Joins("JOIN branches ON branches.salon_id = products.salon_id AND branches.is_active AND branches.deleted_at IS NULL").
+		Joins("LEFT JOIN stock_levels ON stock_levels.branch_id = branches.id AND stock_levels.product_id = products.id").
+		Where("products.salon_id = ? AND products.is_active AND products.deleted_at IS NULL", salonID).
+		Where("products.low_stock_threshold > 0 AND COALESCE(stock_levels.quantity, 0) <= products.low_stock_threshold")
+	if branchID != 0 {
+		query = query.Where("branches.id = ?", branchID)
+	}
+
+	items := []LowStockItem{}
+	err := query.Order("branches.name, COALESCE(stock_levels.quantity, 0) - products.low_stock_threshold, products.name").Scan(&items).Error
+	return items, err
+}
+
+// UsageVariance adalah selisih pemakaian bahan back-bar seorang staff untuk satu produk
+type UsageVariance struct {
+	StaffID     *uint  `json:"staff_id"`
+	StaffName   string `json:"staff_name"`
+	ProductID   uint   `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Unit        string `json:"unit"`
+	Services    int64  `json:"services"`
+	Expected    int64  `json:"expected"`
+	Actual      int64  `json:"actual"`
+	Variance    int64  `json:"variance"`
+	// VarianceCost adalah selisih pemakaian dikali harga pokok produk saat ini
+	VarianceCost int64 `json:"variance_cost"`
+}
+
+// Variance menghitung perkiraan vs pemakaian nyata bahan per staff dan produk pada rentang [from, to),
+// tanpa transaksi yang di-void. branchID dan staffID 0 berarti semua.
+func Variance(db *gorm.DB, salonID, branchID, staffID uint, from, to time.Time) ([]UsageVariance, error) {
+	query := db.Table("service_usages").
+		Select("service_usages.staff_id, COALESCE(users.name, '') AS staff_name, service_usages.product_id, "+
+			"products.name AS product_name, products.unit, COUNT(DISTINCT service_usages.sale_line_id) AS services, "+
+			"SUM(service_usages.expected_quantity) AS expected, SUM(service_usages.actual_quantity) AS actual, "+
+			"SUM(service_usages.actual_quantity - service_usages.expected_quantity) AS variance, "+
+			"SUM(service_usages.actual_quantity - service_usages.expected_quantity) * products.cost_price AS variance_cost").
+		Joins("JOIN products ON products.id = service_usages.product_id").
+		Joins("JOIN sales ON sales.id = service_usages.sale_id").
+		Joins("LEFT JOIN users ON users.id = service_usages.staff_id").
+		Where("service_usages.salon_id = ? AND service_usages.created_at >= ? AND service_usages.created_at < ?", salonID, from, to).
+		Where("sales.status <> ?", models.SaleStatusVoided)
+	if branchID != 0 {
+		query = query.Where("service_usages.branch_id = ?", branchID)
+	}
+	if staffID != 0 {
+		query = query.Where("service_usages.staff_id = ?", staffID)
+	}
+
+	rows := []UsageVariance{}
+	err := query.Group("service_usages.staff_id, users.name, service_usages.product_id, products.name, products.unit, products.cost_price").
+		Order("staff_name, product_name").Scan(&rows).Error
+	return rows, err
+}
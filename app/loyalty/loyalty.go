@@ -0,0 +1,391 @@
+package loyalty
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/config"
+)
+
+// ErrProgramInactive dikembalikan jika salon belum memiliki program loyalty aktif
+var ErrProgramInactive = errors.New("program loyalty salon tidak aktif")
+
+// ErrCustomerRequired dikembalikan jika poin ditukar pada transaksi tanpa pelanggan
+var ErrCustomerRequired = errors.New("penukaran poin membutuhkan data pelanggan")
+
+// ErrInsufficientPoints dikembalikan jika saldo poin kurang dari poin yang ditukar
+var ErrInsufficientPoints = errors.New("saldo poin tidak mencukupi")
+
+// ErrBelowMinimum dikembalikan jika poin yang ditukar kurang dari minimal penukaran
+var ErrBelowMinimum = errors.New("poin yang ditukar kurang dari minimal penukaran")
+
+// Program mengambil program loyalty aktif salon, atau nil jika tidak ada
+func Program(db *gorm.DB, salonID uint) (*models.LoyaltyProgram, error) {
+	var program models.LoyaltyProgram
+	err := db.Where("salon_id = ? AND is_active", salonID).First(&program).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
+
+// Balance mengembalikan saldo poin pelanggan (0 jika belum memiliki akun)
+func Balance(db *gorm.DB, customerID uint) (int64, error) {
+	var account models.LoyaltyAccount
+	err := db.Where("customer_id = ?", customerID).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return account.Balance, err
+}
+
+// CheckRedemption memvalidasi penukaran poin tanpa mengunci akun (untuk quote) dan mengembalikan
+// nilai diskonnya dalam Rupiah
+func CheckRedemption(db *gorm.DB, program *models.LoyaltyProgram, customerID *uint, points int64) (int64, error) {
+	if program == nil || program.PointValue <= 0 {
+		return 0, ErrProgramInactive
+	}
+	if customerID == nil {
+		return 0, ErrCustomerRequired
+	}
+	if points < program.MinRedeemPoints {
+		return 0, fmt.Errorf("%w (minimal %d poin)", ErrBelowMinimum, program.MinRedeemPoints)
+	}
+	balance, err := Balance(db, *customerID)
+	if err != nil {
+		return 0, err
+	}
+	if balance < points {
+		return 0, fmt.Errorf("%w (saldo %d poin)", ErrInsufficientPoints, balance)
+	}
+	return points * program.PointValue, nil
+}
+
+// Account mengambil akun poin pelanggan dengan FOR UPDATE, dibuat jika belum ada
+func Account(tx *gorm.DB, salonID, customerID uint) (*models.LoyaltyAccount, error) {
+	account := models.LoyaltyAccount{SalonID: salonID, CustomerID: customerID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("customer_id = ?", customerID).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Apply dijalankan setelah sale tersimpan di transaksi yang sama: memotong poin yang ditukar lalu
+// memberi poin dari belanja, kunjungan dan bonus ulang tahun. Poin dari belanja dihitung dari nilai
+// layanan, produk, paket dan membership setelah diskon (tanpa pajak, tip dan gift card) lalu dikali multiplier tier.
+func Apply(tx *gorm.DB, sale *models.Sale, redeemPoints int64) error {
+	if sale.CustomerID == nil {
+		if redeemPoints > 0 {
+			return ErrCustomerRequired
+		}
+		return nil
+	}
+	program, err := Program(tx, sale.SalonID)
+	if err != nil || program == nil {
+		if err == nil && redeemPoints > 0 {
+			return ErrProgramInactive
+		}
+		return err
+	}
+
+	account, err := Account(tx, sale.SalonID, *sale.CustomerID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	if redeemPoints > 0 {
+		if account.Balance < redeemPoints {
+			return fmt.Errorf("%w (saldo %d poin)", ErrInsufficientPoints, account.Balance)
+		}
+		if err := post(tx, account, models.LoyaltyTxRedeem, -redeemPoints, &sale.ID, nil, &sale.CashierID, sale.ReceiptNumber); err != nil {
+			return err
+		}
+		sale.LoyaltyPointsRedeemed = redeemPoints
+	}
+
+	var spend int64
+	for _, line := range sale.Lines {
+		switch line.Type {
+		case models.SaleLineService, models.SaleLineProduct, models.SaleLinePackage, models.SaleLineMembership:
+			spend += line.NetAmount
+		}
+	}
+
+	var tier *models.LoyaltyTier
+	if account.TierID != nil {
+		tier = &models.LoyaltyTier{}
+		if err := tx.First(tier, *account.TierID).Error; err != nil {
+			return err
+		}
+	}
+
+	expires := expiry(program, now)
+	var earned int64
+	if program.SpendPerPoint > 0 {
+		earned = spend / program.SpendPerPoint
+		if tier != nil {
+			earned = earned * int64(tier.EarnMultiplierBps) / 10000
+		}
+	}
+	if spend > 0 {
+		earned += program.PointsPerVisit
+	}
+	if earned > 0 {
+		if err := post(tx, account, models.LoyaltyTxEarn, earned, &sale.ID, expires, &sale.CashierID, sale.ReceiptNumber); err != nil {
+			return err
+		}
+	}
+
+	bonus, err := birthdayBonus(tx, program, account, *sale.CustomerID, now)
+	if err != nil {
+		return err
+	}
+	if bonus > 0 {
+		if err := post(tx, account, models.LoyaltyTxBonus, bonus, &sale.ID, expires, &sale.CashierID, "Bonus ulang tahun"); err != nil {
+			return err
+		}
+	}
+
+	sale.LoyaltyPointsEarned = earned + bonus
+	if sale.LoyaltyPointsEarned == 0 && sale.LoyaltyPointsRedeemed == 0 {
+		return nil
+	}
+	if err := updateLifetime(tx, account, sale.LoyaltyPointsEarned); err != nil {
+		return err
+	}
+	return tx.Model(sale).Select("loyalty_points_earned", "loyalty_points_redeemed").Updates(sale).Error
+}
+
+// birthdayBonus mengembalikan bonus ulang tahun jika bulan ini bulan ulang tahun pelanggan dan bonus
+// tahun ini belum diberikan
+func birthdayBonus(tx *gorm.DB, program *models.LoyaltyProgram, account *models.LoyaltyAccount, customerID uint, now time.Time) (int64, error) {
+	if program.BirthdayBonusPoints <= 0 {
+		return 0, nil
+	}
+	var customer models.Customer
+	if err := tx.First(&customer, customerID).Error; err != nil {
+		return 0, err
+	}
+	local := now.In(config.Location())
+	if customer.BirthDate == nil || customer.BirthDate.Month() != local.Month() {
+		return 0, nil
+	}
+
+	yearStart := time.Date(local.Year(), 1, 1, 0, 0, 0, 0, config.Location())
+	var given int64
+	if err := tx.Model(&models.LoyaltyTransaction{}).
+		Where("account_id = ? AND type = ? AND created_at >= ?", account.ID, models.LoyaltyTxBonus, yearStart).
+		Count(&given).Error; err != nil {
+		return 0, err
+	}
+	if given > 0 {
+		return 0, nil
+	}
+	return program.BirthdayBonusPoints, nil
+}
+
+// Reverse menyesuaikan poin setelah refund/void: poin yang diperoleh ditarik dan poin yang ditukar
+// dikembalikan sebanding dengan nominal yang sudah di-refund. Dipanggil setelah RefundedTotal dan
+// status sale diperbarui, sehingga refund terakhir selalu menarik/mengembalikan sisanya.
+func Reverse(tx *gorm.DB, sale *models.Sale, userID uint) error {
+	if sale.CustomerID == nil || (sale.LoyaltyPointsEarned == 0 && sale.LoyaltyPointsRedeemed == 0) {
+		return nil
+	}
+	closed := sale.Status == models.SaleStatusRefunded || sale.Status == models.SaleStatusVoided
+	share := func(points int64) int64 {
+		if closed || sale.Total <= 0 {
+			return points
+		}
+		return points * sale.RefundedTotal / sale.Total
+	}
+
+	var done struct {
+		Reversed int64
+		Restored int64
+	}
+	if err := tx.Model(&models.LoyaltyTransaction{}).
+		Select("COALESCE(SUM(CASE WHEN type = ? THEN -points ELSE 0 END), 0) AS reversed, "+
+			"COALESCE(SUM(CASE WHEN type = ? THEN points ELSE 0 END), 0) AS restored",
+			models.LoyaltyTxReverse, models.LoyaltyTxRestore).
+		Where("sale_id = ?", sale.ID).Scan(&done).Error; err != nil {
+		return err
+	}
+
+	reverse := share(sale.LoyaltyPointsEarned) - done.Reversed
+	restore := share(sale.LoyaltyPointsRedeemed) - done.Restored
+	if reverse <= 0 && restore <= 0 {
+		return nil
+	}
+
+	account, err := Account(tx, sale.SalonID, *sale.CustomerID)
+	if err != nil {
+		return err
+	}
+	if reverse > 0 {
+		// Saldo boleh negatif jika poin sudah terpakai; penukaran berikutnya tertahan sampai saldo kembali positif
+		if err := post(tx, account, models.LoyaltyTxReverse, -reverse, &sale.ID, nil, &userID, sale.ReceiptNumber); err != nil {
+			return err
+		}
+		if err := updateLifetime(tx, account, -reverse); err != nil {
+			return err
+		}
+	}
+	if restore > 0 {
+		program, err := Program(tx, sale.SalonID)
+		if err != nil {
+			return err
+		}
+		var expires *time.Time
+		if program != nil {
+			expires = expiry(program, time.Now())
+		}
+		if err := post(tx, account, models.LoyaltyTxRestore, restore, &sale.ID, expires, &userID, sale.ReceiptNumber); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Adjust mencatat koreksi poin manual oleh owner/manager
+func Adjust(tx *gorm.DB, salonID, customerID uint, points int64, userID uint, note string) (*models.LoyaltyAccount, error) {
+	account, err := Account(tx, salonID, customerID)
+	if err != nil {
+		return nil, err
+	}
+	var expires *time.Time
+	if points > 0 {
+		program, err := Program(tx, salonID)
+		if err != nil {
+			return nil, err
+		}
+		if program != nil {
+			expires = expiry(program, time.Now())
+		}
+	}
+	if err := post(tx, account, models.LoyaltyTxAdjust, points, nil, expires, &userID, note); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ExpireDue menghanguskan poin yang melewati masa berlaku. Poin dianggap terpakai FIFO: poin hangus
+// = total kredit yang sudah jatuh tempo - total debit (tukar, tarik, hangus, koreksi), sehingga ledger
+// tetap append-only dan task aman dijalankan berulang kali.
+func ExpireDue(db *gorm.DB, now time.Time) (int, error) {
+	var accountIDs []uint
+	if err := db.Model(&models.LoyaltyTransaction{}).
+		Joins("JOIN loyalty_accounts ON loyalty_accounts.id = loyalty_transactions.account_id").
+		Where("loyalty_accounts.balance > 0 AND loyalty_transactions.points > 0 AND loyalty_transactions.expires_at <= ?", now).
+		Distinct().Pluck("loyalty_transactions.account_id", &accountIDs).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, accountID := range accountIDs {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var account models.LoyaltyAccount
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, accountID).Error; err != nil {
+				return err
+			}
+			var totals struct {
+				Due   int64
+				Debit int64
+			}
+			if err := tx.Model(&models.LoyaltyTransaction{}).
+				Select("COALESCE(SUM(CASE WHEN points > 0 AND expires_at <= ? THEN points ELSE 0 END), 0) AS due, "+
+					"COALESCE(SUM(CASE WHEN points < 0 THEN -points ELSE 0 END), 0) AS debit", now).
+				Where("account_id = ?", account.ID).Scan(&totals).Error; err != nil {
+				return err
+			}
+
+			points := min(totals.Due-totals.Debit, account.Balance)
+			if points <= 0 {
+				return nil
+			}
+			expired++
+			return post(tx, &account, models.LoyaltyTxExpire, -points, nil, nil, nil, "Poin kedaluwarsa")
+		})
+		if err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// Tiers mengembalikan tier salon dari yang tertinggi
+func Tiers(db *gorm.DB, salonID uint) ([]models.LoyaltyTier, error) {
+	var tiers []models.LoyaltyTier
+	err := db.Where("salon_id = ?", salonID).Order("min_points DESC, id").Find(&tiers).Error
+	return tiers, err
+}
+
+// RefreshTier menyesuaikan tier akun dengan LifetimePoints-nya
+func RefreshTier(tx *gorm.DB, account *models.LoyaltyAccount) error {
+	tiers, err := Tiers(tx, account.SalonID)
+	if err != nil {
+		return err
+	}
+	account.TierID = nil
+	for _, tier := range tiers {
+		if account.LifetimePoints >= tier.MinPoints {
+			id := tier.ID
+			account.TierID = &id
+			break
+		}
+	}
+	return tx.Model(account).Select("tier_id").Updates(account).Error
+}
+
+// updateLifetime menambah LifetimePoints lalu menghitung ulang tier
+func updateLifetime(tx *gorm.DB, account *models.LoyaltyAccount, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	account.LifetimePoints += delta
+	if err := tx.Model(account).Update("lifetime_points", account.LifetimePoints).Error; err != nil {
+		return err
+	}
+	return RefreshTier(tx, account)
+}
+
+// expiry menghitung tanggal hangus poin yang diperoleh pada now
+func expiry(program *models.LoyaltyProgram, now time.Time) *time.Time {
+	if program.ExpiryDays <= 0 {
+		return nil
+	}
+	expires := now.AddDate(0, 0, program.ExpiryDays)
+	return &expires
+}
+
+// post memperbarui saldo akun dan menambah satu baris ledger
+func post(tx *gorm.DB, account *models.LoyaltyAccount, txType string, points int64, saleID *uint, expiresAt *time.Time, userID *uint, note string) error {
+	account.Balance += points
+	if err := tx.Model(account).Update("balance", account.Balance).Error; err != nil {
+		return err
+	}
+	return tx.Create(&models.LoyaltyTransaction{
+		AccountID:    account.ID,
+		CustomerID:   account.CustomerID,
+		Type:         txType,
+		Points:       points,
+		BalanceAfter: account.Balance,
+		SaleID:       saleID,
+		ExpiresAt:    expiresAt,
+		UserID:       userID,
+		Note:         note,
+		CreatedAt:    time.Now(),
+	}).Error
+}
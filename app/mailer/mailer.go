@@ -0,0 +1,161 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/queue"
+	"gin-sass-salon/config"
+)
+
+// JobSendEmail adalah jenis job antrian untuk mengirim email
+const JobSendEmail = "send_email"
+
+// ErrNoRecipient dikembalikan jika email tidak memiliki penerima
+var ErrNoRecipient = errors.New("email tidak memiliki penerima")
+
+// Attachment adalah lampiran email
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// Message adalah satu email teks beserta lampirannya
+type Message struct {
+	To          []string     `json:"to"`
+	Subject     string       `json:"subject"`
+	Body        string       `json:"body"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Mailer mengirim email. Implementasi dipilih lewat MAIL_DRIVER.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New mengembalikan mailer sesuai MAIL_DRIVER (log atau smtp)
+func New() Mailer {
+	if config.MailDriver() == "smtp" {
+		user, pass := config.SMTPCredentials()
+		return &SMTPMailer{Addr: config.SMTPAddr(), Username: user, Password: pass, From: config.MailFrom()}
+	}
+	return LogMailer{}
+}
+
+// RegisterJobs mendaftarkan handler job send_email ke antrian
+func RegisterJobs() {
+	queue.Register(JobSendEmail, func(ctx context.Context, msg Message) error {
+		return New().Send(ctx, msg)
+	})
+}
+
+// Queue memasukkan email ke antrian agar dikirim worker (dengan retry jika server email gagal).
+// Gunakan tx dari transaksi yang sedang berjalan agar email ikut ter-commit atau ter-rollback.
+func Queue(db *gorm.DB, msg Message) (*models.Job, error) {
+	if len(msg.To) == 0 {
+		return nil, ErrNoRecipient
+	}
+	return queue.Dispatch(db, JobSendEmail, msg)
+}
+
+// LogMailer hanya menulis email ke log; dipakai saat development
+type LogMailer struct{}
+
+// Send menulis ringkasan email ke log
+func (LogMailer) Send(_ context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipient
+	}
+	names := make([]string, 0, len(msg.Attachments))
+	for _, a := range msg.Attachments {
+		names = append(names, fmt.Sprintf("%s (%d byte)", a.Filename, len(a.Data)))
+	}
+	log.Printf("✉️  Email ke %s: %q, lampiran: %v", strings.Join(msg.To, ", "), msg.Subject, names)
+	return nil
+}
+
+// SMTPMailer mengirim email lewat server SMTP dengan PLAIN auth (STARTTLS jika didukung server)
+type SMTPMailer struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send mengirim email sebagai MIME multipart/mixed
+func (m *SMTPMailer) Send(_ context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipient
+	}
+	body, err := buildMIME(m.From, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		host := m.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From, msg.To, body)
+}
+
+// buildMIME menyusun email teks dengan lampiran base64
+func buildMIME(from string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+
+	for _, a := range msg.Attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
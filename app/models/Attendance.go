@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis absensi
+const (
+	PunchClockIn    = "clock_in"
+	PunchClockOut   = "clock_out"
+	PunchBreakStart = "break_start"
+	PunchBreakEnd   = "break_end"
+)
+
+// StaffShift adalah jadwal kerja staff di satu cabang. Berbeda dengan CashShift yang merupakan sesi laci kas.
+type StaffShift struct {
+	gorm.Model
+	SalonID     uint      `json:"salon_id" gorm:"not null;index"`
+	BranchID    uint      `json:"branch_id" gorm:"not null;index"`
+	StaffID     uint      `json:"staff_id" gorm:"not null;index:idx_staff_shifts_staff,priority:1"`
+	StartAt     time.Time `json:"start_at" gorm:"not null;index:idx_staff_shifts_staff,priority:2"`
+	EndAt       time.Time `json:"end_at" gorm:"not null"`
+	Note        string    `json:"note"`
+	CreatedByID uint      `json:"created_by_id" gorm:"not null"`
+}
+
+// AttendancePunch adalah satu catatan absensi staff (append-only). Absensi manual dicatat owner/manager
+// untuk staff yang lupa absen dan tidak melewati pemeriksaan geofence maupun device.
+type AttendancePunch struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	SalonID   uint      `json:"salon_id" gorm:"not null;index"`
+	BranchID  uint      `json:"branch_id" gorm:"not null;index"`
+	StaffID   uint      `json:"staff_id" gorm:"not null;index:idx_attendance_punches_staff,priority:1"`
+	Type      string    `json:"type" gorm:"not null"`
+	PunchedAt time.Time `json:"punched_at" gorm:"not null;index:idx_attendance_punches_staff,priority:2"`
+	Latitude  *float64  `json:"latitude"`
+	Longitude *float64  `json:"longitude"`
+	// DistanceMeters adalah jarak ke lokasi cabang saat absen, jika cabang memiliki lokasi
+	DistanceMeters *int      `json:"distance_meters"`
+	DeviceID       string    `json:"device_id"`
+	Manual         bool      `json:"manual" gorm:"not null;default:false"`
+	RecordedByID   uint      `json:"recorded_by_id" gorm:"not null"`
+	Note           string    `json:"note"`
+	CreatedAt      time.Time `json:"created_at"`
+}
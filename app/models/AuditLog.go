@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// AuditLog mencatat siapa melakukan aksi sensitif apa, kapan, dan alasannya.
+// Tabel ini hanya ditambah (append-only), tidak pernah diubah atau dihapus oleh aplikasi.
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	SalonID    uint      `json:"salon_id" gorm:"not null;index"`
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	ApprovedBy *uint     `json:"approved_by"`
+	Action     string    `json:"action" gorm:"not null;index"`
+	EntityType string    `json:"entity_type" gorm:"not null;index:idx_audit_logs_entity,priority:1"`
+	EntityID   uint      `json:"entity_id" gorm:"not null;index:idx_audit_logs_entity,priority:2"`
+	Reason     string    `json:"reason"`
+	Data       string    `json:"data" gorm:"type:jsonb"`
+	CreatedAt  time.Time `json:"created_at" gorm:"not null;index"`
+}
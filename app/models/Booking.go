@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status booking
+const (
+	BookingStatusBooked    = "booked"
+	BookingStatusCompleted = "completed"
+	BookingStatusCancelled = "cancelled"
+	BookingStatusNoShow    = "no_show"
+)
+
+// Booking merepresentasikan janji temu pelanggan dengan seorang staff untuk satu layanan
+type Booking struct {
+	gorm.Model
+	SalonID    uint `json:"salon_id" gorm:"not null;index:idx_bookings_salon_start,priority:1"`
+	CustomerID uint `json:"customer_id" gorm:"not null;index"`
+	StaffID    uint `json:"staff_id" gorm:"not null;index:idx_bookings_staff_start,priority:1"`
+	ServiceID  uint `json:"service_id" gorm:"not null"`
+	// BranchID adalah cabang tempat layanan; kosong hanya untuk booking lama sebelum ada cabang
+	BranchID     *uint      `json:"branch_id" gorm:"index"`
+	StartAt      time.Time  `json:"start_at" gorm:"not null;index:idx_bookings_salon_start,priority:2;index:idx_bookings_staff_start,priority:2"`
+	EndAt        time.Time  `json:"end_at" gorm:"not null"`
+	Status       string     `json:"status" gorm:"not null;default:booked;index"`
+	Notes        string     `json:"notes"`
+	CancelledAt  *time.Time `json:"cancelled_at"`
+	CancelReason string     `json:"cancel_reason"`
+	Customer     *Customer  `json:"customer,omitempty"`
+	Service      *Service   `json:"service,omitempty"`
+}
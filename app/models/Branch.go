@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Branch adalah cabang (lokasi fisik) salon. Setiap salon memiliki satu cabang utama yang dibuat
+// otomatis saat pertama dibutuhkan.
+type Branch struct {
+	gorm.Model
+	SalonID uint   `json:"salon_id" gorm:"not null;index"`
+	Name    string `json:"name" gorm:"not null"`
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+	// Timezone adalah zona waktu IANA cabang (mis. Asia/Makassar); kosong berarti zona waktu aplikasi
+	Timezone string `json:"timezone"`
+	IsMain   bool   `json:"is_main" gorm:"not null;default:false"`
+	IsActive bool   `json:"is_active" gorm:"not null"`
+	// Latitude dan Longitude adalah lokasi cabang untuk geofence absensi
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	// GeofenceRadiusMeters 0 berarti absensi tidak dibatasi lokasi
+	GeofenceRadiusMeters int `json:"geofence_radius_meters" gorm:"not null;default:0"`
+	// LateGraceMinutes adalah toleransi keterlambatan clock in terhadap awal shift
+	LateGraceMinutes int                 `json:"late_grace_minutes" gorm:"not null;default:0"`
+	OpeningHours     []BranchOpeningHour `json:"opening_hours,omitempty" gorm:"foreignKey:BranchID"`
+}
+
+// Location mengembalikan zona waktu cabang, atau fallback jika kosong/tidak dikenal
+func (b *Branch) Location(fallback *time.Location) *time.Location {
+	if b.Timezone == "" {
+		return fallback
+	}
+	loc, err := time.LoadLocation(b.Timezone)
+	if err != nil {
+		return fallback
+	}
+	return loc
+}
+
+// IsOpenAt mengecek apakah cabang buka pada waktu t menurut jam buka dan zona waktu cabang.
+// OpeningHours harus sudah dimuat.
+func (b *Branch) IsOpenAt(t time.Time, fallback *time.Location) bool {
+	local := t.In(b.Location(fallback))
+	clock := local.Format("15:04")
+	for _, hour := range b.OpeningHours {
+		if hour.Weekday == int(local.Weekday()) && clock >= hour.OpenTime && clock < hour.CloseTime {
+			return true
+		}
+	}
+	return false
+}
+
+// BranchOpeningHour adalah jam buka cabang pada satu hari. Satu hari boleh memiliki beberapa rentang
+// (mis. tutup saat istirahat siang); hari tanpa rentang berarti tutup.
+type BranchOpeningHour struct {
+	ID       uint `json:"id" gorm:"primarykey"`
+	BranchID uint `json:"branch_id" gorm:"not null;index"`
+	// Weekday 0 = Minggu sampai 6 = Sabtu
+	Weekday   int    `json:"weekday" gorm:"not null"`
+	OpenTime  string `json:"open_time" gorm:"type:char(5);not null"`
+	CloseTime string `json:"close_time" gorm:"type:char(5);not null"`
+}
+
+// BranchStaff menugaskan staff ke cabang. Staff tanpa penugasan dapat bekerja di semua cabang,
+// sedangkan branch manager hanya dapat mengakses data cabang yang ditugaskan kepadanya.
+type BranchStaff struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	BranchID  uint      `json:"branch_id" gorm:"not null;uniqueIndex:idx_branch_staff,priority:2"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_branch_staff,priority:1"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status shift kasir
+const (
+	CashShiftOpen   = "open"
+	CashShiftClosed = "closed"
+)
+
+// Jenis pergerakan kas di luar transaksi penjualan
+const (
+	CashMovementIn  = "cash_in"
+	CashMovementOut = "cash_out"
+)
+
+// CashShift adalah satu sesi laci kas: dibuka dengan modal awal (float) dan ditutup dengan uang
+// yang dihitung kasir. Hanya boleh ada satu shift terbuka per cabang.
+type CashShift struct {
+	gorm.Model
+	SalonID uint `json:"salon_id" gorm:"not null;index"`
+	// BranchID adalah cabang pemilik laci kas; kosong hanya untuk shift lama sebelum ada cabang
+	BranchID     *uint      `json:"branch_id" gorm:"uniqueIndex:idx_cash_shifts_branch_open,where:status = 'open'"`
+	Status       string     `json:"status" gorm:"not null;default:open"`
+	OpenedByID   uint       `json:"opened_by_id" gorm:"not null"`
+	OpenedAt     time.Time  `json:"opened_at" gorm:"not null"`
+	OpeningFloat int64      `json:"opening_float" gorm:"not null"`
+	ClosedByID   *uint      `json:"closed_by_id"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	// ExpectedCash dihitung sistem saat tutup; Variance = CountedCash - ExpectedCash
+	ExpectedCash int64          `json:"expected_cash" gorm:"not null;default:0"`
+	CountedCash  *int64         `json:"counted_cash"`
+	Variance     int64          `json:"variance" gorm:"not null;default:0"`
+	Notes        string         `json:"notes"`
+	Movements    []CashMovement `json:"movements,omitempty" gorm:"foreignKey:ShiftID"`
+}
+
+// CashMovement adalah kas masuk/keluar manual (mis. setor ke bank, beli keperluan salon)
+type CashMovement struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	ShiftID   uint      `json:"shift_id" gorm:"not null;index"`
+	Type      string    `json:"type" gorm:"not null"`
+	Amount    int64     `json:"amount" gorm:"not null"`
+	Reason    string    `json:"reason" gorm:"not null"`
+	UserID    uint      `json:"user_id" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+}
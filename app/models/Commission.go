@@ -0,0 +1,46 @@
+package models
+
+import "gorm.io/gorm"
+
+// Jenis tarif komisi
+const (
+	CommissionTypePercent = "percent"
+	CommissionTypeFlat    = "flat"
+)
+
+// CommissionPlan adalah skema komisi yang dipasang ke staff lewat User.CommissionPlanID
+type CommissionPlan struct {
+	gorm.Model
+	SalonID  uint             `json:"salon_id" gorm:"not null;index"`
+	Name     string           `json:"name" gorm:"not null"`
+	IsActive bool             `json:"is_active" gorm:"not null"`
+	Rules    []CommissionRule `json:"rules" gorm:"foreignKey:PlanID"`
+}
+
+// CommissionRule adalah tarif komisi untuk jenis item (service atau product) dan kategori tertentu.
+// Aturan berlaku jika omzet staff pada bulan yang sama untuk jenis item tersebut minimal MinMonthlyRevenue,
+// sehingga beberapa aturan dengan MinMonthlyRevenue berbeda membentuk tier.
+type CommissionRule struct {
+	ID       uint   `json:"id" gorm:"primarykey"`
+	PlanID   uint   `json:"plan_id" gorm:"not null;index"`
+	ItemType string `json:"item_type" gorm:"not null"`
+	// Category kosong berarti semua kategori; aturan dengan kategori lebih diutamakan
+	Category string `json:"category"`
+	Type     string `json:"type" gorm:"not null"`
+	// ValueBps untuk tarif persen dari nilai baris setelah diskon dan tanpa pajak
+	ValueBps int `json:"value_bps" gorm:"not null;default:0"`
+	// ValueAmount untuk tarif flat per unit
+	ValueAmount       int64 `json:"value_amount" gorm:"not null;default:0"`
+	MinMonthlyRevenue int64 `json:"min_monthly_revenue" gorm:"not null;default:0"`
+}
+
+// SaleLineSplit membagi satu baris layanan ke beberapa staff (split service). Baris tanpa split
+// sepenuhnya milik SaleLine.StaffID.
+type SaleLineSplit struct {
+	ID         uint `json:"id" gorm:"primarykey"`
+	SaleID     uint `json:"sale_id" gorm:"not null;index"`
+	SaleLineID uint `json:"sale_line_id" gorm:"not null;uniqueIndex:idx_sale_line_splits_staff,priority:1"`
+	StaffID    uint `json:"staff_id" gorm:"not null;uniqueIndex:idx_sale_line_splits_staff,priority:2;index"`
+	// ShareBps adalah bagian staff dari nilai baris; total seluruh split satu baris 10000
+	ShareBps int `json:"share_bps" gorm:"not null"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Customer merepresentasikan pelanggan sebuah salon. Pelanggan berlaku di semua cabang; BranchID hanya
+// mencatat cabang tempat pelanggan didaftarkan.
+type Customer struct {
+	gorm.Model
+	SalonID   uint       `json:"salon_id" gorm:"not null;index"`
+	BranchID  *uint      `json:"branch_id" gorm:"index"`
+	Name      string     `json:"name" gorm:"not null"`
+	Phone     string     `json:"phone" gorm:"index"`
+	Email     string     `json:"email"`
+	BirthDate *time.Time `json:"birth_date" gorm:"type:date"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status gift card
+const (
+	GiftCardStatusActive  = "active"
+	GiftCardStatusExpired = "expired"
+	GiftCardStatusVoided  = "voided"
+)
+
+// Jenis mutasi saldo gift card
+const (
+	GiftCardTxIssue  = "issue"
+	GiftCardTxRedeem = "redeem"
+	GiftCardTxRefund = "refund"
+	GiftCardTxExpire = "expire"
+	GiftCardTxVoid   = "void"
+)
+
+// GiftCard adalah kartu hadiah bersaldo yang dapat dipakai sebagai pembayaran sebagian atau penuh
+type GiftCard struct {
+	gorm.Model
+	SalonID uint `json:"salon_id" gorm:"not null;index"`
+	// Code bersifat rahasia seperti uang tunai; hanya ditampilkan utuh saat diterbitkan dan lookup
+	Code          string     `json:"code" gorm:"not null;uniqueIndex"`
+	InitialValue  int64      `json:"initial_value" gorm:"not null"`
+	Balance       int64      `json:"balance" gorm:"not null"`
+	Status        string     `json:"status" gorm:"not null;default:active;index"`
+	ExpiresAt     *time.Time `json:"expires_at" gorm:"index"`
+	CustomerID    *uint      `json:"customer_id" gorm:"index"`
+	RecipientName string     `json:"recipient_name"`
+	// SaleID dan SaleLineID diisi jika gift card dijual lewat POS
+	SaleID       *uint                 `json:"sale_id" gorm:"index"`
+	SaleLineID   *uint                 `json:"sale_line_id" gorm:"index"`
+	IssuedByID   uint                  `json:"issued_by_id" gorm:"not null"`
+	Notes        string                `json:"notes"`
+	Transactions []GiftCardTransaction `json:"transactions,omitempty"`
+}
+
+// GiftCardTransaction adalah ledger mutasi saldo gift card. Amount positif untuk kredit dan negatif
+// untuk debit; BalanceAfter adalah saldo setelah mutasi. Tabel ini append-only.
+type GiftCardTransaction struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	GiftCardID   uint      `json:"gift_card_id" gorm:"not null;index"`
+	Type         string    `json:"type" gorm:"not null"`
+	Amount       int64     `json:"amount" gorm:"not null"`
+	BalanceAfter int64     `json:"balance_after" gorm:"not null"`
+	SaleID       *uint     `json:"sale_id" gorm:"index"`
+	SaleTenderID *uint     `json:"sale_tender_id"`
+	UserID       *uint     `json:"user_id"`
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status job pada antrian
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusDead      = "dead"
+)
+
+// Job merepresentasikan satu pekerjaan pada antrian background (tabel jobs)
+type Job struct {
+	gorm.Model
+	Queue       string     `json:"queue" gorm:"not null;default:default;index:idx_jobs_fetch,priority:1"`
+	Type        string     `json:"type" gorm:"not null;index"`
+	Payload     string     `json:"payload" gorm:"type:jsonb;not null;default:'{}'"`
+	Status      string     `json:"status" gorm:"not null;default:pending;index:idx_jobs_fetch,priority:2"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int        `json:"max_attempts" gorm:"not null;default:5"`
+	AvailableAt time.Time  `json:"available_at" gorm:"not null;index:idx_jobs_fetch,priority:3"`
+	LockedAt    *time.Time `json:"locked_at"`
+	LockedBy    string     `json:"locked_by"`
+	LastError   string     `json:"last_error" gorm:"type:text"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
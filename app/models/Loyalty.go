@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis mutasi poin loyalty
+const (
+	LoyaltyTxEarn = "earn"
+	// LoyaltyTxBonus adalah bonus ulang tahun, diberikan sekali per tahun pada transaksi pertama di bulan ulang tahun
+	LoyaltyTxBonus   = "bonus"
+	LoyaltyTxRedeem  = "redeem"
+	LoyaltyTxReverse = "reverse"
+	// LoyaltyTxRestore mengembalikan poin yang ditukar pada transaksi yang di-refund/void
+	LoyaltyTxRestore = "restore"
+	LoyaltyTxExpire  = "expire"
+	LoyaltyTxAdjust  = "adjust"
+)
+
+// LoyaltyProgram adalah aturan poin loyalty sebuah salon
+type LoyaltyProgram struct {
+	gorm.Model
+	SalonID  uint `json:"salon_id" gorm:"not null;uniqueIndex"`
+	IsActive bool `json:"is_active" gorm:"not null"`
+	// SpendPerPoint adalah nominal belanja (Rupiah) untuk 1 poin; 0 berarti belanja tidak menghasilkan poin
+	SpendPerPoint       int64 `json:"spend_per_point" gorm:"not null;default:0"`
+	PointsPerVisit      int64 `json:"points_per_visit" gorm:"not null;default:0"`
+	BirthdayBonusPoints int64 `json:"birthday_bonus_points" gorm:"not null;default:0"`
+	// PointValue adalah nilai Rupiah 1 poin saat ditukar sebagai diskon
+	PointValue      int64 `json:"point_value" gorm:"not null;default:0"`
+	MinRedeemPoints int64 `json:"min_redeem_points" gorm:"not null;default:0"`
+	// ExpiryDays adalah masa berlaku poin sejak diperoleh; 0 berarti poin tidak hangus
+	ExpiryDays int `json:"expiry_days" gorm:"not null;default:0"`
+}
+
+// LoyaltyTier adalah tingkatan member berdasarkan total poin yang pernah diperoleh
+type LoyaltyTier struct {
+	gorm.Model
+	SalonID   uint   `json:"salon_id" gorm:"not null;index"`
+	Name      string `json:"name" gorm:"not null"`
+	MinPoints int64  `json:"min_points" gorm:"not null"`
+	// EarnMultiplierBps mengalikan poin dari belanja (10000 = 1x, 15000 = 1,5x)
+	EarnMultiplierBps int `json:"earn_multiplier_bps" gorm:"not null;default:10000"`
+}
+
+// LoyaltyAccount menyimpan saldo poin pelanggan. Saldo hanya berubah bersama baris ledger.
+type LoyaltyAccount struct {
+	ID         uint  `json:"id" gorm:"primarykey"`
+	SalonID    uint  `json:"salon_id" gorm:"not null;index"`
+	CustomerID uint  `json:"customer_id" gorm:"not null;uniqueIndex"`
+	Balance    int64 `json:"balance" gorm:"not null;default:0"`
+	// LifetimePoints adalah total poin yang pernah diperoleh (dikurangi reversal), dasar penentuan tier
+	LifetimePoints int64        `json:"lifetime_points" gorm:"not null;default:0"`
+	TierID         *uint        `json:"tier_id"`
+	Tier           *LoyaltyTier `json:"tier,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// LoyaltyTransaction adalah ledger poin pelanggan (append-only). Points positif untuk kredit dan
+// negatif untuk debit; ExpiresAt diisi pada kredit yang dapat hangus.
+type LoyaltyTransaction struct {
+	ID           uint       `json:"id" gorm:"primarykey"`
+	AccountID    uint       `json:"account_id" gorm:"not null;index"`
+	CustomerID   uint       `json:"customer_id" gorm:"not null;index"`
+	Type         string     `json:"type" gorm:"not null"`
+	Points       int64      `json:"points" gorm:"not null"`
+	BalanceAfter int64      `json:"balance_after" gorm:"not null"`
+	SaleID       *uint      `json:"sale_id" gorm:"index"`
+	ExpiresAt    *time.Time `json:"expires_at" gorm:"index"`
+	UserID       *uint      `json:"user_id"`
+	Note         string     `json:"note"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"not null"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status pembayaran pada payment provider
+const (
+	PaymentStatusPending           = "pending"
+	PaymentStatusSucceeded         = "succeeded"
+	PaymentStatusFailed            = "failed"
+	PaymentStatusPartiallyRefunded = "partially_refunded"
+	PaymentStatusRefunded          = "refunded"
+)
+
+// Metode pembayaran elektronik
+const (
+	PaymentMethodQRIS           = "qris"
+	PaymentMethodVirtualAccount = "virtual_account"
+	PaymentMethodCard           = "card"
+)
+
+// Payment adalah tagihan yang dibuat di payment provider (QRIS, virtual account, kartu).
+// Setelah berhasil, payment dipakai sebagai tender pada checkout.
+type Payment struct {
+	gorm.Model
+	SalonID     uint   `json:"salon_id" gorm:"not null;index"`
+	SaleID      *uint  `json:"sale_id" gorm:"index"`
+	Provider    string `json:"provider" gorm:"not null;uniqueIndex:idx_payments_provider_ref,priority:1"`
+	ProviderRef string `json:"provider_ref" gorm:"not null;uniqueIndex:idx_payments_provider_ref,priority:2"`
+	Method      string `json:"method" gorm:"not null"`
+	Amount      int64  `json:"amount" gorm:"not null"`
+	// RefundedAmount adalah total refund yang sudah berhasil atau masih diproses provider
+	RefundedAmount int64  `json:"refunded_amount" gorm:"not null;default:0"`
+	Status         string `json:"status" gorm:"not null;default:pending;index"`
+	Description    string `json:"description"`
+	// Instruksi bayar dari provider (QR string, nomor VA, atau URL halaman kartu)
+	QRString      string     `json:"qr_string,omitempty"`
+	VANumber      string     `json:"va_number,omitempty"`
+	RedirectURL   string     `json:"redirect_url,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	PaidAt        *time.Time `json:"paid_at"`
+	FailureReason string     `json:"failure_reason"`
+	CreatedByID   uint       `json:"created_by_id" gorm:"not null"`
+}
+
+// Status refund di payment provider
+const (
+	PaymentRefundPending   = "pending"
+	PaymentRefundSucceeded = "succeeded"
+	PaymentRefundFailed    = "failed"
+)
+
+// PaymentRefund adalah pengembalian dana lewat payment provider. Dicatat pending di dalam transaksi
+// refund lalu dikirim ke provider setelah commit, dengan ID refund sebagai idempotency key.
+type PaymentRefund struct {
+	gorm.Model
+	SalonID           uint       `json:"salon_id" gorm:"not null;index"`
+	PaymentID         uint       `json:"payment_id" gorm:"not null;index"`
+	Amount            int64      `json:"amount" gorm:"not null"`
+	Status            string     `json:"status" gorm:"not null;default:pending;index"`
+	ProviderRefundRef string     `json:"provider_refund_ref"`
+	FailureReason     string     `json:"failure_reason"`
+	CompletedAt       *time.Time `json:"completed_at"`
+}
+
+// PaymentEvent mencatat setiap webhook/perubahan status dari provider. EventID unik per provider
+// sehingga webhook yang dikirim ulang tidak diproses dua kali.
+type PaymentEvent struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	Provider   string    `json:"provider" gorm:"not null;uniqueIndex:idx_payment_events_event,priority:1"`
+	EventID    string    `json:"event_id" gorm:"not null;uniqueIndex:idx_payment_events_event,priority:2"`
+	PaymentID  *uint     `json:"payment_id" gorm:"index"`
+	Status     string    `json:"status"`
+	Payload    string    `json:"payload" gorm:"type:jsonb"`
+	ReceivedAt time.Time `json:"received_at" gorm:"not null"`
+}
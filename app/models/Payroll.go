@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status periode payroll
+const (
+	PayrollStatusDraft = "draft"
+	// PayrollStatusApproved berarti periode sudah dikunci dan tidak dapat diubah lagi
+	PayrollStatusApproved = "approved"
+)
+
+// PayrollPeriod adalah satu periode penggajian salon, mis. satu bulan kalender. StartDate dan EndDate
+// adalah tanggal (inklusif) menurut zona waktu salon.
+type PayrollPeriod struct {
+	gorm.Model
+	SalonID      uint           `json:"salon_id" gorm:"not null;index"`
+	StartDate    time.Time      `json:"start_date" gorm:"type:date;not null"`
+	EndDate      time.Time      `json:"end_date" gorm:"type:date;not null"`
+	Status       string         `json:"status" gorm:"not null;default:draft"`
+	CreatedByID  uint           `json:"created_by_id" gorm:"not null"`
+	ApprovedByID *uint          `json:"approved_by_id"`
+	ApprovedAt   *time.Time     `json:"approved_at"`
+	Notes        string         `json:"notes"`
+	Entries      []PayrollEntry `json:"entries,omitempty" gorm:"foreignKey:PeriodID"`
+}
+
+// PayrollEntry adalah rincian gaji satu staff pada satu periode. Komisi, omzet dan tip diisi dari
+// statement komisi saat periode dihitung; gaji pokok dan potongan dapat diubah selama periode masih draft.
+type PayrollEntry struct {
+	ID             uint   `json:"id" gorm:"primarykey"`
+	PeriodID       uint   `json:"period_id" gorm:"not null;uniqueIndex:idx_payroll_entries_staff,priority:1"`
+	StaffID        uint   `json:"staff_id" gorm:"not null;uniqueIndex:idx_payroll_entries_staff,priority:2"`
+	StaffName      string `json:"staff_name" gorm:"not null"`
+	StaffEmail     string `json:"staff_email"`
+	BasePay        int64  `json:"base_pay" gorm:"not null;default:0"`
+	ServiceRevenue int64  `json:"service_revenue" gorm:"not null;default:0"`
+	RetailRevenue  int64  `json:"retail_revenue" gorm:"not null;default:0"`
+	Commission     int64  `json:"commission" gorm:"not null;default:0"`
+	Tips           int64  `json:"tips" gorm:"not null;default:0"`
+	// Deductions adalah total PayrollDeduction
+	Deductions     int64              `json:"deductions" gorm:"not null;default:0"`
+	NetPay         int64              `json:"net_pay" gorm:"not null;default:0"`
+	Notes          string             `json:"notes"`
+	DeductionItems []PayrollDeduction `json:"deduction_items" gorm:"foreignKey:EntryID"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// PayrollDeduction adalah satu potongan gaji, mis. kasbon atau BPJS
+type PayrollDeduction struct {
+	ID          uint   `json:"id" gorm:"primarykey"`
+	EntryID     uint   `json:"entry_id" gorm:"not null;index"`
+	Description string `json:"description" gorm:"not null"`
+	Amount      int64  `json:"amount" gorm:"not null"`
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status paket milik pelanggan
+const (
+	CustomerPackageStatusActive  = "active"
+	CustomerPackageStatusExpired = "expired"
+	CustomerPackageStatusVoided  = "voided"
+)
+
+// Status membership pelanggan
+const (
+	MembershipStatusActive = "active"
+	// MembershipStatusPastDue berarti periode sudah habis dan menunggu pembayaran perpanjangan; benefit ditahan
+	MembershipStatusPastDue   = "past_due"
+	MembershipStatusCancelled = "cancelled"
+	MembershipStatusExpired   = "expired"
+)
+
+// Status periode tagihan membership
+const (
+	MembershipPeriodPaid     = "paid"
+	MembershipPeriodRefunded = "refunded"
+)
+
+// Jenis mutasi pemakaian kredit prepaid
+const (
+	CreditUsageConsume = "consume"
+	CreditUsageRestore = "restore"
+)
+
+// Package adalah paket prepaid di katalog, mis. "10x blow dry", yang memberi kredit layanan
+type Package struct {
+	gorm.Model
+	SalonID uint   `json:"salon_id" gorm:"not null;index"`
+	Name    string `json:"name" gorm:"not null"`
+	Price   int64  `json:"price" gorm:"not null"`
+	// ValidityDays adalah masa berlaku kredit sejak dibeli; 0 berarti tidak kedaluwarsa
+	ValidityDays int           `json:"validity_days" gorm:"not null;default:0"`
+	TaxClassID   *uint         `json:"tax_class_id"`
+	IsActive     bool          `json:"is_active" gorm:"not null"`
+	Items        []PackageItem `json:"items"`
+}
+
+// PackageItem adalah jumlah kredit layanan yang diberikan sebuah paket
+type PackageItem struct {
+	ID        uint `json:"id" gorm:"primarykey"`
+	PackageID uint `json:"package_id" gorm:"not null;index"`
+	ServiceID uint `json:"service_id" gorm:"not null"`
+	Quantity  int  `json:"quantity" gorm:"not null"`
+}
+
+// MembershipPlan adalah paket langganan berulang, mis. "Unlimited cuci rambut bulanan"
+type MembershipPlan struct {
+	gorm.Model
+	SalonID uint   `json:"salon_id" gorm:"not null;index"`
+	Name    string `json:"name" gorm:"not null"`
+	// Price adalah harga per periode tagihan
+	Price        int64 `json:"price" gorm:"not null"`
+	PeriodMonths int   `json:"period_months" gorm:"not null;default:1"`
+	// GraceDays adalah lama status past_due sebelum membership dinyatakan expired
+	GraceDays  int                 `json:"grace_days" gorm:"not null;default:0"`
+	TaxClassID *uint               `json:"tax_class_id"`
+	IsActive   bool                `json:"is_active" gorm:"not null"`
+	Benefits   []MembershipBenefit `json:"benefits" gorm:"foreignKey:PlanID"`
+}
+
+// MembershipBenefit adalah layanan yang termasuk dalam membership per periode
+type MembershipBenefit struct {
+	ID        uint `json:"id" gorm:"primarykey"`
+	PlanID    uint `json:"plan_id" gorm:"not null;index"`
+	ServiceID uint `json:"service_id" gorm:"not null"`
+	// QuantityPerPeriod 0 berarti tanpa batas
+	QuantityPerPeriod int `json:"quantity_per_period" gorm:"not null;default:0"`
+}
+
+// CustomerPackage adalah paket yang sudah dibeli pelanggan beserta sisa kreditnya
+type CustomerPackage struct {
+	gorm.Model
+	SalonID    uint            `json:"salon_id" gorm:"not null;index"`
+	CustomerID uint            `json:"customer_id" gorm:"not null;index"`
+	PackageID  uint            `json:"package_id" gorm:"not null"`
+	Name       string          `json:"name" gorm:"not null"`
+	SaleID     *uint           `json:"sale_id" gorm:"index"`
+	SaleLineID *uint           `json:"sale_line_id" gorm:"index"`
+	Status     string          `json:"status" gorm:"not null;default:active;index"`
+	ExpiresAt  *time.Time      `json:"expires_at" gorm:"index"`
+	Credits    []PackageCredit `json:"credits"`
+}
+
+// PackageCredit adalah kredit satu layanan pada paket pelanggan
+type PackageCredit struct {
+	ID                uint `json:"id" gorm:"primarykey"`
+	CustomerPackageID uint `json:"customer_package_id" gorm:"not null;index"`
+	ServiceID         uint `json:"service_id" gorm:"not null;index"`
+	Quantity          int  `json:"quantity" gorm:"not null"`
+	Used              int  `json:"used" gorm:"not null;default:0"`
+}
+
+// Membership adalah langganan pelanggan pada sebuah plan. PaidThrough adalah akhir periode terakhir
+// yang sudah dibayar; perpanjangan dibayar lewat checkout POS.
+type Membership struct {
+	gorm.Model
+	SalonID     uint               `json:"salon_id" gorm:"not null;index"`
+	CustomerID  uint               `json:"customer_id" gorm:"not null;index"`
+	PlanID      uint               `json:"plan_id" gorm:"not null"`
+	Status      string             `json:"status" gorm:"not null;default:active;index"`
+	AutoRenew   bool               `json:"auto_renew" gorm:"not null"`
+	StartedAt   time.Time          `json:"started_at" gorm:"not null"`
+	PaidThrough time.Time          `json:"paid_through" gorm:"not null;index"`
+	CancelledAt *time.Time         `json:"cancelled_at"`
+	Plan        *MembershipPlan    `json:"plan,omitempty" gorm:"foreignKey:PlanID"`
+	Periods     []MembershipPeriod `json:"periods,omitempty"`
+}
+
+// MembershipPeriod adalah satu periode tagihan membership yang dibayar pada sebuah transaksi
+type MembershipPeriod struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	MembershipID uint      `json:"membership_id" gorm:"not null;index"`
+	SaleID       *uint     `json:"sale_id" gorm:"index"`
+	SaleLineID   *uint     `json:"sale_line_id" gorm:"index"`
+	StartsAt     time.Time `json:"starts_at" gorm:"not null"`
+	EndsAt       time.Time `json:"ends_at" gorm:"not null"`
+	Status       string    `json:"status" gorm:"not null;default:paid"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null"`
+}
+
+// CreditUsage mencatat pemakaian (dan pengembalian) kredit paket atau benefit membership pada
+// baris transaksi. Tabel ini append-only.
+type CreditUsage struct {
+	ID                 uint      `json:"id" gorm:"primarykey"`
+	CustomerID         uint      `json:"customer_id" gorm:"not null;index"`
+	SaleID             uint      `json:"sale_id" gorm:"not null;index"`
+	SaleLineID         uint      `json:"sale_line_id" gorm:"not null;index"`
+	ServiceID          uint      `json:"service_id" gorm:"not null"`
+	Type               string    `json:"type" gorm:"not null"`
+	Quantity           int       `json:"quantity" gorm:"not null"`
+	PackageCreditID    *uint     `json:"package_credit_id" gorm:"index"`
+	MembershipPeriodID *uint     `json:"membership_period_id" gorm:"index"`
+	CreatedAt          time.Time `json:"created_at" gorm:"not null"`
+}
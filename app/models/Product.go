@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis mutasi stok. Quantity mutasi bertanda: positif menambah stok, negatif mengurangi.
+const (
+	StockMovementPurchase   = "purchase"
+	StockMovementSale       = "sale"
+	StockMovementReturn     = "return"
+	StockMovementAdjustment = "adjustment"
+	// StockMovementTransfer dicatat berpasangan: keluar dari cabang asal dan masuk ke cabang tujuan
+	StockMovementTransfer = "transfer"
+	// StockMovementUsage adalah pemakaian produk untuk layanan (back-bar), bukan dijual
+	StockMovementUsage = "usage"
+)
+
+// Product adalah produk retail atau bahan salon (mis. shampoo, tube cat rambut). Quantity stok
+// dihitung dalam satuan Unit.
+type Product struct {
+	gorm.Model
+	SalonID  uint   `json:"salon_id" gorm:"not null;uniqueIndex:idx_products_sku,priority:1"`
+	SKU      string `json:"sku" gorm:"not null;uniqueIndex:idx_products_sku,priority:2"`
+	Barcode  string `json:"barcode" gorm:"index"`
+	Name     string `json:"name" gorm:"not null"`
+	Brand    string `json:"brand"`
+	Category string `json:"category" gorm:"index"`
+	Unit     string `json:"unit" gorm:"not null;default:pcs"`
+	// CostPrice adalah harga pokok per unit; RetailPrice adalah harga jual di kasir
+	CostPrice   int64 `json:"cost_price" gorm:"not null;default:0"`
+	RetailPrice int64 `json:"retail_price" gorm:"not null;default:0"`
+	// IsRetail bernilai false untuk bahan yang hanya dipakai salon dan tidak dijual di kasir
+	IsRetail bool `json:"is_retail" gorm:"not null"`
+	// LowStockThreshold adalah batas stok per cabang untuk laporan stok menipis; 0 berarti tidak dipantau
+	LowStockThreshold int64 `json:"low_stock_threshold" gorm:"not null;default:0"`
+	// TaxClassID kosong berarti memakai kelas pajak default salon
+	TaxClassID *uint `json:"tax_class_id"`
+	IsActive   bool  `json:"is_active" gorm:"not null"`
+}
+
+// StockLevel adalah stok satu produk di satu cabang. Nilainya hanya berubah bersama baris StockMovement.
+type StockLevel struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	SalonID   uint      `json:"salon_id" gorm:"not null;index"`
+	BranchID  uint      `json:"branch_id" gorm:"not null;uniqueIndex:idx_stock_levels_branch_product,priority:1"`
+	ProductID uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_stock_levels_branch_product,priority:2"`
+	Quantity  int64     `json:"quantity" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockMovement adalah ledger mutasi stok (append-only)
+type StockMovement struct {
+	ID        uint   `json:"id" gorm:"primarykey"`
+	SalonID   uint   `json:"salon_id" gorm:"not null;index"`
+	BranchID  uint   `json:"branch_id" gorm:"not null;index"`
+	ProductID uint   `json:"product_id" gorm:"not null;index"`
+	Type      string `json:"type" gorm:"not null;index"`
+	Quantity  int64  `json:"quantity" gorm:"not null"`
+	// QuantityAfter adalah stok cabang setelah mutasi ini
+	QuantityAfter int64 `json:"quantity_after" gorm:"not null"`
+	// UnitCost adalah harga pokok per unit saat mutasi, dasar nilai persediaan
+	UnitCost int64 `json:"unit_cost" gorm:"not null;default:0"`
+	// CounterpartBranchID adalah cabang asal/tujuan pada mutasi transfer
+	CounterpartBranchID *uint     `json:"counterpart_branch_id"`
+	SaleID              *uint     `json:"sale_id" gorm:"index"`
+	SaleLineID          *uint     `json:"sale_line_id"`
+	UserID              *uint     `json:"user_id"`
+	Note                string    `json:"note"`
+	CreatedAt           time.Time `json:"created_at" gorm:"not null;index"`
+	// PurchaseOrderID diisi pada mutasi purchase dari penerimaan purchase order
+	PurchaseOrderID *uint `json:"purchase_order_id,omitempty" gorm:"index"`
+}
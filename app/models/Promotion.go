@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis benefit promo
+const (
+	PromotionTypePercent = "percent"
+	PromotionTypeAmount  = "amount"
+	// PromotionTypeBuyXGetY memberi diskon ValueBps untuk GetQuantity unit termurah setiap BuyQuantity unit dibeli
+	PromotionTypeBuyXGetY = "buy_x_get_y"
+)
+
+// Segmen pelanggan yang dapat disyaratkan promo, dihitung dari riwayat transaksi pelanggan
+const (
+	SegmentReturning     = "returning"
+	SegmentLapsed        = "lapsed"
+	SegmentBirthdayMonth = "birthday_month"
+)
+
+// Promotion adalah kode promo beserta aturan kelayakan dan batas pemakaiannya.
+// Kondisi yang kosong/nol berarti tidak dibatasi.
+type Promotion struct {
+	gorm.Model
+	SalonID     uint   `json:"salon_id" gorm:"not null;uniqueIndex:idx_promotions_code,priority:1"`
+	Code        string `json:"code" gorm:"not null;uniqueIndex:idx_promotions_code,priority:2"`
+	Name        string `json:"name" gorm:"not null"`
+	Description string `json:"description"`
+	IsActive    bool   `json:"is_active" gorm:"not null"`
+
+	// Benefit
+	Type        string `json:"type" gorm:"not null"`
+	ValueBps    int    `json:"value_bps" gorm:"not null;default:0"`
+	ValueAmount int64  `json:"value_amount" gorm:"not null;default:0"`
+	BuyQuantity int    `json:"buy_quantity" gorm:"not null;default:0"`
+	GetQuantity int    `json:"get_quantity" gorm:"not null;default:0"`
+
+	// Kondisi
+	Category string     `json:"category"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	// DaysOfWeek berisi hari berlaku dipisah koma (0 = Minggu ... 6 = Sabtu)
+	DaysOfWeek string `json:"days_of_week"`
+	// StartTime dan EndTime adalah jam berlaku HH:MM menurut zona waktu salon
+	StartTime       string `json:"start_time"`
+	EndTime         string `json:"end_time"`
+	MinSpend        int64  `json:"min_spend" gorm:"not null;default:0"`
+	FirstVisitOnly  bool   `json:"first_visit_only" gorm:"not null;default:false"`
+	CustomerSegment string `json:"customer_segment"`
+
+	// Batas pemakaian (0 = tanpa batas); transaksi yang di-void tidak dihitung
+	MaxUses            int `json:"max_uses" gorm:"not null;default:0"`
+	MaxUsesPerCustomer int `json:"max_uses_per_customer" gorm:"not null;default:0"`
+	// Stackable false berarti promo tidak dapat digabung dengan promo lain di transaksi yang sama
+	Stackable bool `json:"stackable" gorm:"not null;default:false"`
+}
+
+// PromotionRedemption mencatat pemakaian promo pada sebuah transaksi
+type PromotionRedemption struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	PromotionID uint      `json:"promotion_id" gorm:"not null;index"`
+	SaleID      uint      `json:"sale_id" gorm:"not null;index"`
+	CustomerID  *uint     `json:"customer_id" gorm:"index"`
+	Amount      int64     `json:"amount" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status purchase order
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+	PurchaseOrderCancelled         = "cancelled"
+)
+
+// Supplier adalah distributor tempat salon membeli produk
+type Supplier struct {
+	gorm.Model
+	SalonID     uint   `json:"salon_id" gorm:"not null;index"`
+	Name        string `json:"name" gorm:"not null"`
+	ContactName string `json:"contact_name"`
+	// Phone dipakai untuk mengirim PO lewat WhatsApp
+	Phone    string `json:"phone"`
+	Email    string `json:"email"`
+	Address  string `json:"address"`
+	Notes    string `json:"notes"`
+	IsActive bool   `json:"is_active" gorm:"not null"`
+}
+
+// PurchaseOrder adalah pesanan pembelian ke supplier untuk satu cabang
+type PurchaseOrder struct {
+	gorm.Model
+	SalonID     uint   `json:"salon_id" gorm:"not null;uniqueIndex:idx_purchase_orders_number,priority:1"`
+	Seq         int64  `json:"seq" gorm:"not null;uniqueIndex:idx_purchase_orders_number,priority:2"`
+	Number      string `json:"number" gorm:"not null"`
+	SupplierID  uint   `json:"supplier_id" gorm:"not null;index"`
+	BranchID    uint   `json:"branch_id" gorm:"not null;index"`
+	Status      string `json:"status" gorm:"not null;default:draft;index"`
+	CreatedByID uint   `json:"created_by_id" gorm:"not null"`
+	// Total adalah nilai pesanan (quantity x unit_cost seluruh baris)
+	Total      int64               `json:"total" gorm:"not null;default:0"`
+	Notes      string              `json:"notes"`
+	ExpectedAt *time.Time          `json:"expected_at"`
+	SentAt     *time.Time          `json:"sent_at"`
+	ReceivedAt *time.Time          `json:"received_at"`
+	Supplier   *Supplier           `json:"supplier,omitempty"`
+	Lines      []PurchaseOrderLine `json:"lines"`
+	Receipts   []GoodsReceipt      `json:"receipts,omitempty"`
+}
+
+// PurchaseOrderLine adalah satu produk yang dipesan
+type PurchaseOrderLine struct {
+	ID               uint  `json:"id" gorm:"primarykey"`
+	PurchaseOrderID  uint  `json:"purchase_order_id" gorm:"not null;index"`
+	ProductID        uint  `json:"product_id" gorm:"not null;index"`
+	Quantity         int64 `json:"quantity" gorm:"not null"`
+	ReceivedQuantity int64 `json:"received_quantity" gorm:"not null;default:0"`
+	// UnitCost adalah harga beli per unit yang disepakati
+	UnitCost int64 `json:"unit_cost" gorm:"not null"`
+}
+
+// GoodsReceipt mencatat satu kali penerimaan barang dari purchase order
+type GoodsReceipt struct {
+	ID              uint               `json:"id" gorm:"primarykey"`
+	PurchaseOrderID uint               `json:"purchase_order_id" gorm:"not null;index"`
+	ReceivedByID    uint               `json:"received_by_id" gorm:"not null"`
+	Note            string             `json:"note"`
+	ReceivedAt      time.Time          `json:"received_at" gorm:"not null"`
+	Lines           []GoodsReceiptLine `json:"lines"`
+}
+
+// GoodsReceiptLine adalah jumlah barang yang diterima untuk satu baris purchase order
+type GoodsReceiptLine struct {
+	ID                  uint  `json:"id" gorm:"primarykey"`
+	GoodsReceiptID      uint  `json:"goods_receipt_id" gorm:"not null;index"`
+	PurchaseOrderLineID uint  `json:"purchase_order_line_id" gorm:"not null;index"`
+	ProductID           uint  `json:"product_id" gorm:"not null"`
+	Quantity            int64 `json:"quantity" gorm:"not null"`
+	// UnitCost adalah harga beli aktual (boleh berbeda dari harga di PO)
+	UnitCost int64 `json:"unit_cost" gorm:"not null"`
+}
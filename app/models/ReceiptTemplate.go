@@ -0,0 +1,26 @@
+package models
+
+import "gorm.io/gorm"
+
+// Ukuran kertas struk
+const (
+	ReceiptPaperA4      = "a4"
+	ReceiptPaperThermal = "80mm"
+)
+
+// ReceiptTemplate menyimpan pengaturan cetak struk/invoice sebuah salon
+type ReceiptTemplate struct {
+	gorm.Model
+	SalonID uint `json:"salon_id" gorm:"not null;uniqueIndex"`
+	// Paper adalah ukuran kertas default: a4 atau 80mm
+	Paper  string `json:"paper" gorm:"not null;default:80mm"`
+	Header string `json:"header"`
+	Footer string `json:"footer"`
+	// LogoData adalah logo dalam format JPEG (PNG dikonversi saat upload)
+	LogoData     []byte `json:"-"`
+	HasLogo      bool   `json:"has_logo" gorm:"not null;default:false"`
+	LogoWidth    int    `json:"logo_width"`
+	LogoHeight   int    `json:"logo_height"`
+	EmailSubject string `json:"email_subject"`
+	EmailBody    string `json:"email_body"`
+}
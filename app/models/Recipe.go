@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ServiceRecipe adalah perkiraan pemakaian satu produk (back-bar) untuk satu kali layanan, dalam satuan produk
+type ServiceRecipe struct {
+	ID        uint  `json:"id" gorm:"primarykey"`
+	SalonID   uint  `json:"salon_id" gorm:"not null;index"`
+	ServiceID uint  `json:"service_id" gorm:"not null;uniqueIndex:idx_service_recipes_product,priority:1"`
+	ProductID uint  `json:"product_id" gorm:"not null;uniqueIndex:idx_service_recipes_product,priority:2"`
+	Quantity  int64 `json:"quantity" gorm:"not null"`
+}
+
+// ServiceUsage adalah pemakaian produk pada satu baris layanan yang sudah dikerjakan. ExpectedQuantity
+// berasal dari resep; ActualQuantity awalnya sama dengan perkiraan lalu dikoreksi staff sesuai pemakaian nyata.
+type ServiceUsage struct {
+	ID               uint      `json:"id" gorm:"primarykey"`
+	SalonID          uint      `json:"salon_id" gorm:"not null;index"`
+	BranchID         uint      `json:"branch_id" gorm:"not null"`
+	SaleID           uint      `json:"sale_id" gorm:"not null;index"`
+	SaleLineID       uint      `json:"sale_line_id" gorm:"not null;uniqueIndex:idx_service_usages_line_product,priority:1"`
+	ProductID        uint      `json:"product_id" gorm:"not null;uniqueIndex:idx_service_usages_line_product,priority:2"`
+	ServiceID        uint      `json:"service_id" gorm:"not null;index"`
+	StaffID          *uint     `json:"staff_id" gorm:"index"`
+	ExpectedQuantity int64     `json:"expected_quantity" gorm:"not null"`
+	ActualQuantity   int64     `json:"actual_quantity" gorm:"not null"`
+	UpdatedByID      *uint     `json:"updated_by_id"`
+	CreatedAt        time.Time `json:"created_at" gorm:"not null;index"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
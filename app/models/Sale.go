@@ -0,0 +1,131 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status penjualan
+const (
+	SaleStatusCompleted         = "completed"
+	SaleStatusPartiallyRefunded = "partially_refunded"
+	SaleStatusRefunded          = "refunded"
+	SaleStatusVoided            = "voided"
+)
+
+// Jenis baris penjualan
+const (
+	SaleLineService  = "service"
+	SaleLineProduct  = "product"
+	SaleLineDiscount = "discount"
+	SaleLineTip      = "tip"
+	// SaleLineServiceCharge adalah service charge salon yang dihitung dari nilai item
+	SaleLineServiceCharge = "service_charge"
+	// SaleLineGiftCard adalah penjualan gift card; tidak dikenai pajak, diskon maupun service charge
+	SaleLineGiftCard = "gift_card"
+	// SaleLinePackage dan SaleLineMembership adalah penjualan paket prepaid dan periode membership
+	SaleLinePackage    = "package"
+	SaleLineMembership = "membership"
+)
+
+// Metode pembayaran (tender)
+const (
+	TenderCash     = "cash"
+	TenderCard     = "card"
+	TenderQRIS     = "qris"
+	TenderTransfer = "transfer"
+	// Tender elektronik lewat payment provider memakai nama metode yang sama dengan Payment
+	TenderVirtualAccount = PaymentMethodVirtualAccount
+	TenderGiftCard       = "gift_card"
+)
+
+// Sale merepresentasikan satu transaksi kasir (POS). Semua nominal uang disimpan sebagai
+// bilangan bulat dalam satuan terkecil mata uang (Rupiah) untuk menghindari galat pembulatan.
+type Sale struct {
+	gorm.Model
+	SalonID       uint   `json:"salon_id" gorm:"not null;uniqueIndex:idx_sales_receipt,priority:1"`
+	ReceiptSeq    int64  `json:"receipt_seq" gorm:"not null;uniqueIndex:idx_sales_receipt,priority:2"`
+	ReceiptNumber string `json:"receipt_number" gorm:"not null"`
+	CustomerID    *uint  `json:"customer_id" gorm:"index"`
+	BookingID     *uint  `json:"booking_id" gorm:"index"`
+	CashierID     uint   `json:"cashier_id" gorm:"not null"`
+	ShiftID       *uint  `json:"shift_id" gorm:"index"`
+	Status        string `json:"status" gorm:"not null;default:completed;index"`
+	Subtotal      int64  `json:"subtotal" gorm:"not null"`
+	DiscountTotal int64  `json:"discount_total" gorm:"not null"`
+	// ServiceChargeTotal adalah service charge sebelum pajak
+	ServiceChargeTotal int64 `json:"service_charge_total" gorm:"not null;default:0"`
+	TaxTotal           int64 `json:"tax_total" gorm:"not null"`
+	// PricesIncludeTax menandakan TaxTotal sudah termasuk di dalam harga item
+	PricesIncludeTax bool         `json:"prices_include_tax" gorm:"not null;default:false"`
+	TipTotal         int64        `json:"tip_total" gorm:"not null"`
+	Total            int64        `json:"total" gorm:"not null"`
+	PaidTotal        int64        `json:"paid_total" gorm:"not null"`
+	ChangeDue        int64        `json:"change_due" gorm:"not null"`
+	RefundedTotal    int64        `json:"refunded_total" gorm:"not null;default:0"`
+	Notes            string       `json:"notes"`
+	CompletedAt      time.Time    `json:"completed_at" gorm:"not null;index"`
+	Lines            []SaleLine   `json:"lines"`
+	Tenders          []SaleTender `json:"tenders"`
+	Refunds          []SaleRefund `json:"refunds,omitempty"`
+	// Poin loyalty yang diperoleh dan ditukar pada transaksi ini
+	LoyaltyPointsEarned   int64 `json:"loyalty_points_earned" gorm:"not null;default:0"`
+	LoyaltyPointsRedeemed int64 `json:"loyalty_points_redeemed" gorm:"not null;default:0"`
+	// BranchID adalah cabang tempat transaksi terjadi; stok produk dikurangi dari cabang ini
+	BranchID *uint `json:"branch_id" gorm:"index"`
+}
+
+// SaleLine adalah baris penjualan: layanan, produk retail, diskon atau tip
+type SaleLine struct {
+	gorm.Model
+	SaleID    uint   `json:"sale_id" gorm:"not null;index"`
+	Type      string `json:"type" gorm:"not null"`
+	ServiceID *uint  `json:"service_id"`
+	ProductID *uint  `json:"product_id"`
+	StaffID   *uint  `json:"staff_id" gorm:"index"`
+	// Description menyimpan nama item saat transaksi agar struk tidak berubah jika katalog diubah
+	Description       string `json:"description" gorm:"not null"`
+	Quantity          int    `json:"quantity" gorm:"not null;default:1"`
+	UnitPrice         int64  `json:"unit_price" gorm:"not null"`
+	Gross             int64  `json:"gross" gorm:"not null"`
+	DiscountAmount    int64  `json:"discount_amount" gorm:"not null"`
+	AllocatedDiscount int64  `json:"allocated_discount" gorm:"not null"`
+	NetAmount         int64  `json:"net_amount" gorm:"not null"`
+	// TaxableAmount adalah dasar pengenaan pajak (nilai baris tanpa pajak)
+	TaxableAmount    int64         `json:"taxable_amount" gorm:"not null;default:0"`
+	TaxAmount        int64         `json:"tax_amount" gorm:"not null"`
+	LineTotal        int64         `json:"line_total" gorm:"not null"`
+	RefundedQuantity int           `json:"refunded_quantity" gorm:"not null;default:0"`
+	RefundedAmount   int64         `json:"refunded_amount" gorm:"not null;default:0"`
+	Taxes            []SaleLineTax `json:"taxes,omitempty"`
+	// PromotionID diisi pada baris diskon yang berasal dari kode promo
+	PromotionID *uint `json:"promotion_id,omitempty" gorm:"index"`
+	// PackageID dan MembershipPlanID diisi pada baris penjualan paket/membership
+	PackageID        *uint `json:"package_id,omitempty"`
+	MembershipPlanID *uint `json:"membership_plan_id,omitempty"`
+	// PrepaidCredit bernilai true jika layanan dibayar dengan kredit paket atau benefit membership
+	PrepaidCredit bool `json:"prepaid_credit" gorm:"not null;default:false"`
+}
+
+// SaleTender adalah satu pembayaran untuk sebuah penjualan (satu sale boleh dibayar dengan beberapa metode)
+type SaleTender struct {
+	gorm.Model
+	SaleID    uint   `json:"sale_id" gorm:"not null;index"`
+	Method    string `json:"method" gorm:"not null;index"`
+	Amount    int64  `json:"amount" gorm:"not null"`
+	Reference string `json:"reference"`
+	// PaymentID diisi jika tender dibayar lewat payment provider
+	PaymentID *uint `json:"payment_id" gorm:"index"`
+	// GiftCardID diisi jika dibayar dengan gift card
+	GiftCardID     *uint `json:"gift_card_id" gorm:"index"`
+	RefundedAmount int64 `json:"refunded_amount" gorm:"not null;default:0"`
+}
+
+// SalonSequence menyimpan nomor urut per salon (mis. nomor struk). Nilai dinaikkan di dalam
+// transaksi yang sama dengan dokumen yang memakainya sehingga nomor tidak pernah bolong.
+type SalonSequence struct {
+	SalonID   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"primaryKey"`
+	LastValue int64  `gorm:"not null"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis pembalikan transaksi
+const (
+	RefundTypeRefund = "refund"
+	RefundTypeVoid   = "void"
+)
+
+// SaleRefund mencatat pengembalian dana (penuh/sebagian) atau void atas sebuah sale.
+// Sale aslinya tidak diubah selain status dan akumulasi refund, sehingga jejaknya tetap utuh.
+type SaleRefund struct {
+	gorm.Model
+	SalonID uint   `json:"salon_id" gorm:"not null;index"`
+	SaleID  uint   `json:"sale_id" gorm:"not null;index"`
+	Type    string `json:"type" gorm:"not null"`
+	Reason  string `json:"reason" gorm:"not null"`
+	// Amount adalah total yang dikembalikan ke pelanggan (termasuk pajak), TaxAmount bagian pajaknya
+	Amount        int64              `json:"amount" gorm:"not null"`
+	TaxAmount     int64              `json:"tax_amount" gorm:"not null"`
+	RequestedByID uint               `json:"requested_by_id" gorm:"not null"`
+	ApprovedByID  uint               `json:"approved_by_id" gorm:"not null"`
+	ShiftID       *uint              `json:"shift_id" gorm:"index"`
+	RefundedAt    time.Time          `json:"refunded_at" gorm:"not null;index"`
+	Lines         []SaleRefundLine   `json:"lines" gorm:"foreignKey:RefundID"`
+	Tenders       []SaleRefundTender `json:"tenders" gorm:"foreignKey:RefundID"`
+}
+
+// SaleRefundLine adalah jumlah item dari satu baris sale yang dikembalikan
+type SaleRefundLine struct {
+	gorm.Model
+	RefundID   uint  `json:"refund_id" gorm:"not null;index"`
+	SaleLineID uint  `json:"sale_line_id" gorm:"not null;index"`
+	Quantity   int   `json:"quantity" gorm:"not null"`
+	Amount     int64 `json:"amount" gorm:"not null"`
+	TaxAmount  int64 `json:"tax_amount" gorm:"not null"`
+	// Restock bernilai true untuk produk retail yang dikembalikan ke stok
+	Restock bool `json:"restock" gorm:"not null;default:false"`
+}
+
+// SaleRefundTender adalah uang yang dikembalikan lewat salah satu tender asli sale
+type SaleRefundTender struct {
+	gorm.Model
+	RefundID     uint   `json:"refund_id" gorm:"not null;index"`
+	SaleTenderID uint   `json:"sale_tender_id" gorm:"not null;index"`
+	Method       string `json:"method" gorm:"not null;index"`
+	Amount       int64  `json:"amount" gorm:"not null"`
+	PaymentID    *uint  `json:"payment_id"`
+	// PaymentRefundID menunjuk refund di payment provider; ProviderRefundRef diisi setelah provider berhasil
+	PaymentRefundID   *uint  `json:"payment_refund_id" gorm:"index"`
+	ProviderRefundRef string `json:"provider_refund_ref"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "gorm.io/gorm"
+
+// Salon merepresentasikan satu tenant (bisnis salon) pada aplikasi
+type Salon struct {
+	gorm.Model
+	Name    string `json:"name" gorm:"not null"`
+	Address string `json:"address"`
+	Phone   string `json:"phone"`
+	OwnerID uint   `json:"owner_id" gorm:"not null;index"`
+	// NPWP salon dicetak di struk dan invoice
+	NPWP string `json:"npwp"`
+	// PricesIncludeTax menandakan harga layanan/produk sudah termasuk pajak
+	PricesIncludeTax bool `json:"prices_include_tax" gorm:"not null;default:false"`
+	// ServiceChargeBps adalah service charge dalam basis poin (500 = 5%) dari nilai item setelah diskon
+	ServiceChargeBps int `json:"service_charge_bps" gorm:"not null;default:0"`
+	// ServiceChargeTaxClassID adalah kelas pajak untuk service charge; kosong berarti tidak dikenai pajak
+	ServiceChargeTaxClassID *uint `json:"service_charge_tax_class_id"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status dan pemicu eksekusi task terjadwal
+const (
+	TaskRunStatusRunning   = "running"
+	TaskRunStatusSucceeded = "succeeded"
+	TaskRunStatusFailed    = "failed"
+
+	TaskRunTriggerSchedule = "schedule"
+	TaskRunTriggerManual   = "manual"
+)
+
+// ScheduledTaskRun mencatat riwayat setiap eksekusi task terjadwal (cron)
+type ScheduledTaskRun struct {
+	gorm.Model
+	TaskName string `json:"task_name" gorm:"not null;uniqueIndex:idx_task_runs_slot"`
+	// ScheduledFor adalah menit jadwal yang dieksekusi; nil untuk eksekusi manual.
+	// Unique index mencegah slot yang sama dijalankan dua kali oleh replika berbeda.
+	ScheduledFor *time.Time `json:"scheduled_for" gorm:"uniqueIndex:idx_task_runs_slot"`
+	Trigger      string     `json:"trigger" gorm:"not null"`
+	TriggeredBy  *uint      `json:"triggered_by"`
+	Status       string     `json:"status" gorm:"not null;index"`
+	Host         string     `json:"host"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	DurationMs   int64      `json:"duration_ms"`
+	Error        string     `json:"error" gorm:"type:text"`
+}
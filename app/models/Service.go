@@ -0,0 +1,16 @@
+package models
+
+import "gorm.io/gorm"
+
+// Service merepresentasikan layanan salon yang dapat dipesan (potong rambut, coloring, dll.)
+type Service struct {
+	gorm.Model
+	SalonID         uint   `json:"salon_id" gorm:"not null;index"`
+	Name            string `json:"name" gorm:"not null"`
+	Category        string `json:"category" gorm:"index"`
+	DurationMinutes int    `json:"duration_minutes" gorm:"not null"`
+	Price           int64  `json:"price" gorm:"not null;default:0"`
+	IsActive        bool   `json:"is_active" gorm:"not null;default:true"`
+	// TaxClassID kosong berarti memakai kelas pajak default salon
+	TaxClassID *uint `json:"tax_class_id"`
+}
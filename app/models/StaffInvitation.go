@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status undangan staff
+const (
+	StaffInvitationPending  = "pending"
+	StaffInvitationAccepted = "accepted"
+	StaffInvitationDeclined = "declined"
+)
+
+// StaffInvitation adalah undangan bagi user terdaftar untuk bergabung ke salon. User baru terhubung
+// ke salon setelah menerima undangan sendiri.
+type StaffInvitation struct {
+	gorm.Model
+	SalonID     uint       `json:"salon_id" gorm:"not null;index"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Role        string     `json:"role" gorm:"not null"`
+	InvitedByID uint       `json:"invited_by_id" gorm:"not null"`
+	Status      string     `json:"status" gorm:"not null;default:pending;index"`
+	RespondedAt *time.Time `json:"responded_at"`
+	Salon       *Salon     `json:"salon,omitempty"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaxClass mengelompokkan item yang dikenai pajak yang sama (mis. "standard" untuk PPN,
+// "exempt" untuk item bebas pajak). Item tanpa kelas memakai kelas default salon.
+type TaxClass struct {
+	gorm.Model
+	SalonID   uint      `json:"salon_id" gorm:"not null;uniqueIndex:idx_tax_classes_code,priority:1"`
+	Code      string    `json:"code" gorm:"not null;uniqueIndex:idx_tax_classes_code,priority:2"`
+	Name      string    `json:"name" gorm:"not null"`
+	IsDefault bool      `json:"is_default" gorm:"not null;default:false"`
+	Rates     []TaxRate `json:"rates,omitempty"`
+}
+
+// TaxRate adalah tarif pajak sebuah kelas yang berlaku pada rentang tanggal tertentu.
+// Perubahan tarif (mis. PPN 11% menjadi 12%) dicatat sebagai baris baru, bukan mengubah baris lama,
+// sehingga transaksi lama tetap bisa dihitung ulang dengan tarif yang berlaku saat itu.
+type TaxRate struct {
+	gorm.Model
+	TaxClassID    uint       `json:"tax_class_id" gorm:"not null;index"`
+	Name          string     `json:"name" gorm:"not null"`
+	RateBps       int        `json:"rate_bps" gorm:"not null"`
+	EffectiveFrom time.Time  `json:"effective_from" gorm:"not null"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+}
+
+// SaleLineTax adalah rincian pajak per baris penjualan
+type SaleLineTax struct {
+	gorm.Model
+	SaleLineID    uint   `json:"sale_line_id" gorm:"not null;index"`
+	TaxRateID     uint   `json:"tax_rate_id" gorm:"not null"`
+	Name          string `json:"name" gorm:"not null"`
+	RateBps       int    `json:"rate_bps" gorm:"not null"`
+	TaxableAmount int64  `json:"taxable_amount" gorm:"not null"`
+	Amount        int64  `json:"amount" gorm:"not null"`
+}
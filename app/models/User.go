@@ -5,12 +5,31 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Role user di dalam salon
+const (
+	RoleOwner   = "owner"
+	RoleManager = "manager"
+	// RoleBranchManager mengelola operasional cabang yang ditugaskan kepadanya saja
+	RoleBranchManager = "branch_manager"
+	RoleStaff         = "staff"
+)
+
 // User merepresentasikan model data user
 type User struct {
 	gorm.Model
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" gorm:"unique" binding:"required,email"`
 	Password string `json:"-" gorm:"not null" binding:"required,min=6"`
+	SalonID  *uint  `json:"salon_id" gorm:"index"`
+	Role     string `json:"role" gorm:"not null;default:staff"`
+	// IsAdmin memberi akses ke endpoint admin platform; hanya diubah lewat perintah `admin grant|revoke`
+	IsAdmin bool `json:"-" gorm:"not null;default:false"`
+	// CommissionPlanID adalah skema komisi staff; kosong berarti tidak mendapat komisi
+	CommissionPlanID *uint `json:"commission_plan_id"`
+	// BaseSalary adalah gaji pokok per periode payroll
+	BaseSalary int64 `json:"base_salary" gorm:"not null;default:0"`
+	// AttendanceDeviceID adalah perangkat terdaftar untuk absensi; kosong berarti absen dari perangkat mana pun
+	AttendanceDeviceID string `json:"attendance_device_id"`
 }
 
 // HashPassword mengenkripsi password sebelum disimpan
@@ -23,6 +42,16 @@ func (u *User) HashPassword() error {
 	return nil
 }
 
+// IsManager mengecek apakah user boleh mengelola operasional salon (owner, manager atau branch manager)
+func (u *User) IsManager() bool {
+	return u.IsBrandManager() || u.Role == RoleBranchManager
+}
+
+// IsBrandManager mengecek apakah user boleh mengelola seluruh cabang dan pengaturan brand (owner atau manager)
+func (u *User) IsBrandManager() bool {
+	return u.Role == RoleOwner || u.Role == RoleManager
+}
+
 // CheckPassword memverifikasi password
 func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status entri waitlist
+const (
+	WaitlistStatusWaiting   = "waiting"
+	WaitlistStatusOffered   = "offered"
+	WaitlistStatusBooked    = "booked"
+	WaitlistStatusCancelled = "cancelled"
+)
+
+// Status penawaran slot dari waitlist
+const (
+	WaitlistOfferPending  = "pending"
+	WaitlistOfferAccepted = "accepted"
+	WaitlistOfferDeclined = "declined"
+	WaitlistOfferExpired  = "expired"
+)
+
+// WaitlistEntry adalah pendaftaran pelanggan yang menunggu slot kosong pada rentang waktu tertentu.
+// Urutan antrian ditentukan oleh CreatedAt.
+type WaitlistEntry struct {
+	gorm.Model
+	SalonID    uint `json:"salon_id" gorm:"not null;index"`
+	CustomerID uint `json:"customer_id" gorm:"not null;index"`
+	ServiceID  uint `json:"service_id" gorm:"not null"`
+	// BranchID adalah cabang yang diinginkan; hanya slot kosong di cabang ini yang ditawarkan
+	BranchID    *uint     `json:"branch_id" gorm:"index"`
+	StaffID     *uint     `json:"staff_id"` // nil berarti staff mana saja
+	WindowStart time.Time `json:"window_start" gorm:"not null"`
+	WindowEnd   time.Time `json:"window_end" gorm:"not null"`
+	Status      string    `json:"status" gorm:"not null;default:waiting;index"`
+	BookingID   *uint     `json:"booking_id"`
+	Notes       string    `json:"notes"`
+	Customer    *Customer `json:"customer,omitempty"`
+	Service     *Service  `json:"service,omitempty"`
+}
+
+// WaitlistOffer adalah slot yang ditahan untuk satu entri waitlist sampai ExpiresAt
+type WaitlistOffer struct {
+	gorm.Model
+	SalonID         uint `json:"salon_id" gorm:"not null;index"`
+	EntryID         uint `json:"entry_id" gorm:"not null;index"`
+	SourceBookingID uint `json:"source_booking_id" gorm:"not null;index"`
+	// BranchID mengikuti cabang booking sumber
+	BranchID    *uint          `json:"branch_id" gorm:"index"`
+	StaffID     uint           `json:"staff_id" gorm:"not null"`
+	StartAt     time.Time      `json:"start_at" gorm:"not null"`
+	EndAt       time.Time      `json:"end_at" gorm:"not null"`
+	ExpiresAt   time.Time      `json:"expires_at" gorm:"not null;index"`
+	Status      string         `json:"status" gorm:"not null;default:pending;index"`
+	RespondedAt *time.Time     `json:"responded_at"`
+	Entry       *WaitlistEntry `json:"entry,omitempty" gorm:"foreignKey:EntryID"`
+}
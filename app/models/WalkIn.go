@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status walk-in pada antrian front desk
+const (
+	WalkInStatusWaiting   = "waiting"
+	WalkInStatusAssigned  = "assigned"
+	WalkInStatusInService = "in_service"
+	WalkInStatusCompleted = "completed"
+	WalkInStatusAbandoned = "abandoned"
+)
+
+// WalkIn adalah pelanggan tanpa booking yang menunggu di antrian salon
+type WalkIn struct {
+	gorm.Model
+	SalonID uint `json:"salon_id" gorm:"not null;index:idx_walk_ins_salon_status,priority:1"`
+	// BranchID adalah cabang tempat walk-in mengantri; nomor tiket berurutan per cabang per hari
+	BranchID         *uint      `json:"branch_id" gorm:"index"`
+	TicketNumber     int        `json:"ticket_number" gorm:"not null"`
+	CustomerID       *uint      `json:"customer_id" gorm:"index"`
+	CustomerName     string     `json:"customer_name" gorm:"not null"`
+	Phone            string     `json:"phone"`
+	ServiceID        uint       `json:"service_id" gorm:"not null"`
+	PreferredStaffID *uint      `json:"preferred_staff_id"`
+	StaffID          *uint      `json:"staff_id" gorm:"index"`
+	Status           string     `json:"status" gorm:"not null;default:waiting;index:idx_walk_ins_salon_status,priority:2"`
+	CheckedInAt      time.Time  `json:"checked_in_at" gorm:"not null"`
+	AssignedAt       *time.Time `json:"assigned_at"`
+	StartedAt        *time.Time `json:"started_at"`
+	FinishedAt       *time.Time `json:"finished_at"`
+	AbandonedAt      *time.Time `json:"abandoned_at"`
+	Service          *Service   `json:"service,omitempty"`
+}
@@ -0,0 +1,158 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-sass-salon/app/models"
+)
+
+// Skenario yang disimulasikan provider fake (dipilih lewat Metadata["scenario"])
+const (
+	FakeScenarioSuccess = "success"
+	FakeScenarioFailure = "failure"
+	FakeScenarioDelayed = "delayed"
+)
+
+// FakeSignatureHeader adalah header berisi HMAC-SHA256 hex dari body webhook provider fake
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeProvider adalah provider lokal untuk development dan pengujian. Tidak ada state di memori:
+// skenario dan waktu pembuatan disimpan di dalam provider ref sehingga hasilnya konsisten
+// antara server API dan worker.
+type FakeProvider struct {
+	Secret string
+	// SettleAfter adalah lama tagihan skenario "delayed" tetap pending sebelum berhasil
+	SettleAfter time.Duration
+}
+
+// NewFakeProvider membuat provider fake dengan secret webhook dan jeda settlement
+func NewFakeProvider(secret string, settleAfter time.Duration) *FakeProvider {
+	return &FakeProvider{Secret: secret, SettleAfter: settleAfter}
+}
+
+// Name mengembalikan nama provider
+func (f *FakeProvider) Name() string {
+	return "fake"
+}
+
+// CreateCharge membuat tagihan palsu sesuai skenario
+func (f *FakeProvider) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	scenario := req.Metadata["scenario"]
+	if scenario == "" {
+		scenario = FakeScenarioSuccess
+	}
+	if scenario != FakeScenarioSuccess && scenario != FakeScenarioFailure && scenario != FakeScenarioDelayed {
+		return nil, fmt.Errorf("skenario fake tidak dikenal: %s", scenario)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	ref := fmt.Sprintf("fake_%s_%d_%s", scenario, time.Now().Unix(), hex.EncodeToString(suffix))
+
+	charge, err := f.GetCharge(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := time.Now().Add(15 * time.Minute)
+	charge.ExpiresAt = &expires
+	switch req.Method {
+	case models.PaymentMethodQRIS:
+		charge.QRString = "00020101021226570011ID.FAKE.WWW" + ref
+	case models.PaymentMethodVirtualAccount:
+		charge.VANumber = "8808" + strconv.FormatInt(time.Now().UnixNano()%1e10, 10)
+	case models.PaymentMethodCard:
+		charge.RedirectURL = "https://fake-payments.local/pay/" + ref
+	}
+	return charge, nil
+}
+
+// GetCharge menghitung status tagihan dari skenario dan umur provider ref
+func (f *FakeProvider) GetCharge(ctx context.Context, providerRef string) (*Charge, error) {
+	scenario, createdAt, err := parseFakeRef(providerRef)
+	if err != nil {
+		return nil, err
+	}
+
+	charge := &Charge{ProviderRef: providerRef}
+	switch scenario {
+	case FakeScenarioSuccess:
+		charge.Status = models.PaymentStatusSucceeded
+	case FakeScenarioFailure:
+		charge.Status = models.PaymentStatusFailed
+		charge.FailureReason = "Simulasi: pembayaran ditolak issuer"
+	case FakeScenarioDelayed:
+		charge.Status = models.PaymentStatusPending
+		if time.Since(createdAt) >= f.SettleAfter {
+			charge.Status = models.PaymentStatusSucceeded
+		}
+	}
+	return charge, nil
+}
+
+// Refund selalu berhasil untuk tagihan yang sudah dibayar. Ref refund diturunkan dari idempotency key
+// sehingga permintaan ulang menghasilkan refund yang sama.
+func (f *FakeProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	charge, err := f.GetCharge(ctx, req.ProviderRef)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Status != models.PaymentStatusSucceeded {
+		return nil, fmt.Errorf("%w: tagihan belum dibayar", ErrRefundRejected)
+	}
+	return &RefundResult{RefundRef: "fake_refund_" + req.IdempotencyKey, Amount: req.Amount}, nil
+}
+
+// ParseWebhook memverifikasi header X-Fake-Signature lalu mengurai body JSON
+func (f *FakeProvider) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	signature, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil || !hmac.Equal(signature, f.sign(body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("body webhook tidak valid: %w", err)
+	}
+	if event.EventID == "" || event.ProviderRef == "" {
+		return nil, errors.New("event_id dan provider_ref wajib diisi")
+	}
+	return &event, nil
+}
+
+// Sign mengembalikan signature hex untuk body webhook (dipakai untuk mensimulasikan webhook)
+func (f *FakeProvider) Sign(body []byte) string {
+	return hex.EncodeToString(f.sign(body))
+}
+
+func (f *FakeProvider) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(f.Secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// parseFakeRef mengurai ref berformat fake_<scenario>_<unix>_<acak>
+func parseFakeRef(ref string) (string, time.Time, error) {
+	parts := strings.Split(ref, "_")
+	if len(parts) != 4 || parts[0] != "fake" {
+		return "", time.Time{}, fmt.Errorf("provider ref fake tidak valid: %s", ref)
+	}
+	unix, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("provider ref fake tidak valid: %s", ref)
+	}
+	return parts[1], time.Unix(unix, 0), nil
+}
@@ -0,0 +1,302 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-sass-salon/app/models"
+)
+
+// URL API Midtrans untuk sandbox dan production
+const (
+	MidtransSandboxURL        = "https://api.sandbox.midtrans.com"
+	MidtransProductionURL     = "https://api.midtrans.com"
+	MidtransSnapSandboxURL    = "https://app.sandbox.midtrans.com"
+	MidtransSnapProductionURL = "https://app.midtrans.com"
+)
+
+// midtransLocation adalah zona waktu yang dipakai Midtrans untuk expiry_time (WIB)
+var midtransLocation = time.FixedZone("WIB", 7*60*60)
+
+// MidtransProvider adalah adapter ke Midtrans. QRIS dan virtual account dibuat lewat Core API,
+// kartu lewat halaman Snap (redirect_url). Order ID dari aplikasi dipakai sebagai provider ref.
+type MidtransProvider struct {
+	ServerKey string
+	// BaseURL untuk Core API dan SnapURL untuk halaman pembayaran kartu
+	BaseURL string
+	SnapURL string
+	// VABank adalah bank virtual account (bca, bni, bri, cimb)
+	VABank string
+	Client *http.Client
+}
+
+// NewMidtransProvider membuat provider Midtrans dengan server key, mode production dan bank VA
+func NewMidtransProvider(serverKey string, production bool, vaBank string) *MidtransProvider {
+	m := &MidtransProvider{
+		ServerKey: serverKey,
+		BaseURL:   MidtransSandboxURL,
+		SnapURL:   MidtransSnapSandboxURL,
+		VABank:    vaBank,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+	}
+	if production {
+		m.BaseURL = MidtransProductionURL
+		m.SnapURL = MidtransSnapProductionURL
+	}
+	return m
+}
+
+// midtransTransaction adalah response status/charge sekaligus body notifikasi Midtrans
+type midtransTransaction struct {
+	StatusCode        string `json:"status_code"`
+	StatusMessage     string `json:"status_message"`
+	TransactionID     string `json:"transaction_id"`
+	OrderID           string `json:"order_id"`
+	GrossAmount       string `json:"gross_amount"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+	SignatureKey      string `json:"signature_key"`
+	QRString          string `json:"qr_string"`
+	ExpiryTime        string `json:"expiry_time"`
+	VANumbers         []struct {
+		Bank     string `json:"bank"`
+		VANumber string `json:"va_number"`
+	} `json:"va_numbers"`
+	PermataVANumber string `json:"permata_va_number"`
+	Refunds         []struct {
+		RefundKey string `json:"refund_key"`
+	} `json:"refunds"`
+	// RedirectURL hanya ada pada response Snap
+	RedirectURL string `json:"redirect_url"`
+}
+
+type midtransTransactionDetails struct {
+	OrderID     string `json:"order_id"`
+	GrossAmount int64  `json:"gross_amount"`
+}
+
+// Name mengembalikan nama provider
+func (m *MidtransProvider) Name() string {
+	return "midtrans"
+}
+
+// CreateCharge membuat transaksi QRIS atau virtual account lewat Core API, atau transaksi kartu lewat Snap
+func (m *MidtransProvider) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	details := midtransTransactionDetails{OrderID: req.Reference, GrossAmount: req.Amount}
+
+	if req.Method == models.PaymentMethodCard {
+		var snap midtransTransaction
+		body := map[string]any{
+			"transaction_details": details,
+			"enabled_payments":    []string{"credit_card"},
+			"credit_card":         map[string]bool{"secure": true},
+		}
+		if err := m.do(ctx, http.MethodPost, m.SnapURL+"/snap/v1/transactions", body, &snap); err != nil {
+			return nil, err
+		}
+		if snap.RedirectURL == "" {
+			return nil, errors.New("midtrans snap tidak mengembalikan redirect_url")
+		}
+		return &Charge{ProviderRef: req.Reference, Status: models.PaymentStatusPending, RedirectURL: snap.RedirectURL}, nil
+	}
+
+	body := map[string]any{"transaction_details": details}
+	switch req.Method {
+	case models.PaymentMethodQRIS:
+		body["payment_type"] = "qris"
+	case models.PaymentMethodVirtualAccount:
+		body["payment_type"] = "bank_transfer"
+		body["bank_transfer"] = map[string]string{"bank": m.VABank}
+	default:
+		return nil, fmt.Errorf("metode %s tidak didukung midtrans", req.Method)
+	}
+
+	var tx midtransTransaction
+	if err := m.do(ctx, http.MethodPost, m.BaseURL+"/v2/charge", body, &tx); err != nil {
+		return nil, err
+	}
+	if tx.StatusCode != "200" && tx.StatusCode != "201" && tx.StatusCode != "202" {
+		return nil, fmt.Errorf("midtrans menolak charge (%s): %s", tx.StatusCode, tx.StatusMessage)
+	}
+	return m.toCharge(req.Reference, &tx)
+}
+
+// GetCharge menanyakan status transaksi berdasarkan order ID. Transaksi Snap yang belum dibayar
+// belum dikenal Core API (404) dan dianggap masih pending.
+func (m *MidtransProvider) GetCharge(ctx context.Context, providerRef string) (*Charge, error) {
+	tx, err := m.status(ctx, providerRef)
+	if err != nil {
+		return nil, err
+	}
+	if tx.StatusCode == "404" {
+		return &Charge{ProviderRef: providerRef, Status: models.PaymentStatusPending}, nil
+	}
+	return m.toCharge(providerRef, tx)
+}
+
+// Refund mengembalikan dana dengan idempotency key sebagai refund_key. Refund dengan key yang sudah
+// tercatat di Midtrans dikembalikan apa adanya tanpa mengirim refund baru. Virtual account tidak
+// mendukung refund lewat API sehingga ditolak Midtrans.
+func (m *MidtransProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	tx, err := m.status(ctx, req.ProviderRef)
+	if err != nil {
+		return nil, err
+	}
+	for _, refund := range tx.Refunds {
+		if refund.RefundKey == req.IdempotencyKey {
+			return &RefundResult{RefundRef: refund.RefundKey, Amount: req.Amount}, nil
+		}
+	}
+
+	var result midtransTransaction
+	body := map[string]any{"refund_key": req.IdempotencyKey, "amount": req.Amount}
+	if err := m.do(ctx, http.MethodPost, m.BaseURL+"/v2/"+req.ProviderRef+"/refund", body, &result); err != nil {
+		return nil, err
+	}
+	switch code, _ := strconv.Atoi(result.StatusCode); {
+	case code == 200:
+		return &RefundResult{RefundRef: req.IdempotencyKey, Amount: req.Amount}, nil
+	case code >= 500 || code == 0:
+		return nil, fmt.Errorf("midtrans gagal memproses refund (%s): %s", result.StatusCode, result.StatusMessage)
+	default:
+		return nil, fmt.Errorf("%w: %s (%s)", ErrRefundRejected, result.StatusMessage, result.StatusCode)
+	}
+}
+
+// ParseWebhook memverifikasi signature_key notifikasi (SHA512 dari order_id, status_code, gross_amount
+// dan server key) lalu memetakan status transaksi Midtrans
+func (m *MidtransProvider) ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	var n midtransTransaction
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, fmt.Errorf("body notifikasi tidak valid: %w", err)
+	}
+	if n.SignatureKey == "" || !hmac.Equal([]byte(n.SignatureKey), []byte(m.signature(n.OrderID, n.StatusCode, n.GrossAmount))) {
+		return nil, ErrInvalidSignature
+	}
+
+	status, err := midtransStatus(n.TransactionStatus, n.FraudStatus)
+	if err != nil {
+		return nil, err
+	}
+	event := &WebhookEvent{
+		// Midtrans tidak mengirim ID notifikasi; kombinasi transaksi dan status unik per perubahan status
+		EventID:     fmt.Sprintf("%s-%s-%s", n.TransactionID, n.TransactionStatus, n.FraudStatus),
+		ProviderRef: n.OrderID,
+		Status:      status,
+	}
+	if status == models.PaymentStatusFailed {
+		event.FailureReason = "Midtrans: " + n.TransactionStatus
+	}
+	return event, nil
+}
+
+// signature menghitung signature_key notifikasi Midtrans
+func (m *MidtransProvider) signature(orderID, statusCode, grossAmount string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + m.ServerKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// status mengambil status transaksi dari Core API
+func (m *MidtransProvider) status(ctx context.Context, orderID string) (*midtransTransaction, error) {
+	var tx midtransTransaction
+	if err := m.do(ctx, http.MethodGet, m.BaseURL+"/v2/"+orderID+"/status", nil, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// toCharge mengubah transaksi Midtrans menjadi Charge
+func (m *MidtransProvider) toCharge(orderID string, tx *midtransTransaction) (*Charge, error) {
+	status, err := midtransStatus(tx.TransactionStatus, tx.FraudStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	charge := &Charge{ProviderRef: orderID, Status: status, QRString: tx.QRString, VANumber: tx.PermataVANumber}
+	if len(tx.VANumbers) > 0 {
+		charge.VANumber = tx.VANumbers[0].VANumber
+	}
+	if status == models.PaymentStatusFailed {
+		charge.FailureReason = "Midtrans: " + tx.TransactionStatus
+	}
+	if tx.ExpiryTime != "" {
+		if expires, err := time.ParseInLocation("2006-01-02 15:04:05", tx.ExpiryTime, midtransLocation); err == nil {
+			charge.ExpiresAt = &expires
+		}
+	}
+	return charge, nil
+}
+
+// do mengirim request ber-Basic Auth server key lalu mengurai body JSON ke out. Midtrans menaruh
+// hasil bisnis di status_code body, jadi hanya error HTTP 5xx yang dikembalikan sebagai error di sini.
+func (m *MidtransProvider) do(ctx context.Context, method, url string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(m.ServerKey, "")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("midtrans merespons HTTP %d: %s", resp.StatusCode, raw)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("response midtrans tidak valid (HTTP %d): %w", resp.StatusCode, err)
+	}
+	return nil
+}
+
+// midtransStatus memetakan transaction_status dan fraud_status Midtrans ke status payment.
+// Status refund/chargeback berarti tagihan sudah pernah dibayar; refund-nya dicatat terpisah.
+func midtransStatus(transactionStatus, fraudStatus string) (string, error) {
+	switch transactionStatus {
+	case "settlement":
+		return models.PaymentStatusSucceeded, nil
+	case "capture":
+		// Kartu dengan fraud_status challenge menunggu keputusan dari dashboard Midtrans
+		if fraudStatus == "challenge" {
+			return models.PaymentStatusPending, nil
+		}
+		if fraudStatus == "deny" {
+			return models.PaymentStatusFailed, nil
+		}
+		return models.PaymentStatusSucceeded, nil
+	case "pending", "authorize":
+		return models.PaymentStatusPending, nil
+	case "deny", "cancel", "expire", "failure":
+		return models.PaymentStatusFailed, nil
+	case "refund", "partial_refund", "chargeback", "partial_chargeback":
+		return models.PaymentStatusSucceeded, nil
+	}
+	return "", fmt.Errorf("transaction_status midtrans tidak dikenal: %q", transactionStatus)
+}
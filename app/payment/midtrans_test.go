@@ -0,0 +1,136 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gin-sass-salon/app/models"
+)
+
+func TestMidtransStatus(t *testing.T) {
+	tests := []struct {
+		transaction, fraud, want string
+	}{
+		{"settlement", "", models.PaymentStatusSucceeded},
+		{"capture", "accept", models.PaymentStatusSucceeded},
+		{"capture", "challenge", models.PaymentStatusPending},
+		{"capture", "deny", models.PaymentStatusFailed},
+		{"pending", "", models.PaymentStatusPending},
+		{"authorize", "", models.PaymentStatusPending},
+		{"deny", "", models.PaymentStatusFailed},
+		{"cancel", "", models.PaymentStatusFailed},
+		{"expire", "", models.PaymentStatusFailed},
+		{"failure", "", models.PaymentStatusFailed},
+		{"partial_refund", "", models.PaymentStatusSucceeded},
+	}
+	for _, tt := range tests {
+		got, err := midtransStatus(tt.transaction, tt.fraud)
+		if err != nil || got != tt.want {
+			t.Errorf("midtransStatus(%q, %q) = %q, %v, ingin %q", tt.transaction, tt.fraud, got, err, tt.want)
+		}
+	}
+
+	if _, err := midtransStatus("unknown", ""); err == nil {
+		t.Error("status tidak dikenal tidak mengembalikan error")
+	}
+}
+
+func TestMidtransParseWebhook(t *testing.T) {
+	m := NewMidtransProvider("SB-Mid-server-test", false, "bca")
+	notification := func(status, signature string) []byte {
+		body, _ := json.Marshal(map[string]string{
+			"transaction_id":     "tx-1",
+			"order_id":           "salon1-1700000000",
+			"status_code":        "200",
+			"gross_amount":       "150000.00",
+			"transaction_status": status,
+			"signature_key":      signature,
+		})
+		return body
+	}
+	valid := m.signature("salon1-1700000000", "200", "150000.00")
+
+	event, err := m.ParseWebhook(http.Header{}, notification("settlement", valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.ProviderRef != "salon1-1700000000" || event.Status != models.PaymentStatusSucceeded || event.EventID != "tx-1-settlement-" {
+		t.Errorf("event = %+v", event)
+	}
+
+	event, err = m.ParseWebhook(http.Header{}, notification("expire", valid))
+	if err != nil || event.Status != models.PaymentStatusFailed || event.FailureReason == "" {
+		t.Errorf("event expire = %+v, %v", event, err)
+	}
+
+	for name, signature := range map[string]string{"kosong": "", "salah": valid[:len(valid)-1] + "0"} {
+		if _, err := m.ParseWebhook(http.Header{}, notification("settlement", signature)); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("signature %s: err = %v, ingin ErrInvalidSignature", name, err)
+		}
+	}
+
+	other := NewMidtransProvider("SB-Mid-server-lain", false, "bca")
+	if _, err := other.ParseWebhook(http.Header{}, notification("settlement", valid)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("server key berbeda: err = %v, ingin ErrInvalidSignature", err)
+	}
+}
+
+func TestMidtransRefund(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   string
+		statusCode string
+		wantPost   bool
+		wantErr    error
+	}{
+		{"refund baru", "", "200", true, nil},
+		{"key sudah tercatat tidak dikirim ulang", "salon1-refund7", "", false, nil},
+		{"ditolak", "", "412", true, ErrRefundRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posted := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if user, _, ok := r.BasicAuth(); !ok || user != "server-key" {
+					t.Errorf("basic auth = %q", user)
+				}
+				switch r.URL.Path {
+				case "/v2/salon1-1/status":
+					status := map[string]any{"status_code": "200", "transaction_status": "settlement"}
+					if tt.existing != "" {
+						status["refunds"] = []map[string]string{{"refund_key": tt.existing}}
+					}
+					json.NewEncoder(w).Encode(status)
+				case "/v2/salon1-1/refund":
+					posted = true
+					var body map[string]any
+					json.NewDecoder(r.Body).Decode(&body)
+					if body["refund_key"] != "salon1-refund7" || body["amount"] != float64(50000) {
+						t.Errorf("body refund = %v", body)
+					}
+					json.NewEncoder(w).Encode(map[string]string{"status_code": tt.statusCode, "status_message": "ok"})
+				default:
+					t.Errorf("path tidak terduga %s", r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			m := NewMidtransProvider("server-key", false, "bca")
+			m.BaseURL = server.URL
+			result, err := m.Refund(context.Background(), RefundRequest{ProviderRef: "salon1-1", Amount: 50000, IdempotencyKey: "salon1-refund7"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, ingin %v", err, tt.wantErr)
+			}
+			if posted != tt.wantPost {
+				t.Errorf("refund dikirim = %v, ingin %v", posted, tt.wantPost)
+			}
+			if err == nil && result.RefundRef != "salon1-refund7" {
+				t.Errorf("refund ref = %q", result.RefundRef)
+			}
+		})
+	}
+}
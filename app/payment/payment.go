@@ -0,0 +1,410 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/queue"
+	"gin-sass-salon/app/realtime"
+	"gin-sass-salon/config"
+)
+
+// ErrPaymentNotFound dikembalikan jika webhook merujuk tagihan yang tidak dikenal
+var ErrPaymentNotFound = errors.New("payment tidak ditemukan")
+
+// ErrInvalidWebhook dikembalikan jika body webhook tidak dapat diurai
+var ErrInvalidWebhook = errors.New("webhook tidak valid")
+
+// ErrNotRefundable dikembalikan jika payment belum dibayar atau nominal refund melebihi sisa
+var ErrNotRefundable = errors.New("payment tidak dapat di-refund sebesar nominal tersebut")
+
+// ErrAttachedToSale dikembalikan jika refund langsung diminta untuk payment yang sudah menjadi tender transaksi
+var ErrAttachedToSale = errors.New("payment sudah dipakai di transaksi, gunakan refund transaksi")
+
+// ErrPaymentNotUsable dikembalikan jika payment tidak dapat dipakai sebagai tender checkout
+var ErrPaymentNotUsable = errors.New("payment belum berhasil, sudah dipakai, atau nominalnya tidak sesuai")
+
+// JobProcessRefund adalah jenis job antrian untuk mengirim refund pending ke provider
+const JobProcessRefund = "process_payment_refund"
+
+// RefundJob adalah payload job process_payment_refund
+type RefundJob struct {
+	RefundID uint `json:"refund_id"`
+}
+
+// RegisterJobs mendaftarkan handler job process_payment_refund ke antrian
+func RegisterJobs(db *gorm.DB) {
+	queue.Register(JobProcessRefund, func(ctx context.Context, job RefundJob) error {
+		_, err := ProcessRefund(ctx, db, job.RefundID)
+		return err
+	})
+}
+
+// RegisterDefaultProviders mendaftarkan provider yang dikonfigurasi. Midtrans didaftarkan jika server key
+// terisi; provider fake hanya didaftarkan dengan APP_ENV=development dan PAYMENT_FAKE_SECRET terisi.
+// Mengembalikan error jika PAYMENT_PROVIDER tidak dapat dipakai agar aplikasi gagal saat start.
+func RegisterDefaultProviders() error {
+	if key := config.MidtransServerKey(); key != "" {
+		Register(NewMidtransProvider(key, config.MidtransProduction(), config.MidtransVABank()))
+	}
+	if secret := config.PaymentFakeSecret(); secret != "" && config.IsDevelopment() {
+		Register(NewFakeProvider(secret, config.PaymentFakeSettleDelay()))
+	}
+
+	name := config.PaymentProvider()
+	if _, err := Get(name); err != nil {
+		switch name {
+		case "":
+			return errors.New("PAYMENT_PROVIDER wajib diisi (midtrans, atau fake untuk development)")
+		case "midtrans":
+			return errors.New("MIDTRANS_SERVER_KEY wajib diisi untuk PAYMENT_PROVIDER=midtrans")
+		case "fake":
+			return errors.New("provider fake hanya tersedia dengan APP_ENV=development dan PAYMENT_FAKE_SECRET terisi")
+		}
+		return err
+	}
+	return nil
+}
+
+// Create membuat tagihan di provider lalu menyimpannya. Provider dipanggil di luar transaksi
+// database agar koneksi tidak tertahan selama menunggu respons gateway.
+func Create(ctx context.Context, db *gorm.DB, provider PaymentProvider, p *models.Payment, metadata map[string]string) error {
+	charge, err := provider.CreateCharge(ctx, ChargeRequest{
+		Reference:   fmt.Sprintf("salon%d-%d", p.SalonID, time.Now().UnixNano()),
+		Method:      p.Method,
+		Amount:      p.Amount,
+		Description: p.Description,
+		Metadata:    metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	p.Provider = provider.Name()
+	p.ProviderRef = charge.ProviderRef
+	p.Status = models.PaymentStatusPending
+	p.QRString = charge.QRString
+	p.VANumber = charge.VANumber
+	p.RedirectURL = charge.RedirectURL
+	p.ExpiresAt = charge.ExpiresAt
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(p).Error; err != nil {
+			return err
+		}
+		// Provider bisa langsung mengembalikan status akhir (mis. kartu yang langsung ditolak)
+		return applyCharge(tx, p, "create-"+charge.ProviderRef, charge, nil)
+	})
+}
+
+// HandleWebhook memverifikasi dan menerapkan webhook dari provider. Event dengan event_id yang
+// sama hanya diproses sekali; pengiriman ulang dianggap sukses tanpa mengubah data.
+func HandleWebhook(db *gorm.DB, provider PaymentProvider, header http.Header, body []byte) (*models.Payment, error) {
+	event, err := provider.ParseWebhook(header, body)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSignature) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+
+	var p models.Payment
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("provider = ? AND provider_ref = ?", provider.Name(), event.ProviderRef).
+			First(&p).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+
+		return applyCharge(tx, &p, event.EventID, &Charge{
+			ProviderRef:   event.ProviderRef,
+			Status:        event.Status,
+			FailureReason: event.FailureReason,
+		}, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Sync menanyakan status terbaru ke provider, untuk payment yang webhook-nya terlambat atau hilang
+func Sync(ctx context.Context, db *gorm.DB, p *models.Payment) error {
+	provider, err := Get(p.Provider)
+	if err != nil {
+		return err
+	}
+	charge, err := provider.GetCharge(ctx, p.ProviderRef)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(p, p.ID).Error; err != nil {
+			return err
+		}
+		return applyCharge(tx, p, fmt.Sprintf("sync-%s-%s", charge.ProviderRef, charge.Status), charge, nil)
+	})
+}
+
+// SyncPending menyinkronkan payment pending yang berumur lebih dari 15 detik (maksimal 24 jam)
+func SyncPending(ctx context.Context, db *gorm.DB) (int, error) {
+	var payments []models.Payment
+	now := time.Now()
+	if err := db.WithContext(ctx).
+		Where("status = ? AND created_at <= ? AND created_at > ?", models.PaymentStatusPending, now.Add(-15*time.Second), now.Add(-24*time.Hour)).
+		Order("id").Limit(100).Find(&payments).Error; err != nil {
+		return 0, err
+	}
+
+	synced := 0
+	for i := range payments {
+		if err := Sync(ctx, db, &payments[i]); err != nil {
+			log.Printf("❌ Gagal sinkron payment #%d: %v", payments[i].ID, err)
+			continue
+		}
+		synced++
+	}
+	return synced, nil
+}
+
+// RequestRefund mencatat refund pending di dalam transaksi pemanggil dan mencadangkan nominalnya pada
+// payment agar refund yang bersamaan tidak melebihi nominal. saleID nil berarti refund langsung payment yang
+// belum dipakai checkout; selain itu payment harus tender transaksi tersebut. Keduanya dicek setelah payment
+// dikunci sehingga tidak balapan dengan AttachToSale. Provider tidak dipanggil di sini: panggil ProcessRefund
+// setelah commit. Job antrian ikut dijadwalkan sebagai cadangan jika pemanggilan itu gagal.
+func RequestRefund(tx *gorm.DB, p *models.Payment, saleID *uint, amount int64) (*models.PaymentRefund, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(p, p.ID).Error; err != nil {
+		return nil, err
+	}
+	if saleID == nil && p.SaleID != nil {
+		return nil, ErrAttachedToSale
+	}
+	if saleID != nil && (p.SaleID == nil || *p.SaleID != *saleID) {
+		return nil, ErrNotRefundable
+	}
+	if p.Status != models.PaymentStatusSucceeded && p.Status != models.PaymentStatusPartiallyRefunded {
+		return nil, ErrNotRefundable
+	}
+	if amount <= 0 || amount > p.Amount-p.RefundedAmount {
+		return nil, ErrNotRefundable
+	}
+
+	refund := models.PaymentRefund{
+		SalonID:   p.SalonID,
+		PaymentID: p.ID,
+		Amount:    amount,
+		Status:    models.PaymentRefundPending,
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		return nil, err
+	}
+
+	p.RefundedAmount += amount
+	if err := tx.Model(p).Update("refunded_amount", p.RefundedAmount).Error; err != nil {
+		return nil, err
+	}
+
+	if _, err := queue.Dispatch(tx, JobProcessRefund, RefundJob{RefundID: refund.ID}, queue.Options{Delay: time.Minute}); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// ProcessRefund mengirim refund pending ke provider. Aman dipanggil berulang dari request maupun worker:
+// refund yang sudah selesai dilewati dan provider menerima idempotency key yang sama. Refund yang ditolak
+// provider ditandai failed dan cadangannya dilepas; gangguan sementara dikembalikan sebagai error.
+func ProcessRefund(ctx context.Context, db *gorm.DB, refundID uint) (*models.PaymentRefund, error) {
+	var refund models.PaymentRefund
+	if err := db.First(&refund, refundID).Error; err != nil {
+		return nil, err
+	}
+	if refund.Status != models.PaymentRefundPending {
+		return &refund, nil
+	}
+
+	var p models.Payment
+	if err := db.First(&p, refund.PaymentID).Error; err != nil {
+		return nil, err
+	}
+	provider, err := Get(p.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	result, refundErr := provider.Refund(ctx, RefundRequest{
+		ProviderRef:    p.ProviderRef,
+		Amount:         refund.Amount,
+		IdempotencyKey: fmt.Sprintf("salon%d-refund%d", refund.SalonID, refund.ID),
+	})
+	if refundErr != nil && !errors.Is(refundErr, ErrRefundRejected) {
+		return nil, refundErr
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&refund, refund.ID).Error; err != nil {
+			return err
+		}
+		// Sudah diselesaikan oleh pemanggil lain (request dan worker bisa berjalan bersamaan)
+		if refund.Status != models.PaymentRefundPending {
+			return nil
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, p.ID).Error; err != nil {
+			return err
+		}
+		if refundErr != nil {
+			return failRefund(tx, &p, &refund, refundErr.Error())
+		}
+		return completeRefund(tx, &p, &refund, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// completeRefund menandai refund berhasil dan memperbarui status payment dari total refund yang berhasil
+func completeRefund(tx *gorm.DB, p *models.Payment, refund *models.PaymentRefund, result *RefundResult) error {
+	now := time.Now()
+	refund.Status = models.PaymentRefundSucceeded
+	refund.ProviderRefundRef = result.RefundRef
+	refund.CompletedAt = &now
+	if err := tx.Model(refund).Select("status", "provider_refund_ref", "completed_at").Updates(refund).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.SaleRefundTender{}).Where("payment_refund_id = ?", refund.ID).
+		Update("provider_refund_ref", result.RefundRef).Error; err != nil {
+		return err
+	}
+
+	var succeeded int64
+	if err := tx.Model(&models.PaymentRefund{}).
+		Where("payment_id = ? AND status = ?", p.ID, models.PaymentRefundSucceeded).
+		Select("COALESCE(SUM(amount), 0)").Scan(&succeeded).Error; err != nil {
+		return err
+	}
+	p.Status = models.PaymentStatusPartiallyRefunded
+	if succeeded == p.Amount {
+		p.Status = models.PaymentStatusRefunded
+	}
+	if err := tx.Model(p).Update("status", p.Status).Error; err != nil {
+		return err
+	}
+
+	payload, _ := json.Marshal(result)
+	if _, err := recordEvent(tx, p, "refund-"+result.RefundRef, p.Status, payload); err != nil {
+		return err
+	}
+	realtime.Publish(tx, p.SalonID, realtime.EventPaymentUpdated, p)
+	return nil
+}
+
+// failRefund menandai refund gagal dan melepas cadangan nominalnya. Refund sale yang sudah tercatat tidak
+// dibatalkan; dana harus dikembalikan ke pelanggan dengan cara lain.
+func failRefund(tx *gorm.DB, p *models.Payment, refund *models.PaymentRefund, reason string) error {
+	now := time.Now()
+	refund.Status = models.PaymentRefundFailed
+	refund.FailureReason = reason
+	refund.CompletedAt = &now
+	if err := tx.Model(refund).Select("status", "failure_reason", "completed_at").Updates(refund).Error; err != nil {
+		return err
+	}
+
+	p.RefundedAmount -= refund.Amount
+	if err := tx.Model(p).Update("refunded_amount", p.RefundedAmount).Error; err != nil {
+		return err
+	}
+
+	payload, _ := json.Marshal(refund)
+	if _, err := recordEvent(tx, p, fmt.Sprintf("refund-failed-%d", refund.ID), models.PaymentRefundFailed, payload); err != nil {
+		return err
+	}
+	log.Printf("❌ Refund payment #%d (refund #%d) ditolak provider: %s", p.ID, refund.ID, reason)
+	realtime.Publish(tx, p.SalonID, realtime.EventPaymentUpdated, p)
+	return nil
+}
+
+// AttachToSale menautkan payment yang dipakai sebagai tender ke sale yang baru disimpan.
+// Payment harus milik salon yang sama, sudah berhasil, belum dipakai atau di-refund, dan nominalnya sama dengan tender.
+func AttachToSale(tx *gorm.DB, sale *models.Sale) error {
+	for _, tender := range sale.Tenders {
+		if tender.PaymentID == nil {
+			continue
+		}
+
+		var p models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("salon_id = ?", sale.SalonID).First(&p, *tender.PaymentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotUsable
+			}
+			return err
+		}
+		if p.Status != models.PaymentStatusSucceeded || p.SaleID != nil || p.RefundedAmount != 0 ||
+			p.Amount != tender.Amount || p.Method != tender.Method {
+			return ErrPaymentNotUsable
+		}
+		if err := tx.Model(&p).Update("sale_id", sale.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyCharge mencatat event lalu menerapkan status charge ke payment. Status hanya bergerak maju
+// dari pending; event yang sudah pernah diterima diabaikan.
+func applyCharge(tx *gorm.DB, p *models.Payment, eventID string, charge *Charge, payload []byte) error {
+	if payload == nil {
+		payload, _ = json.Marshal(charge)
+	}
+
+	recorded, err := recordEvent(tx, p, eventID, charge.Status, payload)
+	if err != nil || !recorded {
+		return err
+	}
+
+	if p.Status != models.PaymentStatusPending || charge.Status == models.PaymentStatusPending {
+		return nil
+	}
+	if charge.Status != models.PaymentStatusSucceeded && charge.Status != models.PaymentStatusFailed {
+		return fmt.Errorf("status payment dari provider tidak dikenal: %s", charge.Status)
+	}
+
+	p.Status = charge.Status
+	p.FailureReason = charge.FailureReason
+	if charge.Status == models.PaymentStatusSucceeded {
+		now := time.Now()
+		p.PaidAt = &now
+	}
+	if err := tx.Model(p).Select("status", "failure_reason", "paid_at").Updates(p).Error; err != nil {
+		return err
+	}
+
+	realtime.Publish(tx, p.SalonID, realtime.EventPaymentUpdated, p)
+	return nil
+}
+
+// recordEvent menyimpan event payment. Mengembalikan false jika event_id sudah pernah tercatat.
+func recordEvent(tx *gorm.DB, p *models.Payment, eventID, status string, payload []byte) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.PaymentEvent{
+		Provider:   p.Provider,
+		EventID:    eventID,
+		PaymentID:  &p.ID,
+		Status:     status,
+		Payload:    string(payload),
+		ReceivedAt: time.Now(),
+	})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,97 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrInvalidSignature dikembalikan jika signature webhook tidak cocok
+var ErrInvalidSignature = errors.New("signature webhook tidak valid")
+
+// ErrUnknownProvider dikembalikan jika nama provider tidak terdaftar
+var ErrUnknownProvider = errors.New("payment provider tidak dikenal")
+
+// ErrRefundRejected dikembalikan provider jika refund ditolak secara permanen (bukan gangguan sementara)
+var ErrRefundRejected = errors.New("refund ditolak payment provider")
+
+// ChargeRequest adalah permintaan pembuatan tagihan ke provider
+type ChargeRequest struct {
+	// Reference adalah ID unik dari sisi aplikasi, dikirim ke provider untuk rekonsiliasi
+	Reference   string
+	Method      string
+	Amount      int64
+	Description string
+	// Metadata berisi opsi tambahan khusus provider (mis. "scenario" untuk provider fake)
+	Metadata map[string]string
+}
+
+// Charge adalah kondisi tagihan di sisi provider
+type Charge struct {
+	ProviderRef   string
+	Status        string
+	QRString      string
+	VANumber      string
+	RedirectURL   string
+	ExpiresAt     *time.Time
+	FailureReason string
+}
+
+// RefundRequest adalah permintaan pengembalian dana ke provider
+type RefundRequest struct {
+	ProviderRef string
+	Amount      int64
+	// IdempotencyKey unik per refund; permintaan ulang dengan key yang sama tidak mengembalikan dana dua kali
+	IdempotencyKey string
+}
+
+// RefundResult adalah hasil pengembalian dana dari provider
+type RefundResult struct {
+	RefundRef string
+	Amount    int64
+}
+
+// WebhookEvent adalah notifikasi status dari provider yang sudah diverifikasi
+type WebhookEvent struct {
+	EventID       string `json:"event_id"`
+	ProviderRef   string `json:"provider_ref"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+}
+
+// PaymentProvider adalah adapter ke payment gateway (QRIS, virtual account, kartu).
+// Status yang dikembalikan memakai konstanta models.PaymentStatus*.
+type PaymentProvider interface {
+	Name() string
+	CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error)
+	GetCharge(ctx context.Context, providerRef string) (*Charge, error)
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+	// ParseWebhook memverifikasi signature lalu mengurai body webhook
+	ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]PaymentProvider{}
+)
+
+// Register mendaftarkan provider berdasarkan namanya
+func Register(p PaymentProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[p.Name()] = p
+}
+
+// Get mengembalikan provider terdaftar berdasarkan nama
+func Get(name string) (PaymentProvider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return p, nil
+}
@@ -0,0 +1,192 @@
+package payroll
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/commission"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/pos"
+	"gin-sass-salon/app/spreadsheet"
+)
+
+// ErrLocked dikembalikan jika periode payroll sudah disetujui sehingga tidak dapat diubah
+var ErrLocked = errors.New("periode payroll sudah disetujui dan dikunci")
+
+// ErrOverlap dikembalikan jika periode baru beririsan dengan periode payroll lain
+var ErrOverlap = errors.New("periode payroll beririsan dengan periode lain")
+
+// Deduction adalah potongan gaji pada request perubahan entri
+type Deduction struct {
+	Description string
+	Amount      int64
+}
+
+// Lock mengambil periode payroll salon beserta entri dan potongannya dengan FOR UPDATE
+func Lock(tx *gorm.DB, salonID, periodID uint) (*models.PayrollPeriod, error) {
+	var period models.PayrollPeriod
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("salon_id = ?", salonID).First(&period, periodID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Preload("DeductionItems").Where("period_id = ?", period.ID).
+		Order("staff_name, id").Find(&period.Entries).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// Create menyimpan periode baru lalu menghitung entrinya. Pembuatan periode salon diserialkan dengan
+// mengunci baris salon agar dua periode yang beririsan tidak tersimpan bersamaan.
+func Create(tx *gorm.DB, period *models.PayrollPeriod, loc *time.Location) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Salon{}, period.SalonID).Error; err != nil {
+		return err
+	}
+	var count int64
+	if err := tx.Model(&models.PayrollPeriod{}).
+		Where("salon_id = ? AND start_date <= ? AND end_date >= ?", period.SalonID, period.EndDate, period.StartDate).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrOverlap
+	}
+
+	period.Status = models.PayrollStatusDraft
+	if err := tx.Create(period).Error; err != nil {
+		return err
+	}
+	return Calculate(tx, period, loc)
+}
+
+// Calculate mengisi ulang omzet, komisi dan tip setiap staff dari statement komisi periode. Staff baru
+// mendapat gaji pokok dari User.BaseSalary; gaji pokok dan potongan entri yang sudah ada tidak diubah.
+// Staff tanpa gaji pokok, komisi maupun tip dilewati. Periode harus dikunci dengan Lock atau baru dibuat.
+func Calculate(tx *gorm.DB, period *models.PayrollPeriod, loc *time.Location) error {
+	if period.Status != models.PayrollStatusDraft {
+		return ErrLocked
+	}
+
+	from := time.Date(period.StartDate.Year(), period.StartDate.Month(), period.StartDate.Day(), 0, 0, 0, 0, loc)
+	to := time.Date(period.EndDate.Year(), period.EndDate.Month(), period.EndDate.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	statements, err := commission.Statements(tx, period.SalonID, 0, from, to, loc)
+	if err != nil {
+		return err
+	}
+
+	var staff []models.User
+	if err := tx.Where("salon_id = ?", period.SalonID).Find(&staff).Error; err != nil {
+		return err
+	}
+	users := make(map[uint]models.User, len(staff))
+	for _, member := range staff {
+		users[member.ID] = member
+	}
+
+	existing := make(map[uint]*models.PayrollEntry, len(period.Entries))
+	for i := range period.Entries {
+		existing[period.Entries[i].StaffID] = &period.Entries[i]
+	}
+
+	var added []models.PayrollEntry
+	for _, statement := range statements {
+		user := users[statement.StaffID]
+		entry, ok := existing[statement.StaffID]
+		if !ok {
+			if user.BaseSalary == 0 && statement.TotalCommission == 0 && statement.Tips == 0 {
+				continue
+			}
+			entry = &models.PayrollEntry{PeriodID: period.ID, StaffID: statement.StaffID, BasePay: user.BaseSalary}
+		}
+		entry.StaffName = user.Name
+		entry.StaffEmail = user.Email
+		entry.ServiceRevenue = statement.ServiceRevenue
+		entry.RetailRevenue = statement.RetailRevenue
+		entry.Commission = statement.TotalCommission
+		entry.Tips = statement.Tips
+		entry.NetPay = entry.BasePay + entry.Commission + entry.Tips - entry.Deductions
+		if err := tx.Omit("DeductionItems").Save(entry).Error; err != nil {
+			return err
+		}
+		if !ok {
+			added = append(added, *entry)
+		}
+	}
+	period.Entries = append(period.Entries, added...)
+	return nil
+}
+
+// UpdateEntry mengganti gaji pokok, potongan dan catatan satu entri pada periode yang masih draft
+func UpdateEntry(tx *gorm.DB, period *models.PayrollPeriod, entry *models.PayrollEntry, basePay int64, deductions []Deduction, notes string) error {
+	if period.Status != models.PayrollStatusDraft {
+		return ErrLocked
+	}
+
+	if err := tx.Where("entry_id = ?", entry.ID).Delete(&models.PayrollDeduction{}).Error; err != nil {
+		return err
+	}
+	entry.DeductionItems = nil
+	entry.Deductions = 0
+	for _, d := range deductions {
+		entry.DeductionItems = append(entry.DeductionItems, models.PayrollDeduction{
+			EntryID:     entry.ID,
+			Description: d.Description,
+			Amount:      d.Amount,
+		})
+		entry.Deductions += d.Amount
+	}
+	if len(entry.DeductionItems) > 0 {
+		if err := tx.Create(&entry.DeductionItems).Error; err != nil {
+			return err
+		}
+	}
+
+	entry.BasePay = basePay
+	entry.Notes = notes
+	entry.NetPay = entry.BasePay + entry.Commission + entry.Tips - entry.Deductions
+	return tx.Omit("DeductionItems").Save(entry).Error
+}
+
+// Approve mengunci periode payroll sehingga entri tidak dapat dihitung ulang atau diubah
+func Approve(tx *gorm.DB, period *models.PayrollPeriod, userID uint) error {
+	if period.Status != models.PayrollStatusDraft {
+		return ErrLocked
+	}
+	now := time.Now()
+	period.Status = models.PayrollStatusApproved
+	period.ApprovedByID = &userID
+	period.ApprovedAt = &now
+	return tx.Model(period).Select("status", "approved_by_id", "approved_at").Updates(period).Error
+}
+
+// Table menyusun periode payroll sebagai tabel ekspor: satu baris per staff tanpa baris total, dengan kolom
+// nominal berupa angka Rupiah tanpa pemisah ribuan agar dapat langsung diimpor ke aplikasi akuntansi
+func Table(period *models.PayrollPeriod) spreadsheet.Table {
+	table := spreadsheet.Table{
+		Sheet: "Payroll " + period.StartDate.Format("2006-01-02"),
+		Header: []string{
+			"period_start", "period_end", "status", "staff_id", "staff_name", "staff_email",
+			"base_pay", "commission", "tips", "gross_pay", "deductions", "net_pay",
+			"service_revenue", "retail_revenue", "deduction_details", "notes",
+		},
+	}
+
+	for _, entry := range period.Entries {
+		details := make([]string, 0, len(entry.DeductionItems))
+		for _, d := range entry.DeductionItems {
+			details = append(details, d.Description+" "+pos.FormatRupiah(d.Amount))
+		}
+		table.Rows = append(table.Rows, []any{
+			period.StartDate.Format("2006-01-02"), period.EndDate.Format("2006-01-02"), period.Status,
+			entry.StaffID, entry.StaffName, entry.StaffEmail,
+			entry.BasePay, entry.Commission, entry.Tips, entry.BasePay + entry.Commission + entry.Tips,
+			entry.Deductions, entry.NetPay, entry.ServiceRevenue, entry.RetailRevenue,
+			strings.Join(details, "; "), entry.Notes,
+		})
+	}
+	return table
+}
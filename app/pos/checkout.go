@@ -0,0 +1,307 @@
+package pos
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+)
+
+// ErrEmptyCart dikembalikan jika tidak ada item layanan/produk
+var ErrEmptyCart = errors.New("keranjang kosong")
+
+// ErrInsufficientPayment dikembalikan jika total tender kurang dari total tagihan
+var ErrInsufficientPayment = errors.New("pembayaran kurang dari total tagihan")
+
+// ErrOverpayment dikembalikan jika kelebihan bayar tidak dapat dikembalikan sebagai kembalian tunai
+var ErrOverpayment = errors.New("kelebihan pembayaran hanya boleh untuk tender tunai")
+
+// Item adalah layanan atau produk retail di keranjang
+type Item struct {
+	Type        string
+	ServiceID   *uint
+	ProductID   *uint
+	StaffID     *uint
+	Description string
+	Quantity    int
+	UnitPrice   int64
+	// Category kategori katalog item, dipakai aturan promo
+	Category string
+	// PackageID dan MembershipPlanID untuk item paket prepaid dan membership
+	PackageID        *uint
+	MembershipPlanID *uint
+	// PrepaidCredit menandai layanan yang dibayar dengan kredit paket/membership (harga 0)
+	PrepaidCredit bool
+	// Diskon khusus baris: nominal tetap dan/atau persen (basis poin)
+	DiscountAmount int64
+	DiscountBps    int
+	// Rates adalah tarif pajak yang berlaku untuk item (lihat TaxResolver)
+	Rates []Rate
+}
+
+// Net menghitung nilai item setelah diskon baris
+func (i Item) Net() int64 {
+	gross := i.UnitPrice * int64(i.Quantity)
+	discount := i.DiscountAmount + PercentOf(gross-i.DiscountAmount, i.DiscountBps)
+	if discount > gross {
+		discount = gross
+	}
+	return gross - discount
+}
+
+// Discount adalah diskon tingkat transaksi yang dibagi proporsional ke semua item,
+// atau hanya ke item dengan indeks di Items jika diisi
+type Discount struct {
+	Description string
+	Amount      int64
+	Bps         int
+	Items       []int
+	PromotionID *uint
+	// Strict menolak diskon nominal yang melebihi sisa nilai item (mis. penukaran poin) alih-alih memotongnya
+	Strict bool
+}
+
+// Tip adalah tip untuk staff; tidak dikenai pajak
+type Tip struct {
+	StaffID *uint
+	Amount  int64
+}
+
+// Cart adalah input perhitungan penjualan
+type Cart struct {
+	Items     []Item
+	Discounts []Discount
+	Tips      []Tip
+}
+
+// Calculate menghitung baris dan total penjualan dari keranjang. Pajak dihitung per baris
+// setelah diskon lalu dijumlahkan; service charge dihitung dari dasar pengenaan pajak item dan
+// dikenai pajaknya sendiri. Hasilnya belum disimpan ke database.
+func Calculate(cart Cart, cfg TaxConfig) (*models.Sale, error) {
+	if len(cart.Items) == 0 {
+		return nil, ErrEmptyCart
+	}
+
+	sale := &models.Sale{PricesIncludeTax: cfg.PricesIncludeTax}
+
+	for _, item := range cart.Items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("jumlah item %q harus lebih dari 0", item.Description)
+		}
+		if item.UnitPrice < 0 || item.DiscountAmount < 0 || item.DiscountBps < 0 || item.DiscountBps > 10000 {
+			return nil, fmt.Errorf("harga atau diskon item %q tidak valid", item.Description)
+		}
+
+		if item.Type == models.SaleLineGiftCard && (item.UnitPrice <= 0 || item.DiscountAmount > 0 || item.DiscountBps > 0) {
+			return nil, errors.New("gift card harus bernilai lebih dari 0 dan tidak boleh didiskon")
+		}
+
+		gross := item.UnitPrice * int64(item.Quantity)
+		discount := gross - item.Net()
+
+		sale.Lines = append(sale.Lines, models.SaleLine{
+			Type:             item.Type,
+			ServiceID:        item.ServiceID,
+			ProductID:        item.ProductID,
+			PackageID:        item.PackageID,
+			MembershipPlanID: item.MembershipPlanID,
+			PrepaidCredit:    item.PrepaidCredit,
+			StaffID:          item.StaffID,
+			Description:      item.Description,
+			Quantity:         item.Quantity,
+			UnitPrice:        item.UnitPrice,
+			Gross:            gross,
+			DiscountAmount:   discount,
+			NetAmount:        gross - discount,
+		})
+		sale.Subtotal += gross
+		sale.DiscountTotal += discount
+	}
+	// Baris item selalu di awal; baris diskon dan tip ditambahkan setelahnya
+	itemCount := len(sale.Lines)
+
+	// Diskon transaksi dihitung berurutan dari sisa nilai setelah diskon sebelumnya
+	for _, discount := range cart.Discounts {
+		if discount.Amount < 0 || discount.Bps < 0 || discount.Bps > 10000 {
+			return nil, fmt.Errorf("diskon %q tidak valid", discount.Description)
+		}
+
+		targets := discount.Items
+		if len(targets) == 0 {
+			for i := 0; i < itemCount; i++ {
+				targets = append(targets, i)
+			}
+		}
+		weights := make([]int64, itemCount)
+		var remaining int64
+		for _, i := range targets {
+			if i < 0 || i >= itemCount {
+				return nil, fmt.Errorf("diskon %q merujuk item yang tidak ada", discount.Description)
+			}
+			if sale.Lines[i].Type == models.SaleLineGiftCard || weights[i] > 0 {
+				continue
+			}
+			weights[i] = sale.Lines[i].NetAmount
+			remaining += sale.Lines[i].NetAmount
+		}
+
+		amount := discount.Amount + PercentOf(remaining-discount.Amount, discount.Bps)
+		if amount > remaining {
+			if discount.Strict {
+				return nil, fmt.Errorf("%s melebihi nilai belanja", discount.Description)
+			}
+			amount = remaining
+		}
+
+		for i, share := range Allocate(amount, weights) {
+			sale.Lines[i].AllocatedDiscount += share
+			sale.Lines[i].NetAmount -= share
+		}
+
+		description := discount.Description
+		if description == "" {
+			description = "Diskon"
+		}
+		// Baris diskon hanya sebagai keterangan struk; nilainya sudah masuk ke AllocatedDiscount item
+		sale.Lines = append(sale.Lines, models.SaleLine{
+			Type:        models.SaleLineDiscount,
+			Description: description,
+			Quantity:    1,
+			UnitPrice:   -amount,
+			Gross:       -amount,
+			PromotionID: discount.PromotionID,
+		})
+		sale.DiscountTotal += amount
+	}
+
+	var taxableBase int64
+	for i := 0; i < itemCount; i++ {
+		line := &sale.Lines[i]
+		if line.Type == models.SaleLineGiftCard {
+			// Gift card adalah uang muka, bukan penyerahan jasa: tidak dikenai pajak maupun service charge
+			line.LineTotal = line.NetAmount
+			continue
+		}
+		applyTax(line, cart.Items[i].Rates, cfg.PricesIncludeTax)
+		taxableBase += line.TaxableAmount
+	}
+
+	if serviceCharge := PercentOf(taxableBase, cfg.ServiceChargeBps); serviceCharge > 0 {
+		line := models.SaleLine{
+			Type:        models.SaleLineServiceCharge,
+			Description: "Service charge",
+			Quantity:    1,
+			UnitPrice:   serviceCharge,
+			Gross:       serviceCharge,
+			NetAmount:   serviceCharge,
+		}
+		// Service charge tidak tercantum di harga menu sehingga pajaknya selalu ditambahkan
+		applyTax(&line, cfg.ServiceChargeRates, false)
+		sale.Lines = append(sale.Lines, line)
+		sale.ServiceChargeTotal = serviceCharge
+	}
+
+	for _, line := range sale.Lines {
+		sale.TaxTotal += line.TaxAmount
+		if line.Type != models.SaleLineDiscount {
+			sale.Total += line.LineTotal
+		}
+	}
+
+	for _, tip := range cart.Tips {
+		if tip.Amount <= 0 {
+			return nil, errors.New("nominal tip harus lebih dari 0")
+		}
+		sale.Lines = append(sale.Lines, models.SaleLine{
+			Type:        models.SaleLineTip,
+			StaffID:     tip.StaffID,
+			Description: "Tip",
+			Quantity:    1,
+			UnitPrice:   tip.Amount,
+			Gross:       tip.Amount,
+			NetAmount:   tip.Amount,
+			LineTotal:   tip.Amount,
+		})
+		sale.TipTotal += tip.Amount
+		sale.Total += tip.Amount
+	}
+
+	return sale, nil
+}
+
+// ApplyTenders mencatat pembayaran ke sale dan menghitung kembalian.
+// Kelebihan bayar hanya diterima jika ada tender tunai yang cukup untuk menutup kembalian.
+func ApplyTenders(sale *models.Sale, tenders []models.SaleTender) error {
+	var paid, cash int64
+	for _, tender := range tenders {
+		if tender.Amount <= 0 {
+			return errors.New("nominal pembayaran harus lebih dari 0")
+		}
+		paid += tender.Amount
+		if tender.Method == models.TenderCash {
+			cash += tender.Amount
+		}
+	}
+
+	if paid < sale.Total {
+		return ErrInsufficientPayment
+	}
+	change := paid - sale.Total
+	if change > cash {
+		return ErrOverpayment
+	}
+
+	sale.Tenders = tenders
+	sale.PaidTotal = paid
+	sale.ChangeDue = change
+	return nil
+}
+
+// NextSequence menaikkan nomor urut salon di dalam transaksi tx. Baris sequence terkunci sampai
+// transaksi selesai, sehingga jika transaksi di-rollback nomor tersebut dipakai lagi (tanpa celah).
+func NextSequence(tx *gorm.DB, salonID uint, name string) (int64, error) {
+	seq := models.SalonSequence{SalonID: salonID, Name: name, LastValue: 1}
+	err := tx.Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "salon_id"}, {Name: "name"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"last_value": gorm.Expr("salon_sequences.last_value + 1")}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "last_value"}}},
+	).Create(&seq).Error
+	if err != nil {
+		return 0, err
+	}
+	return seq.LastValue, nil
+}
+
+// Checkout memberi nomor struk lalu menyimpan sale beserta baris dan tendernya di dalam tx.
+// Sale dicatat pada shift kasir yang sedang terbuka di cabangnya; pembayaran tunai wajib memiliki shift terbuka.
+func Checkout(tx *gorm.DB, sale *models.Sale) error {
+	usesCash := false
+	for _, tender := range sale.Tenders {
+		if tender.Method == models.TenderCash {
+			usesCash = true
+		}
+	}
+	if err := attachShift(tx, sale.SalonID, sale.BranchID, &sale.ShiftID, usesCash); err != nil {
+		return err
+	}
+
+	seq, err := NextSequence(tx, sale.SalonID, "receipt")
+	if err != nil {
+		return err
+	}
+
+	sale.ReceiptSeq = seq
+	sale.ReceiptNumber = fmt.Sprintf("R%d-%06d", sale.SalonID, seq)
+	sale.Status = models.SaleStatusCompleted
+	if sale.CompletedAt.IsZero() {
+		sale.CompletedAt = time.Now()
+	}
+
+	return tx.Create(sale).Error
+}
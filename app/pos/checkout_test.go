@@ -0,0 +1,178 @@
+package pos
+
+import (
+	"errors"
+	"testing"
+
+	"gin-sass-salon/app/models"
+)
+
+func TestCalculate(t *testing.T) {
+	cart := Cart{
+		Items: []Item{
+			{Type: models.SaleLineService, Description: "Potong", Quantity: 1, UnitPrice: 100000},
+			{Type: models.SaleLineProduct, Description: "Shampo", Quantity: 2, UnitPrice: 50000, DiscountBps: 1000},
+		},
+		Discounts: []Discount{{Description: "Promo", Amount: 10000}},
+		Tips:      []Tip{{Amount: 20000}},
+	}
+	sale, err := Calculate(cart, TaxConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sale.Subtotal != 200000 || sale.DiscountTotal != 20000 || sale.TipTotal != 20000 || sale.Total != 200000 {
+		t.Errorf("subtotal/diskon/tip/total = %d/%d/%d/%d, ingin 200000/20000/20000/200000",
+			sale.Subtotal, sale.DiscountTotal, sale.TipTotal, sale.Total)
+	}
+	if len(sale.Lines) != 4 {
+		t.Fatalf("jumlah baris = %d, ingin 4 (2 item, diskon, tip)", len(sale.Lines))
+	}
+
+	// Diskon baris 10% dari 100000, lalu diskon transaksi 10000 dibagi 100000:90000 dengan sisa ke item kedua
+	wantLines := []struct {
+		gross, discount, allocated, net int64
+	}{
+		{100000, 0, 5263, 94737},
+		{100000, 10000, 4737, 85263},
+	}
+	for i, want := range wantLines {
+		line := sale.Lines[i]
+		if line.Gross != want.gross || line.DiscountAmount != want.discount || line.AllocatedDiscount != want.allocated ||
+			line.NetAmount != want.net || line.LineTotal != want.net {
+			t.Errorf("baris %d = gross %d diskon %d alokasi %d net %d total %d, ingin %+v", i, line.Gross,
+				line.DiscountAmount, line.AllocatedDiscount, line.NetAmount, line.LineTotal, want)
+		}
+	}
+	if line := sale.Lines[2]; line.Type != models.SaleLineDiscount || line.Gross != -10000 {
+		t.Errorf("baris diskon = %s %d, ingin discount -10000", line.Type, line.Gross)
+	}
+	if line := sale.Lines[3]; line.Type != models.SaleLineTip || line.LineTotal != 20000 {
+		t.Errorf("baris tip = %s %d, ingin tip 20000", line.Type, line.LineTotal)
+	}
+}
+
+func TestCalculateDiscounts(t *testing.T) {
+	items := func() []Item {
+		return []Item{
+			{Type: models.SaleLineService, Description: "Potong", Quantity: 1, UnitPrice: 100000},
+			{Type: models.SaleLineService, Description: "Creambath", Quantity: 1, UnitPrice: 50000},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		discounts []Discount
+		wantNet   []int64
+		wantTotal int64
+	}{
+		{"persen dihitung dari sisa setelah nominal", []Discount{{Amount: 15000, Bps: 1000}}, []int64{81000, 40500}, 121500},
+		{"berurutan dari sisa diskon sebelumnya", []Discount{{Bps: 5000}, {Bps: 5000}}, []int64{25000, 12500}, 37500},
+		{"hanya item tertentu", []Discount{{Amount: 10000, Items: []int{1}}}, []int64{100000, 40000}, 140000},
+		{"melebihi sisa dipotong", []Discount{{Amount: 500000}}, []int64{0, 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sale, err := Calculate(Cart{Items: items(), Discounts: tt.discounts}, TaxConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.wantNet {
+				if got := sale.Lines[i].NetAmount; got != want {
+					t.Errorf("net baris %d = %d, ingin %d", i, got, want)
+				}
+			}
+			if sale.Total != tt.wantTotal {
+				t.Errorf("total = %d, ingin %d", sale.Total, tt.wantTotal)
+			}
+			if sale.Subtotal-sale.DiscountTotal != sale.Total {
+				t.Errorf("subtotal %d - diskon %d != total %d", sale.Subtotal, sale.DiscountTotal, sale.Total)
+			}
+		})
+	}
+}
+
+func TestCalculateGiftCardExcludedFromDiscount(t *testing.T) {
+	cart := Cart{
+		Items: []Item{
+			{Type: models.SaleLineService, Description: "Potong", Quantity: 1, UnitPrice: 100000},
+			{Type: models.SaleLineGiftCard, Description: "Gift card", Quantity: 1, UnitPrice: 200000},
+		},
+		Discounts: []Discount{{Bps: 1000}},
+	}
+	sale, err := Calculate(cart, TaxConfig{ServiceChargeBps: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sale.Lines[1].NetAmount; got != 200000 {
+		t.Errorf("net gift card = %d, ingin 200000", got)
+	}
+	// Service charge 5% hanya dari layanan setelah diskon (90000)
+	if sale.ServiceChargeTotal != 4500 || sale.Total != 294500 {
+		t.Errorf("service charge/total = %d/%d, ingin 4500/294500", sale.ServiceChargeTotal, sale.Total)
+	}
+}
+
+func TestCalculateErrors(t *testing.T) {
+	item := Item{Type: models.SaleLineService, Description: "Potong", Quantity: 1, UnitPrice: 100000}
+	tests := []struct {
+		name string
+		cart Cart
+	}{
+		{"jumlah nol", Cart{Items: []Item{{Type: models.SaleLineService, Quantity: 0, UnitPrice: 1000}}}},
+		{"harga negatif", Cart{Items: []Item{{Type: models.SaleLineService, Quantity: 1, UnitPrice: -1}}}},
+		{"diskon baris lebih dari 100%", Cart{Items: []Item{{Type: models.SaleLineService, Quantity: 1, UnitPrice: 1000, DiscountBps: 10001}}}},
+		{"gift card didiskon", Cart{Items: []Item{{Type: models.SaleLineGiftCard, Quantity: 1, UnitPrice: 1000, DiscountBps: 100}}}},
+		{"diskon negatif", Cart{Items: []Item{item}, Discounts: []Discount{{Amount: -1}}}},
+		{"diskon merujuk item tidak ada", Cart{Items: []Item{item}, Discounts: []Discount{{Amount: 1, Items: []int{3}}}}},
+		{"diskon strict melebihi belanja", Cart{Items: []Item{item}, Discounts: []Discount{{Amount: 100001, Strict: true}}}},
+		{"tip nol", Cart{Items: []Item{item}, Tips: []Tip{{Amount: 0}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Calculate(tt.cart, TaxConfig{}); err == nil {
+				t.Error("Calculate tidak mengembalikan error")
+			}
+		})
+	}
+
+	if _, err := Calculate(Cart{}, TaxConfig{}); !errors.Is(err, ErrEmptyCart) {
+		t.Errorf("keranjang kosong: err = %v, ingin ErrEmptyCart", err)
+	}
+}
+
+func TestApplyTenders(t *testing.T) {
+	tests := []struct {
+		name       string
+		tenders    []models.SaleTender
+		wantErr    error
+		wantChange int64
+	}{
+		{"pas", []models.SaleTender{{Method: models.TenderCard, Amount: 95000}}, nil, 0},
+		{"kembalian tunai", []models.SaleTender{{Method: models.TenderCash, Amount: 100000}}, nil, 5000},
+		{"campuran dengan kembalian", []models.SaleTender{
+			{Method: models.TenderCard, Amount: 50000}, {Method: models.TenderCash, Amount: 50000},
+		}, nil, 5000},
+		{"kurang bayar", []models.SaleTender{{Method: models.TenderCash, Amount: 90000}}, ErrInsufficientPayment, 0},
+		{"lebih bayar non tunai", []models.SaleTender{{Method: models.TenderCard, Amount: 100000}}, ErrOverpayment, 0},
+		{"kembalian melebihi tunai", []models.SaleTender{
+			{Method: models.TenderCard, Amount: 99000}, {Method: models.TenderCash, Amount: 1000},
+		}, ErrOverpayment, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sale := &models.Sale{Total: 95000}
+			err := ApplyTenders(sale, tt.tenders)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, ingin %v", err, tt.wantErr)
+			}
+			if err == nil && sale.ChangeDue != tt.wantChange {
+				t.Errorf("kembalian = %d, ingin %d", sale.ChangeDue, tt.wantChange)
+			}
+		})
+	}
+
+	if err := ApplyTenders(&models.Sale{Total: 1000}, []models.SaleTender{{Method: models.TenderCash, Amount: 0}}); err == nil {
+		t.Error("tender nol tidak mengembalikan error")
+	}
+}
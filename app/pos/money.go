@@ -0,0 +1,78 @@
+package pos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PercentOf menghitung amount * bps / 10000 dengan pembulatan half-up (bps = basis poin, 100 = 1%).
+// Seluruh perhitungan memakai bilangan bulat sehingga tidak ada galat floating point.
+func PercentOf(amount int64, bps int) int64 {
+	if amount == 0 || bps == 0 {
+		return 0
+	}
+	negative := amount < 0
+	if negative {
+		amount = -amount
+	}
+
+	result := (amount*int64(bps) + 5000) / 10000
+	if negative {
+		return -result
+	}
+	return result
+}
+
+// Allocate membagi total ke beberapa bagian sebanding dengan weights menggunakan metode
+// largest remainder, sehingga jumlah hasil selalu tepat sama dengan total.
+func Allocate(total int64, weights []int64) []int64 {
+	result := make([]int64, len(weights))
+
+	var sum int64
+	for _, w := range weights {
+		sum += w
+	}
+	if sum <= 0 || total == 0 {
+		return result
+	}
+
+	remainders := make([]int64, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		result[i] = total * w / sum
+		remainders[i] = total * w % sum
+		allocated += result[i]
+	}
+
+	// Sisa dibagikan satu per satu ke bagian dengan sisa pembagian terbesar
+	for left := total - allocated; left > 0; left-- {
+		best := -1
+		for i := range remainders {
+			if weights[i] > 0 && (best < 0 || remainders[i] > remainders[best]) {
+				best = i
+			}
+		}
+		result[best]++
+		remainders[best] = -1
+	}
+
+	return result
+}
+
+// FormatRupiah memformat nominal dengan pemisah ribuan, mis. 150000 -> Rp150.000
+func FormatRupiah(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := fmt.Sprintf("%d", amount)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return sign + "Rp" + b.String()
+}
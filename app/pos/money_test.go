@@ -0,0 +1,86 @@
+package pos
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPercentOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		bps    int
+		want   int64
+	}{
+		{"tepat", 10000, 1100, 1100},
+		{"setengah dibulatkan ke atas", 15, 5000, 8},
+		{"0,5 dibulatkan ke atas", 5, 1000, 1},
+		{"0,4 dibulatkan ke bawah", 4, 1000, 0},
+		{"negatif dibulatkan menjauhi nol", -15, 5000, -8},
+		{"pecahan tarif", 333, 3333, 111},
+		{"100 persen", 123457, 10000, 123457},
+		{"nominal nol", 0, 1100, 0},
+		{"tarif nol", 150000, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PercentOf(tt.amount, tt.bps); got != tt.want {
+				t.Errorf("PercentOf(%d, %d) = %d, ingin %d", tt.amount, tt.bps, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{"sisa ke indeks pertama jika sisa sama", 100, []int64{1, 1, 1}, []int64{34, 33, 33}},
+		{"sisa ke sisa pembagian terbesar", 7, []int64{2, 3, 5}, []int64{1, 2, 4}},
+		{"diskon proporsional", 10000, []int64{100000, 90000}, []int64{5263, 4737}},
+		{"bobot nol tidak mendapat bagian", 10, []int64{0, 5, 5}, []int64{0, 5, 5}},
+		{"total lebih kecil dari jumlah bagian", 1, []int64{1, 1, 1}, []int64{1, 0, 0}},
+		{"total nol", 0, []int64{1, 2}, []int64{0, 0}},
+		{"semua bobot nol", 5, []int64{0, 0}, []int64{0, 0}},
+		{"tanpa bobot", 5, nil, []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Allocate(tt.total, tt.weights)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Allocate(%d, %v) = %v, ingin %v", tt.total, tt.weights, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateSumsToTotal(t *testing.T) {
+	weights := []int64{3, 7, 11, 13, 0, 17}
+	for total := int64(1); total <= 500; total++ {
+		var sum int64
+		for _, share := range Allocate(total, weights) {
+			sum += share
+		}
+		if sum != total {
+			t.Fatalf("jumlah Allocate(%d) = %d", total, sum)
+		}
+	}
+}
+
+func TestFormatRupiah(t *testing.T) {
+	tests := map[int64]string{
+		0:        "Rp0",
+		999:      "Rp999",
+		1000:     "Rp1.000",
+		150000:   "Rp150.000",
+		-1234567: "-Rp1.234.567",
+	}
+	for amount, want := range tests {
+		if got := FormatRupiah(amount); got != want {
+			t.Errorf("FormatRupiah(%d) = %q, ingin %q", amount, got, want)
+		}
+	}
+}
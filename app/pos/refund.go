@@ -0,0 +1,411 @@
+package pos
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/giftcard"
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/loyalty"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/payment"
+	"gin-sass-salon/app/prepaid"
+	"gin-sass-salon/config"
+)
+
+// ErrNothingToRefund dikembalikan jika tidak ada baris yang bisa dikembalikan
+var ErrNothingToRefund = errors.New("tidak ada item yang dapat di-refund")
+
+// ErrRefundQuantity dikembalikan jika jumlah refund melebihi sisa jumlah baris
+var ErrRefundQuantity = errors.New("jumlah refund melebihi sisa item pada transaksi")
+
+// ErrNotVoidable dikembalikan jika sale bukan transaksi hari ini atau sudah pernah di-refund
+var ErrNotVoidable = errors.New("void hanya untuk transaksi hari ini yang belum pernah di-refund")
+
+// ErrSaleClosed dikembalikan jika sale sudah di-void atau di-refund penuh
+var ErrSaleClosed = errors.New("transaksi sudah di-void atau di-refund penuh")
+
+// RefundLine adalah jumlah item dari satu baris sale yang dikembalikan
+type RefundLine struct {
+	SaleLineID uint
+	Quantity   int
+	// NoRestock untuk produk rusak/terpakai yang tidak dikembalikan ke stok
+	NoRestock bool
+}
+
+// RefundRequest adalah permintaan refund atau void. Jika Lines kosong, seluruh sisa sale
+// (termasuk tip) dikembalikan.
+type RefundRequest struct {
+	Type          string
+	Reason        string
+	Lines         []RefundLine
+	RequestedByID uint
+	ApprovedByID  uint
+}
+
+// LockSale mengambil sale milik salon beserta baris dan tendernya dengan FOR UPDATE
+func LockSale(tx *gorm.DB, salonID, saleID uint) (*models.Sale, error) {
+	var sale models.Sale
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("salon_id = ?", salonID).First(&sale, saleID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("sale_id = ?", sale.ID).Order("id").Find(&sale.Lines).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("sale_id = ?", sale.ID).Order("id").Find(&sale.Tenders).Error; err != nil {
+		return nil, err
+	}
+	return &sale, nil
+}
+
+// Refund mengembalikan sebagian atau seluruh sale. Nominal per baris dihitung proporsional
+// terhadap jumlah (termasuk pajak); refund terakhir sebuah baris mengambil sisanya agar tidak
+// ada selisih pembulatan. Service charge ikut dikembalikan sebanding dengan item yang di-refund.
+// Uang dikembalikan lewat tender asli, mulai dari tender terakhir;
+// tender dari payment provider dicatat sebagai refund pending yang dikirim ke provider setelah commit
+// lewat ProcessProviderRefunds. Sale harus dikunci dengan LockSale.
+func Refund(tx *gorm.DB, sale *models.Sale, req RefundRequest) (*models.SaleRefund, error) {
+	if sale.Status == models.SaleStatusVoided || sale.Status == models.SaleStatusRefunded {
+		return nil, ErrSaleClosed
+	}
+	if req.Type == models.RefundTypeVoid {
+		if sale.RefundedTotal > 0 || !sameDay(sale.CompletedAt, time.Now()) {
+			return nil, ErrNotVoidable
+		}
+		req.Lines = nil
+	}
+
+	requested := make(map[uint]int, len(req.Lines))
+	noRestock := make(map[uint]bool)
+	for _, line := range req.Lines {
+		if line.Quantity <= 0 {
+			return nil, ErrRefundQuantity
+		}
+		requested[line.SaleLineID] += line.Quantity
+		if line.NoRestock {
+			noRestock[line.SaleLineID] = true
+		}
+	}
+
+	refund := &models.SaleRefund{
+		SalonID:       sale.SalonID,
+		SaleID:        sale.ID,
+		Type:          req.Type,
+		Reason:        req.Reason,
+		RequestedByID: req.RequestedByID,
+		ApprovedByID:  req.ApprovedByID,
+		RefundedAt:    time.Now(),
+	}
+
+	for i := range sale.Lines {
+		line := &sale.Lines[i]
+		if line.Type == models.SaleLineDiscount {
+			continue
+		}
+		remaining := line.Quantity - line.RefundedQuantity
+
+		qty := remaining
+		if len(req.Lines) > 0 {
+			var ok bool
+			if qty, ok = requested[line.ID]; !ok {
+				continue
+			}
+			delete(requested, line.ID)
+			if qty > remaining {
+				return nil, ErrRefundQuantity
+			}
+		}
+		if qty == 0 {
+			continue
+		}
+
+		amount := line.LineTotal * int64(qty) / int64(line.Quantity)
+		tax := line.TaxAmount * int64(qty) / int64(line.Quantity)
+		if qty == remaining {
+			previousTax, err := refundedTax(tx, line.ID)
+			if err != nil {
+				return nil, err
+			}
+			amount = line.LineTotal - line.RefundedAmount
+			tax = line.TaxAmount - previousTax
+		}
+
+		if line.Type == models.SaleLineGiftCard {
+			if err := giftcard.VoidSold(tx, line, qty, req.ApprovedByID); err != nil {
+				return nil, err
+			}
+		}
+		if err := refundPrepaid(tx, line, qty); err != nil {
+			return nil, err
+		}
+		restock := line.Type == models.SaleLineProduct && !noRestock[line.ID]
+		if restock {
+			if err := inventory.Restock(tx, sale, line, qty, req.ApprovedByID); err != nil {
+				return nil, err
+			}
+		}
+
+		line.RefundedQuantity += qty
+		line.RefundedAmount += amount
+		if err := tx.Model(line).Select("refunded_quantity", "refunded_amount").Updates(line).Error; err != nil {
+			return nil, err
+		}
+
+		refund.Lines = append(refund.Lines, models.SaleRefundLine{
+			SaleLineID: line.ID,
+			Quantity:   qty,
+			Amount:     amount,
+			TaxAmount:  tax,
+			Restock:    restock,
+		})
+		refund.Amount += amount
+		refund.TaxAmount += tax
+	}
+
+	// Sisa di map berarti ID baris tidak ada di sale ini
+	if len(requested) > 0 {
+		return nil, ErrRefundQuantity
+	}
+	if err := refundServiceCharge(tx, sale, refund); err != nil {
+		return nil, err
+	}
+	if len(refund.Lines) == 0 {
+		return nil, ErrNothingToRefund
+	}
+
+	tenders, err := refundTenders(tx, sale, refund.Amount, req.ApprovedByID)
+	if err != nil {
+		return nil, err
+	}
+	refund.Tenders = tenders
+
+	usesCash := false
+	for _, tender := range tenders {
+		if tender.Method == models.TenderCash {
+			usesCash = true
+		}
+	}
+	// Refund tunai keluar dari laci cabang tempat transaksi dilakukan
+	if err := attachShift(tx, sale.SalonID, sale.BranchID, &refund.ShiftID, usesCash); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(refund).Error; err != nil {
+		return nil, err
+	}
+
+	sale.RefundedTotal += refund.Amount
+	sale.Status = models.SaleStatusPartiallyRefunded
+	if fullyRefunded(sale) {
+		sale.Status = models.SaleStatusRefunded
+	}
+	if req.Type == models.RefundTypeVoid {
+		sale.Status = models.SaleStatusVoided
+	}
+	if err := tx.Model(sale).Select("refunded_total", "status").Updates(sale).Error; err != nil {
+		return nil, err
+	}
+	if err := loyalty.Reverse(tx, sale, req.ApprovedByID); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// ProcessProviderRefunds mengirim refund payment provider milik refund sale ke provider. Dipanggil setelah
+// transaksi refund di-commit; kegagalan hanya dicatat di log karena job antrian akan mencoba ulang.
+func ProcessProviderRefunds(ctx context.Context, db *gorm.DB, refund *models.SaleRefund) {
+	for i := range refund.Tenders {
+		tender := &refund.Tenders[i]
+		if tender.PaymentRefundID == nil {
+			continue
+		}
+		paymentRefund, err := payment.ProcessRefund(ctx, db, *tender.PaymentRefundID)
+		if err != nil {
+			log.Printf("❌ Gagal mengirim refund #%d ke payment provider, dicoba ulang oleh worker: %v", *tender.PaymentRefundID, err)
+			continue
+		}
+		tender.ProviderRefundRef = paymentRefund.ProviderRefundRef
+	}
+}
+
+// refundTenders membagi nominal refund ke tender asli dari yang terakhir. Kapasitas tender tunai
+// dikurangi kembalian yang sudah diberikan saat checkout. Tender gift card dikreditkan kembali ke
+// gift card asalnya.
+func refundTenders(tx *gorm.DB, sale *models.Sale, amount int64, userID uint) ([]models.SaleRefundTender, error) {
+	change := sale.ChangeDue
+	capacity := make([]int64, len(sale.Tenders))
+	for i, tender := range sale.Tenders {
+		capacity[i] = tender.Amount
+		if tender.Method == models.TenderCash && change > 0 {
+			used := change
+			if used > capacity[i] {
+				used = capacity[i]
+			}
+			capacity[i] -= used
+			change -= used
+		}
+		capacity[i] -= tender.RefundedAmount
+	}
+
+	var result []models.SaleRefundTender
+	left := amount
+	for i := len(sale.Tenders) - 1; i >= 0 && left > 0; i-- {
+		if capacity[i] <= 0 {
+			continue
+		}
+		share := capacity[i]
+		if share > left {
+			share = left
+		}
+		tender := &sale.Tenders[i]
+
+		refundTender := models.SaleRefundTender{
+			SaleTenderID: tender.ID,
+			Method:       tender.Method,
+			Amount:       share,
+			PaymentID:    tender.PaymentID,
+		}
+		if tender.PaymentID != nil {
+			p := models.Payment{}
+			p.ID = *tender.PaymentID
+			paymentRefund, err := payment.RequestRefund(tx, &p, &sale.ID, share)
+			if err != nil {
+				return nil, err
+			}
+			refundTender.PaymentRefundID = &paymentRefund.ID
+		}
+		if tender.GiftCardID != nil {
+			if err := giftcard.Credit(tx, *tender.GiftCardID, share, sale.ID, tender.ID, userID); err != nil {
+				return nil, err
+			}
+		}
+
+		tender.RefundedAmount += share
+		if err := tx.Model(tender).Update("refunded_amount", tender.RefundedAmount).Error; err != nil {
+			return nil, err
+		}
+
+		result = append(result, refundTender)
+		left -= share
+	}
+
+	if left > 0 {
+		return nil, errors.New("nominal refund melebihi pembayaran yang tersisa")
+	}
+	return result, nil
+}
+
+// refundServiceCharge mengembalikan service charge yang belum di-refund sebanding dengan nilai item
+// yang sudah dikembalikan. Service charge berjumlah 1 sehingga refund sebagian dicatat dengan jumlah 0;
+// baris ditandai ter-refund setelah semua item dikembalikan seluruhnya.
+func refundServiceCharge(tx *gorm.DB, sale *models.Sale, refund *models.SaleRefund) error {
+	for i := range sale.Lines {
+		line := &sale.Lines[i]
+		if line.Type != models.SaleLineServiceCharge || line.RefundedQuantity >= line.Quantity {
+			continue
+		}
+		amount, tax, complete := serviceChargeRefunded(sale, line)
+		previousTax, err := refundedTax(tx, line.ID)
+		if err != nil {
+			return err
+		}
+		amount -= line.RefundedAmount
+		tax -= previousTax
+		if amount == 0 && tax == 0 && !complete {
+			continue
+		}
+
+		qty := 0
+		if complete {
+			qty = line.Quantity - line.RefundedQuantity
+		}
+		line.RefundedQuantity += qty
+		line.RefundedAmount += amount
+		if err := tx.Model(line).Select("refunded_quantity", "refunded_amount").Updates(line).Error; err != nil {
+			return err
+		}
+
+		refund.Lines = append(refund.Lines, models.SaleRefundLine{
+			SaleLineID: line.ID,
+			Quantity:   qty,
+			Amount:     amount,
+			TaxAmount:  tax,
+		})
+		refund.Amount += amount
+		refund.TaxAmount += tax
+	}
+	return nil
+}
+
+// serviceChargeRefunded menghitung total service charge (termasuk pajak) yang seharusnya sudah
+// dikembalikan, sebanding dengan dasar pengenaan item yang sudah di-refund. complete bernilai true jika
+// semua item sudah dikembalikan sehingga seluruh service charge ikut dikembalikan.
+func serviceChargeRefunded(sale *models.Sale, charge *models.SaleLine) (amount, tax int64, complete bool) {
+	var base, refunded int64
+	complete = true
+	for _, line := range sale.Lines {
+		switch line.Type {
+		case models.SaleLineDiscount, models.SaleLineTip, models.SaleLineServiceCharge, models.SaleLineGiftCard:
+			continue
+		}
+		base += line.TaxableAmount
+		refunded += line.TaxableAmount * int64(line.RefundedQuantity) / int64(line.Quantity)
+		if line.RefundedQuantity < line.Quantity {
+			complete = false
+		}
+	}
+	if complete {
+		return charge.LineTotal, charge.TaxAmount, true
+	}
+	if base == 0 {
+		return 0, 0, false
+	}
+	return charge.LineTotal * refunded / base, charge.TaxAmount * refunded / base, false
+}
+
+// refundedTax menjumlahkan pajak yang sudah dikembalikan untuk satu baris sale
+func refundedTax(tx *gorm.DB, saleLineID uint) (int64, error) {
+	var total int64
+	err := tx.Model(&models.SaleRefundLine{}).Where("sale_line_id = ?", saleLineID).
+		Select("COALESCE(SUM(tax_amount), 0)").Scan(&total).Error
+	return total, err
+}
+
+// refundPrepaid membatalkan paket/periode membership yang dijual, atau mengembalikan kredit yang
+// dipakai, sesuai jenis baris yang di-refund
+func refundPrepaid(tx *gorm.DB, line *models.SaleLine, qty int) error {
+	switch {
+	case line.Type == models.SaleLinePackage:
+		return prepaid.VoidPackages(tx, line, qty)
+	case line.Type == models.SaleLineMembership:
+		return prepaid.RefundPeriods(tx, line, qty, time.Now())
+	case line.PrepaidCredit:
+		return prepaid.Restore(tx, line, qty)
+	}
+	return nil
+}
+
+// fullyRefunded mengecek apakah semua baris item dan tip sudah dikembalikan seluruhnya
+func fullyRefunded(sale *models.Sale) bool {
+	for _, line := range sale.Lines {
+		if line.Type != models.SaleLineDiscount && line.RefundedQuantity < line.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// sameDay mengecek apakah dua waktu jatuh di tanggal yang sama pada zona waktu aplikasi
+func sameDay(a, b time.Time) bool {
+	loc := config.Location()
+	y1, m1, d1 := a.In(loc).Date()
+	y2, m2, d2 := b.In(loc).Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
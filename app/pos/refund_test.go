@@ -0,0 +1,54 @@
+package pos
+
+import (
+	"testing"
+
+	"gin-sass-salon/app/models"
+)
+
+func TestServiceChargeRefunded(t *testing.T) {
+	ppn := Rate{TaxRateID: 1, Name: "PPN", Bps: 1100}
+	cart := Cart{Items: []Item{
+		{Type: models.SaleLineService, Description: "Potong", Quantity: 1, UnitPrice: 100000, Rates: []Rate{ppn}},
+		{Type: models.SaleLineProduct, Description: "Shampo", Quantity: 2, UnitPrice: 50000, Rates: []Rate{ppn}},
+		{Type: models.SaleLineGiftCard, Description: "Gift card", Quantity: 1, UnitPrice: 100000},
+	}}
+
+	tests := []struct {
+		name string
+		// refunded adalah jumlah yang sudah di-refund untuk layanan, produk dan gift card
+		refunded     [3]int
+		wantAmount   int64
+		wantTax      int64
+		wantComplete bool
+	}{
+		// Service charge 5% dari DPP 200000 = 10000 + pajak 1100 = 11100
+		{"belum ada refund", [3]int{0, 0, 0}, 0, 0, false},
+		{"satu dari dua produk", [3]int{0, 1, 0}, 2775, 275, false},
+		{"layanan saja", [3]int{1, 0, 0}, 5550, 550, false},
+		{"layanan dan satu produk", [3]int{1, 1, 0}, 8325, 825, false},
+		{"gift card tidak dihitung", [3]int{0, 0, 1}, 0, 0, false},
+		{"semua item kecuali gift card", [3]int{1, 2, 0}, 11100, 1100, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sale, err := Calculate(cart, TaxConfig{ServiceChargeBps: 500, ServiceChargeRates: []Rate{ppn}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, qty := range tt.refunded {
+				sale.Lines[i].RefundedQuantity = qty
+			}
+
+			charge := &sale.Lines[len(sale.Lines)-1]
+			if charge.Type != models.SaleLineServiceCharge || charge.LineTotal != 11100 {
+				t.Fatalf("baris service charge = %s %d, ingin service_charge 11100", charge.Type, charge.LineTotal)
+			}
+			amount, tax, complete := serviceChargeRefunded(sale, charge)
+			if amount != tt.wantAmount || tax != tt.wantTax || complete != tt.wantComplete {
+				t.Errorf("serviceChargeRefunded = %d/%d/%v, ingin %d/%d/%v", amount, tax, complete,
+					tt.wantAmount, tt.wantTax, tt.wantComplete)
+			}
+		})
+	}
+}
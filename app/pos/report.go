@@ -0,0 +1,132 @@
+package pos
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/models"
+)
+
+// TenderSummary adalah total per metode pembayaran
+type TenderSummary struct {
+	Method string `json:"method"`
+	Count  int    `json:"count"`
+	Amount int64  `json:"amount"`
+}
+
+// ZReport adalah ringkasan akhir hari satu salon atau satu cabang
+type ZReport struct {
+	SalonID uint `json:"salon_id"`
+	// BranchID 0 berarti semua cabang
+	BranchID      uint            `json:"branch_id"`
+	Date          string          `json:"date"`
+	SalesCount    int             `json:"sales_count"`
+	Subtotal      int64           `json:"subtotal"`
+	DiscountTotal int64           `json:"discount_total"`
+	TaxTotal      int64           `json:"tax_total"`
+	ServiceCharge int64           `json:"service_charge_total"`
+	TipTotal      int64           `json:"tip_total"`
+	Total         int64           `json:"total"`
+	ChangeTotal   int64           `json:"change_total"`
+	Tenders       []TenderSummary `json:"tenders"`
+	RefundCount   int             `json:"refund_count"`
+	VoidCount     int             `json:"void_count"`
+	RefundTotal   int64           `json:"refund_total"`
+	RefundTax     int64           `json:"refund_tax"`
+	Refunds       []TenderSummary `json:"refunds"`
+	// NetSales = Total - RefundTotal (termasuk pajak dan tip)
+	NetSales int64              `json:"net_sales"`
+	Shifts   []models.CashShift `json:"shifts"`
+}
+
+// BuildZReport merangkum penjualan, pembayaran per metode, refund/void, tip dan shift kasir
+// pada rentang [start, start+1 hari). branchID 0 berarti semua cabang; refund ikut cabang sale asalnya.
+func BuildZReport(db *gorm.DB, salonID, branchID uint, start time.Time) (*ZReport, error) {
+	end := start.AddDate(0, 0, 1)
+	report := &ZReport{SalonID: salonID, BranchID: branchID, Date: start.Format("2006-01-02")}
+
+	sales := db.Model(&models.Sale{}).Where("salon_id = ?", salonID)
+	if branchID != 0 {
+		sales = sales.Where("branch_id = ?", branchID)
+	}
+	saleIDs := sales.Session(&gorm.Session{}).Select("id")
+
+	var totals struct {
+		SalesCount    int
+		Subtotal      int64
+		DiscountTotal int64
+		TaxTotal      int64
+		ServiceCharge int64
+		TipTotal      int64
+		Total         int64
+		ChangeTotal   int64
+	}
+	if err := sales.Session(&gorm.Session{}).
+		Select(`COUNT(*) AS sales_count, COALESCE(SUM(subtotal), 0) AS subtotal,
+			COALESCE(SUM(discount_total), 0) AS discount_total, COALESCE(SUM(tax_total), 0) AS tax_total,
+			COALESCE(SUM(service_charge_total), 0) AS service_charge, COALESCE(SUM(tip_total), 0) AS tip_total, COALESCE(SUM(total), 0) AS total,
+			COALESCE(SUM(change_due), 0) AS change_total`).
+		Where("completed_at >= ? AND completed_at < ?", start, end).
+		Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	report.SalesCount = totals.SalesCount
+	report.Subtotal = totals.Subtotal
+	report.DiscountTotal = totals.DiscountTotal
+	report.TaxTotal = totals.TaxTotal
+	report.ServiceCharge = totals.ServiceCharge
+	report.TipTotal = totals.TipTotal
+	report.Total = totals.Total
+	report.ChangeTotal = totals.ChangeTotal
+
+	if err := db.Table("sale_tenders t").
+		Select("t.method, COUNT(*) AS count, SUM(t.amount) AS amount").
+		Joins("JOIN sales s ON s.id = t.sale_id AND s.deleted_at IS NULL").
+		Where("s.salon_id = ? AND s.completed_at >= ? AND s.completed_at < ? AND t.deleted_at IS NULL", salonID, start, end).
+		Where("t.sale_id IN (?)", saleIDs).
+		Group("t.method").Order("t.method").
+		Scan(&report.Tenders).Error; err != nil {
+		return nil, err
+	}
+
+	var refunds struct {
+		RefundCount int
+		VoidCount   int
+		RefundTotal int64
+		RefundTax   int64
+	}
+	if err := db.Model(&models.SaleRefund{}).
+		Select(`COUNT(*) FILTER (WHERE type = ?) AS refund_count, COUNT(*) FILTER (WHERE type = ?) AS void_count,
+			COALESCE(SUM(amount), 0) AS refund_total, COALESCE(SUM(tax_amount), 0) AS refund_tax`,
+			models.RefundTypeRefund, models.RefundTypeVoid).
+		Where("salon_id = ? AND refunded_at >= ? AND refunded_at < ? AND sale_id IN (?)", salonID, start, end, saleIDs).
+		Scan(&refunds).Error; err != nil {
+		return nil, err
+	}
+	report.RefundCount = refunds.RefundCount
+	report.VoidCount = refunds.VoidCount
+	report.RefundTotal = refunds.RefundTotal
+	report.RefundTax = refunds.RefundTax
+
+	if err := db.Table("sale_refund_tenders rt").
+		Select("rt.method, COUNT(*) AS count, SUM(rt.amount) AS amount").
+		Joins("JOIN sale_refunds r ON r.id = rt.refund_id AND r.deleted_at IS NULL").
+		Where("r.salon_id = ? AND r.refunded_at >= ? AND r.refunded_at < ? AND rt.deleted_at IS NULL", salonID, start, end).
+		Where("r.sale_id IN (?)", saleIDs).
+		Group("rt.method").Order("rt.method").
+		Scan(&report.Refunds).Error; err != nil {
+		return nil, err
+	}
+
+	shifts := db.Where("salon_id = ? AND opened_at < ? AND (closed_at IS NULL OR closed_at >= ?)", salonID, end, start)
+	if branchID != 0 {
+		shifts = shifts.Where("branch_id = ?", branchID)
+	}
+	if err := shifts.Order("opened_at").Find(&report.Shifts).Error; err != nil {
+		return nil, err
+	}
+
+	report.NetSales = report.Total - report.RefundTotal
+	return report, nil
+}
@@ -0,0 +1,163 @@
+package pos
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/models"
+)
+
+// ErrShiftAlreadyOpen dikembalikan jika cabang masih memiliki shift kasir terbuka
+var ErrShiftAlreadyOpen = errors.New("masih ada shift kasir yang terbuka di cabang ini")
+
+// ErrNoOpenShift dikembalikan jika transaksi tunai dilakukan tanpa shift kasir terbuka
+var ErrNoOpenShift = errors.New("belum ada shift kasir yang terbuka")
+
+// MigrateShiftBranches menyesuaikan data shift lama ke shift per cabang: index lama satu shift terbuka per
+// salon dihapus (AutoMigrate tidak menghapus index) dan shift tanpa cabang dipindahkan ke cabang utama salon
+func MigrateShiftBranches(db *gorm.DB) error {
+	if db.Migrator().HasIndex(&models.CashShift{}, "idx_cash_shifts_open") {
+		if err := db.Migrator().DropIndex(&models.CashShift{}, "idx_cash_shifts_open"); err != nil {
+			return err
+		}
+	}
+	return inventory.AssignMainBranch(db, "cash_shifts")
+}
+
+// OpenShift membuka shift kasir baru di cabang dengan modal awal
+func OpenShift(tx *gorm.DB, salonID, branchID, userID uint, openingFloat int64) (*models.CashShift, error) {
+	if _, err := CurrentShift(tx, salonID, branchID); err == nil {
+		return nil, ErrShiftAlreadyOpen
+	} else if !errors.Is(err, ErrNoOpenShift) {
+		return nil, err
+	}
+
+	shift := models.CashShift{
+		SalonID:      salonID,
+		BranchID:     &branchID,
+		Status:       models.CashShiftOpen,
+		OpenedByID:   userID,
+		OpenedAt:     time.Now(),
+		OpeningFloat: openingFloat,
+	}
+	if err := tx.Create(&shift).Error; err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// CurrentShift mengambil shift terbuka cabang dengan FOR UPDATE sehingga kas masuk/keluar dan
+// penutupan shift tidak berjalan bersamaan
+func CurrentShift(tx *gorm.DB, salonID, branchID uint) (*models.CashShift, error) {
+	var shift models.CashShift
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("salon_id = ? AND branch_id = ? AND status = ?", salonID, branchID, models.CashShiftOpen).
+		First(&shift).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoOpenShift
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// AddCashMovement mencatat kas masuk atau keluar pada shift terbuka
+func AddCashMovement(tx *gorm.DB, shift *models.CashShift, movementType string, amount int64, reason string, userID uint) (*models.CashMovement, error) {
+	if amount <= 0 {
+		return nil, errors.New("nominal harus lebih dari 0")
+	}
+	movement := models.CashMovement{
+		ShiftID:   shift.ID,
+		Type:      movementType,
+		Amount:    amount,
+		Reason:    reason,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+	if err := tx.Create(&movement).Error; err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+// ExpectedCash menghitung uang yang seharusnya ada di laci: modal awal + tunai diterima - kembalian
+// + kas masuk - kas keluar - refund tunai
+func ExpectedCash(tx *gorm.DB, shift *models.CashShift) (int64, error) {
+	var totals struct {
+		CashIn      int64
+		ChangeTotal int64
+		Movements   int64
+		CashRefunds int64
+	}
+
+	err := tx.Raw(`
+		SELECT
+			(SELECT COALESCE(SUM(t.amount), 0) FROM sale_tenders t JOIN sales s ON s.id = t.sale_id
+				WHERE s.shift_id = @shift AND t.method = @cash AND t.deleted_at IS NULL AND s.deleted_at IS NULL) AS cash_in,
+			(SELECT COALESCE(SUM(s.change_due), 0) FROM sales s
+				WHERE s.shift_id = @shift AND s.deleted_at IS NULL) AS change_total,
+			(SELECT COALESCE(SUM(CASE WHEN m.type = @in THEN m.amount ELSE -m.amount END), 0) FROM cash_movements m
+				WHERE m.shift_id = @shift) AS movements,
+			(SELECT COALESCE(SUM(rt.amount), 0) FROM sale_refund_tenders rt JOIN sale_refunds r ON r.id = rt.refund_id
+				WHERE r.shift_id = @shift AND rt.method = @cash AND rt.deleted_at IS NULL AND r.deleted_at IS NULL) AS cash_refunds`,
+		map[string]interface{}{"shift": shift.ID, "cash": models.TenderCash, "in": models.CashMovementIn},
+	).Scan(&totals).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return shift.OpeningFloat + totals.CashIn - totals.ChangeTotal + totals.Movements - totals.CashRefunds, nil
+}
+
+// CloseShift menutup shift dengan uang hasil hitung kasir dan mencatat selisihnya
+func CloseShift(tx *gorm.DB, shift *models.CashShift, counted int64, userID uint, notes string) error {
+	expected, err := ExpectedCash(tx, shift)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	shift.Status = models.CashShiftClosed
+	shift.ClosedByID = &userID
+	shift.ClosedAt = &now
+	shift.ExpectedCash = expected
+	shift.CountedCash = &counted
+	shift.Variance = counted - expected
+	shift.Notes = notes
+	return tx.Model(shift).
+		Select("status", "closed_by_id", "closed_at", "expected_cash", "counted_cash", "variance", "notes").
+		Updates(shift).Error
+}
+
+// attachShift mengisi shift terbuka cabang ke shiftID. Baris shift dikunci FOR SHARE agar shift
+// tidak bisa ditutup sebelum transaksi ini selesai. Jika tidak ada shift terbuka (atau sale lama tanpa
+// cabang), hanya transaksi tanpa uang tunai yang diizinkan.
+func attachShift(tx *gorm.DB, salonID uint, branchID *uint, shiftID **uint, usesCash bool) error {
+	if branchID == nil {
+		if usesCash {
+			return ErrNoOpenShift
+		}
+		return nil
+	}
+
+	var shift models.CashShift
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("salon_id = ? AND branch_id = ? AND status = ?", salonID, *branchID, models.CashShiftOpen).
+		First(&shift).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if usesCash {
+			return ErrNoOpenShift
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	*shiftID = &shift.ID
+	return nil
+}
@@ -0,0 +1,179 @@
+package pos
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+)
+
+// ErrUnknownTaxClass dikembalikan jika kelas pajak bukan milik salon
+var ErrUnknownTaxClass = errors.New("kelas pajak tidak ditemukan di salon ini")
+
+// ErrTaxRateOverlap dikembalikan jika rentang tarif baru bertumpuk dengan tarif lain bernama sama di kelas yang sama
+var ErrTaxRateOverlap = errors.New("rentang tanggal tarif bertumpuk dengan tarif lain bernama sama")
+
+// Rate adalah tarif pajak yang berlaku untuk satu baris pada waktu transaksi
+type Rate struct {
+	TaxRateID uint
+	Name      string
+	Bps       int
+}
+
+// TaxConfig adalah pengaturan pajak salon yang dipakai Calculate
+type TaxConfig struct {
+	PricesIncludeTax   bool
+	ServiceChargeBps   int
+	ServiceChargeRates []Rate
+}
+
+// TaxResolver mencari tarif pajak yang berlaku untuk kelas pajak salon pada satu waktu.
+// Hasilnya di-cache per kelas selama satu perhitungan.
+type TaxResolver struct {
+	db      *gorm.DB
+	salonID uint
+	at      time.Time
+	cache   map[uint][]Rate
+	// defaultClass berisi ID kelas default (0 jika tidak ada) setelah pertama kali dicari
+	defaultClass *uint
+}
+
+// NewTaxResolver membuat resolver tarif pajak salon pada waktu at
+func NewTaxResolver(db *gorm.DB, salonID uint, at time.Time) *TaxResolver {
+	return &TaxResolver{db: db, salonID: salonID, at: at, cache: map[uint][]Rate{}}
+}
+
+// Rates mengembalikan tarif yang berlaku untuk kelas pajak; nil berarti kelas default salon
+func (r *TaxResolver) Rates(classID *uint) ([]Rate, error) {
+	if classID == nil {
+		if r.defaultClass == nil {
+			var class models.TaxClass
+			err := r.db.Where("salon_id = ? AND is_default", r.salonID).First(&class).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			id := class.ID
+			r.defaultClass = &id
+		}
+		if *r.defaultClass == 0 {
+			return nil, nil
+		}
+		classID = r.defaultClass
+	}
+
+	if rates, ok := r.cache[*classID]; ok {
+		return rates, nil
+	}
+
+	var class models.TaxClass
+	if err := r.db.Where("salon_id = ?", r.salonID).First(&class, *classID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnknownTaxClass
+		}
+		return nil, err
+	}
+
+	var taxRates []models.TaxRate
+	if err := r.db.Where("tax_class_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", class.ID, r.at, r.at).
+		Order("id").Find(&taxRates).Error; err != nil {
+		return nil, err
+	}
+
+	rates := make([]Rate, 0, len(taxRates))
+	for _, rate := range taxRates {
+		rates = append(rates, Rate{TaxRateID: rate.ID, Name: rate.Name, Bps: rate.RateBps})
+	}
+	r.cache[*classID] = rates
+	return rates, nil
+}
+
+// AddTaxRate menyimpan tarif baru pada kelas pajak. Kelas dikunci FOR UPDATE agar dua tarif tidak ditambahkan
+// bersamaan. Lihat planTaxRate untuk aturan tarif lama yang ditutup dan tumpukan yang ditolak.
+func AddTaxRate(tx *gorm.DB, rate *models.TaxRate) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.TaxClass{}, rate.TaxClassID).Error; err != nil {
+		return err
+	}
+
+	var existing []models.TaxRate
+	if err := tx.Where("tax_class_id = ? AND name = ?", rate.TaxClassID, rate.Name).Find(&existing).Error; err != nil {
+		return err
+	}
+	closeIDs, err := planTaxRate(existing, *rate)
+	if err != nil {
+		return err
+	}
+	if len(closeIDs) > 0 {
+		if err := tx.Model(&models.TaxRate{}).Where("id IN ?", closeIDs).Update("effective_to", rate.EffectiveFrom).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Create(rate).Error
+}
+
+// planTaxRate mengembalikan tarif lama yang ditutup pada tanggal mulai tarif baru. Pergantian tarif (tarif baru
+// tanpa batas akhir setelah tarif lama tanpa batas akhir) menutup tarif lama; tumpukan rentang [from, to) lain
+// ditolak dengan ErrTaxRateOverlap karena TaxResolver menjumlahkan semua tarif yang berlaku.
+func planTaxRate(existing []models.TaxRate, rate models.TaxRate) ([]uint, error) {
+	var closeIDs []uint
+	for _, old := range existing {
+		if rate.EffectiveTo == nil && old.EffectiveTo == nil && old.EffectiveFrom.Before(rate.EffectiveFrom) {
+			closeIDs = append(closeIDs, old.ID)
+			continue
+		}
+		startsBeforeEnd := rate.EffectiveTo == nil || old.EffectiveFrom.Before(*rate.EffectiveTo)
+		endsAfterStart := old.EffectiveTo == nil || old.EffectiveTo.After(rate.EffectiveFrom)
+		if startsBeforeEnd && endsAfterStart {
+			return nil, ErrTaxRateOverlap
+		}
+	}
+	return closeIDs, nil
+}
+
+// applyTax mengisi pajak baris dari NetAmount. Untuk harga termasuk pajak, dasar pengenaan pajak
+// dihitung mundur (net * 10000 / (10000 + total tarif)) lalu pajaknya dibagi ke tiap tarif secara
+// proporsional; untuk harga belum termasuk pajak, tiap tarif dihitung dari net dan ditambahkan.
+func applyTax(line *models.SaleLine, rates []Rate, inclusive bool) {
+	line.Taxes = nil
+	line.TaxAmount = 0
+	line.TaxableAmount = line.NetAmount
+	line.LineTotal = line.NetAmount
+	if len(rates) == 0 || line.NetAmount == 0 {
+		return
+	}
+
+	if inclusive {
+		totalBps := 0
+		weights := make([]int64, len(rates))
+		for i, rate := range rates {
+			totalBps += rate.Bps
+			weights[i] = int64(rate.Bps)
+		}
+		line.TaxableAmount = (line.NetAmount*10000 + int64(10000+totalBps)/2) / int64(10000+totalBps)
+		line.TaxAmount = line.NetAmount - line.TaxableAmount
+
+		for i, amount := range Allocate(line.TaxAmount, weights) {
+			line.Taxes = append(line.Taxes, lineTax(rates[i], line.TaxableAmount, amount))
+		}
+		return
+	}
+
+	for _, rate := range rates {
+		amount := PercentOf(line.NetAmount, rate.Bps)
+		line.Taxes = append(line.Taxes, lineTax(rate, line.NetAmount, amount))
+		line.TaxAmount += amount
+	}
+	line.LineTotal = line.NetAmount + line.TaxAmount
+}
+
+func lineTax(rate Rate, taxable, amount int64) models.SaleLineTax {
+	return models.SaleLineTax{
+		TaxRateID:     rate.TaxRateID,
+		Name:          rate.Name,
+		RateBps:       rate.Bps,
+		TaxableAmount: taxable,
+		Amount:        amount,
+	}
+}
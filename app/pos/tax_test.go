@@ -0,0 +1,142 @@
+package pos
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"gin-sass-salon/app/models"
+)
+
+func TestApplyTax(t *testing.T) {
+	ppn := Rate{TaxRateID: 1, Name: "PPN", Bps: 1100}
+	pb1 := Rate{TaxRateID: 2, Name: "PB1", Bps: 1000}
+	levy := Rate{TaxRateID: 3, Name: "Retribusi", Bps: 250}
+	local := Rate{TaxRateID: 4, Name: "Pajak daerah", Bps: 100}
+
+	tests := []struct {
+		name        string
+		net         int64
+		rates       []Rate
+		inclusive   bool
+		wantTaxable int64
+		wantTax     int64
+		wantTotal   int64
+		wantAmounts []int64
+	}{
+		{"eksklusif satu tarif", 100000, []Rate{ppn}, false, 100000, 11000, 111000, []int64{11000}},
+		{"eksklusif tiap tarif dibulatkan half-up", 99999, []Rate{pb1, levy}, false, 99999, 12500, 112499, []int64{10000, 2500}},
+		{"inklusif tepat", 111000, []Rate{ppn}, true, 100000, 11000, 111000, []int64{11000}},
+		{"inklusif dihitung mundur dan dibulatkan", 100000, []Rate{ppn}, true, 90090, 9910, 100000, []int64{9910}},
+		{"inklusif beberapa tarif dibagi proporsional", 55000, []Rate{pb1, local}, true, 49550, 5450, 55000, []int64{4955, 495}},
+		{"tanpa tarif", 50000, nil, false, 50000, 0, 50000, nil},
+		{"nilai nol", 0, []Rate{ppn}, true, 0, 0, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := models.SaleLine{NetAmount: tt.net}
+			applyTax(&line, tt.rates, tt.inclusive)
+
+			if line.TaxableAmount != tt.wantTaxable || line.TaxAmount != tt.wantTax || line.LineTotal != tt.wantTotal {
+				t.Errorf("DPP/pajak/total = %d/%d/%d, ingin %d/%d/%d", line.TaxableAmount, line.TaxAmount,
+					line.LineTotal, tt.wantTaxable, tt.wantTax, tt.wantTotal)
+			}
+			if len(line.Taxes) != len(tt.wantAmounts) {
+				t.Fatalf("jumlah rincian pajak = %d, ingin %d", len(line.Taxes), len(tt.wantAmounts))
+			}
+			var sum int64
+			for i, tax := range line.Taxes {
+				if tax.Amount != tt.wantAmounts[i] || tax.TaxableAmount != tt.wantTaxable || tax.RateBps != tt.rates[i].Bps {
+					t.Errorf("rincian %d = %+v, ingin pajak %d dari DPP %d", i, tax, tt.wantAmounts[i], tt.wantTaxable)
+				}
+				sum += tax.Amount
+			}
+			if sum != line.TaxAmount {
+				t.Errorf("jumlah rincian %d != pajak baris %d", sum, line.TaxAmount)
+			}
+		})
+	}
+}
+
+func TestCalculateTaxAndServiceCharge(t *testing.T) {
+	ppn := Rate{TaxRateID: 1, Name: "PPN", Bps: 1100}
+
+	tests := []struct {
+		name              string
+		price             int64
+		discountBps       int
+		inclusive         bool
+		wantItemTaxable   int64
+		wantServiceCharge int64
+		wantTax           int64
+		wantTotal         int64
+	}{
+		// Service charge 5% dari DPP 100000 = 5000, pajaknya 550 selalu ditambahkan
+		{"harga belum termasuk pajak", 100000, 0, false, 100000, 5000, 11550, 116550},
+		{"harga termasuk pajak", 111000, 0, true, 100000, 5000, 11550, 116550},
+		// Diskon 10% dari 111000 = 99900, DPP dihitung mundur 90000, service charge 4500 + pajak 495
+		{"harga termasuk pajak dengan diskon", 111000, 1000, true, 90000, 4500, 10395, 104895},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := Cart{Items: []Item{{
+				Type: models.SaleLineService, Description: "Potong", Quantity: 1, UnitPrice: tt.price,
+				DiscountBps: tt.discountBps, Rates: []Rate{ppn},
+			}}}
+			cfg := TaxConfig{PricesIncludeTax: tt.inclusive, ServiceChargeBps: 500, ServiceChargeRates: []Rate{ppn}}
+			sale, err := Calculate(cart, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := sale.Lines[0].TaxableAmount; got != tt.wantItemTaxable {
+				t.Errorf("DPP item = %d, ingin %d", got, tt.wantItemTaxable)
+			}
+			if sale.ServiceChargeTotal != tt.wantServiceCharge || sale.TaxTotal != tt.wantTax || sale.Total != tt.wantTotal {
+				t.Errorf("service charge/pajak/total = %d/%d/%d, ingin %d/%d/%d", sale.ServiceChargeTotal,
+					sale.TaxTotal, sale.Total, tt.wantServiceCharge, tt.wantTax, tt.wantTotal)
+			}
+
+			last := sale.Lines[len(sale.Lines)-1]
+			if last.Type != models.SaleLineServiceCharge || last.TaxAmount != PercentOf(tt.wantServiceCharge, ppn.Bps) {
+				t.Errorf("baris service charge = %s pajak %d", last.Type, last.TaxAmount)
+			}
+		})
+	}
+}
+
+func TestPlanTaxRate(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	until := func(d int) *time.Time { to := day(d); return &to }
+	open := models.TaxRate{Name: "PPN", RateBps: 1100, EffectiveFrom: day(1)}
+	open.ID = 1
+	closed := models.TaxRate{Name: "PPN", RateBps: 1100, EffectiveFrom: day(1), EffectiveTo: until(10)}
+	closed.ID = 2
+
+	tests := []struct {
+		name      string
+		existing  []models.TaxRate
+		rate      models.TaxRate
+		wantClose []uint
+		wantErr   error
+	}{
+		{"pergantian tarif menutup tarif lama", []models.TaxRate{open}, models.TaxRate{EffectiveFrom: day(15)}, []uint{1}, nil},
+		{"tarif mundur sebelum tarif berjalan bertumpuk", []models.TaxRate{open}, models.TaxRate{EffectiveFrom: day(1)}, nil, ErrTaxRateOverlap},
+		{"tarif dengan tanggal akhir di tengah tarif berjalan", []models.TaxRate{open}, models.TaxRate{EffectiveFrom: day(5), EffectiveTo: until(8)}, nil, ErrTaxRateOverlap},
+		{"tarif sebelum tarif berjalan dimulai", []models.TaxRate{{EffectiveFrom: day(10)}}, models.TaxRate{EffectiveFrom: day(1), EffectiveTo: until(10)}, nil, nil},
+		{"tarif tanpa akhir bertumpuk dengan rentang tertutup", []models.TaxRate{closed}, models.TaxRate{EffectiveFrom: day(5)}, nil, ErrTaxRateOverlap},
+		{"tarif setelah rentang tertutup", []models.TaxRate{closed}, models.TaxRate{EffectiveFrom: day(10)}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := planTaxRate(tt.existing, tt.rate)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, ingin %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.wantClose) {
+				t.Errorf("tarif ditutup = %v, ingin %v", got, tt.wantClose)
+			}
+		})
+	}
+}
@@ -0,0 +1,560 @@
+package prepaid
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/mailer"
+	"gin-sass-salon/app/models"
+)
+
+// ErrCustomerRequired dikembalikan jika paket, membership atau kredit dipakai tanpa data pelanggan
+var ErrCustomerRequired = errors.New("paket, membership dan pemakaian kredit membutuhkan data pelanggan")
+
+// ErrNoCredit dikembalikan jika kredit paket/benefit membership untuk layanan tidak mencukupi
+var ErrNoCredit = errors.New("kredit paket/membership untuk layanan ini tidak mencukupi")
+
+// ErrAlreadyUsed dikembalikan jika paket yang akan di-refund sudah dipakai
+var ErrAlreadyUsed = errors.New("paket sudah dipakai sehingga tidak dapat di-refund")
+
+// ErrNotFound dikembalikan jika paket/plan pada baris penjualan tidak ada di katalog salon
+var ErrNotFound = errors.New("paket atau plan membership tidak ditemukan")
+
+// Available menghitung sisa kredit layanan pelanggan tanpa mengunci (untuk quote). unlimited bernilai
+// true jika pelanggan memiliki membership aktif dengan benefit tanpa batas untuk layanan tersebut.
+func Available(db *gorm.DB, customerID, serviceID uint, now time.Time) (int, bool, error) {
+	var memberships []models.Membership
+	if err := activeMemberships(db, customerID, now).Find(&memberships).Error; err != nil {
+		return 0, false, err
+	}
+
+	total := 0
+	for _, m := range memberships {
+		left, unlimited, _, err := membershipAllowance(db, &m, serviceID, now)
+		if err != nil {
+			return 0, false, err
+		}
+		if unlimited {
+			return 0, true, nil
+		}
+		total += left
+	}
+
+	var credits []models.PackageCredit
+	if err := packageCredits(db, customerID, serviceID, now).Find(&credits).Error; err != nil {
+		return 0, false, err
+	}
+	for _, credit := range credits {
+		total += credit.Quantity - credit.Used
+	}
+	return total, false, nil
+}
+
+// AttachToSale dijalankan setelah sale tersimpan di transaksi yang sama: menerbitkan paket, membuat
+// atau memperpanjang membership, dan memotong kredit untuk layanan yang dibayar dengan kredit.
+func AttachToSale(tx *gorm.DB, sale *models.Sale) error {
+	now := time.Now()
+	for i := range sale.Lines {
+		line := &sale.Lines[i]
+		if line.Type != models.SaleLinePackage && line.Type != models.SaleLineMembership && !line.PrepaidCredit {
+			continue
+		}
+		if sale.CustomerID == nil {
+			return ErrCustomerRequired
+		}
+
+		var err error
+		switch {
+		case line.Type == models.SaleLinePackage:
+			err = issuePackages(tx, sale, line, now)
+		case line.Type == models.SaleLineMembership:
+			_, err = Renew(tx, sale, line, now)
+		default:
+			err = consume(tx, sale, line, now)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// issuePackages menerbitkan satu paket pelanggan per quantity beserta kredit layanannya
+func issuePackages(tx *gorm.DB, sale *models.Sale, line *models.SaleLine, now time.Time) error {
+	var pkg models.Package
+	if line.PackageID == nil {
+		return ErrNotFound
+	}
+	if err := tx.Preload("Items").Where("salon_id = ?", sale.SalonID).First(&pkg, *line.PackageID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	var expires *time.Time
+	if pkg.ValidityDays > 0 {
+		t := now.AddDate(0, 0, pkg.ValidityDays)
+		expires = &t
+	}
+	for n := 0; n < line.Quantity; n++ {
+		cp := models.CustomerPackage{
+			SalonID:    sale.SalonID,
+			CustomerID: *sale.CustomerID,
+			PackageID:  pkg.ID,
+			Name:       pkg.Name,
+			SaleID:     &sale.ID,
+			SaleLineID: &line.ID,
+			Status:     models.CustomerPackageStatusActive,
+			ExpiresAt:  expires,
+		}
+		for _, item := range pkg.Items {
+			cp.Credits = append(cp.Credits, models.PackageCredit{ServiceID: item.ServiceID, Quantity: item.Quantity})
+		}
+		if err := tx.Create(&cp).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Renew membuat membership baru atau memperpanjang membership pelanggan pada plan yang sama sebanyak
+// quantity periode. Perpanjangan dimulai dari akhir periode terakhir yang dibayar sehingga tidak ada celah.
+func Renew(tx *gorm.DB, sale *models.Sale, line *models.SaleLine, now time.Time) (*models.Membership, error) {
+	var plan models.MembershipPlan
+	if line.MembershipPlanID == nil {
+		return nil, ErrNotFound
+	}
+	if err := tx.Where("salon_id = ?", sale.SalonID).First(&plan, *line.MembershipPlanID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var m models.Membership
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("customer_id = ? AND plan_id = ? AND status IN ?", *sale.CustomerID, plan.ID,
+			[]string{models.MembershipStatusActive, models.MembershipStatusPastDue}).
+		Order("id DESC").First(&m).Error
+	start := now
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		m = models.Membership{
+			SalonID:    sale.SalonID,
+			CustomerID: *sale.CustomerID,
+			PlanID:     plan.ID,
+			AutoRenew:  true,
+			StartedAt:  now,
+		}
+	case err != nil:
+		return nil, err
+	default:
+		start = m.PaidThrough
+	}
+
+	var periods []models.MembershipPeriod
+	for n := 0; n < line.Quantity; n++ {
+		end := start.AddDate(0, plan.PeriodMonths, 0)
+		periods = append(periods, models.MembershipPeriod{
+			SaleID:     &sale.ID,
+			SaleLineID: &line.ID,
+			StartsAt:   start,
+			EndsAt:     end,
+			Status:     models.MembershipPeriodPaid,
+			CreatedAt:  now,
+		})
+		start = end
+	}
+
+	m.PaidThrough = start
+	m.Status = models.MembershipStatusActive
+	if !m.PaidThrough.After(now) {
+		// Pembayaran belum menutup semua periode yang tertunggak
+		m.Status = models.MembershipStatusPastDue
+	}
+	if err := tx.Save(&m).Error; err != nil {
+		return nil, err
+	}
+	for i := range periods {
+		periods[i].MembershipID = m.ID
+	}
+	if err := tx.Create(&periods).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// consume memotong kredit untuk layanan yang dibayar dengan kredit: benefit membership lebih dulu,
+// lalu kredit paket yang paling cepat kedaluwarsa
+func consume(tx *gorm.DB, sale *models.Sale, line *models.SaleLine, now time.Time) error {
+	if line.ServiceID == nil {
+		return ErrNoCredit
+	}
+	serviceID := *line.ServiceID
+	need := line.Quantity
+
+	var memberships []models.Membership
+	if err := activeMemberships(tx, *sale.CustomerID, now).
+		Clauses(clause.Locking{Strength: "UPDATE"}).Find(&memberships).Error; err != nil {
+		return err
+	}
+	for i := 0; i < len(memberships) && need > 0; i++ {
+		left, unlimited, period, err := membershipAllowance(tx, &memberships[i], serviceID, now)
+		if err != nil {
+			return err
+		}
+		take := min(need, left)
+		if unlimited {
+			take = need
+		}
+		if take <= 0 {
+			continue
+		}
+		if err := recordUsage(tx, sale, line, models.CreditUsageConsume, take, nil, &period.ID); err != nil {
+			return err
+		}
+		need -= take
+	}
+
+	if need > 0 {
+		var credits []models.PackageCredit
+		if err := packageCredits(tx, *sale.CustomerID, serviceID, now).
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "package_credits"}}).
+			Find(&credits).Error; err != nil {
+			return err
+		}
+		for i := 0; i < len(credits) && need > 0; i++ {
+			credit := &credits[i]
+			take := min(need, credit.Quantity-credit.Used)
+			credit.Used += take
+			if err := tx.Model(credit).Update("used", credit.Used).Error; err != nil {
+				return err
+			}
+			if err := recordUsage(tx, sale, line, models.CreditUsageConsume, take, &credit.ID, nil); err != nil {
+				return err
+			}
+			need -= take
+		}
+	}
+
+	if need > 0 {
+		return fmt.Errorf("%w (%s)", ErrNoCredit, line.Description)
+	}
+	return nil
+}
+
+// Restore mengembalikan kredit yang dipakai baris sale saat baris tersebut di-refund, mulai dari
+// pemakaian terakhir
+func Restore(tx *gorm.DB, line *models.SaleLine, qty int) error {
+	var usages []models.CreditUsage
+	if err := tx.Where("sale_line_id = ?", line.ID).Order("id DESC").Find(&usages).Error; err != nil {
+		return err
+	}
+
+	// Sisa pemakaian per sumber kredit setelah dikurangi pengembalian sebelumnya
+	type source struct {
+		usage  models.CreditUsage
+		netQty int
+	}
+	var sources []*source
+	bySource := map[[2]uint]*source{}
+	for _, u := range usages {
+		key := [2]uint{0, 0}
+		if u.PackageCreditID != nil {
+			key[0] = *u.PackageCreditID
+		}
+		if u.MembershipPeriodID != nil {
+			key[1] = *u.MembershipPeriodID
+		}
+		s, ok := bySource[key]
+		if !ok {
+			s = &source{usage: u}
+			bySource[key] = s
+			sources = append(sources, s)
+		}
+		if u.Type == models.CreditUsageConsume {
+			s.netQty += u.Quantity
+		} else {
+			s.netQty -= u.Quantity
+		}
+	}
+
+	sale := &models.Sale{}
+	sale.ID = line.SaleID
+	for _, s := range sources {
+		if qty == 0 {
+			break
+		}
+		take := min(qty, s.netQty)
+		if take <= 0 {
+			continue
+		}
+		if s.usage.PackageCreditID != nil {
+			if err := tx.Model(&models.PackageCredit{}).Where("id = ?", *s.usage.PackageCreditID).
+				Update("used", gorm.Expr("used - ?", take)).Error; err != nil {
+				return err
+			}
+		}
+		sale.CustomerID = &s.usage.CustomerID
+		if err := recordUsage(tx, sale, line, models.CreditUsageRestore, take, s.usage.PackageCreditID, s.usage.MembershipPeriodID); err != nil {
+			return err
+		}
+		qty -= take
+	}
+	return nil
+}
+
+// VoidPackages membatalkan qty paket yang dijual pada satu baris sale. Hanya paket yang kreditnya
+// belum dipakai sama sekali yang boleh dibatalkan.
+func VoidPackages(tx *gorm.DB, line *models.SaleLine, qty int) error {
+	var packages []models.CustomerPackage
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("sale_line_id = ? AND status = ?", line.ID, models.CustomerPackageStatusActive).
+		Where("NOT EXISTS (SELECT 1 FROM package_credits WHERE package_credits.customer_package_id = customer_packages.id AND package_credits.used > 0)").
+		Order("id").Limit(qty).Find(&packages).Error; err != nil {
+		return err
+	}
+	if len(packages) < qty {
+		return ErrAlreadyUsed
+	}
+	for i := range packages {
+		if err := tx.Model(&packages[i]).Update("status", models.CustomerPackageStatusVoided).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefundPeriods membatalkan qty periode membership yang dibayar pada satu baris sale, dari periode
+// paling akhir. Jika periode yang sedang berjalan ikut di-refund, membership langsung dibatalkan.
+func RefundPeriods(tx *gorm.DB, line *models.SaleLine, qty int, now time.Time) error {
+	var periods []models.MembershipPeriod
+	if err := tx.Where("sale_line_id = ? AND status = ?", line.ID, models.MembershipPeriodPaid).
+		Order("starts_at DESC").Limit(qty).Find(&periods).Error; err != nil {
+		return err
+	}
+	if len(periods) == 0 {
+		return nil
+	}
+
+	var m models.Membership
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, periods[0].MembershipID).Error; err != nil {
+		return err
+	}
+	current := false
+	for _, p := range periods {
+		if err := tx.Model(&models.MembershipPeriod{}).Where("id = ?", p.ID).
+			Update("status", models.MembershipPeriodRefunded).Error; err != nil {
+			return err
+		}
+		if !p.StartsAt.After(now) {
+			current = true
+		}
+	}
+
+	var last models.MembershipPeriod
+	err := tx.Where("membership_id = ? AND status = ?", m.ID, models.MembershipPeriodPaid).
+		Order("ends_at DESC").First(&last).Error
+	switch {
+	case current || errors.Is(err, gorm.ErrRecordNotFound):
+		m.Status = models.MembershipStatusCancelled
+		m.AutoRenew = false
+		m.CancelledAt = &now
+		if m.PaidThrough.After(now) {
+			m.PaidThrough = now
+		}
+	case err != nil:
+		return err
+	default:
+		m.PaidThrough = last.EndsAt
+	}
+	return tx.Save(&m).Error
+}
+
+// Cancel menghentikan perpanjangan membership. Jika immediate, benefit langsung berhenti; jika tidak,
+// membership tetap aktif sampai akhir periode yang sudah dibayar lalu expired.
+func Cancel(tx *gorm.DB, m *models.Membership, immediate bool, now time.Time) error {
+	m.AutoRenew = false
+	if immediate {
+		m.Status = models.MembershipStatusCancelled
+		m.CancelledAt = &now
+	}
+	return tx.Model(m).Select("auto_renew", "status", "cancelled_at").Updates(m).Error
+}
+
+// Usage menghitung pemakaian benefit membership per layanan pada periode yang sedang berjalan
+func Usage(db *gorm.DB, m *models.Membership, now time.Time) (map[uint]int, error) {
+	used := map[uint]int{}
+	period, err := currentPeriod(db, m.ID, now)
+	if err != nil || period == nil {
+		return used, err
+	}
+	var rows []struct {
+		ServiceID uint
+		Used      int
+	}
+	if err := db.Model(&models.CreditUsage{}).
+		Select("service_id, COALESCE(SUM(CASE WHEN type = ? THEN quantity ELSE -quantity END), 0) AS used", models.CreditUsageConsume).
+		Where("membership_period_id = ?", period.ID).Group("service_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		used[row.ServiceID] = row.Used
+	}
+	return used, nil
+}
+
+// ProcessDue menjalankan siklus tagihan: membership yang periodenya habis menjadi past_due (dengan
+// email pengingat perpanjangan) atau expired jika tidak diperpanjang otomatis, membership past_due
+// yang melewati masa tenggang menjadi expired, dan paket yang melewati masa berlaku menjadi expired.
+func ProcessDue(db *gorm.DB, now time.Time) (int, error) {
+	processed := 0
+	for {
+		done := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var m models.Membership
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED", Table: clause.Table{Name: "memberships"}}).
+				Joins("Plan").
+				Where("(memberships.status = ? AND memberships.paid_through <= ?) OR "+
+					"(memberships.status = ? AND memberships.paid_through + make_interval(days => \"Plan\".grace_days) <= ?)",
+					models.MembershipStatusActive, now, models.MembershipStatusPastDue, now).
+				Order("memberships.paid_through").First(&m).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				done = true
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if m.Status == models.MembershipStatusActive && m.AutoRenew {
+				m.Status = models.MembershipStatusPastDue
+				if err := remindRenewal(tx, &m); err != nil {
+					return err
+				}
+			} else {
+				m.Status = models.MembershipStatusExpired
+			}
+			return tx.Model(&m).Update("status", m.Status).Error
+		})
+		if err != nil {
+			return processed, err
+		}
+		if done {
+			break
+		}
+		processed++
+	}
+
+	result := db.Model(&models.CustomerPackage{}).
+		Where("status = ? AND expires_at <= ?", models.CustomerPackageStatusActive, now).
+		Update("status", models.CustomerPackageStatusExpired)
+	return processed + int(result.RowsAffected), result.Error
+}
+
+// remindRenewal mengantrikan email pengingat perpanjangan jika pelanggan memiliki email
+func remindRenewal(tx *gorm.DB, m *models.Membership) error {
+	var customer models.Customer
+	if err := tx.First(&customer, m.CustomerID).Error; err != nil {
+		return err
+	}
+	if customer.Email == "" {
+		return nil
+	}
+	var salon models.Salon
+	if err := tx.First(&salon, m.SalonID).Error; err != nil {
+		return err
+	}
+	planName := ""
+	if m.Plan != nil {
+		planName = m.Plan.Name
+	}
+	_, err := mailer.Queue(tx, mailer.Message{
+		To:      []string{customer.Email},
+		Subject: fmt.Sprintf("Perpanjangan membership %s - %s", planName, salon.Name),
+		Body: fmt.Sprintf("Halo %s,\n\nPeriode membership %s Anda di %s telah berakhir. "+
+			"Silakan lakukan perpanjangan di kasir agar benefit dapat digunakan kembali.\n",
+			customer.Name, planName, salon.Name),
+	})
+	if err != nil {
+		log.Printf("⚠️ Gagal mengantrikan pengingat membership %d: %v", m.ID, err)
+	}
+	return err
+}
+
+// activeMemberships adalah query membership aktif pelanggan yang periodenya masih berjalan
+func activeMemberships(db *gorm.DB, customerID uint, now time.Time) *gorm.DB {
+	return db.Where("customer_id = ? AND status = ? AND paid_through > ?", customerID, models.MembershipStatusActive, now).
+		Order("id")
+}
+
+// packageCredits adalah query kredit paket aktif pelanggan untuk satu layanan, yang paling cepat
+// kedaluwarsa lebih dulu
+func packageCredits(db *gorm.DB, customerID, serviceID uint, now time.Time) *gorm.DB {
+	return db.Model(&models.PackageCredit{}).
+		Joins("JOIN customer_packages ON customer_packages.id = package_credits.customer_package_id").
+		Where("customer_packages.customer_id = ? AND customer_packages.status = ? AND customer_packages.deleted_at IS NULL",
+			customerID, models.CustomerPackageStatusActive).
+		Where("customer_packages.expires_at IS NULL OR customer_packages.expires_at > ?", now).
+		Where("package_credits.service_id = ? AND package_credits.used < package_credits.quantity", serviceID).
+		Order("customer_packages.expires_at NULLS LAST, package_credits.id")
+}
+
+// membershipAllowance mengembalikan sisa benefit layanan pada periode berjalan membership
+func membershipAllowance(db *gorm.DB, m *models.Membership, serviceID uint, now time.Time) (int, bool, *models.MembershipPeriod, error) {
+	var benefit models.MembershipBenefit
+	err := db.Where("plan_id = ? AND service_id = ?", m.PlanID, serviceID).First(&benefit).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil, nil
+	}
+	if err != nil {
+		return 0, false, nil, err
+	}
+	period, err := currentPeriod(db, m.ID, now)
+	if err != nil || period == nil {
+		return 0, false, nil, err
+	}
+	if benefit.QuantityPerPeriod == 0 {
+		return 0, true, period, nil
+	}
+	used, err := Usage(db, m, now)
+	if err != nil {
+		return 0, false, nil, err
+	}
+	return max(benefit.QuantityPerPeriod-used[serviceID], 0), false, period, nil
+}
+
+// currentPeriod mengambil periode membership yang sedang berjalan, atau nil
+func currentPeriod(db *gorm.DB, membershipID uint, now time.Time) (*models.MembershipPeriod, error) {
+	var period models.MembershipPeriod
+	err := db.Where("membership_id = ? AND status = ? AND starts_at <= ? AND ends_at > ?",
+		membershipID, models.MembershipPeriodPaid, now, now).Order("starts_at DESC").First(&period).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// recordUsage menambah satu baris pemakaian kredit
+func recordUsage(tx *gorm.DB, sale *models.Sale, line *models.SaleLine, usageType string, qty int, creditID, periodID *uint) error {
+	return tx.Create(&models.CreditUsage{
+		CustomerID:         *sale.CustomerID,
+		SaleID:             sale.ID,
+		SaleLineID:         line.ID,
+		ServiceID:          *line.ServiceID,
+		Type:               usageType,
+		Quantity:           qty,
+		PackageCreditID:    creditID,
+		MembershipPeriodID: periodID,
+		CreatedAt:          time.Now(),
+	}).Error
+}
@@ -0,0 +1,415 @@
+package promo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/pos"
+)
+
+// Kode aturan yang membuat promo ditolak, dikembalikan ke kasir beserta penjelasannya
+const (
+	RuleNotFound           = "not_found"
+	RuleInactive           = "inactive"
+	RuleNotStarted         = "not_started"
+	RuleEnded              = "ended"
+	RuleDayOfWeek          = "day_of_week"
+	RuleTimeOfDay          = "time_of_day"
+	RuleNoEligibleItems    = "no_eligible_items"
+	RuleMinSpend           = "min_spend"
+	RuleCustomerRequired   = "customer_required"
+	RuleFirstVisit         = "first_visit"
+	RuleSegment            = "customer_segment"
+	RuleUsageLimit         = "usage_limit"
+	RuleCustomerUsageLimit = "customer_usage_limit"
+	RuleNotStackable       = "not_stackable"
+	RuleDuplicate          = "duplicate"
+)
+
+// lapsedAfter adalah lama tidak berkunjung sebelum pelanggan masuk segmen lapsed
+const lapsedAfter = 90 * 24 * time.Hour
+
+var dayNames = [7]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+// Reason adalah satu aturan promo yang tidak terpenuhi
+type Reason struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Rejection menjelaskan mengapa sebuah kode promo tidak dapat dipakai
+type Rejection struct {
+	Code    string   `json:"code"`
+	Reasons []Reason `json:"reasons"`
+}
+
+func (r *Rejection) Error() string {
+	if len(r.Reasons) == 0 {
+		return fmt.Sprintf("promo %s tidak dapat dipakai", r.Code)
+	}
+	return fmt.Sprintf("promo %s: %s", r.Code, r.Reasons[0].Message)
+}
+
+// Customer adalah ringkasan riwayat pelanggan untuk aturan kunjungan pertama dan segmen
+type Customer struct {
+	ID          uint
+	PriorVisits int64
+	LastVisitAt *time.Time
+	BirthDate   *time.Time
+}
+
+// Segments mengembalikan segmen pelanggan pada waktu now
+func (c *Customer) Segments(now time.Time) map[string]bool {
+	segments := map[string]bool{}
+	if c.PriorVisits > 0 {
+		segments[models.SegmentReturning] = true
+	}
+	if c.LastVisitAt != nil && now.Sub(*c.LastVisitAt) >= lapsedAfter {
+		segments[models.SegmentLapsed] = true
+	}
+	if c.BirthDate != nil && c.BirthDate.Month() == now.Month() {
+		segments[models.SegmentBirthdayMonth] = true
+	}
+	return segments
+}
+
+// Input adalah keranjang dan konteks transaksi yang dinilai. Now harus dalam zona waktu salon.
+type Input struct {
+	Now      time.Time
+	Items    []pos.Item
+	Customer *Customer
+}
+
+// Usage adalah jumlah pemakaian promo sejauh ini (total dan oleh pelanggan transaksi)
+type Usage struct {
+	Total    int64
+	Customer int64
+}
+
+// Applied adalah promo yang lolos validasi beserta diskon yang diteruskan ke pos.Calculate
+type Applied struct {
+	Promotion models.Promotion
+	Discount  pos.Discount
+}
+
+// Check menilai semua aturan promo terhadap input dan mengembalikan setiap aturan yang tidak
+// terpenuhi (kosong berarti promo dapat dipakai)
+func Check(p *models.Promotion, in Input, usage Usage) []Reason {
+	var reasons []Reason
+	fail := func(rule, format string, args ...interface{}) {
+		reasons = append(reasons, Reason{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !p.IsActive {
+		fail(RuleInactive, "Promo sedang tidak aktif")
+	}
+	if p.StartsAt != nil && in.Now.Before(*p.StartsAt) {
+		fail(RuleNotStarted, "Promo baru berlaku mulai %s", p.StartsAt.In(in.Now.Location()).Format("02-01-2006 15:04"))
+	}
+	if p.EndsAt != nil && !in.Now.Before(*p.EndsAt) {
+		fail(RuleEnded, "Promo sudah berakhir pada %s", p.EndsAt.In(in.Now.Location()).Format("02-01-2006 15:04"))
+	}
+	if days := ParseDays(p.DaysOfWeek); len(days) > 0 && !days[in.Now.Weekday()] {
+		var names []string
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if days[d] {
+				names = append(names, dayNames[d])
+			}
+		}
+		fail(RuleDayOfWeek, "Promo hanya berlaku hari %s", strings.Join(names, ", "))
+	}
+	if p.StartTime != "" || p.EndTime != "" {
+		clock := in.Now.Format("15:04")
+		if (p.StartTime != "" && clock < p.StartTime) || (p.EndTime != "" && clock >= p.EndTime) {
+			fail(RuleTimeOfDay, "Promo hanya berlaku pukul %s-%s", orDefault(p.StartTime, "00:00"), orDefault(p.EndTime, "24:00"))
+		}
+	}
+
+	if len(EligibleItems(p, in.Items)) == 0 {
+		if p.Category != "" {
+			fail(RuleNoEligibleItems, "Tidak ada item kategori %s di keranjang", p.Category)
+		} else {
+			fail(RuleNoEligibleItems, "Tidak ada item yang dapat didiskon di keranjang")
+		}
+	} else if p.Type == models.PromotionTypeBuyXGetY && eligibleUnits(p, in.Items) < p.BuyQuantity+p.GetQuantity {
+		fail(RuleNoEligibleItems, "Beli %d gratis/diskon %d: baru ada %d item yang memenuhi syarat",
+			p.BuyQuantity, p.GetQuantity, eligibleUnits(p, in.Items))
+	}
+	if p.MinSpend > 0 {
+		if spend := Spend(in.Items); spend < p.MinSpend {
+			fail(RuleMinSpend, "Minimal belanja %s, kurang %s", pos.FormatRupiah(p.MinSpend), pos.FormatRupiah(p.MinSpend-spend))
+		}
+	}
+
+	needsCustomer := p.FirstVisitOnly || p.CustomerSegment != "" || p.MaxUsesPerCustomer > 0
+	if needsCustomer && in.Customer == nil {
+		fail(RuleCustomerRequired, "Promo ini membutuhkan data pelanggan")
+	}
+	if in.Customer != nil {
+		if p.FirstVisitOnly && in.Customer.PriorVisits > 0 {
+			fail(RuleFirstVisit, "Promo hanya untuk kunjungan pertama, pelanggan sudah %d kali bertransaksi", in.Customer.PriorVisits)
+		}
+		if p.CustomerSegment != "" && !in.Customer.Segments(in.Now)[p.CustomerSegment] {
+			fail(RuleSegment, "Promo hanya untuk pelanggan segmen %s", p.CustomerSegment)
+		}
+		if p.MaxUsesPerCustomer > 0 && usage.Customer >= int64(p.MaxUsesPerCustomer) {
+			fail(RuleCustomerUsageLimit, "Pelanggan sudah memakai promo ini %d kali (batas %d)", usage.Customer, p.MaxUsesPerCustomer)
+		}
+	}
+	if p.MaxUses > 0 && usage.Total >= int64(p.MaxUses) {
+		fail(RuleUsageLimit, "Kuota promo sudah habis (%d pemakaian)", p.MaxUses)
+	}
+	return reasons
+}
+
+// Discount menyusun diskon transaksi untuk promo yang sudah lolos Check
+func Discount(p *models.Promotion, items []pos.Item) pos.Discount {
+	discount := pos.Discount{
+		Description: fmt.Sprintf("Promo %s", p.Code),
+		PromotionID: &p.ID,
+		Items:       EligibleItems(p, items),
+	}
+	switch p.Type {
+	case models.PromotionTypePercent:
+		discount.Bps = p.ValueBps
+	case models.PromotionTypeAmount:
+		discount.Amount = p.ValueAmount
+	case models.PromotionTypeBuyXGetY:
+		discount.Amount, discount.Items = buyXGetY(p, items)
+	}
+	return discount
+}
+
+// EligibleItems mengembalikan indeks item keranjang yang dapat didiskon promo
+func EligibleItems(p *models.Promotion, items []pos.Item) []int {
+	var eligible []int
+	for i, item := range items {
+		if item.Type == models.SaleLineGiftCard || item.PrepaidCredit {
+			continue
+		}
+		if p.Category != "" && !strings.EqualFold(item.Category, p.Category) {
+			continue
+		}
+		eligible = append(eligible, i)
+	}
+	return eligible
+}
+
+// Spend adalah nilai belanja untuk syarat minimal belanja: item setelah diskon baris, tanpa gift card
+func Spend(items []pos.Item) int64 {
+	var spend int64
+	for _, item := range items {
+		if item.Type != models.SaleLineGiftCard {
+			spend += item.Net()
+		}
+	}
+	return spend
+}
+
+func eligibleUnits(p *models.Promotion, items []pos.Item) int {
+	units := 0
+	for _, i := range EligibleItems(p, items) {
+		units += items[i].Quantity
+	}
+	return units
+}
+
+// buyXGetY mendiskon unit termurah: setiap kelipatan BuyQuantity+GetQuantity unit, GetQuantity
+// unit termurah mendapat diskon ValueBps (10000 = gratis)
+func buyXGetY(p *models.Promotion, items []pos.Item) (int64, []int) {
+	type unit struct {
+		item  int
+		price int64
+	}
+	var units []unit
+	for _, i := range EligibleItems(p, items) {
+		item := items[i]
+		// Harga per unit setelah diskon baris; sisa pembagian dibebankan ke unit pertama
+		net := item.Net()
+		each := net / int64(item.Quantity)
+		for n := 0; n < item.Quantity; n++ {
+			price := each
+			if n == 0 {
+				price += net - each*int64(item.Quantity)
+			}
+			units = append(units, unit{item: i, price: price})
+		}
+	}
+	sort.SliceStable(units, func(a, b int) bool { return units[a].price < units[b].price })
+
+	free := len(units) / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+	var amount int64
+	var targets []int
+	seen := map[int]bool{}
+	for _, u := range units[:free] {
+		amount += pos.PercentOf(u.price, p.ValueBps)
+		if !seen[u.item] {
+			seen[u.item] = true
+			targets = append(targets, u.item)
+		}
+	}
+	sort.Ints(targets)
+	return amount, targets
+}
+
+// ParseDays membaca daftar hari "1,2,3" (0 = Minggu). Nilai tidak valid diabaikan.
+func ParseDays(s string) map[time.Weekday]bool {
+	days := map[time.Weekday]bool{}
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err == nil && n >= 0 && n <= 6 {
+			days[time.Weekday(n)] = true
+		}
+	}
+	return days
+}
+
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// LoadCustomer menghitung riwayat kunjungan pelanggan dari transaksi yang tidak di-void
+func LoadCustomer(db *gorm.DB, customer models.Customer) (*Customer, error) {
+	result := &Customer{ID: customer.ID, BirthDate: customer.BirthDate}
+	var stats struct {
+		Visits int64
+		Last   *time.Time
+	}
+	err := db.Model(&models.Sale{}).
+		Select("COUNT(*) AS visits, MAX(completed_at) AS last").
+		Where("salon_id = ? AND customer_id = ? AND status <> ?", customer.SalonID, customer.ID, models.SaleStatusVoided).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	result.PriorVisits = stats.Visits
+	result.LastVisitAt = stats.Last
+	return result, nil
+}
+
+// usage menghitung pemakaian promo dari redemption pada transaksi yang tidak di-void
+func usage(db *gorm.DB, promotionID uint, customerID *uint) (Usage, error) {
+	var u Usage
+	base := db.Model(&models.PromotionRedemption{}).
+		Joins("JOIN sales ON sales.id = promotion_redemptions.sale_id").
+		Where("promotion_redemptions.promotion_id = ? AND sales.status <> ?", promotionID, models.SaleStatusVoided)
+	if err := base.Session(&gorm.Session{}).Count(&u.Total).Error; err != nil {
+		return u, err
+	}
+	if customerID != nil {
+		if err := base.Session(&gorm.Session{}).
+			Where("promotion_redemptions.customer_id = ?", *customerID).Count(&u.Customer).Error; err != nil {
+			return u, err
+		}
+	}
+	return u, nil
+}
+
+// Resolve memvalidasi kode promo sesuai urutan yang dimasukkan kasir. Kode yang lolos dikembalikan
+// sebagai Applied; kode yang gagal dikembalikan sebagai Rejection beserta semua alasannya.
+// Promo yang tidak stackable tidak dapat digabung dengan promo lain yang sudah diterima.
+func Resolve(db *gorm.DB, salonID uint, codes []string, in Input) ([]Applied, []Rejection, error) {
+	var applied []Applied
+	var rejections []Rejection
+	seen := map[string]bool{}
+
+	for _, raw := range codes {
+		code := NormalizeCode(raw)
+		if seen[code] {
+			rejections = append(rejections, Rejection{Code: code, Reasons: []Reason{{Rule: RuleDuplicate, Message: "Kode promo dimasukkan lebih dari sekali"}}})
+			continue
+		}
+		seen[code] = true
+
+		var p models.Promotion
+		err := db.Where("salon_id = ? AND code = ?", salonID, code).First(&p).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			rejections = append(rejections, Rejection{Code: code, Reasons: []Reason{{Rule: RuleNotFound, Message: "Kode promo tidak dikenal"}}})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var customerID *uint
+		if in.Customer != nil {
+			customerID = &in.Customer.ID
+		}
+		u, err := usage(db, p.ID, customerID)
+		if err != nil {
+			return nil, nil, err
+		}
+		reasons := Check(&p, in, u)
+		if len(applied) > 0 && !p.Stackable {
+			reasons = append(reasons, Reason{Rule: RuleNotStackable, Message: "Promo ini tidak dapat digabung dengan promo lain"})
+		}
+		for _, other := range applied {
+			if !other.Promotion.Stackable {
+				reasons = append(reasons, Reason{Rule: RuleNotStackable,
+					Message: fmt.Sprintf("Promo %s tidak dapat digabung dengan promo lain", other.Promotion.Code)})
+				break
+			}
+		}
+		if len(reasons) > 0 {
+			rejections = append(rejections, Rejection{Code: code, Reasons: reasons})
+			continue
+		}
+		applied = append(applied, Applied{Promotion: p, Discount: Discount(&p, in.Items)})
+	}
+	return applied, rejections, nil
+}
+
+// Redeem dijalankan setelah sale tersimpan di transaksi yang sama: mengunci promo, memeriksa ulang
+// batas pemakaian (agar kuota tidak terlampaui oleh checkout bersamaan) lalu mencatat redemption.
+func Redeem(tx *gorm.DB, sale *models.Sale, applied []Applied) error {
+	for _, a := range applied {
+		var p models.Promotion
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, a.Promotion.ID).Error; err != nil {
+			return err
+		}
+		u, err := usage(tx, p.ID, sale.CustomerID)
+		if err != nil {
+			return err
+		}
+		if p.MaxUses > 0 && u.Total >= int64(p.MaxUses) {
+			return &Rejection{Code: p.Code, Reasons: []Reason{{Rule: RuleUsageLimit, Message: fmt.Sprintf("Kuota promo sudah habis (%d pemakaian)", p.MaxUses)}}}
+		}
+		if sale.CustomerID != nil && p.MaxUsesPerCustomer > 0 && u.Customer >= int64(p.MaxUsesPerCustomer) {
+			return &Rejection{Code: p.Code, Reasons: []Reason{{Rule: RuleCustomerUsageLimit,
+				Message: fmt.Sprintf("Pelanggan sudah memakai promo ini %d kali (batas %d)", u.Customer, p.MaxUsesPerCustomer)}}}
+		}
+
+		var amount int64
+		for _, line := range sale.Lines {
+			if line.Type == models.SaleLineDiscount && line.PromotionID != nil && *line.PromotionID == p.ID {
+				amount -= line.Gross
+			}
+		}
+		if err := tx.Create(&models.PromotionRedemption{
+			PromotionID: p.ID,
+			SaleID:      sale.ID,
+			CustomerID:  sale.CustomerID,
+			Amount:      amount,
+			CreatedAt:   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NormalizeCode menyeragamkan kode promo: huruf besar tanpa spasi di tepi
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
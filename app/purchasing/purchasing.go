@@ -0,0 +1,265 @@
+package purchasing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/pos"
+)
+
+// ErrInvalidStatus dikembalikan jika aksi tidak sesuai status purchase order
+var ErrInvalidStatus = errors.New("aksi tidak dapat dilakukan pada status purchase order saat ini")
+
+// ErrOverReceive dikembalikan jika jumlah diterima melebihi sisa pesanan
+var ErrOverReceive = errors.New("jumlah diterima melebihi sisa pesanan")
+
+// ReceiveLine adalah jumlah barang yang diterima untuk satu baris PO. UnitCost nil berarti harga di PO;
+// 0 berarti barang diterima gratis.
+type ReceiveLine struct {
+	LineID   uint
+	Quantity int64
+	UnitCost *int64
+}
+
+// cost adalah harga per unit barang yang diterima: harga override jika diisi, selain itu harga di PO
+func (in ReceiveLine) cost(line *models.PurchaseOrderLine) int64 {
+	if in.UnitCost != nil {
+		return *in.UnitCost
+	}
+	return line.UnitCost
+}
+
+// Number memberi nomor urut PO salon di dalam tx
+func Number(tx *gorm.DB, po *models.PurchaseOrder) error {
+	seq, err := pos.NextSequence(tx, po.SalonID, "purchase_order")
+	if err != nil {
+		return err
+	}
+	po.Seq = seq
+	po.Number = fmt.Sprintf("PO%d-%05d", po.SalonID, seq)
+	return nil
+}
+
+// Lock mengambil purchase order salon beserta barisnya dengan FOR UPDATE
+func Lock(tx *gorm.DB, salonID, poID uint) (*models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("salon_id = ?", salonID).First(&po, poID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("purchase_order_id = ?", po.ID).Order("id").Find(&po.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &po, nil
+}
+
+// Receive mencatat penerimaan barang: menambah stok cabang PO, memperbarui harga pokok produk dengan
+// rata-rata tertimbang, dan mengubah status PO menjadi partially_received atau received.
+// PO harus dikunci dengan Lock.
+func Receive(tx *gorm.DB, po *models.PurchaseOrder, lines []ReceiveLine, userID uint, note string) (*models.GoodsReceipt, error) {
+	if po.Status != models.PurchaseOrderSent && po.Status != models.PurchaseOrderPartiallyReceived {
+		return nil, ErrInvalidStatus
+	}
+
+	byID := make(map[uint]*models.PurchaseOrderLine, len(po.Lines))
+	for i := range po.Lines {
+		byID[po.Lines[i].ID] = &po.Lines[i]
+	}
+
+	now := time.Now()
+	receipt := &models.GoodsReceipt{
+		PurchaseOrderID: po.ID,
+		ReceivedByID:    userID,
+		Note:            note,
+		ReceivedAt:      now,
+	}
+	for _, in := range lines {
+		line, ok := byID[in.LineID]
+		if !ok || in.Quantity <= 0 {
+			return nil, ErrOverReceive
+		}
+		if line.ReceivedQuantity+in.Quantity > line.Quantity {
+			return nil, fmt.Errorf("%w (sisa %d)", ErrOverReceive, line.Quantity-line.ReceivedQuantity)
+		}
+		unitCost := in.cost(line)
+
+		if err := UpdateAverageCost(tx, line.ProductID, in.Quantity, unitCost); err != nil {
+			return nil, err
+		}
+		if err := inventory.Move(tx, &models.StockMovement{
+			SalonID:         po.SalonID,
+			BranchID:        po.BranchID,
+			ProductID:       line.ProductID,
+			Type:            models.StockMovementPurchase,
+			Quantity:        in.Quantity,
+			UnitCost:        unitCost,
+			UserID:          &userID,
+			Note:            po.Number,
+			PurchaseOrderID: &po.ID,
+		}); err != nil {
+			return nil, err
+		}
+
+		line.ReceivedQuantity += in.Quantity
+		if err := tx.Model(line).Update("received_quantity", line.ReceivedQuantity).Error; err != nil {
+			return nil, err
+		}
+		receipt.Lines = append(receipt.Lines, models.GoodsReceiptLine{
+			PurchaseOrderLineID: line.ID,
+			ProductID:           line.ProductID,
+			Quantity:            in.Quantity,
+			UnitCost:            unitCost,
+		})
+	}
+	if len(receipt.Lines) == 0 {
+		return nil, ErrOverReceive
+	}
+	if err := tx.Create(receipt).Error; err != nil {
+		return nil, err
+	}
+
+	po.Status = receivedStatus(po.Lines)
+	po.ReceivedAt = nil
+	if po.Status == models.PurchaseOrderReceived {
+		po.ReceivedAt = &now
+	}
+	if err := tx.Model(po).Select("status", "received_at").Updates(po).Error; err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// receivedStatus menentukan status PO setelah penerimaan: received jika semua baris sudah diterima
+// seluruhnya, selain itu partially_received
+func receivedStatus(lines []models.PurchaseOrderLine) string {
+	for _, line := range lines {
+		if line.ReceivedQuantity < line.Quantity {
+			return models.PurchaseOrderPartiallyReceived
+		}
+	}
+	return models.PurchaseOrderReceived
+}
+
+// UpdateAverageCost menghitung ulang harga pokok produk dengan rata-rata tertimbang antara stok yang
+// ada di semua cabang (stok negatif dihitung 0) dan barang yang baru diterima. Dipanggil sebelum mutasi
+// stok barang masuk dicatat.
+func UpdateAverageCost(tx *gorm.DB, productID uint, qty, unitCost int64) error {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+		return err
+	}
+	var onHand int64
+	if err := tx.Model(&models.StockLevel{}).Where("product_id = ? AND quantity > 0", productID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&onHand).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&product).Update("cost_price", averageCost(onHand, product.CostPrice, qty, unitCost)).Error
+}
+
+// averageCost menghitung rata-rata tertimbang harga pokok stok yang ada dan barang masuk, dibulatkan
+// ke Rupiah terdekat. Tanpa stok (atau stok negatif) harga pokok menjadi harga barang masuk.
+func averageCost(onHand, oldCost, qty, unitCost int64) int64 {
+	if onHand <= 0 {
+		return unitCost
+	}
+	return int64(math.Round(float64(onHand*oldCost+qty*unitCost) / float64(onHand+qty)))
+}
+
+// Message menyusun teks PO yang siap dikirim ke supplier lewat WhatsApp atau email
+func Message(po *models.PurchaseOrder, salon *models.Salon, branch *models.Branch, products map[uint]models.Product) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Purchase Order %s\n%s - %s\n\n", po.Number, salon.Name, branch.Name)
+	for i, line := range po.Lines {
+		product := products[line.ProductID]
+		fmt.Fprintf(&b, "%d. %s (%s) x %d %s @ %s\n", i+1, product.Name, product.SKU, line.Quantity, product.Unit,
+			pos.FormatRupiah(line.UnitCost))
+	}
+	fmt.Fprintf(&b, "\nTotal: %s\n", pos.FormatRupiah(po.Total))
+	if po.ExpectedAt != nil {
+		fmt.Fprintf(&b, "Mohon dikirim paling lambat %s\n", po.ExpectedAt.Format("02-01-2006"))
+	}
+	if po.Notes != "" {
+		fmt.Fprintf(&b, "Catatan: %s\n", po.Notes)
+	}
+	return b.String()
+}
+
+// Suggestion adalah satu baris saran pemesanan ulang
+type Suggestion struct {
+	ProductID         uint    `json:"product_id"`
+	SKU               string  `json:"sku"`
+	Name              string  `json:"name"`
+	Unit              string  `json:"unit"`
+	CostPrice         int64   `json:"cost_price"`
+	LowStockThreshold int64   `json:"low_stock_threshold"`
+	OnHand            int64   `json:"on_hand"`
+	OnOrder           int64   `json:"on_order"`
+	Used              int64   `json:"used"`
+	DailyUsage        float64 `json:"daily_usage"`
+	// SuggestedQuantity adalah jumlah yang disarankan dipesan agar stok cukup untuk coverDays hari
+	SuggestedQuantity int64 `json:"suggested_quantity"`
+	// SupplierID adalah supplier PO terakhir untuk produk ini
+	SupplierID *uint `json:"supplier_id"`
+}
+
+// SuggestReorder menghitung saran pemesanan ulang satu cabang dari stok saat ini, barang yang masih
+// dalam pesanan, dan pemakaian (penjualan + pemakaian salon - retur) selama lookbackDays hari terakhir.
+// Target stok adalah pemakaian harian x coverDays, minimal batas stok menipis.
+func SuggestReorder(db *gorm.DB, salonID, branchID uint, lookbackDays, coverDays int, now time.Time) ([]Suggestion, error) {
+	var rows []Suggestion
+	err := db.Raw(`
+		WITH used AS (
+			SELECT product_id, -SUM(quantity) AS used FROM stock_movements
+			WHERE salon_id = @salon AND branch_id = @branch AND type IN @usage_types AND created_at >= @since
+			GROUP BY product_id
+		), on_order AS (
+			SELECT l.product_id, SUM(l.quantity - l.received_quantity) AS on_order
+			FROM purchase_order_lines l JOIN purchase_orders po ON po.id = l.purchase_order_id
+			WHERE po.salon_id = @salon AND po.branch_id = @branch AND po.status IN @open AND po.deleted_at IS NULL
+			GROUP BY l.product_id
+		)
+		SELECT p.id AS product_id, p.sku, p.name, p.unit, p.cost_price, p.low_stock_threshold,
+			COALESCE(sl.quantity, 0) AS on_hand, COALESCE(o.on_order, 0) AS on_order, COALESCE(u.used, 0) AS used,
+			(SELECT po.supplier_id FROM purchase_order_lines l JOIN purchase_orders po ON po.id = l.purchase_order_id
+				WHERE l.product_id = p.id AND po.status <> @cancelled AND po.deleted_at IS NULL
+				ORDER BY po.id DESC LIMIT 1) AS supplier_id
+		FROM products p
+		LEFT JOIN stock_levels sl ON sl.product_id = p.id AND sl.branch_id = @branch
+		LEFT JOIN used u ON u.product_id = p.id
+		LEFT JOIN on_order o ON o.product_id = p.id
+		WHERE p.salon_id = @salon AND p.is_active AND p.deleted_at IS NULL
+			AND (COALESCE(u.used, 0) > 0 OR p.low_stock_threshold > 0)
+		ORDER BY p.name`,
+		map[string]interface{}{
+			"salon":       salonID,
+			"branch":      branchID,
+			"since":       now.AddDate(0, 0, -lookbackDays),
+			"usage_types": []string{models.StockMovementSale, models.StockMovementUsage, models.StockMovementReturn},
+			"open":        []string{models.PurchaseOrderSent, models.PurchaseOrderPartiallyReceived},
+			"cancelled":   models.PurchaseOrderCancelled,
+		},
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []Suggestion{}
+	for _, row := range rows {
+		row.DailyUsage = math.Round(float64(max(row.Used, 0))/float64(lookbackDays)*100) / 100
+		target := max(int64(math.Ceil(float64(max(row.Used, 0))/float64(lookbackDays)*float64(coverDays))), row.LowStockThreshold)
+		row.SuggestedQuantity = target - max(row.OnHand, 0) - row.OnOrder
+		if row.SuggestedQuantity > 0 {
+			suggestions = append(suggestions, row)
+		}
+	}
+	return suggestions, nil
+}
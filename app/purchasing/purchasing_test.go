@@ -0,0 +1,108 @@
+package purchasing
+
+import (
+	"errors"
+	"testing"
+
+	"gin-sass-salon/app/models"
+)
+
+func TestAverageCost(t *testing.T) {
+	tests := []struct {
+		name                           string
+		onHand, oldCost, qty, unitCost int64
+		want                           int64
+	}{
+		{"stok kosong memakai harga baru", 0, 40000, 10, 45000, 45000},
+		{"stok negatif dihitung kosong", -3, 40000, 10, 45000, 45000},
+		{"rata-rata tertimbang", 10, 40000, 10, 50000, 45000},
+		// (3 x 10000 + 1 x 10001) / 4 = 10000,25
+		{"dibulatkan ke bawah", 3, 10000, 1, 10001, 10000},
+		// (1 x 10000 + 1 x 10001) / 2 = 10000,5
+		{"dibulatkan ke atas", 1, 10000, 1, 10001, 10001},
+		{"barang gratis menurunkan harga pokok", 10, 30000, 5, 0, 20000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := averageCost(tt.onHand, tt.oldCost, tt.qty, tt.unitCost); got != tt.want {
+				t.Errorf("averageCost = %d, ingin %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceiveLineCost(t *testing.T) {
+	free, override := int64(0), int64(42000)
+	line := &models.PurchaseOrderLine{UnitCost: 45000}
+
+	tests := []struct {
+		name     string
+		unitCost *int64
+		want     int64
+	}{
+		{"kosong memakai harga di PO", nil, 45000},
+		{"harga override", &override, 42000},
+		{"barang gratis", &free, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (ReceiveLine{UnitCost: tt.unitCost}).cost(line); got != tt.want {
+				t.Errorf("cost = %d, ingin %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceivedStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		received [2]int64
+		want     string
+	}{
+		{"belum ada yang diterima", [2]int64{0, 0}, models.PurchaseOrderPartiallyReceived},
+		{"satu baris lengkap", [2]int64{12, 0}, models.PurchaseOrderPartiallyReceived},
+		{"sebagian tiap baris", [2]int64{6, 3}, models.PurchaseOrderPartiallyReceived},
+		{"semua baris lengkap", [2]int64{12, 6}, models.PurchaseOrderReceived},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := []models.PurchaseOrderLine{
+				{Quantity: 12, ReceivedQuantity: tt.received[0]},
+				{Quantity: 6, ReceivedQuantity: tt.received[1]},
+			}
+			if got := receivedStatus(lines); got != tt.want {
+				t.Errorf("receivedStatus = %s, ingin %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReceiveRejects menguji validasi Receive yang terjadi sebelum ada query ke database
+func TestReceiveRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		lines  []ReceiveLine
+		want   error
+	}{
+		{"PO draft", models.PurchaseOrderDraft, []ReceiveLine{{LineID: 1, Quantity: 1}}, ErrInvalidStatus},
+		{"PO sudah diterima", models.PurchaseOrderReceived, []ReceiveLine{{LineID: 1, Quantity: 1}}, ErrInvalidStatus},
+		{"PO dibatalkan", models.PurchaseOrderCancelled, []ReceiveLine{{LineID: 1, Quantity: 1}}, ErrInvalidStatus},
+		{"tanpa baris", models.PurchaseOrderSent, nil, ErrOverReceive},
+		{"baris bukan milik PO", models.PurchaseOrderSent, []ReceiveLine{{LineID: 9, Quantity: 1}}, ErrOverReceive},
+		{"jumlah 0", models.PurchaseOrderSent, []ReceiveLine{{LineID: 1, Quantity: 0}}, ErrOverReceive},
+		{"melebihi pesanan", models.PurchaseOrderSent, []ReceiveLine{{LineID: 1, Quantity: 13}}, ErrOverReceive},
+		{"melebihi sisa pesanan", models.PurchaseOrderPartiallyReceived, []ReceiveLine{{LineID: 2, Quantity: 4}}, ErrOverReceive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			po := &models.PurchaseOrder{Status: tt.status, Lines: []models.PurchaseOrderLine{
+				{ID: 1, Quantity: 12},
+				{ID: 2, Quantity: 6, ReceivedQuantity: 3},
+			}}
+			if _, err := Receive(nil, po, tt.lines, 1, ""); !errors.Is(err, tt.want) {
+				t.Errorf("Receive error = %v, ingin %v", err, tt.want)
+			}
+		})
+	}
+}
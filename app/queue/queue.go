@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/models"
+)
+
+// DefaultQueue adalah nama antrian jika tidak ditentukan
+const DefaultQueue = "default"
+
+// DefaultMaxAttempts adalah jumlah percobaan maksimal sebelum job masuk dead-letter
+const DefaultMaxAttempts = 5
+
+// HandlerFunc memproses payload mentah dari sebuah job
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = map[string]HandlerFunc{}
+)
+
+// Register mendaftarkan handler bertipe untuk sebuah jenis job.
+// Payload JSON akan di-decode ke T sebelum handler dipanggil.
+func Register[T any](jobType string, fn func(ctx context.Context, payload T) error) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+
+	handlers[jobType] = func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("payload tidak valid untuk job %s: %w", jobType, err)
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// handlerFor mengembalikan handler untuk jenis job tertentu
+func handlerFor(jobType string) (HandlerFunc, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	fn, ok := handlers[jobType]
+	return fn, ok
+}
+
+// Options mengatur antrian, jadwal dan batas percobaan saat dispatch
+type Options struct {
+	Queue       string
+	Delay       time.Duration
+	MaxAttempts int
+}
+
+// Dispatch menyimpan job baru ke tabel jobs agar diproses oleh worker.
+// Gunakan tx dari transaksi yang sedang berjalan agar job ikut ter-commit atau ter-rollback.
+func Dispatch(db *gorm.DB, jobType string, payload any, opts ...Options) (*models.Job, error) {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Queue == "" {
+		opt.Queue = DefaultQueue
+	}
+	if opt.MaxAttempts <= 0 {
+		opt.MaxAttempts = DefaultMaxAttempts
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("gagal encode payload job %s: %w", jobType, err)
+	}
+
+	job := models.Job{
+		Queue:       opt.Queue,
+		Type:        jobType,
+		Payload:     string(raw),
+		Status:      models.JobStatusPending,
+		MaxAttempts: opt.MaxAttempts,
+		AvailableAt: time.Now().Add(opt.Delay),
+	}
+	if err := db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Retry mengembalikan job dead (atau yang gagal) ke status pending dengan jatah percobaan baru
+func Retry(db *gorm.DB, job *models.Job) error {
+	job.Status = models.JobStatusPending
+	job.Attempts = 0
+	job.AvailableAt = time.Now()
+	job.LockedAt = nil
+	job.LockedBy = ""
+	return db.Model(job).Select("status", "attempts", "available_at", "locked_at", "locked_by").Updates(job).Error
+}
+
+// Backoff menghitung jeda sebelum percobaan berikutnya (eksponensial, maksimal 1 jam)
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := 10 * time.Second
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= time.Hour {
+			return time.Hour
+		}
+	}
+	return delay
+}
@@ -0,0 +1,224 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+)
+
+// errNoHandler dikembalikan execute jika tipe job tidak memiliki handler; job langsung masuk dead-letter
+var errNoHandler = errors.New("handler job tidak terdaftar")
+
+// Worker mengambil job dari tabel jobs dan menjalankan handler-nya
+type Worker struct {
+	DB           *gorm.DB
+	Queues       []string
+	Concurrency  int
+	PollInterval time.Duration
+	// StaleAfter menentukan kapan job berstatus running dianggap ditinggal worker yang mati
+	StaleAfter time.Duration
+
+	id string
+}
+
+// NewWorker membuat worker dengan konfigurasi default
+func NewWorker(db *gorm.DB) *Worker {
+	host, _ := os.Hostname()
+	return &Worker{
+		DB:           db,
+		Queues:       []string{DefaultQueue},
+		Concurrency:  4,
+		PollInterval: time.Second,
+		StaleAfter:   15 * time.Minute,
+		id:           fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+}
+
+// Run menjalankan worker sampai ctx dibatalkan
+func (w *Worker) Run(ctx context.Context) {
+	log.Printf("👷 Worker %s berjalan (queues=%v, concurrency=%d)", w.id, w.Queues, w.Concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+
+	// Kembalikan job yang macet karena worker sebelumnya mati di tengah proses
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			w.releaseStale()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	wg.Wait()
+	log.Printf("👷 Worker %s berhenti", w.id)
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := w.claim()
+		if err != nil {
+			log.Printf("❌ Gagal mengambil job: %v", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.PollInterval):
+			}
+			continue
+		}
+
+		w.process(ctx, job)
+	}
+}
+
+// claim mengunci satu job yang siap diproses menggunakan FOR UPDATE SKIP LOCKED
+// sehingga beberapa worker dapat berjalan bersamaan tanpa mengambil job yang sama.
+func (w *Worker) claim() (*models.Job, error) {
+	var job models.Job
+	err := w.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND available_at <= ? AND queue IN ?", models.JobStatusPending, time.Now(), w.Queues).
+			Order("available_at, id").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.LockedAt = &now
+		job.LockedBy = w.id
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_at": job.LockedAt,
+			"locked_by": job.LockedBy,
+		}).Error
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (w *Worker) process(ctx context.Context, job *models.Job) {
+	err := w.execute(ctx, job)
+	now := time.Now()
+
+	if err == nil {
+		w.update(job, map[string]interface{}{
+			"status":       models.JobStatusCompleted,
+			"completed_at": now,
+			"locked_at":    nil,
+			"locked_by":    "",
+			"last_error":   "",
+		})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"last_error": err.Error(),
+		"locked_at":  nil,
+		"locked_by":  "",
+	}
+	if job.Attempts >= job.MaxAttempts || errors.Is(err, errNoHandler) {
+		updates["status"] = models.JobStatusDead
+		log.Printf("💀 Job #%d (%s) masuk dead-letter setelah %d percobaan: %v", job.ID, job.Type, job.Attempts, err)
+	} else {
+		updates["status"] = models.JobStatusPending
+		updates["available_at"] = now.Add(Backoff(job.Attempts))
+		log.Printf("⚠️  Job #%d (%s) gagal (percobaan %d/%d): %v", job.ID, job.Type, job.Attempts, job.MaxAttempts, err)
+	}
+	w.update(job, updates)
+}
+
+// update menyimpan hasil eksekusi job. Jika gagal, job tetap berstatus running dan akan dilepas releaseStale.
+func (w *Worker) update(job *models.Job, updates map[string]interface{}) {
+	if err := w.DB.Model(job).Updates(updates).Error; err != nil {
+		log.Printf("❌ Gagal menyimpan status job #%d (%s): %v", job.ID, job.Type, err)
+	}
+}
+
+// execute menjalankan handler dan mengubah panic menjadi error agar worker tetap hidup
+func (w *Worker) execute(ctx context.Context, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	fn, ok := handlerFor(job.Type)
+	if !ok {
+		// Tidak ada gunanya mencoba ulang job tanpa handler
+		return fmt.Errorf("%w: %s", errNoHandler, job.Type)
+	}
+	return fn(ctx, json.RawMessage(job.Payload))
+}
+
+// releaseStale mengembalikan job running yang terkunci terlalu lama ke status pending. Job yang percobaannya
+// sudah habis (mis. selalu membuat worker mati) dipindahkan ke dead-letter agar tidak dicoba ulang selamanya.
+func (w *Worker) releaseStale() {
+	stale := w.DB.Model(&models.Job{}).
+		Where("status = ? AND locked_at < ?", models.JobStatusRunning, time.Now().Add(-w.StaleAfter)).
+		Session(&gorm.Session{})
+
+	result := stale.Where("attempts >= max_attempts").
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusDead,
+			"last_error": "worker berhenti saat memproses job",
+			"locked_at":  nil,
+			"locked_by":  "",
+		})
+	if result.Error != nil {
+		log.Printf("❌ Gagal memindahkan job macet ke dead-letter: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("💀 %d job macet masuk dead-letter karena percobaan habis", result.RowsAffected)
+	}
+
+	result = stale.Where("attempts < max_attempts").
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusPending,
+			"available_at": time.Now(),
+			"locked_at":    nil,
+			"locked_by":    "",
+		})
+	if result.Error != nil {
+		log.Printf("❌ Gagal melepas job yang macet: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("♻️  %d job macet dikembalikan ke antrian", result.RowsAffected)
+	}
+}
@@ -0,0 +1,147 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// Channel adalah nama channel LISTEN/NOTIFY PostgreSQL untuk event salon
+const Channel = "salon_events"
+
+// Jenis event yang dikirim ke klien
+const (
+	EventBookingCreated   = "booking.created"
+	EventBookingMoved     = "booking.moved"
+	EventBookingCancelled = "booking.cancelled"
+	EventUserAdded        = "user.added"
+	EventUserUpdated      = "user.updated"
+	EventUserRemoved      = "user.removed"
+	EventQueueUpdated     = "queue.updated"
+	EventPaymentUpdated   = "payment.updated"
+)
+
+// Event adalah pesan yang dikirim ke semua klien yang terhubung ke satu salon
+type Event struct {
+	SalonID uint            `json:"salon_id"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+	At      time.Time       `json:"at"`
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers = map[uint]map[chan Event]struct{}{}
+)
+
+// Publish mengirim event melalui pg_notify. Jika db adalah transaksi, event baru
+// terkirim setelah commit sehingga klien tidak melihat perubahan yang di-rollback.
+// Semua instance yang menjalankan Listen akan meneruskan event ke klien masing-masing.
+func Publish(db *gorm.DB, salonID uint, eventType string, data any) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("❌ Gagal encode event %s: %v", eventType, err)
+		return
+	}
+
+	payload, err := json.Marshal(Event{SalonID: salonID, Type: eventType, Data: raw, At: time.Now()})
+	if err != nil {
+		log.Printf("❌ Gagal encode event %s: %v", eventType, err)
+		return
+	}
+
+	if err := db.Exec("SELECT pg_notify(?, ?)", Channel, string(payload)).Error; err != nil {
+		log.Printf("❌ Gagal mengirim event %s: %v", eventType, err)
+	}
+}
+
+// Subscribe mendaftarkan klien untuk menerima event sebuah salon.
+// Panggil fungsi unsubscribe yang dikembalikan saat klien terputus.
+func Subscribe(salonID uint) (<-chan Event, func()) {
+	ch := make(chan Event, 32)
+
+	mu.Lock()
+	if subscribers[salonID] == nil {
+		subscribers[salonID] = map[chan Event]struct{}{}
+	}
+	subscribers[salonID][ch] = struct{}{}
+	mu.Unlock()
+
+	return ch, func() {
+		mu.Lock()
+		delete(subscribers[salonID], ch)
+		if len(subscribers[salonID]) == 0 {
+			delete(subscribers, salonID)
+		}
+		mu.Unlock()
+	}
+}
+
+// broadcast meneruskan event ke semua klien salon di instance ini.
+// Klien yang lambat (buffer penuh) dilewati agar tidak menahan klien lain.
+func broadcast(event Event) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for ch := range subscribers[event.SalonID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Listen menjalankan LISTEN pada koneksi PostgreSQL khusus dan meneruskan setiap
+// notifikasi ke klien lokal. Koneksi dibuat ulang otomatis jika terputus.
+func Listen(ctx context.Context, dsn string) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		err := listen(ctx, dsn, func() { backoff = time.Second })
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("⚠️  Listener realtime terputus: %v (mencoba lagi dalam %s)", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func listen(ctx context.Context, dsn string, connected func()) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	connected()
+	log.Printf("📡 Listener realtime aktif pada channel %s", Channel)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Printf("❌ Payload event tidak valid: %v", err)
+			continue
+		}
+		broadcast(event)
+	}
+}
@@ -0,0 +1,357 @@
+package receipt
+
+import (
+	"fmt"
+	"strings"
+
+	"gin-sass-salon/app/models"
+)
+
+// Ukuran kertas dalam point
+const (
+	a4Width       = 595.28
+	a4Height      = 841.89
+	a4Margin      = 50.0
+	thermalWidth  = 80 * pointsPerMM
+	thermalMargin = 4 * pointsPerMM
+	// thermalCols adalah jumlah karakter per baris Courier 8pt pada area cetak 72mm
+	thermalCols     = 42
+	thermalFontSize = 8.0
+	thermalLeading  = 10.0
+)
+
+// thermalLine adalah satu baris teks monospace pada struk 80mm
+type thermalLine struct {
+	text   string
+	bold   bool
+	center bool
+}
+
+// renderThermal menyusun struk 80mm sebagai satu halaman panjang teks monospace. Tinggi halaman
+// mengikuti isi sehingga printer thermal tidak memotong di tengah struk.
+func renderThermal(pdf *pdfDoc, doc Document, kind string) {
+	sale := doc.Sale
+	var lines []thermalLine
+	add := func(text string, bold, center bool) {
+		lines = append(lines, thermalLine{text: text, bold: bold, center: center})
+	}
+	separator := func() { add(strings.Repeat("-", thermalCols), false, false) }
+	pair := func(left, right string, bold bool) {
+		for _, l := range twoColumns(left, right, thermalCols) {
+			add(l, bold, false)
+		}
+	}
+
+	for _, l := range wrap(doc.Salon.Name, thermalCols) {
+		add(l, true, true)
+	}
+	for _, text := range []string{doc.Salon.Address, doc.Salon.Phone} {
+		for _, l := range wrap(text, thermalCols) {
+			add(l, false, true)
+		}
+	}
+	if doc.Salon.NPWP != "" {
+		add("NPWP "+doc.Salon.NPWP, false, true)
+	}
+	for _, text := range splitLines(doc.Template.Header) {
+		for _, l := range wrap(text, thermalCols) {
+			add(l, false, true)
+		}
+	}
+	separator()
+	add(title(kind), true, true)
+	pair("No", sale.ReceiptNumber, false)
+	pair("Tanggal", formatDate(sale), false)
+	if doc.CashierName != "" {
+		pair("Kasir", doc.CashierName, false)
+	}
+	if doc.Customer != nil {
+		pair("Pelanggan", doc.Customer.Name, false)
+	}
+	separator()
+
+	for _, line := range itemLines(sale) {
+		for _, l := range wrap(line.Description, thermalCols) {
+			add(l, false, false)
+		}
+		if line.Type == models.SaleLineDiscount {
+			pair("", rupiah(line.Gross), false)
+		} else {
+			pair(fmt.Sprintf("  %d x %s", line.Quantity, rupiah(line.UnitPrice)), rupiah(line.Gross), false)
+		}
+		if line.DiscountAmount > 0 {
+			pair("  Diskon", rupiah(-line.DiscountAmount), false)
+		}
+	}
+	separator()
+	for _, r := range summaryRows(sale) {
+		pair(r.label, rupiah(r.amount), r.bold)
+	}
+	if rows := paymentRows(sale); len(rows) > 0 {
+		separator()
+		for _, r := range rows {
+			pair(r.label, rupiah(r.amount), r.bold)
+		}
+	}
+	if stamp := statusStamp(sale); stamp != "" {
+		separator()
+		add(stamp, true, true)
+	}
+	if footer := splitLines(doc.Template.Footer); len(footer) > 0 {
+		separator()
+		for _, text := range footer {
+			for _, l := range wrap(text, thermalCols) {
+				add(l, false, true)
+			}
+		}
+	}
+
+	// Logo maksimal 40mm lebar dan 20mm tinggi, di tengah atas
+	var logoW, logoH float64
+	if pdf.logo != nil {
+		logoW, logoH = fitBox(pdf.logo, 40*pointsPerMM, 20*pointsPerMM)
+		logoH += 4
+	}
+
+	height := 2*thermalMargin + logoH + float64(len(lines))*thermalLeading
+	page := pdf.addPage(thermalWidth, height)
+	y := thermalMargin
+	if pdf.logo != nil {
+		page.image((thermalWidth-logoW)/2, y, logoW, logoH-4)
+		y += logoH
+	}
+	for _, l := range lines {
+		y += thermalLeading
+		font := fontMono
+		if l.bold {
+			font = fontMonoBold
+		}
+		if l.center {
+			page.textCenter(thermalWidth/2, y-2, font, thermalFontSize, l.text)
+		} else {
+			page.text(thermalMargin, y-2, font, thermalFontSize, l.text)
+		}
+	}
+}
+
+// renderA4 menyusun struk/invoice A4 dengan kop salon, tabel item dan ringkasan. Tabel item
+// berlanjut ke halaman berikutnya jika tidak muat.
+func renderA4(pdf *pdfDoc, doc Document, kind string) {
+	sale := doc.Sale
+	const (
+		right     = a4Width - a4Margin
+		colQty    = 340.0
+		colPrice  = 440.0
+		labelX    = 340.0
+		bottom    = a4Height - 70
+		bodySize  = 10.0
+		smallSize = 9.0
+	)
+
+	page := pdf.addPage(a4Width, a4Height)
+	y := a4Margin
+
+	// Kop: logo di kiri, identitas salon di sebelahnya, judul dan nomor dokumen di kanan
+	x := a4Margin
+	headerBottom := y
+	if pdf.logo != nil {
+		w, h := fitBox(pdf.logo, 140, 60)
+		page.image(a4Margin, y, w, h)
+		x += w + 12
+		headerBottom = y + h
+	}
+	infoY := y + 14
+	page.text(x, infoY, fontBold, 14, doc.Salon.Name)
+	for _, text := range []string{doc.Salon.Address, doc.Salon.Phone} {
+		if text != "" {
+			infoY += 12
+			page.text(x, infoY, fontRegular, smallSize, text)
+		}
+	}
+	if doc.Salon.NPWP != "" {
+		infoY += 12
+		page.text(x, infoY, fontRegular, smallSize, "NPWP: "+doc.Salon.NPWP)
+	}
+	for _, text := range splitLines(doc.Template.Header) {
+		infoY += 12
+		page.text(x, infoY, fontRegular, smallSize, text)
+	}
+
+	page.textRight(right, y+14, fontBold, 16, title(kind))
+	page.textRight(right, y+30, fontRegular, bodySize, "No. "+sale.ReceiptNumber)
+	page.textRight(right, y+43, fontRegular, bodySize, formatDate(sale))
+	metaY := y + 43
+	if doc.CashierName != "" {
+		metaY += 13
+		page.textRight(right, metaY, fontRegular, bodySize, "Kasir: "+doc.CashierName)
+	}
+
+	y = max(headerBottom, infoY, metaY) + 20
+	if doc.Customer != nil {
+		label := "Pelanggan"
+		if kind == KindInvoice {
+			label = "Ditagihkan kepada"
+		}
+		page.text(a4Margin, y, fontBold, bodySize, label)
+		y += 13
+		page.text(a4Margin, y, fontRegular, bodySize, doc.Customer.Name)
+		for _, text := range []string{doc.Customer.Phone, doc.Customer.Email} {
+			if text != "" {
+				y += 12
+				page.text(a4Margin, y, fontRegular, smallSize, text)
+			}
+		}
+		y += 20
+	}
+
+	tableHeader := func() {
+		page.line(a4Margin, y, right, y, 0.8)
+		y += 14
+		page.text(a4Margin, y, fontBold, bodySize, "Deskripsi")
+		page.textRight(colQty, y, fontBold, bodySize, "Qty")
+		page.textRight(colPrice, y, fontBold, bodySize, "Harga")
+		page.textRight(right, y, fontBold, bodySize, "Jumlah")
+		y += 6
+		page.line(a4Margin, y, right, y, 0.8)
+	}
+	ensureSpace := func(needed float64) {
+		if y+needed <= bottom {
+			return
+		}
+		page = pdf.addPage(a4Width, a4Height)
+		y = a4Margin
+		page.textRight(right, y, fontRegular, smallSize, fmt.Sprintf("%s %s (lanjutan)", title(kind), sale.ReceiptNumber))
+		y += 12
+		tableHeader()
+	}
+
+	tableHeader()
+	for _, line := range itemLines(sale) {
+		ensureSpace(28)
+		y += 14
+		page.text(a4Margin, y, fontRegular, bodySize, truncate(line.Description, fontRegular, bodySize, colQty-a4Margin-40))
+		page.textRight(colQty, y, fontRegular, bodySize, fmt.Sprintf("%d", line.Quantity))
+		page.textRight(colPrice, y, fontRegular, bodySize, rupiah(line.UnitPrice))
+		page.textRight(right, y, fontRegular, bodySize, rupiah(line.Gross))
+		if line.DiscountAmount > 0 {
+			y += 12
+			page.text(a4Margin+10, y, fontRegular, smallSize, "Diskon item")
+			page.textRight(right, y, fontRegular, smallSize, rupiah(-line.DiscountAmount))
+		}
+	}
+	y += 8
+	page.line(a4Margin, y, right, y, 0.8)
+
+	summary := summaryRows(sale)
+	payments := paymentRows(sale)
+	ensureSpace(float64(len(summary)+len(payments))*14 + 40)
+	for _, r := range summary {
+		y += 14
+		font := fontRegular
+		if r.bold {
+			font = fontBold
+			page.line(labelX, y-10, right, y-10, 0.5)
+		}
+		page.text(labelX, y, font, bodySize, r.label)
+		page.textRight(right, y, font, bodySize, rupiah(r.amount))
+	}
+	if len(payments) > 0 {
+		y += 10
+		for _, r := range payments {
+			y += 14
+			page.text(labelX, y, fontRegular, bodySize, r.label)
+			page.textRight(right, y, fontRegular, bodySize, rupiah(r.amount))
+		}
+	}
+
+	if groups := taxGroups(sale); len(groups) > 0 {
+		ensureSpace(float64(len(groups))*12 + 30)
+		y += 24
+		page.text(a4Margin, y, fontBold, smallSize, "Rincian pajak")
+		for _, g := range groups {
+			y += 12
+			page.text(a4Margin, y, fontRegular, smallSize,
+				fmt.Sprintf("%s %s atas DPP %s = %s", g.name, percent(g.rateBps), rupiah(g.taxable), rupiah(g.amount)))
+		}
+	}
+
+	if stamp := statusStamp(sale); stamp != "" {
+		ensureSpace(30)
+		y += 26
+		page.textCenter(a4Width/2, y, fontBold, 14, stamp)
+	}
+
+	// Footer template dicetak di bagian bawah halaman terakhir
+	footer := splitLines(doc.Template.Footer)
+	fy := a4Height - 40 - float64(len(footer)-1)*11
+	for _, text := range footer {
+		page.textCenter(a4Width/2, fy, fontRegular, smallSize, text)
+		fy += 11
+	}
+}
+
+// fitBox memperkecil ukuran logo agar muat di kotak maxW x maxH dengan rasio tetap
+func fitBox(img *jpegImage, maxW, maxH float64) (float64, float64) {
+	w, h := float64(img.width), float64(img.height)
+	if w <= 0 || h <= 0 {
+		return maxW, maxH
+	}
+	scale := maxW / w
+	if h*scale > maxH {
+		scale = maxH / h
+	}
+	return w * scale, h * scale
+}
+
+// twoColumns menaruh teks kiri dan kanan pada satu baris selebar cols karakter; jika tidak muat
+// teks kiri diletakkan di baris sendiri
+func twoColumns(left, right string, cols int) []string {
+	l, r := []rune(left), []rune(right)
+	if len(l)+len(r)+1 <= cols {
+		return []string{left + strings.Repeat(" ", cols-len(l)-len(r)) + right}
+	}
+	lines := wrap(left, cols)
+	return append(lines, strings.Repeat(" ", max(cols-len(r), 0))+right)
+}
+
+// wrap memecah teks per kata menjadi baris dengan panjang maksimal cols karakter
+func wrap(text string, cols int) []string {
+	var lines []string
+	var current []rune
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+		for len(w) > cols {
+			if len(current) > 0 {
+				lines = append(lines, string(current))
+				current = nil
+			}
+			lines = append(lines, string(w[:cols]))
+			w = w[cols:]
+		}
+		switch {
+		case len(current) == 0:
+			current = w
+		case len(current)+1+len(w) <= cols:
+			current = append(append(current, ' '), w...)
+		default:
+			lines = append(lines, string(current))
+			current = w
+		}
+	}
+	if len(current) > 0 {
+		lines = append(lines, string(current))
+	}
+	return lines
+}
+
+// truncate memotong teks agar lebarnya tidak melebihi maxWidth point
+func truncate(s, font string, size, maxWidth float64) string {
+	if textWidth(font, size, s) <= maxWidth {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && textWidth(font, size, string(r)+"...") > maxWidth {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "..."
+}
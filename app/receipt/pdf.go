@@ -0,0 +1,220 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Font standar PDF (Type1 base-14) sehingga tidak perlu menyematkan file font
+const (
+	fontRegular      = "F1"
+	fontBold         = "F2"
+	fontMono         = "F3"
+	fontMonoBold     = "F4"
+	pointsPerMM      = 72 / 25.4
+	monoCharWidth    = 600
+	defaultCharWidth = 556
+)
+
+var fontNames = []struct{ key, name string }{
+	{fontRegular, "Helvetica"},
+	{fontBold, "Helvetica-Bold"},
+	{fontMono, "Courier"},
+	{fontMonoBold, "Courier-Bold"},
+}
+
+// Lebar karakter ASCII 32..126 dari metrik AFM Helvetica dan Helvetica-Bold (per 1000 unit)
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// textWidth menghitung lebar teks dalam point
+func textWidth(font string, size float64, s string) float64 {
+	total := 0
+	for _, b := range winAnsi(s) {
+		switch {
+		case font == fontMono || font == fontMonoBold:
+			total += monoCharWidth
+		case b >= 32 && b <= 126 && font == fontBold:
+			total += helveticaBoldWidths[b-32]
+		case b >= 32 && b <= 126:
+			total += helveticaWidths[b-32]
+		default:
+			total += defaultCharWidth
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// winAnsi mengubah teks UTF-8 ke WinAnsiEncoding; karakter di luar Latin-1 diganti '?'
+func winAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			out = append(out, ' ')
+		case r < 128 || (r >= 160 && r <= 255):
+			out = append(out, byte(r))
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// escapeText meng-escape karakter khusus string literal PDF
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, c := range winAnsi(s) {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// jpegImage adalah gambar JPEG yang disematkan apa adanya (DCTDecode)
+type jpegImage struct {
+	data          []byte
+	width, height int
+}
+
+// pdfPage adalah satu halaman. Koordinat y dihitung dari atas halaman.
+type pdfPage struct {
+	width, height float64
+	content       bytes.Buffer
+	usesImage     bool
+}
+
+// text menulis teks dengan baseline di (x, y)
+func (p *pdfPage) text(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, p.height-y, escapeText(s))
+}
+
+// textRight menulis teks rata kanan dengan tepi kanan di x
+func (p *pdfPage) textRight(x, y float64, font string, size float64, s string) {
+	p.text(x-textWidth(font, size, s), y, font, size, s)
+}
+
+// textCenter menulis teks rata tengah terhadap x
+func (p *pdfPage) textCenter(x, y float64, font string, size float64, s string) {
+	p.text(x-textWidth(font, size, s)/2, y, font, size, s)
+}
+
+// line menggambar garis dari (x1, y1) ke (x2, y2)
+func (p *pdfPage) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, p.height-y1, x2, p.height-y2)
+}
+
+// image menggambar logo dokumen dengan pojok kiri atas di (x, y)
+func (p *pdfPage) image(x, y, w, h float64) {
+	p.usesImage = true
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im1 Do Q\n", w, h, x, p.height-y-h)
+}
+
+// pdfDoc adalah penulis PDF 1.4 minimal: halaman, teks font standar, garis dan satu gambar JPEG
+type pdfDoc struct {
+	pages []*pdfPage
+	logo  *jpegImage
+}
+
+// addPage menambah halaman baru berukuran width x height point
+func (d *pdfDoc) addPage(width, height float64) *pdfPage {
+	page := &pdfPage{width: width, height: height}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// bytes menyusun objek, tabel xref dan trailer PDF
+func (d *pdfDoc) bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	// Nomor objek: 1 katalog, 2 pages, 3..6 font, 7 logo, lalu pasangan page + content
+	fontObj := 3
+	imageObj := fontObj + len(fontNames)
+	firstPageObj := imageObj + 1
+	begin := func() int {
+		offsets = append(offsets, buf.Len())
+		n := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n", n)
+		return n
+	}
+	end := func() { buf.WriteString("endobj\n") }
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	begin()
+	buf.WriteString("<< /Type /Catalog /Pages 2 0 R >>\n")
+	end()
+
+	begin()
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageObj+i*2)
+	}
+	fmt.Fprintf(&buf, "<< /Type /Pages /Kids [%s] /Count %d >>\n", strings.Join(kids, " "), len(d.pages))
+	end()
+
+	for _, f := range fontNames {
+		begin()
+		fmt.Fprintf(&buf, "<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>\n", f.name)
+		end()
+	}
+
+	begin()
+	if d.logo != nil {
+		fmt.Fprintf(&buf, "<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB "+
+			"/BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n", d.logo.width, d.logo.height, len(d.logo.data))
+		buf.Write(d.logo.data)
+		buf.WriteString("\nendstream\n")
+	} else {
+		buf.WriteString("null\n")
+	}
+	end()
+
+	var fonts []string
+	for i, f := range fontNames {
+		fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", f.key, fontObj+i))
+	}
+	for _, page := range d.pages {
+		pageObj := begin()
+		resources := fmt.Sprintf("/Font << %s >>", strings.Join(fonts, " "))
+		if page.usesImage && d.logo != nil {
+			resources += fmt.Sprintf(" /XObject << /Im1 %d 0 R >>", imageObj)
+		}
+		fmt.Fprintf(&buf, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << %s >> /Contents %d 0 R >>\n",
+			page.width, page.height, resources, pageObj+1)
+		end()
+
+		begin()
+		fmt.Fprintf(&buf, "<< /Length %d >>\nstream\n", page.content.Len())
+		buf.Write(page.content.Bytes())
+		buf.WriteString("endstream\n")
+		end()
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
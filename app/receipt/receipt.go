@@ -0,0 +1,326 @@
+package receipt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // decoder PNG untuk upload logo
+	"sort"
+	"strings"
+
+	"gin-sass-salon/app/mailer"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/pos"
+	"gin-sass-salon/config"
+)
+
+// Jenis dokumen yang bisa dicetak
+const (
+	KindReceipt = "receipt"
+	KindInvoice = "invoice"
+)
+
+// maxLogoSide adalah sisi terpanjang logo (piksel) setelah diperkecil saat upload
+const maxLogoSide = 600
+
+// ErrInvalidLogo dikembalikan jika file logo bukan JPEG/PNG yang valid
+var ErrInvalidLogo = errors.New("logo harus berupa gambar JPEG atau PNG")
+
+// Document adalah data yang dibutuhkan untuk mencetak struk/invoice satu transaksi
+type Document struct {
+	Salon       models.Salon
+	Template    models.ReceiptTemplate
+	Sale        models.Sale
+	Customer    *models.Customer
+	CashierName string
+}
+
+// Options mengatur ukuran kertas (a4 / 80mm) dan jenis dokumen (receipt / invoice)
+type Options struct {
+	Paper string
+	Kind  string
+}
+
+// Render menghasilkan PDF struk atau invoice. Paper kosong memakai pengaturan template salon.
+func Render(doc Document, opt Options) ([]byte, error) {
+	if opt.Paper == "" {
+		opt.Paper = doc.Template.Paper
+	}
+	if opt.Kind == "" {
+		opt.Kind = KindReceipt
+	}
+
+	pdf := &pdfDoc{}
+	if doc.Template.HasLogo && len(doc.Template.LogoData) > 0 {
+		pdf.logo = &jpegImage{data: doc.Template.LogoData, width: doc.Template.LogoWidth, height: doc.Template.LogoHeight}
+	}
+
+	switch opt.Paper {
+	case models.ReceiptPaperA4:
+		renderA4(pdf, doc, opt.Kind)
+	case models.ReceiptPaperThermal, "":
+		renderThermal(pdf, doc, opt.Kind)
+	default:
+		return nil, fmt.Errorf("ukuran kertas %q tidak dikenal", opt.Paper)
+	}
+	return pdf.bytes(), nil
+}
+
+// Filename mengembalikan nama file PDF untuk transaksi
+func Filename(sale models.Sale, kind string) string {
+	prefix := "struk"
+	if kind == KindInvoice {
+		prefix = "invoice"
+	}
+	return fmt.Sprintf("%s-%s.pdf", prefix, sale.ReceiptNumber)
+}
+
+// NormalizeLogo mengubah logo JPEG/PNG menjadi JPEG RGB (latar transparan menjadi putih) dan
+// memperkecilnya jika terlalu besar, sehingga bisa disematkan langsung ke PDF
+func NormalizeLogo(data []byte) ([]byte, int, int, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, ErrInvalidLogo
+	}
+
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return nil, 0, 0, ErrInvalidLogo
+	}
+	if w > maxLogoSide || h > maxLogoSide {
+		if w >= h {
+			w, h = maxLogoSide, h*maxLogoSide/w
+		} else {
+			w, h = w*maxLogoSide/h, maxLogoSide
+		}
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+	}
+
+	// Nearest-neighbour sudah cukup untuk logo kecil di struk; piksel transparan ditimpa ke latar putih
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx := bounds.Min.X + x*bounds.Dx()/w
+			sy := bounds.Min.Y + y*bounds.Dy()/h
+			c := color.RGBAModel.Convert(src.At(sx, sy)).(color.RGBA)
+			white := 255 - c.A
+			dst.SetRGBA(x, y, color.RGBA{R: c.R + white, G: c.G + white, B: c.B + white, A: 255})
+		}
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, 0, 0, err
+	}
+	return out.Bytes(), w, h, nil
+}
+
+// row adalah satu baris label + nominal pada ringkasan total
+type row struct {
+	label  string
+	amount int64
+	bold   bool
+}
+
+// taxGroup adalah total pajak per nama dan tarif untuk rincian di struk
+type taxGroup struct {
+	name    string
+	rateBps int
+	taxable int64
+	amount  int64
+}
+
+// itemLines mengembalikan baris layanan, produk dan diskon transaksi (tanpa tip dan service charge)
+func itemLines(sale models.Sale) []models.SaleLine {
+	var lines []models.SaleLine
+	for _, line := range sale.Lines {
+		switch line.Type {
+		case models.SaleLineService, models.SaleLineProduct, models.SaleLineDiscount:
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// taxGroups menjumlahkan rincian pajak semua baris per nama dan tarif
+func taxGroups(sale models.Sale) []taxGroup {
+	index := map[string]int{}
+	var groups []taxGroup
+	for _, line := range sale.Lines {
+		for _, tax := range line.Taxes {
+			key := fmt.Sprintf("%s|%d", tax.Name, tax.RateBps)
+			i, ok := index[key]
+			if !ok {
+				i = len(groups)
+				index[key] = i
+				groups = append(groups, taxGroup{name: tax.Name, rateBps: tax.RateBps})
+			}
+			groups[i].taxable += tax.TaxableAmount
+			groups[i].amount += tax.Amount
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].name < groups[j].name })
+	return groups
+}
+
+// summaryRows menyusun ringkasan subtotal, diskon, service charge, pajak, tip dan total
+func summaryRows(sale models.Sale) []row {
+	rows := []row{{label: "Subtotal", amount: sale.Subtotal}}
+	if sale.DiscountTotal > 0 {
+		rows = append(rows, row{label: "Diskon", amount: -sale.DiscountTotal})
+	}
+	if sale.ServiceChargeTotal > 0 {
+		rows = append(rows, row{label: "Service charge", amount: sale.ServiceChargeTotal})
+	}
+	for _, g := range taxGroups(sale) {
+		label := fmt.Sprintf("%s %s", g.name, percent(g.rateBps))
+		if sale.PricesIncludeTax {
+			label += " (termasuk)"
+		}
+		rows = append(rows, row{label: label, amount: g.amount})
+	}
+	if sale.TipTotal > 0 {
+		rows = append(rows, row{label: "Tip", amount: sale.TipTotal})
+	}
+	rows = append(rows, row{label: "TOTAL", amount: sale.Total, bold: true})
+	return rows
+}
+
+// paymentRows menyusun baris pembayaran, kembalian dan refund
+func paymentRows(sale models.Sale) []row {
+	var rows []row
+	for _, tender := range sale.Tenders {
+		rows = append(rows, row{label: tenderLabel(tender.Method), amount: tender.Amount})
+	}
+	if sale.ChangeDue > 0 {
+		rows = append(rows, row{label: "Kembalian", amount: sale.ChangeDue})
+	}
+	if sale.RefundedTotal > 0 {
+		rows = append(rows, row{label: "Dikembalikan", amount: -sale.RefundedTotal})
+	}
+	return rows
+}
+
+// statusStamp mengembalikan cap status untuk transaksi yang sudah di-void/refund
+func statusStamp(sale models.Sale) string {
+	switch sale.Status {
+	case models.SaleStatusVoided:
+		return "*** VOID ***"
+	case models.SaleStatusRefunded:
+		return "*** REFUND ***"
+	case models.SaleStatusPartiallyRefunded:
+		return "*** REFUND SEBAGIAN ***"
+	}
+	return ""
+}
+
+func tenderLabel(method string) string {
+	switch method {
+	case models.TenderCash:
+		return "Tunai"
+	case models.TenderCard:
+		return "Kartu"
+	case models.TenderQRIS:
+		return "QRIS"
+	case models.TenderTransfer:
+		return "Transfer"
+	case models.TenderVirtualAccount:
+		return "Virtual Account"
+	case models.TenderGiftCard:
+		return "Gift card"
+	}
+	return method
+}
+
+func title(kind string) string {
+	if kind == KindInvoice {
+		return "INVOICE"
+	}
+	return "STRUK PEMBAYARAN"
+}
+
+func documentName(kind string) string {
+	if kind == KindInvoice {
+		return "Invoice"
+	}
+	return "Struk"
+}
+
+func formatDate(sale models.Sale) string {
+	return sale.CompletedAt.In(config.Location()).Format("02/01/2006 15:04")
+}
+
+// rupiah memformat nominal dengan pemisah ribuan titik, mis. Rp150.000
+func rupiah(amount int64) string {
+	return pos.FormatRupiah(amount)
+}
+
+// percent memformat basis poin menjadi persen, mis. 1100 -> 11%, 1250 -> 12,5%
+func percent(bps int) string {
+	if bps%100 == 0 {
+		return fmt.Sprintf("%d%%", bps/100)
+	}
+	return strings.TrimRight(strings.TrimRight(strings.Replace(fmt.Sprintf("%.2f", float64(bps)/100), ".", ",", 1), "0"), ",") + "%"
+}
+
+// splitLines memecah teks template per baris dan membuang baris kosong di ujung
+func splitLines(s string) []string {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// Email merender PDF lalu menyusun email struk/invoice ke alamat to. Subjek dan isi diambil dari
+// template salon dengan placeholder {salon}, {receipt_number}, {customer} dan {total}.
+func Email(doc Document, opt Options, to string) (mailer.Message, error) {
+	if opt.Kind == "" {
+		opt.Kind = KindReceipt
+	}
+	pdf, err := Render(doc, opt)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+
+	customer := ""
+	if doc.Customer != nil {
+		customer = doc.Customer.Name
+	}
+	replacer := strings.NewReplacer(
+		"{salon}", doc.Salon.Name,
+		"{receipt_number}", doc.Sale.ReceiptNumber,
+		"{customer}", customer,
+		"{total}", rupiah(doc.Sale.Total),
+	)
+
+	subject := doc.Template.EmailSubject
+	if subject == "" {
+		subject = "{salon} - " + documentName(opt.Kind) + " {receipt_number}"
+	}
+	body := doc.Template.EmailBody
+	if body == "" {
+		body = "Terima kasih telah berkunjung ke {salon}.\n\nBerikut kami lampirkan " +
+			strings.ToLower(documentName(opt.Kind)) + " {receipt_number} dengan total {total}."
+	}
+
+	return mailer.Message{
+		To:      []string{to},
+		Subject: replacer.Replace(subject),
+		Body:    replacer.Replace(body),
+		Attachments: []mailer.Attachment{{
+			Filename:    Filename(doc.Sale, opt.Kind),
+			ContentType: "application/pdf",
+			Data:        pdf,
+		}},
+	}, nil
+}
@@ -0,0 +1,88 @@
+// Package report berisi laporan agregat yang dihitung langsung di database
+package report
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/models"
+)
+
+// BranchSummary adalah ringkasan kinerja satu cabang pada satu rentang tanggal
+type BranchSummary struct {
+	BranchID     uint   `json:"branch_id"`
+	BranchName   string `json:"branch_name"`
+	IsMain       bool   `json:"is_main"`
+	Transactions int64  `json:"transactions"`
+	GrossSales   int64  `json:"gross_sales"`
+	Refunds      int64  `json:"refunds"`
+	// NetSales adalah total transaksi dikurangi refund, termasuk pajak, service charge dan tip
+	NetSales int64 `json:"net_sales"`
+	Tax      int64 `json:"tax"`
+	Tips     int64 `json:"tips"`
+	// AverageTicket adalah rata-rata NetSales per transaksi
+	AverageTicket int64 `json:"average_ticket"`
+	// StockValue adalah nilai persediaan saat ini (stok positif dikali harga pokok)
+	StockValue int64 `json:"stock_value"`
+	Staff      int64 `json:"staff"`
+}
+
+// Branches menghitung ringkasan per cabang pada rentang [from, to) tanpa transaksi yang di-void.
+// branchIDs kosong berarti semua cabang salon.
+func Branches(db *gorm.DB, salonID uint, branchIDs []uint, from, to time.Time) ([]BranchSummary, error) {
+	sales := db.Table("sales").
+		Select("branch_id, COUNT(*) AS transactions, SUM(total) AS gross_sales, SUM(refunded_total) AS refunds, "+
+			"SUM(tax_total) AS tax, SUM(tip_total) AS tips").
+		Where("salon_id = ? AND status <> ? AND deleted_at IS NULL AND completed_at >= ? AND completed_at < ?",
+			salonID, models.SaleStatusVoided, from, to).
+		Group("branch_id")
+	stock := db.Table("stock_levels").
+		Select("stock_levels.branch_id, SUM(stock_levels.quantity * products.cost_price) AS stock_value").
+		Joins("JOIN products ON products.id = stock_levels.product_id AND products.deleted_at IS NULL").
+		Where("stock_levels.salon_id = ? AND stock_levels.quantity > 0", salonID).
+		Group("stock_levels.branch_id")
+	staff := db.Table("branch_staffs").
+		Select("branch_staffs.branch_id, COUNT(*) AS staff").
+		Joins("JOIN users ON users.id = branch_staffs.user_id AND users.deleted_at IS NULL").
+		Where("users.salon_id = ?", salonID).
+		Group("branch_staffs.branch_id")
+
+	query := db.Table("branches").
+		Select("branches.id AS branch_id, branches.name AS branch_name, branches.is_main, "+
+			"COALESCE(s.transactions, 0) AS transactions, COALESCE(s.gross_sales, 0) AS gross_sales, "+
+			"COALESCE(s.refunds, 0) AS refunds, COALESCE(s.gross_sales - s.refunds, 0) AS net_sales, "+
+			"COALESCE(s.tax, 0) AS tax, COALESCE(s.tips, 0) AS tips, "+
+			"CAST(COALESCE((s.gross_sales - s.refunds) / NULLIF(s.transactions, 0), 0) AS bigint) AS average_ticket, "+
+			"COALESCE(st.stock_value, 0) AS stock_value, COALESCE(bs.staff, 0) AS staff").
+		Joins("LEFT JOIN (?) AS s ON s.branch_id = branches.id", sales).
+		Joins("LEFT JOIN (?) AS st ON st.branch_id = branches.id", stock).
+		Joins("LEFT JOIN (?) AS bs ON bs.branch_id = branches.id", staff).
+		Where("branches.salon_id = ? AND branches.deleted_at IS NULL", salonID)
+	if len(branchIDs) > 0 {
+		query = query.Where("branches.id IN ?", branchIDs)
+	}
+
+	rows := []BranchSummary{}
+	err := query.Order("branches.is_main DESC, branches.name").Scan(&rows).Error
+	return rows, err
+}
+
+// BranchTotal menjumlahkan ringkasan cabang menjadi angka tingkat brand. Staff tidak dijumlahkan karena
+// satu staff dapat ditugaskan ke beberapa cabang.
+func BranchTotal(rows []BranchSummary) BranchSummary {
+	var total BranchSummary
+	for _, row := range rows {
+		total.Transactions += row.Transactions
+		total.GrossSales += row.GrossSales
+		total.Refunds += row.Refunds
+		total.NetSales += row.NetSales
+		total.Tax += row.Tax
+		total.Tips += row.Tips
+		total.StockValue += row.StockValue
+	}
+	if total.Transactions > 0 {
+		total.AverageTicket = total.NetSales / total.Transactions
+	}
+	return total
+}
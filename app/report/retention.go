@@ -0,0 +1,294 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/models"
+)
+
+// DefaultReturnDays adalah batas hari kunjungan kedua agar pelanggan baru dihitung kembali
+const DefaultReturnDays = 60
+
+// DefaultChurnFactorBps adalah kelipatan rata-rata interval kunjungan (15000 = 1,5x) sebelum pelanggan dianggap
+// berisiko churn
+const DefaultChurnFactorBps = 15000
+
+// CustomerTrend adalah pelanggan baru vs kembali pada satu periode. Pelanggan baru adalah pelanggan yang kunjungan
+// pertamanya jatuh pada periode tersebut.
+type CustomerTrend struct {
+	Key                string `json:"key"`
+	Label              string `json:"label"`
+	Customers          int64  `json:"customers"`
+	NewCustomers       int64  `json:"new_customers"`
+	ReturningCustomers int64  `json:"returning_customers"`
+	Visits             int64  `json:"visits"`
+	NewRevenue         int64  `json:"new_revenue"`
+	ReturningRevenue   int64  `json:"returning_revenue"`
+	// ReturningBps adalah porsi pelanggan kembali dari seluruh pelanggan periode
+	ReturningBps int64 `json:"returning_bps"`
+}
+
+// ReturnRate adalah berapa banyak pelanggan baru pada rentang yang kembali dalam Days hari setelah kunjungan pertama.
+// Rasio hanya dihitung dari pelanggan yang kunjungan pertamanya sudah lewat Days hari (Matured).
+type ReturnRate struct {
+	Days            int   `json:"days"`
+	NewCustomers    int64 `json:"new_customers"`
+	Returned        int64 `json:"returned"`
+	Matured         int64 `json:"matured"`
+	MaturedReturned int64 `json:"matured_returned"`
+	ReturnRateBps   int64 `json:"return_rate_bps"`
+}
+
+// CohortPeriod adalah aktivitas satu cohort pada bulan ke-MonthOffset setelah bulan kunjungan pertama
+type CohortPeriod struct {
+	MonthOffset  int64 `json:"month_offset"`
+	Customers    int64 `json:"customers"`
+	Revenue      int64 `json:"revenue"`
+	RetentionBps int64 `json:"retention_bps"`
+}
+
+// Cohort adalah kelompok pelanggan berdasarkan bulan kunjungan pertama (YYYY-MM)
+type Cohort struct {
+	Month     string         `json:"month"`
+	Customers int64          `json:"customers"`
+	Periods   []CohortPeriod `json:"periods"`
+}
+
+// CustomerValue adalah nilai seumur hidup satu pelanggan: total belanja bersih refund sejak kunjungan pertama
+type CustomerValue struct {
+	CustomerID    uint      `json:"customer_id"`
+	CustomerName  string    `json:"customer_name"`
+	Phone         string    `json:"phone"`
+	Visits        int64     `json:"visits"`
+	Transactions  int64     `json:"transactions"`
+	Spend         int64     `json:"spend"`
+	AverageTicket int64     `json:"average_ticket"`
+	FirstVisitAt  time.Time `json:"first_visit_at"`
+	LastVisitAt   time.Time `json:"last_visit_at"`
+	TenureDays    int64     `json:"tenure_days"`
+}
+
+// LifetimeSummary adalah rata-rata nilai seumur hidup seluruh pelanggan yang pernah berkunjung
+type LifetimeSummary struct {
+	Customers     int64   `json:"customers"`
+	Spend         int64   `json:"spend"`
+	Transactions  int64   `json:"transactions"`
+	AverageValue  int64   `json:"average_value"`
+	AverageVisits float64 `json:"average_visits"`
+	AverageTicket int64   `json:"average_ticket"`
+}
+
+// ChurnRisk adalah pelanggan yang belum kembali melewati rata-rata interval kunjungannya dikali faktor churn
+type ChurnRisk struct {
+	CustomerValue
+	AverageIntervalDays float64 `json:"average_interval_days"`
+	DaysSinceLastVisit  int64   `json:"days_since_last_visit"`
+	OverdueDays         int64   `json:"overdue_days"`
+	// RiskBps adalah hari sejak kunjungan terakhir dibagi rata-rata interval (10000 = tepat pada jadwal)
+	RiskBps int64 `json:"risk_bps"`
+}
+
+// CustomerTrends menghitung pelanggan baru vs kembali per hari, minggu atau bulan pada rentang [From, To)
+func CustomerTrends(db *gorm.DB, f RevenueFilter, group string) ([]CustomerTrend, error) {
+	if group != GroupDay && group != GroupWeek && group != GroupMonth {
+		return nil, fmt.Errorf("pengelompokan %q tidak dikenal", group)
+	}
+
+	bucket := "date_trunc(?, CAST(dv.day AS timestamp))"
+	isNew := "date_trunc(?, CAST(cs.first_day AS timestamp)) = " + bucket
+	rows := []CustomerTrend{}
+	err := trendQuery(db, f).
+		Select("to_char("+bucket+", 'YYYY-MM-DD') AS key, to_char("+bucket+", 'YYYY-MM-DD') AS label, "+
+			"COUNT(DISTINCT dv.customer_id) AS customers, "+
+			"COUNT(DISTINCT dv.customer_id) FILTER (WHERE "+isNew+") AS new_customers, "+
+			"COUNT(*) AS visits, CAST(COALESCE(SUM(dv.amount) FILTER (WHERE "+isNew+"), 0) AS bigint) AS new_revenue, "+
+			"CAST(COALESCE(SUM(dv.amount) FILTER (WHERE NOT "+isNew+"), 0) AS bigint) AS returning_revenue",
+			group, group, group, group, group, group, group, group).
+		Group("1, 2").Order("1").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].ReturningCustomers = rows[i].Customers - rows[i].NewCustomers
+		rows[i].ReturningBps = ratioBps(rows[i].ReturningCustomers, rows[i].Customers)
+	}
+	return rows, nil
+}
+
+// CustomerTrendSummary menghitung pelanggan baru vs kembali untuk seluruh rentang [From, To)
+func CustomerTrendSummary(db *gorm.DB, f RevenueFilter) (CustomerTrend, error) {
+	var summary CustomerTrend
+	isNew := "cs.first_at >= ?"
+	err := trendQuery(db, f).
+		Select("COUNT(DISTINCT dv.customer_id) AS customers, "+
+			"COUNT(DISTINCT dv.customer_id) FILTER (WHERE "+isNew+") AS new_customers, "+
+			"COUNT(*) AS visits, CAST(COALESCE(SUM(dv.amount) FILTER (WHERE "+isNew+"), 0) AS bigint) AS new_revenue, "+
+			"CAST(COALESCE(SUM(dv.amount) FILTER (WHERE NOT "+isNew+"), 0) AS bigint) AS returning_revenue",
+			f.From, f.From, f.From).
+		Scan(&summary).Error
+	summary.Key = "total"
+	summary.Label = "Total"
+	summary.ReturningCustomers = summary.Customers - summary.NewCustomers
+	summary.ReturningBps = ratioBps(summary.ReturningCustomers, summary.Customers)
+	return summary, err
+}
+
+// CustomerReturnRate menghitung berapa banyak pelanggan dengan kunjungan pertama pada rentang [From, To) yang kembali
+// dalam days hari
+func CustomerReturnRate(db *gorm.DB, f RevenueFilter, days int, now time.Time) (ReturnRate, error) {
+	rate := ReturnRate{Days: days}
+	window := "cs.first_at + CAST(? AS interval)"
+	interval := fmt.Sprintf("%d days", days)
+	err := db.Table("(?) AS cs", customerStats(db, f)).
+		Select("COUNT(*) AS new_customers, "+
+			"COUNT(*) FILTER (WHERE cs.second_at <= "+window+") AS returned, "+
+			"COUNT(*) FILTER (WHERE "+window+" <= ?) AS matured, "+
+			"COUNT(*) FILTER (WHERE "+window+" <= ? AND cs.second_at <= "+window+") AS matured_returned",
+			interval, interval, now, interval, now, interval).
+		Where("cs.first_at >= ? AND cs.first_at < ?", f.From, f.To).
+		Scan(&rate).Error
+	rate.ReturnRateBps = ratioBps(rate.MaturedReturned, rate.Matured)
+	return rate, err
+}
+
+// Cohorts mengelompokkan pelanggan berdasarkan bulan kunjungan pertama pada rentang [From, To) dan menghitung berapa
+// yang kembali berkunjung pada bulan-bulan berikutnya sampai hari ini
+func Cohorts(db *gorm.DB, f RevenueFilter) ([]Cohort, error) {
+	var rows []cohortRow
+	offset := "CAST((EXTRACT(YEAR FROM dv.day) - EXTRACT(YEAR FROM cs.first_day)) * 12 + " +
+		"EXTRACT(MONTH FROM dv.day) - EXTRACT(MONTH FROM cs.first_day) AS bigint)"
+	err := db.Table("(?) AS dv", dailyVisits(db, f)).
+		Select("to_char(cs.first_day, 'YYYY-MM') AS cohort, "+offset+" AS month_offset, "+
+			"COUNT(DISTINCT dv.customer_id) AS customers, CAST(SUM(dv.amount) AS bigint) AS revenue").
+		Joins("JOIN (?) AS cs ON cs.customer_id = dv.customer_id", customerStats(db, f)).
+		Where("cs.first_at >= ? AND cs.first_at < ?", f.From, f.To).
+		Group("1, 2").Order("1, 2").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	cohorts := []Cohort{}
+	for _, row := range rows {
+		if len(cohorts) == 0 || cohorts[len(cohorts)-1].Month != row.Cohort {
+			// Bulan ke-0 selalu berisi seluruh anggota cohort
+			cohorts = append(cohorts, Cohort{Month: row.Cohort, Customers: row.Customers})
+		}
+		cohort := &cohorts[len(cohorts)-1]
+		cohort.Periods = append(cohort.Periods, CohortPeriod{
+			MonthOffset:  row.MonthOffset,
+			Customers:    row.Customers,
+			Revenue:      row.Revenue,
+			RetentionBps: ratioBps(row.Customers, cohort.Customers),
+		})
+	}
+	return cohorts, nil
+}
+
+// ChurnRisks mencari pelanggan dengan minimal dua kunjungan yang hari sejak kunjungan terakhirnya melebihi rata-rata
+// interval kunjungan dikali factorBps, diurutkan dari yang paling terlambat. From dan To tidak dipakai.
+func ChurnRisks(db *gorm.DB, f RevenueFilter, now time.Time, factorBps int64, limit int) ([]ChurnRisk, error) {
+	today := now.In(f.Location).Format("2006-01-02")
+	interval := "(CAST(cs.last_day - cs.first_day AS numeric) / (cs.visits - 1))"
+	since := "(CAST(? AS date) - cs.last_day)"
+	rows := []ChurnRisk{}
+	err := lifetimeQuery(db, f).
+		Select(customerValueColumns+", ROUND("+interval+", 1) AS average_interval_days, "+
+			since+" AS days_since_last_visit, CAST(ROUND("+since+" - "+interval+") AS bigint) AS overdue_days, "+
+			"CAST("+since+" * 10000 / "+interval+" AS bigint) AS risk_bps", today, today, today).
+		Where("cs.visits >= 2").
+		Where(since+" * 10000 > "+interval+" * ?", today, factorBps).
+		Order("risk_bps DESC").Limit(limit).Scan(&rows).Error
+	for i := range rows {
+		rows[i].AverageTicket = averageTicket(rows[i].Spend, rows[i].Transactions)
+	}
+	return rows, err
+}
+
+// LifetimeValues menghitung nilai seumur hidup pelanggan, diurutkan dari belanja terbesar. From dan To tidak dipakai.
+func LifetimeValues(db *gorm.DB, f RevenueFilter, limit int) ([]CustomerValue, error) {
+	rows := []CustomerValue{}
+	err := lifetimeQuery(db, f).
+		Select(customerValueColumns).
+		Order("cs.spend DESC, cs.customer_id").Limit(limit).Scan(&rows).Error
+	for i := range rows {
+		rows[i].AverageTicket = averageTicket(rows[i].Spend, rows[i].Transactions)
+	}
+	return rows, err
+}
+
+// LifetimeValueSummary menghitung rata-rata nilai seumur hidup seluruh pelanggan. From dan To tidak dipakai.
+func LifetimeValueSummary(db *gorm.DB, f RevenueFilter) (LifetimeSummary, error) {
+	var summary LifetimeSummary
+	err := db.Table("(?) AS cs", customerStats(db, f)).
+		Select("COUNT(*) AS customers, CAST(COALESCE(SUM(cs.spend), 0) AS bigint) AS spend, " +
+			"CAST(COALESCE(SUM(cs.transactions), 0) AS bigint) AS transactions, " +
+			"COALESCE(ROUND(AVG(cs.visits), 2), 0) AS average_visits").
+		Scan(&summary).Error
+	summary.AverageValue = averageTicket(summary.Spend, summary.Customers)
+	summary.AverageTicket = averageTicket(summary.Spend, summary.Transactions)
+	return summary, err
+}
+
+// cohortRow adalah satu cohort dan bulan ke-n hasil query Cohorts
+type cohortRow struct {
+	Cohort      string
+	MonthOffset int64
+	Customers   int64
+	Revenue     int64
+}
+
+// customerValueColumns adalah kolom CustomerValue dari lifetimeQuery
+const customerValueColumns = "cs.customer_id, customers.name AS customer_name, customers.phone, cs.visits, " +
+	"cs.transactions, cs.spend, cs.first_at AS first_visit_at, cs.last_at AS last_visit_at, " +
+	"cs.last_day - cs.first_day AS tenure_days"
+
+// dailyVisits adalah kunjungan pelanggan terdaftar: satu baris per pelanggan per hari lokal, dari seluruh riwayat
+// transaksi yang tidak di-void. From dan To tidak diterapkan agar kunjungan pertama selalu diketahui.
+func dailyVisits(db *gorm.DB, f RevenueFilter) *gorm.DB {
+	local, args := localTime("sales.completed_at", f.Location)
+	query := db.Table("sales").
+		Select("sales.customer_id, CAST("+local+" AS date) AS day, MIN(sales.completed_at) AS at, "+
+			"COUNT(*) AS transactions, SUM(sales.total - sales.refunded_total) AS amount", args...).
+		Where("sales.salon_id = ? AND sales.status <> ? AND sales.deleted_at IS NULL", f.SalonID, models.SaleStatusVoided).
+		Where("sales.customer_id IS NOT NULL").
+		Group("1, 2")
+	if len(f.BranchIDs) > 0 {
+		query = query.Where("sales.branch_id IN ?", f.BranchIDs)
+	}
+	return query
+}
+
+// customerStats merangkum dailyVisits per pelanggan: jumlah kunjungan, total belanja serta kunjungan pertama,
+// kedua dan terakhir
+func customerStats(db *gorm.DB, f RevenueFilter) *gorm.DB {
+	return db.Table("(?) AS dv", dailyVisits(db, f)).
+		Select("dv.customer_id, COUNT(*) AS visits, CAST(SUM(dv.transactions) AS bigint) AS transactions, " +
+			"CAST(SUM(dv.amount) AS bigint) AS spend, " +
+			"MIN(dv.day) AS first_day, MAX(dv.day) AS last_day, MIN(dv.at) AS first_at, MAX(dv.at) AS last_at, " +
+			"(ARRAY_AGG(dv.at ORDER BY dv.at))[2] AS second_at").
+		Group("dv.customer_id")
+}
+
+// trendQuery adalah kunjungan pada rentang [From, To) beserta ringkasan pelanggannya
+func trendQuery(db *gorm.DB, f RevenueFilter) *gorm.DB {
+	return db.Table("(?) AS dv", dailyVisits(db, f)).
+		Joins("JOIN (?) AS cs ON cs.customer_id = dv.customer_id", customerStats(db, f)).
+		Where("dv.at >= ? AND dv.at < ?", f.From, f.To)
+}
+
+// lifetimeQuery adalah ringkasan per pelanggan beserta data pelanggannya
+func lifetimeQuery(db *gorm.DB, f RevenueFilter) *gorm.DB {
+	return db.Table("(?) AS cs", customerStats(db, f)).
+		Joins("JOIN customers ON customers.id = cs.customer_id AND customers.deleted_at IS NULL")
+}
+
+// ratioBps membagi part dengan total dalam basis poin, 0 jika total 0
+func ratioBps(part, total int64) int64 {
+	if total == 0 {
+		return 0
+	}
+	return part * 10000 / total
+}
@@ -0,0 +1,190 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/models"
+)
+
+// Pengelompokan laporan pendapatan
+const (
+	GroupDay           = "day"
+	GroupWeek          = "week"
+	GroupMonth         = "month"
+	GroupCategory      = "category"
+	GroupStaff         = "staff"
+	GroupBranch        = "branch"
+	GroupPaymentMethod = "payment_method"
+)
+
+// Groups adalah seluruh pengelompokan laporan pendapatan yang didukung
+var Groups = []string{GroupDay, GroupWeek, GroupMonth, GroupCategory, GroupStaff, GroupBranch, GroupPaymentMethod}
+
+// RevenueFilter membatasi transaksi yang dihitung: transaksi salon yang tidak di-void pada rentang [From, To)
+type RevenueFilter struct {
+	SalonID uint
+	// BranchIDs kosong berarti semua cabang
+	BranchIDs []uint
+	From      time.Time
+	To        time.Time
+	// Location adalah zona waktu untuk pengelompokan hari, minggu dan bulan
+	Location *time.Location
+}
+
+// RevenueRow adalah satu baris laporan pendapatan. Untuk pengelompokan waktu dan cabang angkanya per transaksi
+// (termasuk pajak, service charge dan tip); untuk kategori dan staff per baris layanan/produk (termasuk pajak,
+// dibagi sesuai split staff); untuk metode pembayaran berupa uang yang diterima setelah kembalian.
+type RevenueRow struct {
+	Key          string `json:"key"`
+	Label        string `json:"label"`
+	Transactions int64  `json:"transactions"`
+	Quantity     int64  `json:"quantity,omitempty"`
+	Sales        int64  `json:"sales"`
+	Discounts    int64  `json:"discounts"`
+	Refunds      int64  `json:"refunds"`
+	NetSales     int64  `json:"net_sales"`
+	Tax          int64  `json:"tax"`
+	Tips         int64  `json:"tips"`
+	// AverageTicket adalah NetSales dibagi jumlah transaksi
+	AverageTicket int64 `json:"average_ticket"`
+}
+
+// Revenue menghitung laporan pendapatan dengan pengelompokan group. Refund dikurangkan pada tanggal transaksi asal.
+func Revenue(db *gorm.DB, f RevenueFilter, group string) ([]RevenueRow, error) {
+	var query *gorm.DB
+	switch group {
+	case GroupDay, GroupWeek, GroupMonth:
+		local, args := localTime("sales.completed_at", f.Location)
+		args = append([]interface{}{group}, args...)
+		query = saleQuery(db, f).
+			Select("to_char(date_trunc(?, "+local+"), 'YYYY-MM-DD') AS key, "+
+				"to_char(date_trunc(?, "+local+"), 'YYYY-MM-DD') AS label, "+saleColumns, append(args, args...)...).
+			Group("1, 2").Order("1")
+	case GroupBranch:
+		query = saleQuery(db, f).
+			Select("COALESCE(CAST(sales.branch_id AS text), '') AS key, COALESCE(branches.name, '') AS label, " + saleColumns).
+			Joins("LEFT JOIN branches ON branches.id = sales.branch_id").
+			Group("sales.branch_id, branches.name").Order("net_sales DESC")
+	case GroupCategory:
+		category := "COALESCE(services.category, products.category, '')"
+		query = lineQuery(db, f).
+			Select(category+" AS key, "+category+" AS label, "+lineColumns("10000")).
+			Joins("LEFT JOIN services ON services.id = sale_lines.service_id").
+			Joins("LEFT JOIN products ON products.id = sale_lines.product_id").
+			Where("sale_lines.type IN ?", []string{models.SaleLineService, models.SaleLineProduct}).
+			Group(category).Order("net_sales DESC")
+	case GroupStaff:
+		// Tip dicatat sebagai baris tersendiri dengan staff penerimanya
+		staff := "COALESCE(sale_line_splits.staff_id, sale_lines.staff_id)"
+		query = lineQuery(db, f).
+			Select("COALESCE(CAST("+staff+" AS text), '') AS key, COALESCE(users.name, '') AS label, "+
+				lineColumns("COALESCE(sale_line_splits.share_bps, 10000)")+", "+
+				"COALESCE(SUM(CASE WHEN sale_lines.type = 'tip' THEN sale_lines.net_amount - sale_lines.refunded_amount END), 0) AS tips").
+			Joins("LEFT JOIN sale_line_splits ON sale_line_splits.sale_line_id = sale_lines.id").
+			Joins("LEFT JOIN users ON users.id = "+staff).
+			Where("sale_lines.type IN ?", []string{models.SaleLineService, models.SaleLineProduct, models.SaleLineTip}).
+			Group(staff + ", users.name").Order("net_sales DESC")
+	case GroupPaymentMethod:
+		// Dijumlahkan per transaksi dan metode lebih dulu agar kembalian hanya dikurangkan sekali
+		tenders := saleQuery(db, f).
+			Select("sale_tenders.sale_id, sale_tenders.method, SUM(sale_tenders.amount) AS amount, " +
+				"SUM(sale_tenders.refunded_amount) AS refunded").
+			Joins("JOIN sale_tenders ON sale_tenders.sale_id = sales.id AND sale_tenders.deleted_at IS NULL").
+			Group("sale_tenders.sale_id, sale_tenders.method")
+		received := "p.amount - CASE WHEN p.method = '" + models.TenderCash + "' THEN sales.change_due ELSE 0 END"
+		query = db.Table("(?) AS p", tenders).
+			Select("p.method AS key, p.method AS label, COUNT(*) AS transactions, " +
+				"SUM(" + received + ") AS sales, SUM(p.refunded) AS refunds, SUM(" + received + " - p.refunded) AS net_sales").
+			Joins("JOIN sales ON sales.id = p.sale_id").
+			Group("p.method").Order("net_sales DESC")
+	default:
+		return nil, fmt.Errorf("pengelompokan %q tidak dikenal", group)
+	}
+
+	rows := []RevenueRow{}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		rows[i].AverageTicket = averageTicket(rows[i].NetSales, rows[i].Transactions)
+	}
+	return rows, nil
+}
+
+// RevenueSummary menghitung total pendapatan seluruh transaksi pada filter
+func RevenueSummary(db *gorm.DB, f RevenueFilter) (RevenueRow, error) {
+	var summary RevenueRow
+	if err := saleQuery(db, f).Select(saleColumns).Scan(&summary).Error; err != nil {
+		return summary, err
+	}
+	summary.Key = "total"
+	summary.Label = "Total"
+	summary.AverageTicket = averageTicket(summary.NetSales, summary.Transactions)
+	return summary, nil
+}
+
+// saleColumns adalah agregat per transaksi untuk query sales
+const saleColumns = "COUNT(*) AS transactions, COALESCE(SUM(sales.total), 0) AS sales, " +
+	"COALESCE(SUM(sales.discount_total), 0) AS discounts, COALESCE(SUM(sales.refunded_total), 0) AS refunds, " +
+	"COALESCE(SUM(sales.total - sales.refunded_total), 0) AS net_sales, COALESCE(SUM(sales.tax_total), 0) AS tax, " +
+	"COALESCE(SUM(sales.tip_total), 0) AS tips"
+
+// lineColumns adalah agregat baris layanan/produk yang dibagi dengan share (basis poin) untuk query lines.
+// Baris tip tidak dihitung sebagai penjualan.
+func lineColumns(share string) string {
+	item := func(expr string) string {
+		return "CAST(COALESCE(SUM(CASE WHEN sale_lines.type <> 'tip' THEN (" + expr + ") * " + share + " END), 0) / 10000 AS bigint)"
+	}
+	return "COUNT(DISTINCT CASE WHEN sale_lines.type <> 'tip' THEN sales.id END) AS transactions, " +
+		item("sale_lines.quantity - sale_lines.refunded_quantity") + " AS quantity, " +
+		item("sale_lines.line_total") + " AS sales, " +
+		item("sale_lines.gross - sale_lines.net_amount") + " AS discounts, " +
+		item("sale_lines.refunded_amount") + " AS refunds, " +
+		item("sale_lines.line_total - sale_lines.refunded_amount") + " AS net_sales, " +
+		item("sale_lines.tax_amount") + " AS tax"
+}
+
+// saleQuery adalah query transaksi yang dihitung dalam laporan
+func saleQuery(db *gorm.DB, f RevenueFilter) *gorm.DB {
+	return saleFilter(db.Table("sales"), f)
+}
+
+// lineQuery adalah query baris transaksi yang dihitung dalam laporan
+func lineQuery(db *gorm.DB, f RevenueFilter) *gorm.DB {
+	return saleFilter(db.Table("sale_lines").Joins("JOIN sales ON sales.id = sale_lines.sale_id"), f).
+		Where("sale_lines.deleted_at IS NULL")
+}
+
+// saleFilter menerapkan RevenueFilter pada query yang memuat tabel sales
+func saleFilter(query *gorm.DB, f RevenueFilter) *gorm.DB {
+	query = query.
+		Where("sales.salon_id = ? AND sales.status <> ? AND sales.deleted_at IS NULL", f.SalonID, models.SaleStatusVoided).
+		Where("sales.completed_at >= ? AND sales.completed_at < ?", f.From, f.To)
+	if len(f.BranchIDs) > 0 {
+		query = query.Where("sales.branch_id IN ?", f.BranchIDs)
+	}
+	return query
+}
+
+// localTime mengubah kolom timestamptz menjadi waktu lokal loc. Zona waktu tanpa nama IANA (mis. fallback
+// zona tetap) dikirim sebagai offset interval.
+func localTime(column string, loc *time.Location) (string, []interface{}) {
+	if name := loc.String(); name != "Local" {
+		if _, err := time.LoadLocation(name); err == nil {
+			return column + " AT TIME ZONE ?", []interface{}{name}
+		}
+	}
+	_, offset := time.Now().In(loc).Zone()
+	return column + " AT TIME ZONE CAST(? AS interval)", []interface{}{fmt.Sprintf("%d seconds", offset)}
+}
+
+// averageTicket membagi total dengan jumlah transaksi, 0 jika tidak ada transaksi
+func averageTicket(total, transactions int64) int64 {
+	if transactions == 0 {
+		return 0
+	}
+	return total / transactions
+}
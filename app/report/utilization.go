@@ -0,0 +1,129 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/models"
+)
+
+// RebookWindow adalah batas waktu setelah kunjungan untuk menghitung pelanggan sebagai rebook
+const RebookWindow = 24 * time.Hour
+
+// StaffUtilization adalah produktivitas satu staff pada satu periode. Rasio dalam basis poin (10000 = 100%).
+type StaffUtilization struct {
+	StaffID   uint   `json:"staff_id"`
+	StaffName string `json:"staff_name"`
+	// AvailableHours adalah total jam shift terjadwal
+	AvailableHours float64 `json:"available_hours"`
+	// BookedHours adalah jam booking (termasuk no-show) ditambah jam layanan walk-in
+	BookedHours    float64 `json:"booked_hours"`
+	WalkInHours    float64 `json:"walk_in_hours"`
+	UtilizationBps int64   `json:"utilization_bps"`
+	// Tickets adalah jumlah transaksi yang berisi layanan/produk staff; Revenue sesuai porsi split staff
+	Tickets       int64 `json:"tickets"`
+	Revenue       int64 `json:"revenue"`
+	AverageTicket int64 `json:"average_ticket"`
+	// ServiceTickets adalah transaksi berisi layanan staff; RetailAttached di antaranya yang juga berisi produk retail
+	ServiceTickets      int64 `json:"service_tickets"`
+	RetailAttached      int64 `json:"retail_attached"`
+	RetailAttachmentBps int64 `json:"retail_attachment_bps"`
+	// Visits adalah transaksi layanan dengan pelanggan terdaftar; Rebooked di antaranya yang pelanggannya membuat
+	// booking berikutnya dalam RebookWindow
+	Visits       int64 `json:"visits"`
+	Rebooked     int64 `json:"rebooked"`
+	RebookingBps int64 `json:"rebooking_bps"`
+}
+
+// Utilization menghitung jam tersedia vs terpakai, rata-rata transaksi, rebooking dan retail attachment per staff
+// pada rentang [From, To). Filter cabang berlaku untuk shift, booking, walk-in dan transaksi sehingga jam terpakai
+// dibandingkan dengan jam tersedia di cabang yang sama. staffID 0 berarti semua staff.
+func Utilization(db *gorm.DB, f RevenueFilter, staffID uint) ([]StaffUtilization, error) {
+	shifts := db.Table("staff_shifts").
+		Select("staff_id, SUM(EXTRACT(EPOCH FROM LEAST(end_at, ?) - GREATEST(start_at, ?))) / 60 AS minutes", f.To, f.From).
+		Where("salon_id = ? AND deleted_at IS NULL AND start_at < ? AND end_at > ?", f.SalonID, f.To, f.From).
+		Group("staff_id")
+	if len(f.BranchIDs) > 0 {
+		shifts = shifts.Where("branch_id IN ?", f.BranchIDs)
+	}
+
+	bookings := db.Table("bookings").
+		Select("staff_id, SUM(EXTRACT(EPOCH FROM LEAST(end_at, ?) - GREATEST(start_at, ?))) / 60 AS minutes", f.To, f.From).
+		Where("salon_id = ? AND deleted_at IS NULL AND start_at < ? AND end_at > ?", f.SalonID, f.To, f.From).
+		Where("status IN ?", []string{models.BookingStatusBooked, models.BookingStatusCompleted, models.BookingStatusNoShow}).
+		Group("staff_id")
+	if len(f.BranchIDs) > 0 {
+		bookings = bookings.Where("branch_id IN ?", f.BranchIDs)
+	}
+
+	walkIns := db.Table("walk_ins").
+		Select("staff_id, SUM(EXTRACT(EPOCH FROM LEAST(finished_at, ?) - GREATEST(started_at, ?))) / 60 AS minutes", f.To, f.From).
+		Where("salon_id = ? AND deleted_at IS NULL AND status = ? AND staff_id IS NOT NULL", f.SalonID, models.WalkInStatusCompleted).
+		Where("started_at < ? AND finished_at > ?", f.To, f.From).
+		Group("staff_id")
+	if len(f.BranchIDs) > 0 {
+		walkIns = walkIns.Where("branch_id IN ?", f.BranchIDs)
+	}
+
+	// Satu baris per staff dan transaksi
+	staffSales := lineQuery(db, f).
+		Select("COALESCE(sale_line_splits.staff_id, sale_lines.staff_id) AS staff_id, sales.id AS sale_id, "+
+			"sales.customer_id, sales.completed_at, "+
+			"SUM((sale_lines.line_total - sale_lines.refunded_amount) * COALESCE(sale_line_splits.share_bps, 10000)) / 10000 AS revenue, "+
+			"BOOL_OR(sale_lines.type = ?) AS has_service", models.SaleLineService).
+		Joins("LEFT JOIN sale_line_splits ON sale_line_splits.sale_line_id = sale_lines.id").
+		Where("sale_lines.type IN ?", []string{models.SaleLineService, models.SaleLineProduct}).
+		Where("COALESCE(sale_line_splits.staff_id, sale_lines.staff_id) IS NOT NULL").
+		Group("1, sales.id, sales.customer_id, sales.completed_at")
+
+	retail := "EXISTS (SELECT 1 FROM sale_lines p WHERE p.sale_id = ss.sale_id AND p.type = '" + models.SaleLineProduct +
+		"' AND p.deleted_at IS NULL)"
+	// Rebooking dihitung di cabang mana pun karena pelanggan berlaku di semua cabang
+	rebooked := "EXISTS (SELECT 1 FROM bookings nb WHERE nb.customer_id = ss.customer_id AND nb.salon_id = ? " +
+		"AND nb.deleted_at IS NULL AND nb.status <> '" + models.BookingStatusCancelled + "' AND nb.start_at > ss.completed_at " +
+		"AND nb.created_at >= ss.completed_at - INTERVAL '2 hours' AND nb.created_at < ss.completed_at + CAST(? AS interval))"
+	tickets := db.Table("(?) AS ss", staffSales).
+		Select("ss.staff_id, COUNT(*) AS tickets, SUM(ss.revenue) AS revenue, "+
+			"COUNT(*) FILTER (WHERE ss.has_service) AS service_tickets, "+
+			"COUNT(*) FILTER (WHERE ss.has_service AND "+retail+") AS retail_attached, "+
+			"COUNT(*) FILTER (WHERE ss.has_service AND ss.customer_id IS NOT NULL) AS visits, "+
+			"COUNT(*) FILTER (WHERE ss.has_service AND ss.customer_id IS NOT NULL AND "+rebooked+") AS rebooked",
+			f.SalonID, fmt.Sprintf("%d seconds", int64(RebookWindow.Seconds()))).
+		Group("ss.staff_id")
+
+	available := "COALESCE(sh.minutes, 0)"
+	busy := "(COALESCE(b.minutes, 0) + COALESCE(w.minutes, 0))"
+	query := db.Table("users").
+		Select("users.id AS staff_id, users.name AS staff_name, "+
+			"ROUND(CAST("+available+" AS numeric) / 60, 2) AS available_hours, "+
+			"ROUND(CAST("+busy+" AS numeric) / 60, 2) AS booked_hours, "+
+			"ROUND(CAST(COALESCE(w.minutes, 0) AS numeric) / 60, 2) AS walk_in_hours, "+
+			"CAST(COALESCE("+busy+" * 10000 / NULLIF("+available+", 0), 0) AS bigint) AS utilization_bps, "+
+			"COALESCE(t.tickets, 0) AS tickets, CAST(COALESCE(t.revenue, 0) AS bigint) AS revenue, "+
+			"CAST(COALESCE(t.revenue / NULLIF(t.tickets, 0), 0) AS bigint) AS average_ticket, "+
+			"COALESCE(t.service_tickets, 0) AS service_tickets, COALESCE(t.retail_attached, 0) AS retail_attached, "+
+			"COALESCE(t.retail_attached * 10000 / NULLIF(t.service_tickets, 0), 0) AS retail_attachment_bps, "+
+			"COALESCE(t.visits, 0) AS visits, COALESCE(t.rebooked, 0) AS rebooked, "+
+			"COALESCE(t.rebooked * 10000 / NULLIF(t.visits, 0), 0) AS rebooking_bps").
+		Joins("LEFT JOIN (?) AS sh ON sh.staff_id = users.id", shifts).
+		Joins("LEFT JOIN (?) AS b ON b.staff_id = users.id", bookings).
+		Joins("LEFT JOIN (?) AS w ON w.staff_id = users.id", walkIns).
+		Joins("LEFT JOIN (?) AS t ON t.staff_id = users.id", tickets).
+		Where("users.salon_id = ? AND users.deleted_at IS NULL", f.SalonID)
+	if staffID != 0 {
+		query = query.Where("users.id = ?", staffID)
+	}
+	if len(f.BranchIDs) > 0 {
+		// Dengan filter cabang hanya staff yang ditugaskan, berjadwal, melayani atau bertransaksi di cabang tersebut
+		query = query.Where("(sh.staff_id IS NOT NULL OR b.staff_id IS NOT NULL OR w.staff_id IS NOT NULL OR t.staff_id IS NOT NULL OR EXISTS "+
+			"(SELECT 1 FROM branch_staffs WHERE branch_staffs.user_id = users.id AND branch_staffs.branch_id IN ?))", f.BranchIDs)
+	} else {
+		query = query.Where("(sh.staff_id IS NOT NULL OR b.staff_id IS NOT NULL OR w.staff_id IS NOT NULL OR t.staff_id IS NOT NULL)")
+	}
+
+	rows := []StaffUtilization{}
+	err := query.Order("utilization_bps, users.name").Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	specs := []string{
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+	}
+	for _, spec := range specs {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) tidak mengembalikan error", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from string
+		want string
+	}{
+		{"step menit", "*/15 * * * *", "2026-10-19 10:07", "2026-10-19 10:15"},
+		{"tepat pada jadwal dilewati", "*/15 * * * *", "2026-10-19 10:15", "2026-10-19 10:30"},
+		{"pergantian jam", "*/15 * * * *", "2026-10-19 10:59", "2026-10-19 11:00"},
+		{"daftar jam", "30 8,17 * * *", "2026-10-19 09:00", "2026-10-19 17:30"},
+		{"pergantian hari", "0 2 * * *", "2026-10-19 03:00", "2026-10-20 02:00"},
+		{"awal bulan berikutnya", "0 0 1 * *", "2026-01-31 12:00", "2026-02-01 00:00"},
+		{"tanggal 31 melewati bulan pendek", "0 0 31 * *", "2026-02-01 00:00", "2026-03-31 00:00"},
+		{"29 Februari tahun kabisat", "0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		{"pergantian tahun", "59 23 31 12 *", "2026-12-31 23:59", "2027-12-31 23:59"},
+		{"macro yearly", "@yearly", "2026-06-01 00:00", "2027-01-01 00:00"},
+		{"macro weekly hari Minggu", "@weekly", "2026-10-19 00:00", "2026-10-25 00:00"},
+		{"hari kerja dari Jumat", "0 9 * * 1-5", "2026-10-23 10:00", "2026-10-26 09:00"},
+		{"hari 7 sama dengan Minggu", "0 0 * * 7", "2026-10-19 00:00", "2026-10-25 00:00"},
+		{"tanggal atau hari, hari lebih dulu", "0 0 13 * 5", "2026-10-01 00:00", "2026-10-02 00:00"},
+		{"tanggal atau hari, tanggal lebih dulu", "0 0 20 * 5", "2026-10-19 00:00", "2026-10-20 00:00"},
+		{"tanggal dan hari bintang", "0 0 * * *", "2026-10-19 00:00", "2026-10-20 00:00"},
+		{"tanggal dibatasi dengan step", "0 0 */10 * *", "2026-10-19 00:00", "2026-10-21 00:00"},
+		{"bulan dibatasi", "0 0 1 1,7 *", "2026-02-15 00:00", "2026-07-01 00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.spec, err)
+			}
+			got := schedule.Next(at(tt.from))
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, ingin %s", tt.from, got.Format("2006-01-02 15:04"), tt.want)
+			}
+			if !schedule.Matches(got) {
+				t.Errorf("Matches(%s) = false untuk hasil Next", got.Format("2006-01-02 15:04"))
+			}
+		})
+	}
+}
+
+func TestScheduleNextNever(t *testing.T) {
+	schedule, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schedule.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next untuk 30 Februari = %s, ingin zero time", got)
+	}
+}
+
+func TestScheduleNextLocation(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*60*60)
+	schedule, err := ParseCron("0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 20:00 UTC adalah 03:00 WIB sehingga jadwal berikutnya 02:00 WIB keesokan hari
+	got := schedule.Next(time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC).In(loc))
+	want := time.Date(2026, 10, 21, 2, 0, 0, 0, loc)
+	if !got.Equal(want) {
+		t.Errorf("Next = %s, ingin %s", got, want)
+	}
+}
@@ -0,0 +1,260 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/config"
+)
+
+// TaskFunc adalah isi pekerjaan sebuah task terjadwal
+type TaskFunc func(ctx context.Context, db *gorm.DB) error
+
+// Task adalah pekerjaan berulang dengan jadwal cron
+type Task struct {
+	Name        string
+	Spec        string
+	Description string
+	Schedule    *Schedule
+	Run         TaskFunc
+}
+
+// ErrTaskNotFound dikembalikan jika nama task tidak terdaftar
+var ErrTaskNotFound = errors.New("task tidak ditemukan")
+
+// ErrTaskRunning dikembalikan jika task sedang dijalankan oleh instance lain
+var ErrTaskRunning = errors.New("task sedang berjalan")
+
+var (
+	tasksMu sync.RWMutex
+	tasks   = map[string]*Task{}
+)
+
+// Register mendaftarkan task terjadwal. Panic jika ekspresi cron tidak valid
+// karena kesalahan ini harus ketahuan saat aplikasi start.
+func Register(name, spec, description string, fn TaskFunc) {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		panic(fmt.Sprintf("scheduler: task %s: %v", name, err))
+	}
+
+	tasksMu.Lock()
+	defer tasksMu.Unlock()
+	tasks[name] = &Task{Name: name, Spec: spec, Description: description, Schedule: schedule, Run: fn}
+}
+
+// Tasks mengembalikan semua task terdaftar, diurutkan berdasarkan nama
+func Tasks() []*Task {
+	tasksMu.RLock()
+	defer tasksMu.RUnlock()
+
+	list := make([]*Task, 0, len(tasks))
+	for _, task := range tasks {
+		list = append(list, task)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Find mengembalikan task berdasarkan nama
+func Find(name string) (*Task, bool) {
+	tasksMu.RLock()
+	defer tasksMu.RUnlock()
+	task, ok := tasks[name]
+	return task, ok
+}
+
+// Scheduler menjalankan task terdaftar setiap kali jadwal cron-nya jatuh tempo.
+// Beberapa replika boleh menjalankan Scheduler bersamaan: advisory lock PostgreSQL
+// dan unique index pada riwayat memastikan satu slot hanya dieksekusi sekali.
+type Scheduler struct {
+	DB       *gorm.DB
+	Location *time.Location
+
+	host string
+	wg   sync.WaitGroup
+}
+
+// New membuat scheduler dengan zona waktu aplikasi
+func New(db *gorm.DB) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		DB:       db,
+		Location: config.Location(),
+		host:     fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+}
+
+// Start menjalankan loop scheduler di goroutine terpisah
+func (s *Scheduler) Start(ctx context.Context) {
+	go s.Run(ctx)
+}
+
+// Run memeriksa jadwal setiap pergantian menit sampai ctx dibatalkan
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("⏰ Scheduler berjalan (%d task, zona waktu %s)", len(Tasks()), s.Location)
+
+	for {
+		now := time.Now().In(s.Location)
+		next := now.Truncate(time.Minute).Add(time.Minute)
+
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			log.Println("⏰ Scheduler berhenti")
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		for _, task := range Tasks() {
+			if !task.Schedule.Matches(next) {
+				continue
+			}
+			slot := next
+			task := task
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				if _, err := s.execute(ctx, task, &slot); err != nil && !errors.Is(err, ErrTaskRunning) {
+					log.Printf("❌ Task %s gagal: %v", task.Name, err)
+				}
+			}()
+		}
+	}
+}
+
+// Trigger menjalankan task secara manual di background dan mengembalikan catatan run-nya.
+// Mengembalikan ErrTaskRunning jika task sedang dijalankan di instance mana pun.
+func (s *Scheduler) Trigger(ctx context.Context, name string, userID *uint) (*models.ScheduledTaskRun, error) {
+	task, ok := Find(name)
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	conn, err := s.lock(ctx, task.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := s.startRun(task, nil, userID)
+	if err != nil || run == nil {
+		s.unlock(conn, task.Name)
+		return nil, err
+	}
+
+	go func() {
+		defer s.unlock(conn, task.Name)
+		s.finishRun(context.Background(), task, run)
+	}()
+	return run, nil
+}
+
+// execute menjalankan task secara sinkron dengan memegang advisory lock
+func (s *Scheduler) execute(ctx context.Context, task *Task, slot *time.Time) (*models.ScheduledTaskRun, error) {
+	conn, err := s.lock(ctx, task.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer s.unlock(conn, task.Name)
+
+	run, err := s.startRun(task, slot, nil)
+	if err != nil || run == nil {
+		return nil, err
+	}
+	return run, s.finishRun(ctx, task, run)
+}
+
+// lock mengambil advisory lock session-level pada koneksi khusus.
+// Koneksi harus tetap dipegang sampai unlock karena lock terikat pada session.
+func (s *Scheduler) lock(ctx context.Context, name string) (*sql.Conn, error) {
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", lockKey(name)).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrTaskRunning
+	}
+	return conn, nil
+}
+
+// unlock melepas advisory lock lalu mengembalikan koneksi ke pool
+func (s *Scheduler) unlock(conn *sql.Conn, name string) {
+	if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", lockKey(name)); err != nil {
+		log.Printf("❌ Gagal melepas lock task %s: %v", name, err)
+	}
+	conn.Close()
+}
+
+// startRun mencatat awal eksekusi. Mengembalikan nil tanpa error jika slot jadwal
+// yang sama sudah dijalankan replika lain.
+func (s *Scheduler) startRun(task *Task, slot *time.Time, userID *uint) (*models.ScheduledTaskRun, error) {
+	run := models.ScheduledTaskRun{
+		TaskName:     task.Name,
+		ScheduledFor: slot,
+		Trigger:      models.TaskRunTriggerSchedule,
+		TriggeredBy:  userID,
+		Status:       models.TaskRunStatusRunning,
+		Host:         s.host,
+		StartedAt:    time.Now(),
+	}
+	if slot == nil {
+		run.Trigger = models.TaskRunTriggerManual
+	}
+
+	result := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&run)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &run, nil
+}
+
+// finishRun menjalankan isi task lalu menyimpan hasilnya ke riwayat
+func (s *Scheduler) finishRun(ctx context.Context, task *Task, run *models.ScheduledTaskRun) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+
+		finished := time.Now()
+		run.FinishedAt = &finished
+		run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+		run.Status = models.TaskRunStatusSucceeded
+		if err != nil {
+			run.Status = models.TaskRunStatusFailed
+			run.Error = err.Error()
+		}
+		s.DB.Model(run).Select("status", "finished_at", "duration_ms", "error").Updates(run)
+		log.Printf("⏰ Task %s selesai (%s, %dms)", task.Name, run.Status, run.DurationMs)
+	}()
+
+	return task.Run(ctx, s.DB)
+}
+
+func lockKey(name string) string {
+	return "scheduler:" + name
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/booking"
+	"gin-sass-salon/app/giftcard"
+	"gin-sass-salon/app/loyalty"
+	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/payment"
+	"gin-sass-salon/app/prepaid"
+)
+
+// RegisterDefaultTasks mendaftarkan task terjadwal bawaan aplikasi
+func RegisterDefaultTasks() {
+	Register("purge_completed_jobs", "0 2 * * *", "Menghapus job antrian yang sudah selesai lebih dari 14 hari", purgeCompletedJobs)
+	Register("purge_task_runs", "30 2 * * *", "Menghapus riwayat eksekusi task yang lebih lama dari 90 hari", purgeTaskRuns)
+	Register("expire_waitlist_offers", "* * * * *", "Mengakhiri penawaran waitlist yang melewati batas hold dan meneruskan slotnya", expireWaitlistOffers)
+	Register("expire_loyalty_points", "20 0 * * *", "Menghanguskan poin loyalty yang melewati masa berlaku", expireLoyaltyPoints)
+	Register("process_memberships", "30 0 * * *", "Memproses membership yang periodenya habis (pengingat perpanjangan/expired) dan paket yang kedaluwarsa", processMemberships)
+	Register("expire_gift_cards", "10 0 * * *", "Menghanguskan saldo gift card yang melewati masa berlaku", expireGiftCards)
+	Register("sync_pending_payments", "* * * * *", "Menanyakan status payment pending ke provider untuk webhook yang terlambat atau hilang", syncPendingPayments)
+}
+
+func purgeCompletedJobs(ctx context.Context, db *gorm.DB) error {
+	result := db.WithContext(ctx).Unscoped().
+		Where("status = ? AND completed_at < ?", models.JobStatusCompleted, time.Now().AddDate(0, 0, -14)).
+		Delete(&models.Job{})
+	if result.Error != nil {
+		return result.Error
+	}
+	log.Printf("🧹 %d job selesai dihapus", result.RowsAffected)
+	return nil
+}
+
+func purgeTaskRuns(ctx context.Context, db *gorm.DB) error {
+	result := db.WithContext(ctx).Unscoped().
+		Where("started_at < ? AND status <> ?", time.Now().AddDate(0, 0, -90), models.TaskRunStatusRunning).
+		Delete(&models.ScheduledTaskRun{})
+	if result.Error != nil {
+		return result.Error
+	}
+	log.Printf("🧹 %d riwayat task dihapus", result.RowsAffected)
+	return nil
+}
+
+func expireWaitlistOffers(ctx context.Context, db *gorm.DB) error {
+	expired, err := booking.ExpireOffers(db.WithContext(ctx))
+	if expired > 0 {
+		log.Printf("📋 %d penawaran waitlist kedaluwarsa", expired)
+	}
+	return err
+}
+
+func syncPendingPayments(ctx context.Context, db *gorm.DB) error {
+	synced, err := payment.SyncPending(ctx, db)
+	if synced > 0 {
+		log.Printf("💳 %d payment pending disinkronkan", synced)
+	}
+	return err
+}
+
+func expireGiftCards(ctx context.Context, db *gorm.DB) error {
+	expired, err := giftcard.ExpireDue(db.WithContext(ctx))
+	if expired > 0 {
+		log.Printf("🎁 %d gift card kedaluwarsa", expired)
+	}
+	return err
+}
+
+func processMemberships(ctx context.Context, db *gorm.DB) error {
+	processed, err := prepaid.ProcessDue(db.WithContext(ctx), time.Now())
+	if processed > 0 {
+		log.Printf("🎟️ %d membership/paket diperbarui statusnya", processed)
+	}
+	return err
+}
+
+func expireLoyaltyPoints(ctx context.Context, db *gorm.DB) error {
+	accounts, err := loyalty.ExpireDue(db.WithContext(ctx), time.Now())
+	if accounts > 0 {
+		log.Printf("⭐ Poin loyalty hangus pada %d akun pelanggan", accounts)
+	}
+	return err
+}
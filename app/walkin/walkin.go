@@ -0,0 +1,258 @@
+package walkin
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"gin-sass-salon/app/inventory"
+	"gin-sass-salon/app/models"
+)
+
+// ErrNoFreeStaff dikembalikan jika tidak ada stylist yang kosong saat ini
+var ErrNoFreeStaff = errors.New("tidak ada stylist yang kosong")
+
+// Estimate adalah perkiraan kapan walk-in yang menunggu mulai dilayani
+type Estimate struct {
+	WalkInID             uint      `json:"walk_in_id"`
+	Position             int       `json:"position"`
+	StaffID              uint      `json:"staff_id"`
+	EstimatedStart       time.Time `json:"estimated_start"`
+	EstimatedWaitMinutes int       `json:"estimated_wait_minutes"`
+}
+
+// board adalah gambaran ketersediaan stylist sebuah cabang pada satu waktu
+type board struct {
+	durations map[uint]time.Duration
+	freeAt    map[uint]time.Time
+	staffIDs  []uint
+	bookings  map[uint][]models.Booking
+}
+
+// MigrateBranches memindahkan walk-in lama yang belum memiliki cabang ke cabang utama salon
+func MigrateBranches(db *gorm.DB) error {
+	return inventory.AssignMainBranch(db, "walk_ins")
+}
+
+// AverageDurations menghitung rata-rata durasi layanan walk-in 30 hari terakhir per layanan,
+// dengan fallback ke durasi standar layanan jika belum ada data.
+func AverageDurations(db *gorm.DB, salonID uint) (map[uint]time.Duration, error) {
+	var services []models.Service
+	if err := db.Where("salon_id = ?", salonID).Find(&services).Error; err != nil {
+		return nil, err
+	}
+
+	durations := make(map[uint]time.Duration, len(services))
+	for _, service := range services {
+		durations[service.ID] = time.Duration(service.DurationMinutes) * time.Minute
+	}
+
+	var rows []struct {
+		ServiceID  uint
+		AvgSeconds float64
+	}
+	err := db.Model(&models.WalkIn{}).
+		Select("service_id, AVG(EXTRACT(EPOCH FROM finished_at - started_at)) AS avg_seconds").
+		Where("salon_id = ? AND status = ? AND finished_at >= ?", salonID, models.WalkInStatusCompleted, time.Now().AddDate(0, 0, -30)).
+		Group("service_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.AvgSeconds > 0 {
+			durations[row.ServiceID] = time.Duration(row.AvgSeconds) * time.Second
+		}
+	}
+
+	return durations, nil
+}
+
+// loadBoard membaca stylist cabang, walk-in aktif dan booking hari ini untuk menghitung kapan tiap stylist kosong.
+// Stylist cabang adalah staff yang ditugaskan ke cabang, ditambah staff tanpa penugasan untuk cabang utama.
+// Booking dan walk-in yang sedang dilayani di cabang lain tetap membuat stylist tersebut sibuk. branchID nil
+// (walk-in lama tanpa cabang) berarti seluruh staff salon.
+func loadBoard(db *gorm.DB, salonID uint, branchID *uint, now time.Time) (*board, []models.WalkIn, error) {
+	durations, err := AverageDurations(db, salonID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b := &board{
+		durations: durations,
+		freeAt:    map[uint]time.Time{},
+		bookings:  map[uint][]models.Booking{},
+	}
+
+	staff := db.Model(&models.User{}).Where("salon_id = ?", salonID)
+	if branchID != nil {
+		staff = staff.Where("(EXISTS (SELECT 1 FROM branch_staffs WHERE branch_staffs.user_id = users.id AND branch_staffs.branch_id = ?) "+
+			"OR (NOT EXISTS (SELECT 1 FROM branch_staffs WHERE branch_staffs.user_id = users.id) "+
+			"AND EXISTS (SELECT 1 FROM branches WHERE branches.id = ? AND branches.is_main)))", *branchID, *branchID)
+	}
+	if err := staff.Order("id").Pluck("id", &b.staffIDs).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, id := range b.staffIDs {
+		b.freeAt[id] = now
+	}
+
+	var bookings []models.Booking
+	if err := db.Where("salon_id = ? AND staff_id IN ? AND status = ? AND end_at > ? AND start_at < ?",
+		salonID, b.staffIDs, models.BookingStatusBooked, now, now.Add(24*time.Hour)).
+		Order("start_at").Find(&bookings).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, booking := range bookings {
+		b.bookings[booking.StaffID] = append(b.bookings[booking.StaffID], booking)
+	}
+
+	active := db.Where("salon_id = ? AND status IN ?", salonID,
+		[]string{models.WalkInStatusWaiting, models.WalkInStatusAssigned, models.WalkInStatusInService})
+	if branchID != nil {
+		active = active.Where("branch_id = ? OR staff_id IN ?", *branchID, b.staffIDs)
+	}
+	var walkIns []models.WalkIn
+	if err := active.Order("checked_in_at, id").Find(&walkIns).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var waiting []models.WalkIn
+	for _, w := range walkIns {
+		switch w.Status {
+		case models.WalkInStatusInService:
+			started := now
+			if w.StartedAt != nil {
+				started = *w.StartedAt
+			}
+			b.occupy(w.StaffID, started.Add(b.duration(w.ServiceID)))
+		case models.WalkInStatusAssigned:
+			b.occupy(w.StaffID, now.Add(b.duration(w.ServiceID)))
+		default:
+			if branchID == nil || (w.BranchID != nil && *w.BranchID == *branchID) {
+				waiting = append(waiting, w)
+			}
+		}
+	}
+
+	return b, waiting, nil
+}
+
+func (b *board) duration(serviceID uint) time.Duration {
+	if d, ok := b.durations[serviceID]; ok && d > 0 {
+		return d
+	}
+	return 30 * time.Minute
+}
+
+func (b *board) occupy(staffID *uint, until time.Time) {
+	if staffID == nil {
+		return
+	}
+	if current, ok := b.freeAt[*staffID]; ok && until.After(current) {
+		b.freeAt[*staffID] = until
+	}
+}
+
+// slot mengembalikan waktu mulai paling awal bagi staff untuk layanan berdurasi d
+// tanpa bertabrakan dengan booking
+func (b *board) slot(staffID uint, d time.Duration) time.Time {
+	start := b.freeAt[staffID]
+	for _, booking := range b.bookings[staffID] {
+		if booking.StartAt.Before(start.Add(d)) && booking.EndAt.After(start) {
+			start = booking.EndAt
+		}
+	}
+	return start
+}
+
+// candidates mengembalikan staff yang boleh melayani walk-in (sesuai preferensi jika ada)
+func (b *board) candidates(w models.WalkIn) []uint {
+	if w.PreferredStaffID != nil {
+		return []uint{*w.PreferredStaffID}
+	}
+	return b.staffIDs
+}
+
+// EstimateWaits mensimulasikan antrian walk-in cabang secara berurutan untuk memperkirakan waktu tunggu
+func EstimateWaits(db *gorm.DB, salonID, branchID uint, now time.Time) ([]Estimate, error) {
+	b, waiting, err := loadBoard(db, salonID, &branchID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	estimates := make([]Estimate, 0, len(waiting))
+	for i, w := range waiting {
+		d := b.duration(w.ServiceID)
+
+		var bestStaff uint
+		var bestStart time.Time
+		for _, staffID := range b.candidates(w) {
+			if _, ok := b.freeAt[staffID]; !ok {
+				continue
+			}
+			start := b.slot(staffID, d)
+			if bestStaff == 0 || start.Before(bestStart) {
+				bestStaff, bestStart = staffID, start
+			}
+		}
+		if bestStaff == 0 {
+			continue
+		}
+
+		b.freeAt[bestStaff] = bestStart.Add(d)
+		estimates = append(estimates, Estimate{
+			WalkInID:             w.ID,
+			Position:             i + 1,
+			StaffID:              bestStaff,
+			EstimatedStart:       bestStart,
+			EstimatedWaitMinutes: int(bestStart.Sub(now).Round(time.Minute) / time.Minute),
+		})
+	}
+
+	return estimates, nil
+}
+
+// NextFreeStaff memilih stylist cabang walk-in yang kosong saat ini. Jika beberapa kosong,
+// dipilih yang paling lama menganggur (tanpa booking dalam durasi layanan).
+func NextFreeStaff(db *gorm.DB, walkIn models.WalkIn, now time.Time) (uint, error) {
+	b, _, err := loadBoard(db, walkIn.SalonID, walkIn.BranchID, now)
+	if err != nil {
+		return 0, err
+	}
+
+	d := b.duration(walkIn.ServiceID)
+	var free []uint
+	for _, staffID := range b.candidates(walkIn) {
+		if _, ok := b.freeAt[staffID]; !ok {
+			continue
+		}
+		if !b.slot(staffID, d).After(now) {
+			free = append(free, staffID)
+		}
+	}
+	if len(free) == 0 {
+		return 0, ErrNoFreeStaff
+	}
+
+	// Utamakan stylist yang paling lama tidak melayani walk-in
+	lastServed := map[uint]time.Time{}
+	var rows []struct {
+		StaffID uint
+		Last    time.Time
+	}
+	if err := db.Model(&models.WalkIn{}).
+		Select("staff_id, MAX(finished_at) AS last").
+		Where("salon_id = ? AND staff_id IN ? AND finished_at IS NOT NULL", walkIn.SalonID, free).
+		Group("staff_id").Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+	for _, row := range rows {
+		lastServed[row.StaffID] = row.Last
+	}
+	sort.SliceStable(free, func(i, j int) bool { return lastServed[free[i]].Before(lastServed[free[j]]) })
+
+	return free[0], nil
+}
@@ -4,7 +4,6 @@ import (
 	"log"
 	"time"
 
-	"github.com/spf13/viper"
 	postgres "gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	
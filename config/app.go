@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"fmt" // Tambahkan import fmt untuk string formatting
+	"time"
 	"github.com/spf13/viper"
 )
 
@@ -39,4 +40,121 @@ func JWTSecret() string {
 		secret = "your-secret-key-change-this-in-production"
 	}
 	return secret
-}
\ No newline at end of file
+}
+
+// Location mengembalikan zona waktu aplikasi (APP_TIMEZONE, default Asia/Jakarta)
+func Location() *time.Location {
+	name := viper.GetString("APP_TIMEZONE")
+	if name == "" {
+		name = "Asia/Jakarta"
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Zona waktu %s tidak ditemukan, menggunakan WIB (UTC+7)", name)
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
+// WaitlistHoldDuration mengembalikan lama slot ditahan untuk pelanggan waitlist (WAITLIST_HOLD_MINUTES, default 15)
+func WaitlistHoldDuration() time.Duration {
+	minutes := viper.GetInt("WAITLIST_HOLD_MINUTES")
+	if minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// AppEnv mengembalikan lingkungan aplikasi (APP_ENV: development atau production, default production)
+func AppEnv() string {
+	env := viper.GetString("APP_ENV")
+	if env == "" {
+		env = "production"
+	}
+	return env
+}
+
+// IsDevelopment mengecek apakah aplikasi berjalan dengan APP_ENV=development
+func IsDevelopment() bool {
+	return AppEnv() == "development"
+}
+
+// PaymentProvider mengembalikan nama payment provider untuk tagihan baru (PAYMENT_PROVIDER: midtrans atau fake)
+func PaymentProvider() string {
+	return viper.GetString("PAYMENT_PROVIDER")
+}
+
+// PaymentFakeSecret mengembalikan secret HMAC webhook provider fake (PAYMENT_FAKE_SECRET, tanpa default)
+func PaymentFakeSecret() string {
+	return viper.GetString("PAYMENT_FAKE_SECRET")
+}
+
+// MidtransServerKey mengembalikan server key Midtrans untuk API dan signature notifikasi (MIDTRANS_SERVER_KEY)
+func MidtransServerKey() string {
+	return viper.GetString("MIDTRANS_SERVER_KEY")
+}
+
+// MidtransProduction mengecek apakah Midtrans memakai endpoint production (MIDTRANS_PRODUCTION, default sandbox)
+func MidtransProduction() bool {
+	return viper.GetBool("MIDTRANS_PRODUCTION")
+}
+
+// MidtransVABank mengembalikan bank virtual account Midtrans (MIDTRANS_VA_BANK, default bca)
+func MidtransVABank() string {
+	bank := viper.GetString("MIDTRANS_VA_BANK")
+	if bank == "" {
+		bank = "bca"
+	}
+	return bank
+}
+
+// PaymentFakeSettleDelay mengembalikan jeda settlement skenario delayed provider fake
+// (PAYMENT_FAKE_SETTLE_SECONDS, default 30)
+func PaymentFakeSettleDelay() time.Duration {
+	seconds := viper.GetInt("PAYMENT_FAKE_SETTLE_SECONDS")
+	if seconds <= 0 {
+		seconds = 30
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// MailDriver mengembalikan driver pengirim email (MAIL_DRIVER: log atau smtp, default log)
+func MailDriver() string {
+	driver := viper.GetString("MAIL_DRIVER")
+	if driver == "" {
+		driver = "log"
+	}
+	return driver
+}
+
+// MailFrom mengembalikan alamat pengirim email (MAIL_FROM)
+func MailFrom() string {
+	from := viper.GetString("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@example.com"
+	}
+	return from
+}
+
+// SMTPAddr mengembalikan host:port server SMTP (SMTP_HOST, SMTP_PORT default 587)
+func SMTPAddr() string {
+	port := viper.GetString("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	return viper.GetString("SMTP_HOST") + ":" + port
+}
+
+// SMTPCredentials mengembalikan username dan password SMTP (SMTP_USERNAME, SMTP_PASSWORD)
+func SMTPCredentials() (string, string) {
+	return viper.GetString("SMTP_USERNAME"), viper.GetString("SMTP_PASSWORD")
+}
+
+// GiftCardValidity mengembalikan masa berlaku gift card sejak diterbitkan (GIFT_CARD_VALIDITY_DAYS, default 365)
+func GiftCardValidity() time.Duration {
+	days := viper.GetInt("GIFT_CARD_VALIDITY_DAYS")
+	if days <= 0 {
+		days = 365
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
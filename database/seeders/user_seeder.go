@@ -14,6 +14,7 @@ func SeedUsers(db *gorm.DB) error {
 			Name:     "Admin",
 			Email:    "admin@example.com",
 			Password: "password123",
+			IsAdmin:  true,
 		},
 		{
 			Name:     "John Doe",
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,8 +15,16 @@ import (
 	postgres "gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	"gin-sass-salon/app/booking"
 	"gin-sass-salon/app/http/controllers"
+	"gin-sass-salon/app/mailer"
 	"gin-sass-salon/app/models"
+	"gin-sass-salon/app/payment"
+	"gin-sass-salon/app/pos"
+	"gin-sass-salon/app/queue"
+	"gin-sass-salon/app/realtime"
+	"gin-sass-salon/app/scheduler"
+	"gin-sass-salon/app/walkin"
 	"gin-sass-salon/config"
 	"gin-sass-salon/database/seeders"
 	"gin-sass-salon/routes"
@@ -60,11 +73,87 @@ func main() {
 	log.Println("✅ Koneksi Database PostgreSQL berhasil!")
 
 	// 3. Auto Migrate (Migrasi Database)
-	err = db.AutoMigrate(&models.User{})
+	err = db.AutoMigrate(
+		&models.User{},
+		&models.Job{},
+		&models.ScheduledTaskRun{},
+		&models.Salon{},
+		&models.StaffInvitation{},
+		&models.Service{},
+		&models.Customer{},
+		&models.Booking{},
+		&models.WaitlistEntry{},
+		&models.WaitlistOffer{},
+		&models.WalkIn{},
+		&models.Sale{},
+		&models.SaleLine{},
+		&models.SaleTender{},
+		&models.SalonSequence{},
+		&models.Payment{},
+		&models.PaymentEvent{},
+		&models.PaymentRefund{},
+		&models.SaleRefund{},
+		&models.SaleRefundLine{},
+		&models.SaleRefundTender{},
+		&models.AuditLog{},
+		&models.CashShift{},
+		&models.CashMovement{},
+		&models.TaxClass{},
+		&models.TaxRate{},
+		&models.SaleLineTax{},
+		&models.ReceiptTemplate{},
+		&models.GiftCard{},
+		&models.GiftCardTransaction{},
+		&models.Promotion{},
+		&models.PromotionRedemption{},
+		&models.LoyaltyProgram{},
+		&models.LoyaltyTier{},
+		&models.LoyaltyAccount{},
+		&models.LoyaltyTransaction{},
+		&models.Package{},
+		&models.PackageItem{},
+		&models.MembershipPlan{},
+		&models.MembershipBenefit{},
+		&models.CustomerPackage{},
+		&models.PackageCredit{},
+		&models.Membership{},
+		&models.MembershipPeriod{},
+		&models.CreditUsage{},
+		&models.Branch{},
+		&models.BranchOpeningHour{},
+		&models.BranchStaff{},
+		&models.Product{},
+		&models.StockLevel{},
+		&models.StockMovement{},
+		&models.Supplier{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderLine{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptLine{},
+		&models.ServiceRecipe{},
+		&models.ServiceUsage{},
+		&models.CommissionPlan{},
+		&models.CommissionRule{},
+		&models.SaleLineSplit{},
+		&models.PayrollPeriod{},
+		&models.PayrollEntry{},
+		&models.PayrollDeduction{},
+		&models.StaffShift{},
+		&models.AttendancePunch{},
+	)
 	if err != nil {
 		log.Fatalf("❌ Gagal melakukan AutoMigrate: %v", err)
 	}
-	log.Println("✅ Database migration selesai (Tabel User siap).")
+	if err := pos.MigrateShiftBranches(db); err != nil {
+		log.Fatalf("❌ Gagal memindahkan shift kasir ke cabang: %v", err)
+	}
+	if err := booking.MigrateBranches(db); err != nil {
+		log.Fatalf("❌ Gagal memindahkan booking dan waitlist ke cabang: %v", err)
+	}
+	if err := walkin.MigrateBranches(db); err != nil {
+		log.Fatalf("❌ Gagal memindahkan walk-in ke cabang: %v", err)
+	}
+	log.Println("✅ Database migration selesai.")
 
 	// 4. Run Seeder jika flag --seed diberikan
 	if len(os.Args) > 1 && os.Args[1] == "--seed" {
@@ -74,16 +163,61 @@ func main() {
 		return
 	}
 
-	// 5. Inisialisasi Gin Router
+	// 4b. Beri atau cabut akses admin platform: admin grant|revoke <email>
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		if len(os.Args) != 4 || (os.Args[2] != "grant" && os.Args[2] != "revoke") {
+			log.Fatal("❌ Penggunaan: admin grant|revoke <email>")
+		}
+		result := db.Model(&models.User{}).Where("email = ?", os.Args[3]).Update("is_admin", os.Args[2] == "grant")
+		if result.Error != nil {
+			log.Fatalf("❌ Gagal mengubah akses admin: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			log.Fatalf("❌ User dengan email %s tidak ditemukan", os.Args[3])
+		}
+		log.Printf("✅ Akses admin %s: %s", os.Args[3], os.Args[2])
+		return
+	}
+
+	// 4c. Daftarkan payment provider; gagal start jika PAYMENT_PROVIDER atau secret-nya belum dikonfigurasi
+	if err := payment.RegisterDefaultProviders(); err != nil {
+		log.Fatalf("❌ Konfigurasi payment provider tidak valid: %v", err)
+	}
+	payment.RegisterJobs(db)
+
+	// 5. Jalankan scheduler task berulang (aman di banyak replika karena memakai advisory lock)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mailer.RegisterJobs()
+	scheduler.RegisterDefaultTasks()
+	taskScheduler := scheduler.New(db)
+	taskScheduler.Start(ctx)
+
+	// 6. Jalankan worker antrian jika subcommand worker diberikan
+	if len(os.Args) > 1 && os.Args[1] == "worker" {
+		worker := queue.NewWorker(db)
+		if len(os.Args) > 2 {
+			worker.Queues = os.Args[2:]
+		}
+		worker.Run(ctx)
+		return
+	}
+
+	// 7. Jalankan listener LISTEN/NOTIFY untuk event realtime antar instance
+	go realtime.Listen(ctx, config.DSN())
+
+	// 8. Inisialisasi Gin Router
 	r := gin.Default()
 
-	// 6. Injeksi koneksi DB ke Controller
+	// 9. Injeksi koneksi DB ke Controller
 	controllers.DBConnection = db
+	controllers.TaskScheduler = taskScheduler
 
-	// 7. Setup Route (termasuk Swagger)
+	// 10. Setup Route (termasuk Swagger)
 	routes.SetupRoutes(r)
 
-	// 8. Jalankan Server
+	// 11. Jalankan Server
 	appPort := viper.GetString("APP_PORT")
 	if appPort == "" {
 		appPort = "9001"
@@ -93,9 +227,25 @@ func main() {
 	log.Printf("📚 Swagger UI: http://localhost:%s/swagger/index.html", appPort)
 	log.Printf("📖 API Docs: http://localhost:%s/api", appPort)
 
-	// Jalankan server Gin
-	err = r.Run(":" + appPort)
-	if err != nil {
+	// Jalankan server Gin; SIGINT/SIGTERM ditangkap ctx sehingga server dihentikan secara graceful
+	server := &http.Server{Addr: ":" + appPort, Handler: r}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		log.Println("🛑 Menghentikan server...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("❌ Gagal menghentikan server dengan graceful: %v", err)
+		}
+	}()
+
+	err = server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("❌ Gagal menjalankan server Gin: %v", err)
 	}
+	// Tunggu request yang sedang berjalan selesai
+	<-stopped
+	log.Println("👋 Server berhenti")
 }
\ No newline at end of file
@@ -33,6 +33,204 @@ func SetupRoutes(r *gin.Engine) {
 			protected.POST("/users", controllers.CreateUser)
 			protected.PUT("/users/:id", controllers.UpdateUser)
 			protected.DELETE("/users/:id", controllers.DeleteUser)
+
+			// Salon & staff
+			protected.POST("/salons", controllers.CreateSalon)
+			protected.GET("/salon", controllers.GetSalon)
+			protected.POST("/salon/staff", controllers.AddSalonStaff)
+			protected.GET("/invitations", controllers.GetStaffInvitations)
+			protected.POST("/invitations/:id/accept", controllers.AcceptStaffInvitation)
+			protected.POST("/invitations/:id/decline", controllers.DeclineStaffInvitation)
+			protected.PUT("/salon/staff/:id/commission-plan", controllers.UpdateStaffCommissionPlan)
+			protected.PUT("/salon/staff/:id/base-salary", controllers.UpdateStaffBaseSalary)
+			protected.PUT("/salon/staff/:id/attendance-device", controllers.UpdateStaffAttendanceDevice)
+			protected.PUT("/salon/staff/:id/branches", controllers.UpdateStaffBranches)
+			protected.GET("/salon/receipt-template", controllers.GetReceiptTemplate)
+			protected.PUT("/salon/receipt-template", controllers.UpdateReceiptTemplate)
+			protected.PUT("/salon/logo", controllers.UploadSalonLogo)
+
+			// Services
+			protected.GET("/services", controllers.GetServices)
+			protected.POST("/services", controllers.CreateService)
+			protected.PUT("/services/:id", controllers.UpdateService)
+			protected.GET("/services/:id/recipe", controllers.GetServiceRecipe)
+			protected.PUT("/services/:id/recipe", controllers.UpdateServiceRecipe)
+
+			// Customers
+			protected.GET("/customers", controllers.GetCustomers)
+			protected.GET("/customers/:id", controllers.GetCustomer)
+			protected.POST("/customers", controllers.CreateCustomer)
+			protected.GET("/customers/:id/loyalty", controllers.GetCustomerLoyalty)
+			protected.POST("/customers/:id/loyalty/adjustments", controllers.AdjustCustomerLoyalty)
+			protected.GET("/customers/:id/prepaid", controllers.GetCustomerPrepaid)
+
+			// Bookings
+			protected.GET("/bookings", controllers.GetBookings)
+			protected.POST("/bookings", controllers.CreateBooking)
+			protected.POST("/bookings/:id/cancel", controllers.CancelBooking)
+			protected.POST("/bookings/:id/reschedule", controllers.RescheduleBooking)
+
+			// Waitlist
+			protected.GET("/waitlist", controllers.GetWaitlist)
+			protected.POST("/waitlist", controllers.CreateWaitlistEntry)
+			protected.DELETE("/waitlist/:id", controllers.CancelWaitlistEntry)
+			protected.POST("/waitlist/offers/:id/accept", controllers.AcceptWaitlistOffer)
+			protected.POST("/waitlist/offers/:id/decline", controllers.DeclineWaitlistOffer)
+
+			// Walk-in queue (front desk)
+			protected.GET("/queue", controllers.GetQueue)
+			protected.POST("/queue", controllers.CheckInWalkIn)
+			protected.POST("/queue/:id/assign", controllers.AssignWalkIn)
+			protected.POST("/queue/:id/start", controllers.StartWalkIn)
+			protected.POST("/queue/:id/finish", controllers.FinishWalkIn)
+			protected.POST("/queue/:id/abandon", controllers.AbandonWalkIn)
+
+			// Point of sale
+			protected.POST("/sales/quote", controllers.QuoteSale)
+			protected.POST("/sales", controllers.Checkout)
+			protected.GET("/sales", controllers.GetSales)
+			protected.GET("/sales/:id", controllers.GetSale)
+			protected.POST("/sales/:id/refund", controllers.RefundSale)
+			protected.POST("/sales/:id/void", controllers.VoidSale)
+			protected.GET("/sales/:id/usage", controllers.GetSaleUsage)
+			protected.PUT("/sales/:id/lines/:line_id/usage", controllers.UpdateSaleLineUsage)
+			protected.PUT("/sales/:id/lines/:line_id/staff", controllers.UpdateSaleLineStaff)
+			protected.GET("/sales/:id/receipt.pdf", controllers.GetSaleReceiptPDF)
+			protected.POST("/sales/:id/receipt/email", controllers.EmailSaleReceipt)
+
+			// Promotions
+			protected.GET("/promotions", controllers.GetPromotions)
+			protected.POST("/promotions", controllers.CreatePromotion)
+			protected.PUT("/promotions/:id", controllers.UpdatePromotion)
+			protected.POST("/promotions/validate", controllers.ValidatePromotions)
+
+			// Loyalty
+			protected.GET("/loyalty/program", controllers.GetLoyaltyProgram)
+			protected.PUT("/loyalty/program", controllers.UpdateLoyaltyProgram)
+			protected.POST("/loyalty/tiers", controllers.CreateLoyaltyTier)
+			protected.PUT("/loyalty/tiers/:id", controllers.UpdateLoyaltyTier)
+
+			// Packages & memberships
+			protected.GET("/packages", controllers.GetPackages)
+			protected.POST("/packages", controllers.CreatePackage)
+			protected.PUT("/packages/:id", controllers.UpdatePackage)
+			protected.GET("/membership-plans", controllers.GetMembershipPlans)
+			protected.POST("/membership-plans", controllers.CreateMembershipPlan)
+			protected.PUT("/membership-plans/:id", controllers.UpdateMembershipPlan)
+			protected.POST("/memberships/:id/cancel", controllers.CancelMembership)
+
+			// Gift cards
+			protected.POST("/gift-cards", controllers.IssueGiftCard)
+			protected.GET("/gift-cards/lookup", controllers.LookupGiftCard)
+			protected.GET("/gift-cards/:id", controllers.GetGiftCard)
+
+			// Branches & inventory
+			protected.GET("/branches", controllers.GetBranches)
+			protected.POST("/branches", controllers.CreateBranch)
+			protected.GET("/branches/:id", controllers.GetBranch)
+			protected.PUT("/branches/:id", controllers.UpdateBranch)
+			protected.PUT("/branches/:id/opening-hours", controllers.UpdateBranchOpeningHours)
+			protected.GET("/reports/branches", controllers.GetBranchReport)
+
+			// Reports
+			protected.GET("/reports/revenue", controllers.GetRevenueReport)
+			protected.GET("/reports/staff-utilization", controllers.GetStaffUtilizationReport)
+			protected.GET("/reports/customers", controllers.GetCustomerReport)
+			protected.GET("/reports/customers/cohorts", controllers.GetCustomerCohortReport)
+			protected.GET("/reports/customers/churn-risk", controllers.GetChurnRiskReport)
+			protected.GET("/reports/customers/lifetime-value", controllers.GetCustomerLifetimeValueReport)
+			protected.GET("/products", controllers.GetProducts)
+			protected.GET("/products/lookup", controllers.LookupProduct)
+			protected.POST("/products", controllers.CreateProduct)
+			protected.PUT("/products/:id", controllers.UpdateProduct)
+			protected.GET("/products/:id/stock", controllers.GetProductStock)
+			protected.GET("/stock/movements", controllers.GetStockMovements)
+			protected.POST("/stock/movements", controllers.CreateStockMovement)
+			protected.POST("/stock/transfers", controllers.TransferStock)
+			protected.GET("/reports/low-stock", controllers.GetLowStockReport)
+			protected.GET("/reports/backbar-variance", controllers.GetBackBarVarianceReport)
+
+			// Suppliers & purchase orders
+			protected.GET("/suppliers", controllers.GetSuppliers)
+			protected.POST("/suppliers", controllers.CreateSupplier)
+			protected.PUT("/suppliers/:id", controllers.UpdateSupplier)
+			protected.GET("/purchase-orders", controllers.GetPurchaseOrders)
+			protected.GET("/purchase-orders/reorder-suggestions", controllers.GetReorderSuggestions)
+			protected.GET("/purchase-orders/:id", controllers.GetPurchaseOrder)
+			protected.POST("/purchase-orders", controllers.CreatePurchaseOrder)
+			protected.PUT("/purchase-orders/:id", controllers.UpdatePurchaseOrder)
+			protected.POST("/purchase-orders/:id/send", controllers.SendPurchaseOrder)
+			protected.POST("/purchase-orders/:id/receive", controllers.ReceivePurchaseOrder)
+			protected.POST("/purchase-orders/:id/cancel", controllers.CancelPurchaseOrder)
+
+			// Commissions
+			protected.GET("/commission-plans", controllers.GetCommissionPlans)
+			protected.POST("/commission-plans", controllers.CreateCommissionPlan)
+			protected.PUT("/commission-plans/:id", controllers.UpdateCommissionPlan)
+			protected.GET("/commissions/statement", controllers.GetCommissionStatement)
+
+			// Payroll
+			protected.GET("/payroll-periods", controllers.GetPayrollPeriods)
+			protected.POST("/payroll-periods", controllers.CreatePayrollPeriod)
+			protected.GET("/payroll-periods/:id", controllers.GetPayrollPeriod)
+			protected.DELETE("/payroll-periods/:id", controllers.DeletePayrollPeriod)
+			protected.POST("/payroll-periods/:id/recalculate", controllers.RecalculatePayrollPeriod)
+			protected.PUT("/payroll-periods/:id/entries/:entry_id", controllers.UpdatePayrollEntry)
+			protected.POST("/payroll-periods/:id/approve", controllers.ApprovePayrollPeriod)
+			protected.GET("/payroll-periods/:id/export", controllers.ExportPayrollPeriod)
+
+			// Attendance
+			protected.GET("/staff-shifts", controllers.GetStaffShifts)
+			protected.POST("/staff-shifts", controllers.CreateStaffShift)
+			protected.PUT("/staff-shifts/:id", controllers.UpdateStaffShift)
+			protected.DELETE("/staff-shifts/:id", controllers.DeleteStaffShift)
+			protected.GET("/attendance/punches", controllers.GetPunches)
+			protected.POST("/attendance/punches", controllers.CreatePunch)
+			protected.POST("/attendance/punches/manual", controllers.CreateManualPunch)
+			protected.GET("/reports/attendance", controllers.GetAttendanceReport)
+
+			// Cash drawer shifts & Z-report
+			protected.POST("/shifts/open", controllers.OpenShift)
+			protected.GET("/shifts/current", controllers.GetCurrentShift)
+			protected.POST("/shifts/current/movements", controllers.AddCashMovement)
+			protected.POST("/shifts/current/close", controllers.CloseShift)
+			protected.GET("/reports/z", controllers.GetZReport)
+
+			// Taxes & service charge
+			protected.GET("/tax-classes", controllers.GetTaxClasses)
+			protected.POST("/tax-classes", controllers.CreateTaxClass)
+			protected.POST("/tax-classes/:id/rates", controllers.CreateTaxRate)
+			protected.PUT("/salon/tax-settings", controllers.UpdateTaxSettings)
+
+			// Payments (QRIS, virtual account, kartu)
+			protected.POST("/payments", controllers.CreatePayment)
+			protected.GET("/payments/:id", controllers.GetPayment)
+			protected.POST("/payments/:id/refund", controllers.RefundPayment)
+
+			// Audit trail
+			protected.GET("/audit-logs", controllers.GetAuditLogs)
+		}
+
+		// Webhook payment provider (diverifikasi dengan signature, bukan JWT)
+		api.POST("/payments/webhooks/:provider", controllers.PaymentWebhook)
+
+		// Realtime stream (tiket berumur pendek boleh lewat query ticket karena EventSource tidak bisa set header)
+		api.POST("/stream/ticket", middleware.AuthMiddleware(), controllers.CreateStreamTicket)
+		api.GET("/stream", middleware.StreamAuthMiddleware(), controllers.StreamEvents)
+
+		// Admin routes (perlu authentication dan user dengan flag is_admin)
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware(controllers.DBConnection))
+		{
+			// Background job queue
+			admin.GET("/jobs", controllers.GetJobs)
+			admin.GET("/jobs/:id", controllers.GetJob)
+			admin.POST("/jobs/:id/retry", controllers.RetryJob)
+
+			// Scheduled tasks (cron)
+			admin.GET("/tasks", controllers.GetTasks)
+			admin.GET("/tasks/:name/runs", controllers.GetTaskRuns)
+			admin.POST("/tasks/:name/run", controllers.RunTask)
 		}
 	}
 }
\ No newline at end of file